@@ -25,6 +25,21 @@ const (
 	CustomNetworking      FeatureName = "CustomNetworking"
 )
 
+const (
+	// CNINodeConditionTrunkReady is the condition reporting whether the trunk ENI of the node is attached and
+	// initialized for the branch ENIs of the pods
+	CNINodeConditionTrunkReady = "TrunkReady"
+	// CNINodeConditionIPPoolHealthy is the condition reporting whether the warm pool of IPv4 addresses or prefixes of
+	// the Windows node is initialized and its last update succeeded
+	CNINodeConditionIPPoolHealthy = "IPPoolHealthy"
+	// CNINodeConditionSubnetExhausted is the condition reporting whether the subnet of the node doesn't have enough
+	// free CIDR blocks to assign the IPv4 prefixes of the node
+	CNINodeConditionSubnetExhausted = "SubnetExhausted"
+	// CNINodeConditionInstanceTypeUnsupported is the condition reporting whether the instance type of the node is
+	// not supported by the controller
+	CNINodeConditionInstanceTypeUnsupported = "InstanceTypeUnsupported"
+)
+
 // Feature is a type of feature being supported by VPC resource controller and other AWS Services
 type Feature struct {
 	Name  FeatureName `json:"name,omitempty"`
@@ -40,11 +55,19 @@ type CNINodeSpec struct {
 // CNINodeStatus defines the managed VPC resources.
 type CNINodeStatus struct {
 	//TODO: add VPC resources which will be managed by this CRD and its finalizer
+
+	// Conditions report the state of the VPC resources of the node, they are mirrored to the conditions of the Node
+	// prefixed with vpc.amazonaws.com/
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Features",type=string,JSONPath=`.spec.features`,description="The features delegated to VPC resource controller"
 // +kubebuilder:resource:shortName=cnd,scope=Cluster
+// +kubebuilder:subresource:status
 
 // +kubebuilder:object:root=true
 type CNINode struct {
@@ -0,0 +1,104 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ControllerConfigConditionValid is the condition reporting whether all the fields of the spec are valid
+	ControllerConfigConditionValid = "Valid"
+
+	ControllerConfigReasonValid         = "Valid"
+	ControllerConfigReasonInvalidFields = "InvalidFields"
+)
+
+// WindowsIPAMConfig configures the IP address management of the Windows nodes
+type WindowsIPAMConfig struct {
+	// EnableIPAM enables the IP address management of the Windows nodes by the controller
+	// +optional
+	EnableIPAM *bool `json:"enableIPAM,omitempty"`
+	// EnablePrefixDelegation assigns /28 prefixes instead of secondary IPv4 addresses to the Windows nodes
+	// +optional
+	EnablePrefixDelegation *bool `json:"enablePrefixDelegation,omitempty"`
+	// WarmIPTarget is the number of free IPv4 addresses to keep in the warm pool of each Windows node
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WarmIPTarget *int32 `json:"warmIPTarget,omitempty"`
+	// MinimumIPTarget is the minimum number of IPv4 addresses to keep allocated to each Windows node
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinimumIPTarget *int32 `json:"minimumIPTarget,omitempty"`
+	// WarmPrefixTarget is the number of free prefixes to keep in the warm pool of each Windows node, it's only
+	// used if prefix delegation is enabled
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WarmPrefixTarget *int32 `json:"warmPrefixTarget,omitempty"`
+}
+
+// VPCResourceControllerConfigSpec defines the configuration of the controller. The fields that are not set fall
+// back to the deprecated keys of the amazon-vpc-cni ConfigMap and then to the defaults.
+type VPCResourceControllerConfigSpec struct {
+	// Windows configures the IP address management of the Windows nodes
+	// +optional
+	Windows *WindowsIPAMConfig `json:"windows,omitempty"`
+	// BranchENICooldownPeriodSeconds is the time to wait before deleting the branch ENI of a deleted pod
+	// +kubebuilder:validation:Minimum=30
+	// +optional
+	BranchENICooldownPeriodSeconds *int32 `json:"branchENICooldownPeriodSeconds,omitempty"`
+}
+
+// VPCResourceControllerConfigStatus reports the configuration in use by the controller
+type VPCResourceControllerConfigStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Effective is the configuration in use after the fallback to the amazon-vpc-cni ConfigMap and the defaults
+	// +optional
+	Effective VPCResourceControllerConfigSpec `json:"effective,omitempty"`
+	// ConfigMapFallbackKeys are the deprecated amazon-vpc-cni ConfigMap keys in use because the corresponding
+	// field of the spec is not set
+	// +optional
+	ConfigMapFallbackKeys []string `json:"configMapFallbackKeys,omitempty"`
+	// Conditions report the validation errors of the spec, the invalid fields are ignored
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`,description="Whether all the fields of the spec are valid"
+// +kubebuilder:resource:shortName=vpcrcconfig,scope=Cluster
+
+// VPCResourceControllerConfig is the configuration of the VPC resource controller, only the object named default is
+// used by the controller
+type VPCResourceControllerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              VPCResourceControllerConfigSpec   `json:"spec,omitempty"`
+	Status            VPCResourceControllerConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// VPCResourceControllerConfigList contains a list of VPCResourceControllerConfig
+type VPCResourceControllerConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VPCResourceControllerConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VPCResourceControllerConfig{}, &VPCResourceControllerConfigList{})
+}
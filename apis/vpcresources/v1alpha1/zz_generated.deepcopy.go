@@ -18,6 +18,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -27,7 +28,7 @@ func (in *CNINode) DeepCopyInto(out *CNINode) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNINode.
@@ -103,6 +104,13 @@ func (in *CNINodeSpec) DeepCopy() *CNINodeSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CNINodeStatus) DeepCopyInto(out *CNINodeStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNINodeStatus.
@@ -129,3 +137,155 @@ func (in *Feature) DeepCopy() *Feature {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCResourceControllerConfig) DeepCopyInto(out *VPCResourceControllerConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCResourceControllerConfig.
+func (in *VPCResourceControllerConfig) DeepCopy() *VPCResourceControllerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCResourceControllerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCResourceControllerConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCResourceControllerConfigList) DeepCopyInto(out *VPCResourceControllerConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VPCResourceControllerConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCResourceControllerConfigList.
+func (in *VPCResourceControllerConfigList) DeepCopy() *VPCResourceControllerConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCResourceControllerConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCResourceControllerConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCResourceControllerConfigSpec) DeepCopyInto(out *VPCResourceControllerConfigSpec) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = new(WindowsIPAMConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BranchENICooldownPeriodSeconds != nil {
+		in, out := &in.BranchENICooldownPeriodSeconds, &out.BranchENICooldownPeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCResourceControllerConfigSpec.
+func (in *VPCResourceControllerConfigSpec) DeepCopy() *VPCResourceControllerConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCResourceControllerConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCResourceControllerConfigStatus) DeepCopyInto(out *VPCResourceControllerConfigStatus) {
+	*out = *in
+	in.Effective.DeepCopyInto(&out.Effective)
+	if in.ConfigMapFallbackKeys != nil {
+		in, out := &in.ConfigMapFallbackKeys, &out.ConfigMapFallbackKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCResourceControllerConfigStatus.
+func (in *VPCResourceControllerConfigStatus) DeepCopy() *VPCResourceControllerConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCResourceControllerConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowsIPAMConfig) DeepCopyInto(out *WindowsIPAMConfig) {
+	*out = *in
+	if in.EnableIPAM != nil {
+		in, out := &in.EnableIPAM, &out.EnableIPAM
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnablePrefixDelegation != nil {
+		in, out := &in.EnablePrefixDelegation, &out.EnablePrefixDelegation
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WarmIPTarget != nil {
+		in, out := &in.WarmIPTarget, &out.WarmIPTarget
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumIPTarget != nil {
+		in, out := &in.MinimumIPTarget, &out.MinimumIPTarget
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WarmPrefixTarget != nil {
+		in, out := &in.WarmPrefixTarget, &out.WarmPrefixTarget
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowsIPAMConfig.
+func (in *WindowsIPAMConfig) DeepCopy() *WindowsIPAMConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowsIPAMConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,334 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// introspect renders the state of the controller served by the introspection API as tables. The API is reached
+// through a port-forward or a service, for example:
+//
+//	kubectl port-forward -n kube-system deployment/vpc-resource-controller 22775
+//	introspect nodes --endpoint http://localhost:22775
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/introspect"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const usage = `Usage: introspect <command> [flags]
+
+Commands:
+  nodes         summary of the branch ENIs and the IPv4 pools of each node
+  branches      branch ENIs of each pod
+  pools         used, warm and cooling IPv4 addresses and prefixes of each node
+  delete-queue  branch ENIs waiting to be deleted on each node
+  verify        cross-check the pod annotations against the controller state
+
+Run introspect <command> -h for the flags of the command.
+`
+
+// options are the flags common to all the commands
+type options struct {
+	endpoint           string
+	tokenFile          string
+	caFile             string
+	insecureSkipVerify bool
+	timeout            time.Duration
+	node               string
+	nodeSelector       string
+	kubeconfig         string
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string, out io.Writer) error {
+	opts := &options{}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.StringVar(&opts.endpoint, "endpoint", "http://localhost:22775",
+		"The URL of the controller introspection API")
+	flags.StringVar(&opts.tokenFile, "token-file", "",
+		"The path to the file with the bearer token sent to the introspection API")
+	flags.StringVar(&opts.caFile, "ca-file", "",
+		"The path to the CA certificate the introspection API certificate is verified with")
+	flags.BoolVar(&opts.insecureSkipVerify, "insecure-skip-verify", false,
+		"Skip the verification of the introspection API certificate")
+	flags.DurationVar(&opts.timeout, "timeout", time.Minute, "The timeout of the command")
+	flags.StringVar(&opts.nodeSelector, "node-selector", "", "Only show the nodes matching the label selector")
+	switch command {
+	case "branches", "pools", "delete-queue":
+		flags.StringVar(&opts.node, "node", "", "Only show the node")
+	case "verify":
+		flags.StringVar(&opts.kubeconfig, "kubeconfig", "",
+			"The path to the kubeconfig used to list the pods, the default loading rules are used if empty")
+	case "nodes":
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	if command == "nodes" {
+		summary, err := client.GetSummary(ctx, introspect.ListOptions{NodeSelector: opts.nodeSelector})
+		if err != nil {
+			return err
+		}
+		return printNodes(out, summary)
+	}
+
+	var resources *introspect.Resources
+	if opts.node != "" {
+		resources, err = client.GetNode(ctx, opts.node)
+	} else {
+		resources, err = client.GetResources(ctx, introspect.ListOptions{NodeSelector: opts.nodeSelector})
+	}
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "branches":
+		return printBranches(out, resources)
+	case "pools":
+		return printPools(out, resources)
+	case "delete-queue":
+		return printDeleteQueue(out, resources)
+	}
+
+	pods, err := listPods(ctx, opts.kubeconfig)
+	if err != nil {
+		return err
+	}
+	mismatches := introspect.CrossCheck(pods, resources)
+	if err := printMismatches(out, mismatches); err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("found %d mismatches between the pod annotations and the controller", len(mismatches))
+	}
+	return nil
+}
+
+// newClient returns the introspection API client configured with the token and the TLS flags
+func newClient(opts *options) (*introspect.Client, error) {
+	var token string
+	if opts.tokenFile != "" {
+		data, err := os.ReadFile(opts.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.insecureSkipVerify, // #nosec G402 opt-in for self signed certificates
+	}
+	if opts.caFile != "" {
+		caData, err := os.ReadFile(opts.caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificate found in %s", opts.caFile)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return introspect.NewClient(opts.endpoint, token, &http.Client{Transport: transport}), nil
+}
+
+// listPods returns all the pods of the cluster, listed in pages
+func listPods(ctx context.Context, kubeconfig string) ([]corev1.Pod, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	listOptions := metav1.ListOptions{Limit: 500}
+	for {
+		podList, err := clientSet.CoreV1().Pods(metav1.NamespaceAll).List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, podList.Items...)
+		if listOptions.Continue = podList.Continue; listOptions.Continue == "" {
+			return pods, nil
+		}
+	}
+}
+
+// sortedKeys returns the keys of the map in order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shortResourceName returns the resource name without the common prefix
+func shortResourceName(resourceName string) string {
+	return strings.TrimPrefix(resourceName, config.VPCResourcePrefix)
+}
+
+func printNodes(out io.Writer, summary *introspect.Summary) error {
+	nodes := map[string]struct{}{}
+	for nodeName := range summary.Trunks {
+		nodes[nodeName] = struct{}{}
+	}
+	resourceNames := sortedKeys(summary.Pools)
+	header := []string{"NODE", "INSTANCE", "TRUNK ENI", "BRANCH ENIS", "DELETE QUEUE"}
+	for _, resourceName := range resourceNames {
+		for nodeName := range summary.Pools[resourceName] {
+			nodes[nodeName] = struct{}{}
+		}
+		header = append(header, strings.ToUpper(shortResourceName(resourceName))+" USED/WARM/COOLING")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, nodeName := range sortedKeys(nodes) {
+		row := []string{nodeName, "-", "-", "-", "-"}
+		if trunkENI, found := summary.Trunks[nodeName]; found {
+			row = []string{nodeName, trunkENI.InstanceID, trunkENI.TrunkENIID,
+				strconv.Itoa(trunkENI.BranchENICount), strconv.Itoa(trunkENI.DeleteQueueLen)}
+		}
+		for _, resourceName := range resourceNames {
+			counts := "-"
+			if resourcePool, found := summary.Pools[resourceName][nodeName]; found {
+				counts = fmt.Sprintf("%d/%d/%d", resourcePool.UsedResourcesCount,
+					resourcePool.WarmResourcesCount, resourcePool.CoolingResourcesCount)
+			}
+			row = append(row, counts)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func printBranches(out io.Writer, resources *introspect.Resources) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPOD UID\tBRANCH ENI\tVLAN\tIPV4\tIPV6\tSUBNET CIDR")
+	for _, nodeName := range sortedKeys(resources.Trunks) {
+		trunkENI := resources.Trunks[nodeName]
+		for _, uid := range sortedKeys(trunkENI.PodToBranchENI) {
+			for _, branchENI := range trunkENI.PodToBranchENI[uid] {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", nodeName, uid, branchENI.ID, branchENI.VlanID,
+					branchENI.IPV4Addr, valueOrDash(branchENI.IPV6Addr), branchENI.SubnetCIDR)
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func printPools(out io.Writer, resources *introspect.Resources) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tRESOURCE\tUSED\tWARM\tCOOLING")
+	for _, resourceName := range sortedKeys(resources.Pools) {
+		pools := resources.Pools[resourceName]
+		for _, nodeName := range sortedKeys(pools) {
+			resourcePool := pools[nodeName]
+			warm := 0
+			for _, group := range resourcePool.WarmResources {
+				warm += len(group)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", nodeName, shortResourceName(resourceName),
+				len(resourcePool.UsedResources), warm, len(resourcePool.CoolingResources))
+		}
+	}
+	return w.Flush()
+}
+
+func printDeleteQueue(out io.Writer, resources *introspect.Resources) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tTRUNK ENI\tBRANCH ENI\tVLAN\tIPV4")
+	for _, nodeName := range sortedKeys(resources.Trunks) {
+		trunkENI := resources.Trunks[nodeName]
+		for _, branchENI := range trunkENI.DeleteQueue {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", nodeName, trunkENI.TrunkENIID, branchENI.ID, branchENI.VlanID,
+				branchENI.IPV4Addr)
+		}
+	}
+	return w.Flush()
+}
+
+func printMismatches(out io.Writer, mismatches []introspect.Mismatch) error {
+	if len(mismatches) == 0 {
+		fmt.Fprintln(out, "no mismatch found between the pod annotations and the controller")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPOD\tUID\tRESOURCE\tANNOTATION\tCONTROLLER\tPROBLEM")
+	for _, mismatch := range mismatches {
+		pod := "-"
+		if mismatch.Name != "" {
+			pod = mismatch.Namespace + "/" + mismatch.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", mismatch.Node, pod, mismatch.UID,
+			shortResourceName(mismatch.Resource), valueOrDash(mismatch.Annotation), valueOrDash(mismatch.Controller),
+			mismatch.Problem)
+	}
+	return w.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
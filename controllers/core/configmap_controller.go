@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
@@ -28,15 +29,24 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// ConfigMapReconciler reconciles a ConfigMap object
+// ConfigMapReconciler reconciles the VPCResourceControllerConfig and the deprecated amazon-vpc-cni ConfigMap
 type ConfigMapReconciler struct {
 	client.Client
 	Log                               logr.Logger
@@ -53,9 +63,12 @@ type ConfigMapReconciler struct {
 }
 
 //+kubebuilder:rbac:groups=core,resources=configmaps,namespace=kube-system,resourceNames=amazon-vpc-cni,verbs=get;list;watch
+//+kubebuilder:rbac:groups=vpcresources.k8s.aws,resources=vpcresourcecontrollerconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=vpcresources.k8s.aws,resources=vpcresourcecontrollerconfigs/status,verbs=get;update;patch
 
-// Reconcile handles configmap create/update/delete events by invoking NodeManager
-// to update the status of the nodes as per the enable-windows-ipam flag value.
+// Reconcile handles configmap and controller config create/update/delete events by invoking NodeManager
+// to update the status of the nodes as per the enable-windows-ipam flag value. The events of the
+// VPCResourceControllerConfig are mapped to the request of the amazon-vpc-cni ConfigMap.
 
 func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("configmap", req.NamespacedName)
@@ -76,6 +89,28 @@ func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	controllerConfig := &rcv1alpha1.VPCResourceControllerConfig{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: config.ControllerConfigName}, controllerConfig); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to get controller config")
+			return ctrl.Result{}, err
+		}
+		controllerConfig = nil
+	}
+	deprecatedConfigMap := configmap
+	var spec rcv1alpha1.VPCResourceControllerConfigSpec
+	var validationErrs []error
+	if controllerConfig != nil {
+		spec, validationErrs = config.ValidateControllerConfig(controllerConfig.Spec)
+		configmap = config.ApplyControllerConfig(configmap, spec)
+		for _, err := range validationErrs {
+			logger.Info("ignoring invalid controller config field", "error", err.Error())
+		}
+	} else if len(configmap.Data) > 0 {
+		logger.V(1).Info("configuring the controller with the amazon-vpc-cni configMap is deprecated, "+
+			"use the VPCResourceControllerConfig instead", "name", config.ControllerConfigName)
+	}
+
 	// Check if branch ENI cooldown period is updated
 	curCoolDownPeriod := cooldown.GetCoolDown().GetCoolDownPeriod()
 	if newCoolDownPeriod, err := cooldown.GetVpcCniConfigMapCoolDownPeriodOrDefault(r.K8sAPI, r.Log); err == nil {
@@ -91,7 +126,7 @@ func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			)
 		}
 	} else {
-		r.Log.Info("branch ENI cool down period not configured in amazon-vpc-cni configmap, will retain the current cooldown period", "cool down period", curCoolDownPeriod)
+		r.Log.Info("branch ENI cool down period not configured in controller config or amazon-vpc-cni configmap, will retain the current cooldown period", "cool down period", curCoolDownPeriod)
 	}
 
 	// Check if the Windows IPAM flag has changed
@@ -151,9 +186,53 @@ func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	if controllerConfig != nil {
+		if err := r.updateControllerConfigStatus(ctx, controllerConfig, spec, validationErrs, deprecatedConfigMap,
+			configmap); err != nil {
+			logger.Error(err, "Failed to update controller config status")
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// updateControllerConfigStatus reports the effective configuration, the deprecated ConfigMap keys still in use and
+// the validation errors in the status of the VPCResourceControllerConfig
+func (r *ConfigMapReconciler) updateControllerConfigStatus(ctx context.Context,
+	controllerConfig *rcv1alpha1.VPCResourceControllerConfig, validSpec rcv1alpha1.VPCResourceControllerConfigSpec,
+	validationErrs []error, deprecatedConfigMap *corev1.ConfigMap, appliedConfigMap *corev1.ConfigMap) error {
+
+	coolDownPeriodSeconds := int32(cooldown.GetCoolDown().GetCoolDownPeriod().Seconds())
+
+	updated := controllerConfig.DeepCopy()
+	updated.Status.ObservedGeneration = controllerConfig.Generation
+	updated.Status.Effective = rcv1alpha1.VPCResourceControllerConfigSpec{
+		Windows:                        config.EffectiveWindowsIPAMConfig(r.Log, appliedConfigMap),
+		BranchENICooldownPeriodSeconds: &coolDownPeriodSeconds,
+	}
+	updated.Status.ConfigMapFallbackKeys = config.ConfigMapFallbackKeys(deprecatedConfigMap, validSpec)
+
+	condition := metav1.Condition{
+		Type:               rcv1alpha1.ControllerConfigConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             rcv1alpha1.ControllerConfigReasonValid,
+		Message:            "all the fields are valid",
+		ObservedGeneration: controllerConfig.Generation,
+	}
+	if len(validationErrs) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = rcv1alpha1.ControllerConfigReasonInvalidFields
+		condition.Message = fmt.Sprintf("the invalid fields are ignored: %v", utilerrors.NewAggregate(validationErrs))
+	}
+	meta.SetStatusCondition(&updated.Status.Conditions, condition)
+
+	if equality.Semantic.DeepEqual(controllerConfig.Status, updated.Status) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, updated, client.MergeFrom(controllerConfig))
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager, healthzHandler *rcHealthz.HealthzHandler) error {
 	// add health check on subpath for CM controller
@@ -165,6 +244,14 @@ func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager, healthzHandler
 	// Don't change to more than 1 unless the struct is guarded against concurrency issues.
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.ConfigMap{}).
+		Watches(&rcv1alpha1.VPCResourceControllerConfig{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{
+					Namespace: config.KubeSystemNamespace,
+					Name:      config.VpcCniConfigMapName,
+				}}}
+			}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Complete(r)
 }
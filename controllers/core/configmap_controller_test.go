@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	mock_node "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node"
@@ -97,7 +98,9 @@ func NewConfigMapMock(ctrl *gomock.Controller, mockObjects ...client.Object) Con
 
 	scheme := runtime.NewScheme()
 	_ = corev1.AddToScheme(scheme)
-	client := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(mockObjects...).Build()
+	_ = rcv1alpha1.AddToScheme(scheme)
+	client := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(mockObjects...).
+		WithStatusSubresource(&rcv1alpha1.VPCResourceControllerConfig{}).Build()
 
 	return ConfigMapMock{
 		MockNodeManager: mockNodeManager,
@@ -129,7 +132,7 @@ func Test_Reconcile_ConfigMap_Updated_Secondary_IP(t *testing.T) {
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockNodeManager.EXPECT().UpdateNode(mockNodeName).Return(nil)
 
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
@@ -148,7 +151,7 @@ func Test_Reconcile_ConfigMap_Updated_PD(t *testing.T) {
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockNodeManager.EXPECT().UpdateNode(mockNodeName).Return(nil)
 
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
@@ -163,7 +166,7 @@ func Test_Reconcile_ConfigMap_PD_Disabled_If_IPAM_Disabled(t *testing.T) {
 	mock := NewConfigMapMock(ctrl, mockConfigMap)
 	mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
 	mock.MockCondition.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 
@@ -183,7 +186,7 @@ func Test_Reconcile_ConfigMap_NoData(t *testing.T) {
 
 	mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
 	mock.MockCondition.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
@@ -198,7 +201,7 @@ func Test_Reconcile_ConfigMap_Deleted(t *testing.T) {
 	mock := NewConfigMapMock(ctrl)
 	mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
 	mock.MockCondition.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
@@ -216,7 +219,7 @@ func Test_Reconcile_UpdateNode_Error(t *testing.T) {
 	mock.MockK8sAPI.EXPECT().ListNodes().Return(nodeList, nil)
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockNodeManager.EXPECT().UpdateNode(mockNodeName).Return(errMock)
-	mock.MockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil).AnyTimes()
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
 
 	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
@@ -225,6 +228,54 @@ func Test_Reconcile_UpdateNode_Error(t *testing.T) {
 
 }
 
+func Test_Reconcile_ControllerConfig_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	enableIPAM := true
+	warmIPTarget := int32(5)
+	coolDownPeriodSeconds := int32(10)
+	controllerConfig := &rcv1alpha1.VPCResourceControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: config.ControllerConfigName, Generation: 2},
+		Spec: rcv1alpha1.VPCResourceControllerConfigSpec{
+			Windows: &rcv1alpha1.WindowsIPAMConfig{
+				EnableIPAM:   &enableIPAM,
+				WarmIPTarget: &warmIPTarget,
+			},
+			BranchENICooldownPeriodSeconds: &coolDownPeriodSeconds,
+		},
+	}
+
+	mock := NewConfigMapMock(ctrl, mockConfigMap, controllerConfig)
+	mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(true)
+	mock.MockCondition.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
+	mock.MockK8sAPI.EXPECT().ListNodes().Return(nodeList, nil)
+	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
+	mock.MockNodeManager.EXPECT().UpdateNode(mockNodeName).Return(nil)
+	mock.MockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil).AnyTimes()
+
+	cooldown.InitCoolDownPeriod(mock.MockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
+	res, err := mock.ConfigMapReconciler.Reconcile(context.TODO(), mockConfigMapReq)
+	assert.NoError(t, err)
+	assert.Equal(t, res, reconcile.Result{})
+	assert.Equal(t, int(warmIPTarget), mock.ConfigMapReconciler.curWinWarmIPTarget)
+
+	updated := &rcv1alpha1.VPCResourceControllerConfig{}
+	assert.NoError(t, mock.ConfigMapReconciler.Client.Get(context.TODO(),
+		types.NamespacedName{Name: config.ControllerConfigName}, updated))
+	assert.Equal(t, int64(2), updated.Status.ObservedGeneration)
+	assert.True(t, *updated.Status.Effective.Windows.EnableIPAM)
+	assert.Equal(t, warmIPTarget, *updated.Status.Effective.Windows.WarmIPTarget)
+	assert.Equal(t, int32(config.IPv4DefaultWinMinIPTarget), *updated.Status.Effective.Windows.MinimumIPTarget)
+	assert.Equal(t, int32(30), *updated.Status.Effective.BranchENICooldownPeriodSeconds)
+	assert.Equal(t, []string{config.EnableWindowsPrefixDelegationKey, config.WinMinimumIPTarget},
+		updated.Status.ConfigMapFallbackKeys)
+	assert.Len(t, updated.Status.Conditions, 1)
+	assert.Equal(t, metav1.ConditionFalse, updated.Status.Conditions[0].Status)
+	assert.Equal(t, rcv1alpha1.ControllerConfigReasonInvalidFields, updated.Status.Conditions[0].Reason)
+	assert.Contains(t, updated.Status.Conditions[0].Message, "branchENICooldownPeriodSeconds")
+}
+
 func createCoolDownMockCM(cooldownTime string) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
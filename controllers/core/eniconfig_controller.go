@@ -0,0 +1,216 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	ec2Errors "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/errors"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+
+	crdv1alpha1 "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// eniConfigLowFreeAddresses is the number of free addresses in the ENIConfig subnet below which a warning event is
+// sent, the ENIConfig is still applied to the nodes
+const eniConfigLowFreeAddresses = 16
+
+// ENIConfigReconciler validates the ENIConfig used by the nodes with custom networking and applies the changes of the
+// ENIConfig to the nodes, so the new branch ENIs are created in the new subnet with the new security groups
+type ENIConfigReconciler struct {
+	Log          logr.Logger
+	NodeManager  manager.Manager
+	K8sAPI       k8s.K8sWrapper
+	EC2APIHelper api.EC2APIHelper
+	VPCID        string
+}
+
+// Reconcile validates the subnet and the security groups of the ENIConfig with EC2 and updates the nodes referencing
+// the ENIConfig with the node label or the CNINode. The nodes are not updated if the ENIConfig is invalid, and the
+// result of the validation prevents the later node updates from applying the invalid ENIConfig.
+func (r *ENIConfigReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Log.WithValues("eniconfig", req.Name)
+
+	eniConfig, err := r.K8sAPI.GetENIConfig(req.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("eniconfig is deleted")
+			r.NodeManager.SetENIConfigValidation(req.Name, nil)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	// The GVK is required to reference the ENIConfig in the events as it's not registered in the client-go scheme
+	eniConfig.SetGroupVersionKind(crdv1alpha1.GroupVersion.WithKind("ENIConfig"))
+
+	if eniConfig.Spec.Subnet == "" {
+		// The nodes keep using the instance subnet
+		return ctrl.Result{}, nil
+	}
+
+	subnet, err := r.EC2APIHelper.GetSubnet(aws.String(eniConfig.Spec.Subnet))
+	if err != nil {
+		if !strings.Contains(err.Error(), ec2Errors.NotFoundSubnetID) &&
+			!strings.Contains(err.Error(), "subnet not found") {
+			return ctrl.Result{}, err
+		}
+		r.setInvalid(eniConfig, fmt.Sprintf("subnet %s not found", eniConfig.Spec.Subnet))
+		return ctrl.Result{}, nil
+	}
+	if aws.StringValue(subnet.VpcId) != r.VPCID {
+		r.setInvalid(eniConfig, fmt.Sprintf("subnet %s is in %s instead of the cluster VPC %s",
+			eniConfig.Spec.Subnet, aws.StringValue(subnet.VpcId), r.VPCID))
+		return ctrl.Result{}, nil
+	}
+	if freeAddresses := aws.Int64Value(subnet.AvailableIpAddressCount); freeAddresses < eniConfigLowFreeAddresses {
+		r.K8sAPI.BroadcastEvent(eniConfig, utils.ENIConfigSubnetLowOnAddressesReason,
+			fmt.Sprintf("subnet %s has %d free addresses", eniConfig.Spec.Subnet, freeAddresses),
+			corev1.EventTypeWarning)
+	}
+
+	if len(eniConfig.Spec.SecurityGroups) > 0 {
+		missing, err := r.EC2APIHelper.GetMissingSecurityGroups(r.VPCID, eniConfig.Spec.SecurityGroups)
+		if err != nil {
+			// The security groups are validated on the best effort basis, the controller may not be allowed to
+			// describe the security groups
+			logger.Error(err, "failed to verify the security groups")
+		} else if len(missing) > 0 {
+			r.setInvalid(eniConfig, fmt.Sprintf("security groups %s not found in %s",
+				strings.Join(missing, ", "), r.VPCID))
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// The node updates apply the ENIConfig to the nodes in the availability zone of the subnet only
+	r.NodeManager.SetENIConfigValidation(eniConfig.Name, &manager.ENIConfigValidation{
+		Generation: eniConfig.Generation,
+		Zone:       aws.StringValue(subnet.AvailabilityZone),
+	})
+
+	nodes, err := r.getReferencingNodes(eniConfig.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var updated int
+	var errList []error
+	for _, node := range nodes {
+		if _, found := r.NodeManager.GetNode(node.Name); !found {
+			continue
+		}
+		if zone, ok := node.Labels[corev1.LabelTopologyZone]; ok && zone != aws.StringValue(subnet.AvailabilityZone) {
+			msg := fmt.Sprintf("subnet %s of ENIConfig %s is in %s instead of the node availability zone %s",
+				eniConfig.Spec.Subnet, eniConfig.Name, aws.StringValue(subnet.AvailabilityZone), zone)
+			r.sendInvalidEvent(eniConfig, msg)
+			utils.SendNodeEventWithNodeObject(r.K8sAPI, node, utils.InvalidENIConfigReason, msg,
+				corev1.EventTypeWarning, r.Log)
+			continue
+		}
+		if err := r.NodeManager.UpdateNode(node.Name); err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		updated++
+	}
+	if updated > 0 {
+		logger.Info("applied eniconfig to the nodes", "node count", updated)
+		r.K8sAPI.BroadcastEvent(eniConfig, utils.ENIConfigUpdatedReason,
+			fmt.Sprintf("ENIConfig applied to %d nodes", updated), corev1.EventTypeNormal)
+	}
+	if len(errList) > 0 {
+		return ctrl.Result{}, fmt.Errorf("failed to update one or more nodes %v", errList)
+	}
+	return ctrl.Result{}, nil
+}
+
+// getReferencingNodes returns the nodes using the ENIConfig through the custom networking label of the node or the
+// custom networking feature of the CNINode
+func (r *ENIConfigReconciler) getReferencingNodes(eniConfigName string) ([]*corev1.Node, error) {
+	nodeList, err := r.K8sAPI.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*corev1.Node
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if name, ok := node.Labels[config.CustomNetworkingLabel]; ok {
+			if name == eniConfigName {
+				nodes = append(nodes, node)
+			}
+			continue
+		}
+		cniNode, err := r.K8sAPI.GetCNINode(types.NamespacedName{Name: node.Name})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, feature := range cniNode.Spec.Features {
+			if feature.Name == rcv1alpha1.CustomNetworking && feature.Value == eniConfigName {
+				nodes = append(nodes, node)
+				break
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// setInvalid reports the invalid ENIConfig and prevents the node updates from applying it to the nodes
+func (r *ENIConfigReconciler) setInvalid(eniConfig *crdv1alpha1.ENIConfig, msg string) {
+	r.NodeManager.SetENIConfigValidation(eniConfig.Name, &manager.ENIConfigValidation{
+		Generation:    eniConfig.Generation,
+		InvalidReason: msg,
+	})
+	r.sendInvalidEvent(eniConfig, msg)
+}
+
+func (r *ENIConfigReconciler) sendInvalidEvent(eniConfig *crdv1alpha1.ENIConfig, msg string) {
+	r.Log.Info("invalid eniconfig", "eniconfig", eniConfig.Name, "reason", msg)
+	r.K8sAPI.BroadcastEvent(eniConfig, utils.InvalidENIConfigReason, msg, corev1.EventTypeWarning)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ENIConfigReconciler) SetupWithManager(mgr ctrl.Manager, healthzHandler *rcHealthz.HealthzHandler) error {
+	// add health check on subpath for ENIConfig controller
+	healthzHandler.AddControllersHealthCheckers(
+		map[string]healthz.Checker{
+			"health-eniconfig-controller": rcHealthz.SimplePing("eniconfig controller", r.Log),
+		},
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&crdv1alpha1.ENIConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(r)
+}
@@ -0,0 +1,260 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	crdv1alpha1 "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	mock_node "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node"
+	mock_manager "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+)
+
+var (
+	eniConfigName   = "us-west-2a"
+	eniConfigSubnet = "subnet-0123456789abcdef0"
+	eniConfigGroups = []string{"sg-0123456789abcdef0"}
+	clusterVPCID    = "vpc-0123456789abcdef0"
+
+	mockENIConfigReq = reconcile.Request{NamespacedName: types.NamespacedName{Name: eniConfigName}}
+
+	eniConfigSubnetDetails = &ec2.Subnet{
+		SubnetId:                &eniConfigSubnet,
+		VpcId:                   &clusterVPCID,
+		AvailabilityZone:        aws.String("us-west-2a"),
+		AvailableIpAddressCount: aws.Int64(1000),
+	}
+
+	// labelledNode references the ENIConfig with the node label, cniNode references it with the CNINode feature
+	labelledNode = corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "labelled-node", Labels: map[string]string{
+		config.CustomNetworkingLabel: eniConfigName, corev1.LabelTopologyZone: "us-west-2a"}}}
+	cniNode       = corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cni-node"}}
+	otherZoneNode = corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other-zone-node", Labels: map[string]string{
+		config.CustomNetworkingLabel: eniConfigName, corev1.LabelTopologyZone: "us-west-2b"}}}
+	unrelatedNode     = corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unrelated-node"}}
+	eniConfigNodeList = &corev1.NodeList{Items: []corev1.Node{labelledNode, cniNode, unrelatedNode}}
+)
+
+type ENIConfigMock struct {
+	Reconciler       *ENIConfigReconciler
+	MockK8sAPI       *mock_k8s.MockK8sWrapper
+	MockNodeManager  *mock_manager.MockManager
+	MockNode         *mock_node.MockNode
+	MockEC2APIHelper *mock_api.MockEC2APIHelper
+}
+
+func NewENIConfigMock(ctrl *gomock.Controller) ENIConfigMock {
+	mock := ENIConfigMock{
+		MockK8sAPI:       mock_k8s.NewMockK8sWrapper(ctrl),
+		MockNodeManager:  mock_manager.NewMockManager(ctrl),
+		MockNode:         mock_node.NewMockNode(ctrl),
+		MockEC2APIHelper: mock_api.NewMockEC2APIHelper(ctrl),
+	}
+	mock.Reconciler = &ENIConfigReconciler{
+		Log:          zap.New(),
+		NodeManager:  mock.MockNodeManager,
+		K8sAPI:       mock.MockK8sAPI,
+		EC2APIHelper: mock.MockEC2APIHelper,
+		VPCID:        clusterVPCID,
+	}
+	return mock
+}
+
+func newENIConfig() *crdv1alpha1.ENIConfig {
+	return &crdv1alpha1.ENIConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: eniConfigName},
+		Spec:       crdv1alpha1.ENIConfigSpec{Subnet: eniConfigSubnet, SecurityGroups: eniConfigGroups},
+	}
+}
+
+func (m ENIConfigMock) expectReferencingNodes(nodeList *corev1.NodeList) {
+	m.MockK8sAPI.EXPECT().ListNodes().Return(nodeList, nil)
+	m.MockK8sAPI.EXPECT().GetCNINode(types.NamespacedName{Name: cniNode.Name}).Return(&rcv1alpha1.CNINode{
+		Spec: rcv1alpha1.CNINodeSpec{Features: []rcv1alpha1.Feature{
+			{Name: rcv1alpha1.CustomNetworking, Value: eniConfigName}}}}, nil).AnyTimes()
+	m.MockK8sAPI.EXPECT().GetCNINode(types.NamespacedName{Name: unrelatedNode.Name}).
+		Return(&rcv1alpha1.CNINode{}, nil).AnyTimes()
+}
+
+func Test_Reconcile_ENIConfig_UpdatesReferencingNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+	mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(eniConfigSubnetDetails, nil)
+	mock.MockEC2APIHelper.EXPECT().GetMissingSecurityGroups(clusterVPCID, eniConfigGroups).Return(nil, nil)
+	mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, &manager.ENIConfigValidation{
+		Zone: aws.StringValue(eniConfigSubnetDetails.AvailabilityZone)})
+	mock.expectReferencingNodes(eniConfigNodeList)
+
+	mock.MockNodeManager.EXPECT().GetNode(labelledNode.Name).Return(mock.MockNode, true)
+	mock.MockNodeManager.EXPECT().UpdateNode(labelledNode.Name).Return(nil)
+	mock.MockNodeManager.EXPECT().GetNode(cniNode.Name).Return(mock.MockNode, true)
+	mock.MockNodeManager.EXPECT().UpdateNode(cniNode.Name).Return(nil)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.ENIConfigUpdatedReason,
+		"ENIConfig applied to 2 nodes", corev1.EventTypeNormal)
+
+	res, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func Test_Reconcile_ENIConfig_Deleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(nil,
+		apierrors.NewNotFound(schema.GroupResource{Resource: "eniconfigs"}, eniConfigName))
+	mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, nil)
+
+	res, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func Test_Reconcile_ENIConfig_InvalidSubnet(t *testing.T) {
+	otherVPCSubnet := *eniConfigSubnetDetails
+	otherVPCSubnet.VpcId = aws.String("vpc-other")
+
+	tests := []struct {
+		name      string
+		subnet    *ec2.Subnet
+		subnetErr error
+	}{
+		{name: "subnet not found", subnetErr: fmt.Errorf("InvalidSubnetID.NotFound: The subnet ID does not exist")},
+		{name: "subnet in another vpc", subnet: &otherVPCSubnet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := NewENIConfigMock(ctrl)
+			mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+			mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(test.subnet, test.subnetErr)
+			// The nodes are not updated with an invalid ENIConfig
+			mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, gomock.Not(gomock.Nil()))
+			mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.InvalidENIConfigReason, gomock.Any(),
+				corev1.EventTypeWarning)
+
+			res, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+			assert.NoError(t, err)
+			assert.Equal(t, reconcile.Result{}, res)
+		})
+	}
+}
+
+func Test_Reconcile_ENIConfig_SubnetError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+	mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(nil, errMock)
+
+	_, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.Error(t, err)
+}
+
+func Test_Reconcile_ENIConfig_MissingSecurityGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+	mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(eniConfigSubnetDetails, nil)
+	mock.MockEC2APIHelper.EXPECT().GetMissingSecurityGroups(clusterVPCID, eniConfigGroups).
+		Return(eniConfigGroups, nil)
+	mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, &manager.ENIConfigValidation{
+		InvalidReason: fmt.Sprintf("security groups %s not found in %s", eniConfigGroups[0], clusterVPCID)})
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.InvalidENIConfigReason,
+		fmt.Sprintf("security groups %s not found in %s", eniConfigGroups[0], clusterVPCID), corev1.EventTypeWarning)
+
+	res, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func Test_Reconcile_ENIConfig_LowFreeAddressesAndOtherZone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lowSubnet := *eniConfigSubnetDetails
+	lowSubnet.AvailableIpAddressCount = aws.Int64(3)
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+	mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(&lowSubnet, nil)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.ENIConfigSubnetLowOnAddressesReason, gomock.Any(),
+		corev1.EventTypeWarning)
+	// The security groups are not verified if EC2 fails to describe them
+	mock.MockEC2APIHelper.EXPECT().GetMissingSecurityGroups(clusterVPCID, eniConfigGroups).Return(nil, errMock)
+	mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, &manager.ENIConfigValidation{
+		Zone: aws.StringValue(eniConfigSubnetDetails.AvailabilityZone)})
+	mock.expectReferencingNodes(&corev1.NodeList{Items: []corev1.Node{labelledNode, otherZoneNode}})
+
+	mock.MockNodeManager.EXPECT().GetNode(labelledNode.Name).Return(mock.MockNode, true)
+	mock.MockNodeManager.EXPECT().UpdateNode(labelledNode.Name).Return(nil)
+	// The node in another availability zone is not updated
+	mock.MockNodeManager.EXPECT().GetNode(otherZoneNode.Name).Return(mock.MockNode, true)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.InvalidENIConfigReason, gomock.Any(),
+		corev1.EventTypeWarning).Times(2)
+	mock.MockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.ENIConfigUpdatedReason,
+		"ENIConfig applied to 1 nodes", corev1.EventTypeNormal)
+
+	res, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func Test_Reconcile_ENIConfig_UpdateNode_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewENIConfigMock(ctrl)
+	mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(newENIConfig(), nil)
+	mock.MockEC2APIHelper.EXPECT().GetSubnet(&eniConfigSubnet).Return(eniConfigSubnetDetails, nil)
+	mock.MockEC2APIHelper.EXPECT().GetMissingSecurityGroups(clusterVPCID, eniConfigGroups).Return(nil, nil)
+	mock.MockNodeManager.EXPECT().SetENIConfigValidation(eniConfigName, &manager.ENIConfigValidation{
+		Zone: aws.StringValue(eniConfigSubnetDetails.AvailabilityZone)})
+	mock.expectReferencingNodes(&corev1.NodeList{Items: []corev1.Node{labelledNode}})
+	mock.MockNodeManager.EXPECT().GetNode(labelledNode.Name).Return(mock.MockNode, true)
+	mock.MockNodeManager.EXPECT().UpdateNode(labelledNode.Name).Return(errMock)
+
+	_, err := mock.Reconciler.Reconcile(context.TODO(), mockENIConfigReq)
+	assert.Error(t, err)
+}
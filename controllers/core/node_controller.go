@@ -24,6 +24,7 @@ import (
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -37,7 +38,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
@@ -69,6 +72,9 @@ type NodeReconciler struct {
 	Manager    manager.Manager
 	Conditions condition.Conditions
 	Context    context.Context
+	// Sharder is set if the nodes are sharded across multiple active replicas, the replica only manages the nodes
+	// it owns
+	Sharder shard.Sharder
 }
 
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
@@ -91,6 +97,16 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	logger := r.Log.WithValues("node", req.NamespacedName)
 
+	if r.Sharder != nil && !r.Sharder.OwnsNode(req.Name) {
+		// The node is managed by the replica owning its shard, the resources are released without being deleted so
+		// the owner can take them over
+		if _, found := r.Manager.GetNode(req.Name); found {
+			logger.Info("releasing node owned by another replica")
+			return ctrl.Result{}, r.Manager.ReleaseNode(req.Name)
+		}
+		return ctrl.Result{}, nil
+	}
+
 	if nodeErr := r.Client.Get(ctx, req.NamespacedName, node); nodeErr != nil {
 		if errors.IsNotFound(nodeErr) {
 			// clean up CNINode finalizer
@@ -150,11 +166,15 @@ func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager, maxConcurrentReconci
 
 	prometheusRegister()
 
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Node{}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
-		Owns(&v1alpha1.CNINode{}).
-		Complete(r)
+		Owns(&v1alpha1.CNINode{})
+	if r.Sharder != nil {
+		// The nodes of the shards acquired or released by the replica are reconciled to be added or released
+		builder = builder.WatchesRawSource(source.Channel(r.Sharder.NodeEvents(), &handler.EnqueueRequestForObject{}))
+	}
+	return builder.Complete(r)
 }
 
 func (r *NodeReconciler) Check() healthz.Checker {
@@ -22,6 +22,7 @@ import (
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
 	mock_node "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node"
 	mock_manager "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node/manager"
+	mock_shard "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/shard"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -114,6 +115,33 @@ func TestNodeReconciler_Reconcile_UpdateNode(t *testing.T) {
 	assert.Equal(t, res, reconcile.Result{})
 }
 
+// TestNodeReconciler_Reconcile_NotOwned tests the node owned by another replica is released if it's cached and
+// ignored otherwise
+func TestNodeReconciler_Reconcile_NotOwned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewNodeMock(ctrl, mockNodeObj)
+	mockSharder := mock_shard.NewMockSharder(ctrl)
+	mock.Reconciler.Sharder = mockSharder
+
+	mock.Conditions.EXPECT().GetPodDataStoreSyncStatus().Return(true).Times(2)
+	mockSharder.EXPECT().OwnsNode(mockNodeName).Return(false).Times(2)
+	gomock.InOrder(
+		mock.Manager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true),
+		mock.Manager.EXPECT().GetNode(mockNodeName).Return(nil, false),
+	)
+	mock.Manager.EXPECT().ReleaseNode(mockNodeName).Return(nil)
+
+	res, err := mock.Reconciler.Reconcile(context.TODO(), reconcileRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, res, reconcile.Result{})
+
+	res, err = mock.Reconciler.Reconcile(context.TODO(), reconcileRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, res, reconcile.Result{})
+}
+
 func TestNodeReconciler_Reconcile_DeleteNode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -26,11 +26,15 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/dedicated"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/google/uuid"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -41,6 +45,7 @@ import (
 
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;update;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;patch;watch
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=patch
 
 type PodReconciler struct {
 	Log logr.Logger
@@ -53,15 +58,34 @@ type PodReconciler struct {
 	// DataStore is the cache with memory optimized Pod Objects
 	DataStore cache.Indexer
 	Condition condition.Conditions
+	// Sharder is set if the nodes are sharded across multiple active replicas, the replica only handles the pods
+	// on the nodes it owns
+	Sharder shard.Sharder
+	// Requeuer is bound to the work queue of the pod controller if set, so the other controllers can requeue pods
+	Requeuer *custom.Requeuer
 }
 
 var (
 	PodRequeueRequest = ctrl.Result{Requeue: true, RequeueAfter: time.Second}
+	// PodNotOwnedRequeueRequest is used for the pending pods on the nodes owned by another replica
+	PodNotOwnedRequeueRequest = ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second}
 )
 
 // Reconcile handles create/update/delete event by delegating the request to the  handler
 // if the resource is supported by the controller.
 func (r *PodReconciler) Reconcile(request custom.Request) (ctrl.Result, error) {
+	// The span is the root of the pod's networking trace, the handlers propagate it to the
+	// asynchronous jobs and the EC2 API calls
+	ctx, span := tracing.StartSpan(context.Background(), "PodReconciler.Reconcile",
+		attribute.String("pod.namespace", request.NamespacedName.Namespace),
+		attribute.String("pod.name", request.NamespacedName.Name),
+		attribute.Bool("pod.deleted", request.DeletedObject != nil))
+	result, err := r.reconcile(ctx, request)
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+func (r *PodReconciler) reconcile(ctx context.Context, request custom.Request) (ctrl.Result, error) {
 	var isDeleteEvent bool
 	var hasPodCompleted bool
 
@@ -100,6 +124,15 @@ func (r *PodReconciler) Reconcile(request custom.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
+	// The pods on the nodes owned by another replica are handled by that replica, the pending pods are retried in
+	// case the node's shard moves to this replica before they are handled
+	if r.Sharder != nil && !r.Sharder.OwnsNode(pod.Spec.NodeName) {
+		if !isDeleteEvent && pod.Status.Phase == v1.PodPending {
+			return PodNotOwnedRequeueRequest, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// On Controller startup, the Pod event should be processed after the Pod's node
 	// has initialized (or it will be stuck till the next re-sync period or Pod update).
 	// Once the Pod has been initialized if it's managed then wait till the asynchronous
@@ -140,6 +173,11 @@ func (r *PodReconciler) Reconcile(request custom.Request) (ctrl.Result, error) {
 		if resourceName == config.ResourceNameIPAddress {
 			resourceName = r.updateResourceName(isDeleteEvent || hasPodCompleted || nodeDeletedInCluster, pod, node)
 		}
+		// Pod annotation ResourceNamePodENI has two resource managers: branch ENI and dedicated ENI
+		if resourceName == config.ResourceNamePodENI {
+			resourceName = r.updatePodENIResourceName(isDeleteEvent || hasPodCompleted || nodeDeletedInCluster, pod,
+				node)
+		}
 
 		resourceHandler, isSupported := r.ResourceManager.GetResourceHandler(resourceName)
 		if !isSupported {
@@ -149,9 +187,9 @@ func (r *PodReconciler) Reconcile(request custom.Request) (ctrl.Result, error) {
 		var err error
 		var result ctrl.Result
 		if isDeleteEvent || hasPodCompleted || nodeDeletedInCluster {
-			result, err = resourceHandler.HandleDelete(pod)
+			result, err = resourceHandler.HandleDelete(ctx, pod)
 		} else {
-			result, err = resourceHandler.HandleCreate(int(totalCount), pod)
+			result, err = resourceHandler.HandleCreate(ctx, int(totalCount), pod)
 		}
 		if err != nil || result.Requeue == true {
 			return result, err
@@ -172,21 +210,47 @@ func (r *PodReconciler) isNodeExistingInCluster(pod *v1.Pod, logger logr.Logger)
 	return true
 }
 
-// getAggregateResources computes the aggregate resources across all containers for each resource type
+// getAggregateResources computes the aggregate resources across all containers for each resource type. The
+// init containers are accounted the same way as the scheduler does: the sidecar init containers keep running
+// with the containers and are added to them, while a regular init container only needs its own resources along
+// with the sidecars started before it
 func getAggregateResources(pod *v1.Pod) map[string]int64 {
 	aggregateResources := make(map[string]int64)
 	for _, container := range pod.Spec.Containers {
-		for resourceName, request := range container.Resources.Requests {
-			quantity, isConvertible := request.AsInt64()
-			if isConvertible {
-				resCount := aggregateResources[resourceName.String()]
-				aggregateResources[resourceName.String()] = resCount + quantity
-			}
+		addContainerRequests(aggregateResources, container)
+	}
+
+	initResources := make(map[string]int64)
+	sidecarResources := make(map[string]int64)
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			addContainerRequests(aggregateResources, container)
+			addContainerRequests(sidecarResources, container)
+			continue
 		}
+		containerResources := make(map[string]int64)
+		addContainerRequests(containerResources, container)
+		for resourceName, quantity := range containerResources {
+			initResources[resourceName] = max(initResources[resourceName], quantity+sidecarResources[resourceName])
+		}
+	}
+
+	for resourceName, quantity := range initResources {
+		aggregateResources[resourceName] = max(aggregateResources[resourceName], quantity)
 	}
 	return aggregateResources
 }
 
+// addContainerRequests adds the integer requests of the container to the resources
+func addContainerRequests(resources map[string]int64, container v1.Container) {
+	for resourceName, request := range container.Resources.Requests {
+		quantity, isConvertible := request.AsInt64()
+		if isConvertible {
+			resources[resourceName.String()] += quantity
+		}
+	}
+}
+
 // SetupWithManager adds the custom Pod controller's runnable to the manager's
 // list of runnable. After Manager acquire the lease the pod controller runnable
 // will be started and the Pod events will be sent to Reconcile function
@@ -205,7 +269,7 @@ func (r *PodReconciler) SetupWithManager(ctx context.Context, manager ctrl.Manag
 		PageLimit:               pageLimit,
 		ResyncPeriod:            syncPeriod,
 		MaxConcurrentReconciles: maxConcurrentReconciles,
-	}).UsingConditions(r.Condition).Complete(r)
+	}).UsingConditions(r.Condition).UsingRequeuer(r.Requeuer).Complete(r)
 
 	// add health check on subpath for pod and pod customized controllers
 	healthzHandler.AddControllersHealthCheckers(
@@ -270,3 +334,26 @@ func (r *PodReconciler) updateResourceName(isDeletionEvent bool, pod *v1.Pod, no
 	}
 	return resourceName
 }
+
+// updatePodENIResourceName updates resource name to the dedicated ENI resource if the pod's ENIs are dedicated ENIs
+// attached to the instance, which is the case on the instances not supporting trunking
+func (r *PodReconciler) updatePodENIResourceName(isDeletionEvent bool, pod *v1.Pod, node node.Node) string {
+	if _, found := r.ResourceManager.GetResourceProvider(config.ResourceNameDedicatedPodENI); !found {
+		// If dedicated ENI provider doesn't exist, simply return and use branch ENI
+		return config.ResourceNamePodENI
+	}
+
+	// Pod deletion must use the handler that created the ENIs, the node may not be cached anymore
+	if isDeletionEvent {
+		if _, ok := pod.Annotations[config.ResourceNamePodENI]; ok {
+			if dedicated.PodHasDedicatedENIs(pod) {
+				return config.ResourceNameDedicatedPodENI
+			}
+			return config.ResourceNamePodENI
+		}
+	}
+	if node == nil || node.IsTrunkingCompatible() {
+		return config.ResourceNamePodENI
+	}
+	return config.ResourceNameDedicatedPodENI
+}
@@ -27,6 +27,7 @@ import (
 	mock_pool "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/pool"
 	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
+	mock_shard "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/shard"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/golang/mock/gomock"
@@ -144,8 +145,9 @@ func TestPodReconciler_Reconcile_Create(t *testing.T) {
 	mock.MockK8sAPI.EXPECT().GetNode(mockNodeName).Return(nil, nil)
 	mock.MockNode.EXPECT().IsManaged().Return(true)
 	mock.MockNode.EXPECT().IsReady().Return(true)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleCreate(3, gomock.Any()).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleCreate(gomock.Any(), 3, gomock.Any()).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	result, err := mock.PodReconciler.Reconcile(mockReq)
@@ -163,8 +165,9 @@ func TestPodReconciler_Reconcile_Delete(t *testing.T) {
 
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockNode.EXPECT().IsManaged().Return(true)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleDelete(mockPod).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleDelete(gomock.Any(), mockPod).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	delReq := custom.Request{
@@ -208,6 +211,30 @@ func TestPodReconciler_Reconcile_NoNodeAssigned(t *testing.T) {
 	assert.Equal(t, result, controllerruntime.Result{})
 }
 
+// TestPodReconciler_Reconcile_NodeNotOwned tests that the requests for the pods on a node owned by another replica
+// are ignored, and the pending pods are retried
+func TestPodReconciler_Reconcile_NodeNotOwned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pendingPod := mockPod.DeepCopy()
+	pendingPod.Status.Phase = v1.PodPending
+
+	mock := NewMock(ctrl, pendingPod)
+	mockSharder := mock_shard.NewMockSharder(ctrl)
+	mock.PodReconciler.Sharder = mockSharder
+
+	mockSharder.EXPECT().OwnsNode(mockNodeName).Return(false).Times(2)
+
+	result, err := mock.PodReconciler.Reconcile(mockReq)
+	assert.NoError(t, err)
+	assert.Equal(t, PodNotOwnedRequeueRequest, result)
+
+	result, err = mock.PodReconciler.Reconcile(custom.Request{DeletedObject: pendingPod})
+	assert.NoError(t, err)
+	assert.Equal(t, controllerruntime.Result{}, result)
+}
+
 // TestPodReconciler_Reconcile_NodeNotReady tests that the request is ignored when the node is not ready
 func TestPodReconciler_Reconcile_NodeNotReady(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -246,8 +273,9 @@ func TestPodReconcile_Reconcile_NodeDeletedFromCache(t *testing.T) {
 	mock := NewMock(ctrl, mockPod)
 
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(nil, false)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleDelete(mockPod).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleDelete(gomock.Any(), mockPod).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	delReq := custom.Request{
@@ -268,8 +296,9 @@ func TestPodReconcile_Reconcile_NodeDeletedFromCluster(t *testing.T) {
 
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(nil, false)
 	mock.MockK8sAPI.EXPECT().GetNode(mockNodeName).Return(nil, errors.New("Resource not found")).AnyTimes()
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleDelete(mockPod).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleDelete(gomock.Any(), mockPod).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	result, err := mock.PodReconciler.Reconcile(mockReq)
@@ -287,8 +316,9 @@ func TestPodReconcile_Reconcile_ManagedNodeDeletedFromCluster(t *testing.T) {
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockK8sAPI.EXPECT().GetNode(mockNodeName).Return(nil, errors.New("Resource not found")).AnyTimes()
 	mock.MockNode.EXPECT().IsManaged().Return(true)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleDelete(mockPod).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleDelete(gomock.Any(), mockPod).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	result, err := mock.PodReconciler.Reconcile(mockReq)
@@ -306,8 +336,9 @@ func TestPodReconcile_Reconcile_PodDeletedManagedNodeDeletedFromCluster(t *testi
 	mock.MockNodeManager.EXPECT().GetNode(mockNodeName).Return(mock.MockNode, true)
 	mock.MockK8sAPI.EXPECT().GetNode(mockNodeName).Return(nil, errors.New("Resource not found")).AnyTimes()
 	mock.MockNode.EXPECT().IsManaged().Return(true)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockResourceName).Return(mock.MockHandler, true)
-	mock.MockHandler.EXPECT().HandleDelete(mockPod).Return(reconcile.Result{}, nil)
+	mock.MockHandler.EXPECT().HandleDelete(gomock.Any(), mockPod).Return(reconcile.Result{}, nil)
 	mock.MockResourceManager.EXPECT().GetResourceHandler(mockUnsupportedResourceName).Return(nil, false)
 
 	delReq := custom.Request{
@@ -416,3 +447,133 @@ func TestUpdateResourceName_WinPDFeatureOFF(t *testing.T) {
 	// since Windows PD feature flag is off, resource name remains ResourceNameIPAddress for secondary IP mode
 	assert.Equal(t, config.ResourceNameIPAddress, resourceName)
 }
+
+// TestUpdatePodENIResourceName_NonTrunkingInstance tests for pod creation events, instances not supporting trunking
+// should use the dedicated ENI handler
+func TestUpdatePodENIResourceName_NonTrunkingInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, mockPod)
+	mockProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(mockProvider, true)
+	mock.MockNode.EXPECT().IsTrunkingCompatible().Return(false)
+	resourceName := mock.PodReconciler.updatePodENIResourceName(false, mockPod, mock.MockNode)
+
+	assert.Equal(t, config.ResourceNameDedicatedPodENI, resourceName)
+}
+
+// TestUpdatePodENIResourceName_TrunkingInstance tests for pod creation events, instances supporting trunking should
+// use the branch ENI handler
+func TestUpdatePodENIResourceName_TrunkingInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, mockPod)
+	mockProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(mockProvider, true)
+	mock.MockNode.EXPECT().IsTrunkingCompatible().Return(true)
+	resourceName := mock.PodReconciler.updatePodENIResourceName(false, mockPod, mock.MockNode)
+
+	assert.Equal(t, config.ResourceNamePodENI, resourceName)
+}
+
+// TestUpdatePodENIResourceName_IsDeleteEvent tests for pod deletion events, the handler is chosen from the ENIs
+// annotated on the pod as the node may not be cached anymore
+func TestUpdatePodENIResourceName_IsDeleteEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dedicatedENIPod := mockPod.DeepCopy()
+	dedicatedENIPod.Annotations = map[string]string{config.ResourceNamePodENI: `[{"eniId":"eni-1","vlanId":0}]`}
+	branchENIPod := mockPod.DeepCopy()
+	branchENIPod.Annotations = map[string]string{config.ResourceNamePodENI: `[{"eniId":"eni-1","vlanId":1}]`}
+
+	mock := NewMock(ctrl, mockPod)
+	mockProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).
+		Return(mockProvider, true).Times(3)
+
+	assert.Equal(t, config.ResourceNameDedicatedPodENI,
+		mock.PodReconciler.updatePodENIResourceName(true, dedicatedENIPod, nil))
+	assert.Equal(t, config.ResourceNamePodENI, mock.PodReconciler.updatePodENIResourceName(true, branchENIPod, nil))
+	// The pod deleted before it was annotated
+	assert.Equal(t, config.ResourceNamePodENI, mock.PodReconciler.updatePodENIResourceName(true, mockPod, nil))
+}
+
+// TestUpdatePodENIResourceName_DedicatedENIDisabled tests that the branch ENI handler is used if the dedicated ENI
+// resource is not enabled
+func TestUpdatePodENIResourceName_DedicatedENIDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, mockPod)
+	mock.MockResourceManager.EXPECT().GetResourceProvider(config.ResourceNameDedicatedPodENI).Return(nil, false)
+	resourceName := mock.PodReconciler.updatePodENIResourceName(false, mockPod, mock.MockNode)
+
+	assert.Equal(t, config.ResourceNamePodENI, resourceName)
+}
+
+// TestGetAggregateResources tests the resources of the containers, sidecar init containers and regular init
+// containers are aggregated the same way as the scheduler
+func TestGetAggregateResources(t *testing.T) {
+	sidecar := v1.ContainerRestartPolicyAlways
+	withRequests := func(count string, restartPolicy *v1.ContainerRestartPolicy) v1.Container {
+		return v1.Container{
+			RestartPolicy: restartPolicy,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{config.ResourceNamePodENI: resource.MustParse(count)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		containers     []v1.Container
+		initContainers []v1.Container
+		expected       map[string]int64
+	}{
+		{
+			name:       "containers only",
+			containers: []v1.Container{withRequests("1", nil), withRequests("2", nil)},
+			expected:   map[string]int64{config.ResourceNamePodENI: 3},
+		},
+		{
+			name:           "sidecar init container",
+			containers:     []v1.Container{{}},
+			initContainers: []v1.Container{withRequests("1", &sidecar)},
+			expected:       map[string]int64{config.ResourceNamePodENI: 1},
+		},
+		{
+			name:           "sidecar init container added to the containers",
+			containers:     []v1.Container{withRequests("1", nil)},
+			initContainers: []v1.Container{withRequests("1", &sidecar)},
+			expected:       map[string]int64{config.ResourceNamePodENI: 2},
+		},
+		{
+			name:           "regular init container not added to the containers",
+			containers:     []v1.Container{withRequests("1", nil)},
+			initContainers: []v1.Container{withRequests("1", nil)},
+			expected:       map[string]int64{config.ResourceNamePodENI: 1},
+		},
+		{
+			name:           "regular init container running with the sidecars started before it",
+			containers:     []v1.Container{{}},
+			initContainers: []v1.Container{withRequests("1", &sidecar), withRequests("1", nil)},
+			expected:       map[string]int64{config.ResourceNamePodENI: 2},
+		},
+		{
+			name:           "regular init container started before the sidecar",
+			containers:     []v1.Container{{}},
+			initContainers: []v1.Container{withRequests("1", nil), withRequests("1", &sidecar)},
+			expected:       map[string]int64{config.ResourceNamePodENI: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: v1.PodSpec{Containers: test.containers, InitContainers: test.initContainers}}
+			assert.Equal(t, test.expected, getAggregateResources(pod))
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// serviceAccountNameField is the pod field selector on the service account of the pod
+	serviceAccountNameField = "spec.serviceAccountName"
+
+	sgpAffectedPodActionRequeued = "requeued"
+	sgpAffectedPodActionReported = "reported"
+)
+
+var (
+	serviceAccountSGPAffectedPodCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_account_sgp_affected_pod_count",
+			Help: "The number of pods whose matching security groups changed with the labels of their service account",
+		},
+		[]string{"action"},
+	)
+
+	serviceAccountPrometheusRegistered = false
+)
+
+// PodRequeuer requeues the pods in the pod controller
+type PodRequeuer interface {
+	// Requeue adds the pod to the work queue of the pod controller, it returns false if the pod was not requeued
+	Requeue(namespacedName types.NamespacedName) bool
+}
+
+// ServiceAccountReconciler re-evaluates the SecurityGroupPolicy matching the pods of a service account when the
+// labels of the service account change. The security groups are only read at pod admission and when the branch
+// ENI is created, so the pods whose matching security groups changed are reported and, with live update, their
+// branch ENIs are updated by requeuing the pods in the pod controller.
+type ServiceAccountReconciler struct {
+	Log logr.Logger
+	// Client reads the service accounts from the cache
+	Client client.Client
+	// APIReader lists the pods of the service account from the API Server, the pods are not cached by the manager
+	APIReader client.Reader
+	SGPAPI    utils.SecurityGroupForPodsAPI
+	K8sAPI    k8s.K8sWrapper
+	// LiveUpdate updates the security groups of the branch ENIs of the affected pods, the affected pods are only
+	// reported if it's not set
+	LiveUpdate            bool
+	SecurityGroupsUpdater branch.SecurityGroupsUpdater
+	PodRequeuer           PodRequeuer
+	// Sharder is set if the nodes are sharded across multiple active replicas, the replica only handles the pods
+	// on the nodes it owns
+	Sharder shard.Sharder
+	// PageLimit is the number of pods listed per request
+	PageLimit int
+
+	lock sync.Mutex
+	// previousLabels has the labels of the service accounts before the label changes that are not reconciled yet
+	previousLabels map[types.NamespacedName]map[string]string
+}
+
+// Reconcile compares the security groups matching each pod of the service account with the previous and the
+// current labels of the service account, and reports or live updates the pods whose security groups changed
+func (r *ServiceAccountReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	previousLabels, found := r.takePreviousLabels(req.NamespacedName)
+	if !found {
+		// The label change was already reconciled
+		return ctrl.Result{}, nil
+	}
+
+	sa := &corev1.ServiceAccount{}
+	if err := r.Client.Get(ctx, req.NamespacedName, sa); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.restorePreviousLabels(req.NamespacedName, previousLabels)
+		return ctrl.Result{}, err
+	}
+	previousSA := sa.DeepCopy()
+	previousSA.Labels = previousLabels
+
+	pods, err := r.listServiceAccountPods(ctx, sa)
+	if err != nil {
+		r.restorePreviousLabels(req.NamespacedName, previousLabels)
+		return ctrl.Result{}, err
+	}
+
+	affected := 0
+	var errList []error
+	for i := range pods {
+		pod := &pods[i]
+		if !r.isEvaluatedPod(pod) {
+			continue
+		}
+		previousGroups, err := r.SGPAPI.GetMatchingSecurityGroupForServiceAccount(pod, previousSA)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		currentGroups, err := r.SGPAPI.GetMatchingSecurityGroupForServiceAccount(pod, sa)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		if len(utils.Difference(previousGroups, currentGroups)) == 0 &&
+			len(utils.Difference(currentGroups, previousGroups)) == 0 {
+			continue
+		}
+		affected++
+		r.handleAffectedPod(pod, sa.Name, previousGroups, currentGroups)
+	}
+
+	if affected > 0 {
+		r.Log.Info("security groups of the pods changed with the service account labels", "serviceaccount",
+			req.NamespacedName, "affected pod count", affected, "live update", r.LiveUpdate)
+	}
+	if len(errList) > 0 {
+		// The pods are evaluated again, the pods already requeued are not affected anymore
+		r.restorePreviousLabels(req.NamespacedName, previousLabels)
+		return ctrl.Result{}, fmt.Errorf("failed to evaluate the security groups of one or more pods %v", errList)
+	}
+	return ctrl.Result{}, nil
+}
+
+// listServiceAccountPods lists the pods using the service account from the API Server page by page
+func (r *ServiceAccountReconciler) listServiceAccountPods(ctx context.Context,
+	sa *corev1.ServiceAccount) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+	continueToken := ""
+	for {
+		podList := &corev1.PodList{}
+		if err := r.APIReader.List(ctx, podList, client.InNamespace(sa.Namespace),
+			client.MatchingFields{serviceAccountNameField: sa.Name}, client.Limit(int64(r.PageLimit)),
+			client.Continue(continueToken)); err != nil {
+			return nil, fmt.Errorf("listing pods of service account %s/%s, %w", sa.Namespace, sa.Name, err)
+		}
+		pods = append(pods, podList.Items...)
+		if continueToken = podList.Continue; continueToken == "" {
+			return pods, nil
+		}
+	}
+}
+
+// isEvaluatedPod returns true for the running or pending pods scheduled on the nodes owned by the replica, the
+// host network pods don't use the security groups of the SecurityGroupPolicy
+func (r *ServiceAccountReconciler) isEvaluatedPod(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Spec.HostNetwork || pod.Status.Phase == corev1.PodSucceeded ||
+		pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if r.Sharder != nil && (pod.Spec.NodeName == "" || !r.Sharder.OwnsNode(pod.Spec.NodeName)) {
+		return false
+	}
+	return true
+}
+
+// handleAffectedPod requeues the pod with a branch ENI in the pod controller to update the security groups of its
+// branch ENIs if live update is enabled, or reports the pod with a warning event
+func (r *ServiceAccountReconciler) handleAffectedPod(pod *corev1.Pod, saName string, previousGroups,
+	currentGroups []string) {
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	log := r.Log.WithValues("pod", key, "previous security groups", previousGroups,
+		"current security groups", currentGroups)
+	message := fmt.Sprintf("Security groups matching the pod changed from %v to %v with the labels of service "+
+		"account %s", previousGroups, currentGroups, saName)
+
+	_, hasBranchENI := pod.Annotations[config.ResourceNamePodENI]
+	if r.LiveUpdate && hasBranchENI && len(currentGroups) > 0 {
+		r.SecurityGroupsUpdater.SetSecurityGroupsOutdated(key)
+		if r.PodRequeuer.Requeue(key) {
+			log.Info("requeued the pod to update the security groups of its branch ENIs")
+			r.K8sAPI.BroadcastEvent(pod, utils.SecurityGroupsChangedReason,
+				message+", updating the security groups of the branch ENIs", corev1.EventTypeNormal)
+			serviceAccountSGPAffectedPodCount.WithLabelValues(sgpAffectedPodActionRequeued).Inc()
+			return
+		}
+	}
+
+	switch {
+	case !hasBranchENI && !utils.PodHasENIRequest(pod):
+		message += fmt.Sprintf(", the pod must be re-created to get the %s resource", config.ResourceNamePodENI)
+	case !hasBranchENI:
+		// The branch ENI is not created yet, it gets the current security groups
+		return
+	default:
+		message += ", the pod must be re-created to use the current security groups"
+	}
+	log.Info("security groups of the pod changed with the service account labels")
+	r.K8sAPI.BroadcastEvent(pod, utils.SecurityGroupsChangedReason, message, corev1.EventTypeWarning)
+	serviceAccountSGPAffectedPodCount.WithLabelValues(sgpAffectedPodActionReported).Inc()
+}
+
+// onUpdate stores the labels of the service account before the change and adds the service account to the queue
+// if its labels changed. The labels of the oldest change not reconciled yet are kept.
+func (r *ServiceAccountReconciler) onUpdate(_ context.Context, e event.UpdateEvent,
+	queue workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	if e.ObjectOld == nil || e.ObjectNew == nil ||
+		equality.Semantic.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) {
+		return
+	}
+	key := types.NamespacedName{Namespace: e.ObjectNew.GetNamespace(), Name: e.ObjectNew.GetName()}
+
+	r.lock.Lock()
+	if _, found := r.previousLabels[key]; !found {
+		r.previousLabels[key] = utils.CopyMap(e.ObjectOld.GetLabels())
+	}
+	r.lock.Unlock()
+
+	queue.Add(reconcile.Request{NamespacedName: key})
+}
+
+// takePreviousLabels returns and removes the labels of the service account before the change
+func (r *ServiceAccountReconciler) takePreviousLabels(key types.NamespacedName) (map[string]string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	labels, found := r.previousLabels[key]
+	delete(r.previousLabels, key)
+	return labels, found
+}
+
+// restorePreviousLabels stores the labels again so the label change is reconciled on retry, they are older than
+// the labels of any change received since they were taken
+func (r *ServiceAccountReconciler) restorePreviousLabels(key types.NamespacedName, labels map[string]string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.previousLabels[key] = labels
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ServiceAccountReconciler) SetupWithManager(mgr ctrl.Manager, healthzHandler *rcHealthz.HealthzHandler) error {
+	if r.LiveUpdate && (r.SecurityGroupsUpdater == nil || r.PodRequeuer == nil) {
+		return fmt.Errorf("live update requires the security groups updater and the pod requeuer")
+	}
+	r.previousLabels = make(map[types.NamespacedName]map[string]string)
+
+	serviceAccountPrometheusRegister()
+
+	// add health check on subpath for service account controller
+	healthzHandler.AddControllersHealthCheckers(
+		map[string]healthz.Checker{
+			"health-serviceaccount-controller": rcHealthz.SimplePing("serviceaccount controller", r.Log),
+		},
+	)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("serviceaccount").
+		Watches(&corev1.ServiceAccount{}, handler.Funcs{UpdateFunc: r.onUpdate}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(r)
+}
+
+func serviceAccountPrometheusRegister() {
+	if !serviceAccountPrometheusRegistered {
+		metrics.Registry.MustRegister(serviceAccountSGPAffectedPodCount)
+
+		serviceAccountPrometheusRegistered = true
+	}
+}
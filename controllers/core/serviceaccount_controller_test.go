@@ -0,0 +1,212 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	mock_utils "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/utils"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+)
+
+var (
+	saNamespace = "sa-namespace"
+	saKey       = types.NamespacedName{Namespace: saNamespace, Name: "app"}
+	saReq       = reconcile.Request{NamespacedName: saKey}
+
+	// The pods of the service account match the security group with the tier label of the service account
+	previousSALabels = map[string]string{"tier": "frontend"}
+	currentSALabels  = map[string]string{"tier": "backend"}
+	tierGroups       = map[string][]string{"frontend": {"sg-frontend"}, "backend": {"sg-backend"}}
+)
+
+// fakeSecurityGroupsUpdater records the pods marked with outdated security groups
+type fakeSecurityGroupsUpdater struct {
+	outdated []types.NamespacedName
+}
+
+func (f *fakeSecurityGroupsUpdater) SetSecurityGroupsOutdated(pod types.NamespacedName) {
+	f.outdated = append(f.outdated, pod)
+}
+
+// fakePodRequeuer records the requeued pods
+type fakePodRequeuer struct {
+	requeued []types.NamespacedName
+}
+
+func (f *fakePodRequeuer) Requeue(namespacedName types.NamespacedName) bool {
+	f.requeued = append(f.requeued, namespacedName)
+	return true
+}
+
+func newServiceAccountPod(name string, saName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: saNamespace, Annotations: map[string]string{}},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: saName,
+			NodeName:           "node",
+			Containers:         []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func getServiceAccountReconciler(t *testing.T, ctrl *gomock.Controller, objects ...runtime.Object) (
+	*ServiceAccountReconciler, *mock_k8s.MockK8sWrapper) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: saKey.Name, Namespace: saKey.Namespace,
+		Labels: currentSALabels}}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(append(objects, sa)...).
+		WithIndex(&corev1.Pod{}, serviceAccountNameField, func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.ServiceAccountName}
+		}).Build()
+
+	mockSGPAPI := mock_utils.NewMockSecurityGroupForPodsAPI(ctrl)
+	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForServiceAccount(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *corev1.Pod, sa *corev1.ServiceAccount) ([]string, error) {
+			return tierGroups[sa.Labels["tier"]], nil
+		}).AnyTimes()
+	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
+
+	return &ServiceAccountReconciler{
+		Log:            zap.New(),
+		Client:         fakeClient,
+		APIReader:      fakeClient,
+		SGPAPI:         mockSGPAPI,
+		K8sAPI:         mockK8sAPI,
+		PageLimit:      1,
+		previousLabels: map[types.NamespacedName]map[string]string{saKey: previousSALabels},
+	}, mockK8sAPI
+}
+
+// TestServiceAccountReconciler_Reconcile_Report tests the pods of the service account whose security groups
+// changed are reported with an event, unless their branch ENI is not created yet
+func TestServiceAccountReconciler_Reconcile_Report(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withBranch := newServiceAccountPod("with-branch", saKey.Name)
+	withBranch.Annotations[config.ResourceNamePodENI] = "[]"
+	withoutRequest := newServiceAccountPod("without-request", saKey.Name)
+	pendingBranch := newServiceAccountPod("pending-branch", saKey.Name)
+	pendingBranch.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		config.ResourceNamePodENI: resource.MustParse("1"),
+	}
+	hostNetwork := newServiceAccountPod("host-network", saKey.Name)
+	hostNetwork.Spec.HostNetwork = true
+	otherSA := newServiceAccountPod("other-sa", "other")
+
+	reconciler, mockK8sAPI := getServiceAccountReconciler(t, ctrl, withBranch, withoutRequest, pendingBranch,
+		hostNetwork, otherSA)
+
+	for _, name := range []string{withBranch.Name, withoutRequest.Name} {
+		expectedName := name
+		mockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.SecurityGroupsChangedReason, gomock.Any(),
+			corev1.EventTypeWarning).Do(func(obj runtime.Object, _, _, _ string) {
+			assert.Equal(t, expectedName, obj.(*corev1.Pod).Name)
+		})
+	}
+
+	_, err := reconciler.Reconcile(context.TODO(), saReq)
+	assert.NoError(t, err)
+	assert.Empty(t, reconciler.previousLabels)
+}
+
+// TestServiceAccountReconciler_Reconcile_LiveUpdate tests the pods with a branch ENI are marked with outdated
+// security groups and requeued in the pod controller
+func TestServiceAccountReconciler_Reconcile_LiveUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withBranch := newServiceAccountPod("with-branch", saKey.Name)
+	withBranch.Annotations[config.ResourceNamePodENI] = "[]"
+
+	reconciler, mockK8sAPI := getServiceAccountReconciler(t, ctrl, withBranch)
+	updater, requeuer := &fakeSecurityGroupsUpdater{}, &fakePodRequeuer{}
+	reconciler.LiveUpdate = true
+	reconciler.SecurityGroupsUpdater = updater
+	reconciler.PodRequeuer = requeuer
+
+	mockK8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.SecurityGroupsChangedReason, gomock.Any(),
+		corev1.EventTypeNormal)
+
+	_, err := reconciler.Reconcile(context.TODO(), saReq)
+	assert.NoError(t, err)
+
+	podKey := types.NamespacedName{Namespace: saNamespace, Name: withBranch.Name}
+	assert.Equal(t, []types.NamespacedName{podKey}, updater.outdated)
+	assert.Equal(t, []types.NamespacedName{podKey}, requeuer.requeued)
+}
+
+// TestServiceAccountReconciler_Reconcile_NoLabelChange tests the pods are not evaluated if the label change was
+// already reconciled
+func TestServiceAccountReconciler_Reconcile_NoLabelChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withBranch := newServiceAccountPod("with-branch", saKey.Name)
+	withBranch.Annotations[config.ResourceNamePodENI] = "[]"
+
+	reconciler, _ := getServiceAccountReconciler(t, ctrl, withBranch)
+	reconciler.previousLabels = map[types.NamespacedName]map[string]string{}
+
+	_, err := reconciler.Reconcile(context.TODO(), saReq)
+	assert.NoError(t, err)
+}
+
+// TestServiceAccountReconciler_onUpdate tests only the label changes are queued and the labels of the oldest change
+// are kept
+func TestServiceAccountReconciler_onUpdate(t *testing.T) {
+	reconciler := &ServiceAccountReconciler{previousLabels: map[types.NamespacedName]map[string]string{}}
+	queue := workqueue.NewTypedRateLimitingQueue[reconcile.Request](
+		workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	newSA := func(labels map[string]string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: saKey.Name, Namespace: saKey.Namespace,
+			Labels: labels}}
+	}
+	intermediateLabels := map[string]string{"tier": "middle"}
+
+	reconciler.onUpdate(context.TODO(), event.UpdateEvent{ObjectOld: newSA(previousSALabels),
+		ObjectNew: newSA(previousSALabels)}, queue)
+	assert.Equal(t, 0, queue.Len())
+
+	reconciler.onUpdate(context.TODO(), event.UpdateEvent{ObjectOld: newSA(previousSALabels),
+		ObjectNew: newSA(intermediateLabels)}, queue)
+	reconciler.onUpdate(context.TODO(), event.UpdateEvent{ObjectOld: newSA(intermediateLabels),
+		ObjectNew: newSA(currentSALabels)}, queue)
+	assert.Equal(t, 1, queue.Len())
+	assert.Equal(t, previousSALabels, reconciler.previousLabels[saKey])
+}
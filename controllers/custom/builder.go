@@ -16,6 +16,7 @@ package custom
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
@@ -46,6 +47,8 @@ type Builder struct {
 	log        logr.Logger
 	ctx        context.Context
 	conditions condition.Conditions
+	// requeuer is bound to the work queue of the controller if set
+	requeuer *Requeuer
 }
 
 func (b *Builder) Named(name string) *Builder {
@@ -83,6 +86,11 @@ func (b *Builder) UsingConditions(conditions condition.Conditions) *Builder {
 	return b
 }
 
+func (b *Builder) UsingRequeuer(requeuer *Requeuer) *Builder {
+	b.requeuer = requeuer
+	return b
+}
+
 func NewControllerManagedBy(ctx context.Context, mgr manager.Manager) *Builder {
 	return &Builder{mgr: mgr,
 		ctx: ctx}
@@ -111,6 +119,12 @@ func (b *Builder) Complete(reconciler Reconciler) (healthz.Checker, error) {
 
 	workQueue := workqueue.NewNamedRateLimitingQueue(
 		workqueue.DefaultControllerRateLimiter(), b.options.Name)
+	// leading is set by the controller once the replica is elected leader
+	leading := &atomic.Bool{}
+	if b.requeuer != nil {
+		b.requeuer.workQueue = workQueue
+		b.requeuer.leading = leading
+	}
 
 	optimizedListWatch := newOptimizedListWatcher(b.ctx, b.clientSet.CoreV1().RESTClient(),
 		b.converter.Resource(), b.options.Namespace, b.converter, b.log.WithName("listWatcher"))
@@ -123,55 +137,7 @@ func (b *Builder) Complete(reconciler Reconciler) (healthz.Checker, error) {
 		WatchListPageSize: int64(b.options.PageLimit),
 		ObjectType:        b.converter.ResourceType(),
 		FullResyncPeriod:  b.options.ResyncPeriod,
-		Process: func(obj interface{}, _ bool) error {
-			// from oldest to newest
-			for _, d := range obj.(cache.Deltas) {
-				// Strip down the pod object and keep only the required details
-				convertedObj, err := b.converter.ConvertObject(d.Object)
-				if err != nil {
-					return err
-				}
-				switch d.Type {
-				case cache.Sync, cache.Added, cache.Updated:
-					if _, exists, err := b.dataStore.Get(convertedObj); err == nil && exists {
-						if err := b.dataStore.Update(convertedObj); err != nil {
-							return err
-						}
-					} else {
-						if err := b.dataStore.Add(convertedObj); err != nil {
-							return err
-						}
-					}
-					if err != nil {
-						return err
-					}
-					metaObj, ok := convertedObj.(metav1.Object)
-					if !ok {
-						return fmt.Errorf("failed to get object meta %v", obj)
-					}
-
-					// Add the namespace/name to the queue so multiple
-					// duplicate events are processed only once at a time
-					workQueue.Add(Request{
-						NamespacedName: types.NamespacedName{
-							Namespace: metaObj.GetNamespace(),
-							Name:      metaObj.GetName(),
-						},
-					})
-
-				case cache.Deleted:
-					if err := b.dataStore.Delete(convertedObj); err != nil {
-						return err
-					}
-					// Add entire object instead of namespace/name as from this
-					// point onwards the object will no longer be present in cache
-					workQueue.Add(Request{
-						DeletedObject: convertedObj,
-					})
-				}
-			}
-			return nil
-		},
+		Process:           newProcessFunc(b.converter, b.dataStore, workQueue, leading),
 	}
 
 	controller := NewCustomController(
@@ -181,12 +147,76 @@ func (b *Builder) Complete(reconciler Reconciler) (healthz.Checker, error) {
 		reconciler,
 		workQueue,
 		b.conditions,
+		b.dataStore,
+		b.mgr.Elected(),
+		leading,
 	)
 
 	// Adds the controller to the manager's Runnable
 	return controller.checker, b.mgr.Add(controller)
 }
 
+// newProcessFunc returns the function storing the converted objects in the data store. The objects are added to the
+// work queue only once the replica is leading, the standby replicas only keep the data store in sync.
+func newProcessFunc(converter Converter, dataStore cache.Indexer, workQueue workqueue.RateLimitingInterface,
+	leading *atomic.Bool) cache.ProcessFunc {
+	return func(obj interface{}, _ bool) error {
+		// from oldest to newest
+		for _, d := range obj.(cache.Deltas) {
+			// Strip down the pod object and keep only the required details
+			convertedObj, err := converter.ConvertObject(d.Object)
+			if err != nil {
+				return err
+			}
+			switch d.Type {
+			case cache.Sync, cache.Added, cache.Updated:
+				if _, exists, err := dataStore.Get(convertedObj); err == nil && exists {
+					if err := dataStore.Update(convertedObj); err != nil {
+						return err
+					}
+				} else {
+					if err := dataStore.Add(convertedObj); err != nil {
+						return err
+					}
+				}
+				if err != nil {
+					return err
+				}
+				metaObj, ok := convertedObj.(metav1.Object)
+				if !ok {
+					return fmt.Errorf("failed to get object meta %v", obj)
+				}
+
+				if !leading.Load() {
+					continue
+				}
+				// Add the namespace/name to the queue so multiple
+				// duplicate events are processed only once at a time
+				workQueue.Add(Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: metaObj.GetNamespace(),
+						Name:      metaObj.GetName(),
+					},
+				})
+
+			case cache.Deleted:
+				if err := dataStore.Delete(convertedObj); err != nil {
+					return err
+				}
+				if !leading.Load() {
+					continue
+				}
+				// Add entire object instead of namespace/name as from this
+				// point onwards the object will no longer be present in cache
+				workQueue.Add(Request{
+					DeletedObject: convertedObj,
+				})
+			}
+		}
+		return nil
+	}
+}
+
 // SetDefaults sets the default options for controller
 func (b *Builder) SetDefaults() {
 	if b.options.Name == "" {
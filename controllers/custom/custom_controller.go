@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
@@ -98,6 +99,14 @@ type CustomController struct {
 	// the controller
 	options    Options
 	conditions condition.Conditions
+	// dataStore is the data store updated by the client-go controller
+	dataStore cache.Indexer
+	// elected is closed once the replica is elected leader. Till then the data
+	// store is kept in sync but the events are not reconciled
+	elected <-chan struct{}
+	// leading is set once the replica is elected leader and the events are
+	// added to the work queue
+	leading *atomic.Bool
 
 	checker healthz.Checker
 }
@@ -113,13 +122,34 @@ type Request struct {
 	DeletedObject interface{}
 }
 
+// Requeuer adds requests to the work queue of a custom controller from the other controllers. It's bound to the
+// work queue when the custom controller is built and drops the requests till then, or while the replica is not
+// the leader.
+type Requeuer struct {
+	workQueue workqueue.RateLimitingInterface
+	leading   *atomic.Bool
+}
+
+// Requeue adds the namespace/name of the object to the work queue, the object is reconciled with the object from
+// the data store. It returns false if the request was dropped.
+func (r *Requeuer) Requeue(namespacedName types.NamespacedName) bool {
+	if r.workQueue == nil || !r.leading.Load() {
+		return false
+	}
+	r.workQueue.Add(Request{NamespacedName: namespacedName})
+	return true
+}
+
 func NewCustomController(
 	log logr.Logger,
 	options Options,
 	config *cache.Config,
 	reconciler Reconciler,
 	workQueue workqueue.RateLimitingInterface,
-	conditions condition.Conditions) *CustomController {
+	conditions condition.Conditions,
+	dataStore cache.Indexer,
+	elected <-chan struct{},
+	leading *atomic.Bool) *CustomController {
 	cc := &CustomController{
 		log:        log,
 		options:    options,
@@ -127,6 +157,9 @@ func NewCustomController(
 		Do:         reconciler,
 		workQueue:  workQueue,
 		conditions: conditions,
+		dataStore:  dataStore,
+		elected:    elected,
+		leading:    leading,
 	}
 	cc.checker = cc.CustomCheck()
 	return cc
@@ -148,6 +181,16 @@ func (c *CustomController) Start(ctx context.Context) error {
 		// Wait till cache sync
 		c.WaitForCacheSync(coreController)
 
+		// The standby replica keeps the data store warm so the workers can start
+		// as soon as it's elected leader
+		select {
+		case <-c.elected:
+		case <-ctx.Done():
+			return nil
+		}
+		c.leading.Store(true)
+		c.enqueueDataStore()
+
 		c.log.Info("Starting Workers", "worker count",
 			c.options.MaxConcurrentReconciles)
 		for i := 0; i < c.options.MaxConcurrentReconciles; i++ {
@@ -177,6 +220,29 @@ func (c *CustomController) WaitForCacheSync(controller cache.Controller) {
 	c.log.Info("cache has synced successfully")
 }
 
+// enqueueDataStore adds all the objects in the data store to the work queue, the
+// events received before the replica was elected leader are not reconciled
+func (c *CustomController) enqueueDataStore() {
+	keys := c.dataStore.ListKeys()
+	for _, key := range keys {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			c.log.Error(err, "failed to split the data store key", "key", key)
+			continue
+		}
+		c.workQueue.Add(Request{
+			NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+		})
+	}
+	c.log.Info("added the objects from the data store to the work queue", "count", len(keys))
+}
+
+// NeedLeaderElection returns false so the standby replicas keep the data store
+// in sync, the events are only reconciled once the replica is elected leader
+func (c *CustomController) NeedLeaderElection() bool {
+	return false
+}
+
 // newOptimizedListWatcher returns a list watcher with a custom list function that converts the
 // response for each page using the converter function and returns a general watcher
 func newOptimizedListWatcher(ctx context.Context, restClient cache.Getter, resource string, namespace string,
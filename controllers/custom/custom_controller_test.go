@@ -0,0 +1,206 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package custom
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	pod1 = &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-1"}}
+	pod2 = &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-2"}}
+)
+
+// podConverter stores the pods as they are
+type podConverter struct{}
+
+func (podConverter) ConvertObject(originalObj interface{}) (interface{}, error) {
+	return originalObj, nil
+}
+func (podConverter) ConvertList(originalList interface{}) (interface{}, error) {
+	return originalList, nil
+}
+func (podConverter) Resource() string             { return "pods" }
+func (podConverter) ResourceType() runtime.Object { return &v1.Pod{} }
+func (podConverter) Indexer(obj interface{}) (string, error) {
+	return cache.MetaNamespaceKeyFunc(obj)
+}
+
+// recordingReconciler records the requests it reconciles
+type recordingReconciler struct {
+	lock     sync.Mutex
+	requests []Request
+}
+
+func (r *recordingReconciler) Reconcile(request Request) (ctrl.Result, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = append(r.requests, request)
+	return ctrl.Result{}, nil
+}
+
+func (r *recordingReconciler) getRequests() []Request {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Request{}, r.requests...)
+}
+
+func newDataStore() cache.Indexer {
+	return cache.NewIndexer(podConverter{}.Indexer, cache.Indexers{})
+}
+
+func newWorkQueue() workqueue.RateLimitingInterface {
+	return workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+}
+
+// TestProcessFunc_NotLeading tests the data store is kept in sync without adding the events to the work queue
+// while the replica is not leading
+func TestProcessFunc_NotLeading(t *testing.T) {
+	dataStore := newDataStore()
+	workQueue := newWorkQueue()
+	process := newProcessFunc(podConverter{}, dataStore, workQueue, &atomic.Bool{})
+
+	assert.NoError(t, process(cache.Deltas{{Type: cache.Added, Object: pod1}, {Type: cache.Added, Object: pod2}},
+		false))
+	assert.NoError(t, process(cache.Deltas{{Type: cache.Deleted, Object: pod2}}, false))
+
+	assert.Equal(t, []string{"default/pod-1"}, dataStore.ListKeys())
+	assert.Zero(t, workQueue.Len())
+}
+
+// TestProcessFunc_Leading tests the events are added to the work queue once the replica is leading
+func TestProcessFunc_Leading(t *testing.T) {
+	dataStore := newDataStore()
+	workQueue := newWorkQueue()
+	leading := &atomic.Bool{}
+	leading.Store(true)
+	process := newProcessFunc(podConverter{}, dataStore, workQueue, leading)
+
+	assert.NoError(t, process(cache.Deltas{{Type: cache.Added, Object: pod1}}, false))
+	assert.NoError(t, process(cache.Deltas{{Type: cache.Deleted, Object: pod1}}, false))
+
+	assert.Empty(t, dataStore.ListKeys())
+	assert.Equal(t, 2, workQueue.Len())
+	item, _ := workQueue.Get()
+	assert.Equal(t, Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod-1"}}, item)
+	item, _ = workQueue.Get()
+	assert.Equal(t, Request{DeletedObject: pod1}, item)
+}
+
+// TestRequeuer_Requeue tests the requests are dropped till the replica is leading
+func TestRequeuer_Requeue(t *testing.T) {
+	namespacedName := types.NamespacedName{Namespace: "default", Name: "pod-1"}
+	assert.False(t, (&Requeuer{}).Requeue(namespacedName))
+
+	requeuer := &Requeuer{workQueue: newWorkQueue(), leading: &atomic.Bool{}}
+	assert.False(t, requeuer.Requeue(namespacedName))
+
+	requeuer.leading.Store(true)
+	assert.True(t, requeuer.Requeue(namespacedName))
+	assert.Equal(t, 1, requeuer.workQueue.Len())
+}
+
+// TestCustomController_enqueueDataStore tests all the objects of the data store are added to the work queue
+func TestCustomController_enqueueDataStore(t *testing.T) {
+	dataStore := newDataStore()
+	assert.NoError(t, dataStore.Add(pod1))
+	assert.NoError(t, dataStore.Add(pod2))
+	controller := &CustomController{log: zap.New(), dataStore: dataStore, workQueue: newWorkQueue()}
+
+	controller.enqueueDataStore()
+
+	var requests []interface{}
+	for controller.workQueue.Len() > 0 {
+		item, _ := controller.workQueue.Get()
+		requests = append(requests, item)
+	}
+	assert.ElementsMatch(t, []interface{}{
+		Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod-1"}},
+		Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod-2"}},
+	}, requests)
+}
+
+// TestCustomController_Start_Election tests the objects synced by a standby replica are reconciled only once it's
+// elected leader
+func TestCustomController_Start_Election(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	conditions := mock_condition.NewMockConditions(mockCtrl)
+	conditions.EXPECT().SetPodDataStoreSyncStatus(true)
+
+	dataStore := newDataStore()
+	workQueue := newWorkQueue()
+	leading := &atomic.Bool{}
+	elected := make(chan struct{})
+	reconciler := &recordingReconciler{}
+
+	config := &cache.Config{
+		Queue: cache.NewDeltaFIFOWithOptions(cache.DeltaFIFOOptions{KeyFunction: podConverter{}.Indexer,
+			KnownObjects: dataStore}),
+		ListerWatcher: &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return &v1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: []v1.Pod{*pod1}}, nil
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return watch.NewFake(), nil
+			},
+		},
+		ObjectType: &v1.Pod{},
+		Process:    newProcessFunc(podConverter{}, dataStore, workQueue, leading),
+	}
+	controller := NewCustomController(zap.New(), Options{MaxConcurrentReconciles: 1}, config, reconciler,
+		workQueue, conditions, dataStore, elected, leading)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(t, controller.Start(ctx))
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(dataStore.ListKeys()) == 1 }, time.Second*10,
+		time.Millisecond*10)
+	assert.False(t, leading.Load())
+	assert.Empty(t, reconciler.getRequests())
+
+	close(elected)
+	assert.Eventually(t, func() bool { return len(reconciler.getRequests()) == 1 }, time.Second*10,
+		time.Millisecond*10)
+	assert.True(t, leading.Load())
+	assert.Equal(t, Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod-1"}},
+		reconciler.getRequests()[0])
+
+	cancel()
+	<-done
+}
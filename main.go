@@ -14,11 +14,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof" // #nosec G108
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	crdv1alpha1 "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
@@ -26,20 +29,27 @@ import (
 	vpcresourcesv1beta1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1beta1"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/controllers/apps"
 	corecontroller "github.com/aws/amazon-vpc-resource-controller-k8s/controllers/core"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/controllers/custom"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/cooldown"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/standby"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/version"
 	asyncWorkers "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 	webhookcore "github.com/aws/amazon-vpc-resource-controller-k8s/webhooks/core"
+	webhookvpcresources "github.com/aws/amazon-vpc-resource-controller-k8s/webhooks/vpcresources"
 
 	"github.com/go-logr/zapr"
 	zapRaw "go.uber.org/zap"
@@ -86,10 +96,16 @@ func init() {
 // +kubebuilder:rbac:groups=crd.k8s.amazonaws.com,resources=eniconfigs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=vpcresources.k8s.aws,resources=securitygrouppolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=vpcresources.k8s.aws,resources=cninodes,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=vpcresources.k8s.aws,resources=cninodes/status,verbs=get;patch
 
 // Migration to leases based leader election
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,namespace=kube-system,verbs=create
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,namespace=kube-system,resourceNames=cp-vpc-resource-controller,verbs=get;update
+// Authentication of the introspection API requests
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+// Node sharding leases in their dedicated namespace, the lease names depend on the shard count and the replicas
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,namespace=vpc-resource-controller-shards,verbs=create;get;list;update;delete
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
@@ -117,6 +133,36 @@ func main() {
 	var apiServerBurst int
 	var maxPodConcurrentReconciles int
 	var maxNodeConcurrentReconciles int
+	var ec2AuditLogPath string
+	var ec2AuditLogMaxSizeMB int
+	var ec2AuditLogMaxBackups int
+	var tracingEndpoint string
+	var tracingInsecure bool
+	var tracingSampleRatio float64
+	var instanceLimitsOverridesFile string
+	var eniCleanerMode string
+	var eniCleanerInterval time.Duration
+	var eniCleanerGracePeriod time.Duration
+	var eniCleanerIncludeTags string
+	var eniCleanerExcludeTags string
+	var eniCleanerIncludeSubnets string
+	var eniCleanerExcludeSubnets string
+	var leakedIPReconcileInterval time.Duration
+	var leakedIPGracePeriod time.Duration
+	var shardCount int
+	var shardLeaseNamespace string
+	var standbyRefreshInterval time.Duration
+	var introspectAuth string
+	var introspectTokenFile string
+	var introspectTokenAudiences string
+	var introspectTLSCertFile string
+	var introspectTLSKeyFile string
+	var verifySGPSecurityGroups bool
+	var fargateDefaultSecurityGroups string
+	var enableResources string
+	var podWebhookBypassAuditInterval time.Duration
+	var podWebhookBypassAction string
+	var sgpLiveUpdate bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080",
 		"The address the metric endpoint binds to.")
@@ -163,6 +209,82 @@ func main() {
 	flag.IntVar(&apiServerBurst, "apiserver-burst", 30, "The API server client burst limit")
 	flag.IntVar(&maxPodConcurrentReconciles, "max-pod-reconcile", 20, "The maximum number of concurrent reconciles for pod controller")
 	flag.IntVar(&maxNodeConcurrentReconciles, "max-node-reconcile", 10, "The maximum number of concurrent reconciles for node controller")
+	flag.StringVar(&ec2AuditLogPath, "ec2-audit-log", "",
+		"The file path to write the audit log of mutating EC2 API calls as JSON lines, set to stdout to write "+
+			"to the standard output. The audit log is disabled if empty")
+	flag.IntVar(&ec2AuditLogMaxSizeMB, "ec2-audit-log-max-size", 100,
+		"The maximum size in megabytes of the EC2 audit log file before it is rotated")
+	flag.IntVar(&ec2AuditLogMaxBackups, "ec2-audit-log-max-backups", 3,
+		"The maximum number of rotated EC2 audit log files to retain")
+	flag.StringVar(&tracingEndpoint, "tracing-otlp-endpoint", "",
+		"The host:port of the OTLP gRPC collector to export the traces to. Tracing is disabled if empty")
+	flag.BoolVar(&tracingInsecure, "tracing-otlp-insecure", false,
+		"Disable the transport security of the connection to the OTLP collector")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1.0,
+		"The fraction of the pod requests that are traced, between 0 and 1")
+	flag.StringVar(&instanceLimitsOverridesFile, "instance-limits-overrides-file", "",
+		"The path to a YAML file mapping instance types to the ENI, IPv4 and branch interface limits that override "+
+			"the built-in limits. The file is reloaded when it changes")
+	flag.StringVar(&eniCleanerMode, "eni-cleaner-mode", string(ec2API.ENICleanerModeDelete),
+		"The action taken on the dangling ENIs - delete(default), dry-run to only report the ENIs that would be "+
+			"deleted, report to only report the available ENIs")
+	flag.DurationVar(&eniCleanerInterval, "eni-cleaner-interval", config.ENICleanUpInterval,
+		"The interval between two dangling ENI clean up cycles")
+	flag.DurationVar(&eniCleanerGracePeriod, "eni-cleaner-grace-period", 0,
+		"The minimum time an ENI must have been available before it's deleted, in addition to being seen in two "+
+			"clean up cycles")
+	flag.StringVar(&eniCleanerIncludeTags, "eni-cleaner-include-tags", "",
+		"Comma separated key=value tags, only the ENIs with one of the tags are cleaned up. The value can be "+
+			"omitted to match any value")
+	flag.StringVar(&eniCleanerExcludeTags, "eni-cleaner-exclude-tags", "",
+		"Comma separated key=value tags, the ENIs with any of the tags are not cleaned up. The value can be "+
+			"omitted to match any value")
+	flag.StringVar(&eniCleanerIncludeSubnets, "eni-cleaner-include-subnets", "",
+		"Comma separated subnet IDs, only the ENIs in one of the subnets are cleaned up")
+	flag.StringVar(&eniCleanerExcludeSubnets, "eni-cleaner-exclude-subnets", "",
+		"Comma separated subnet IDs, the ENIs in any of the subnets are not cleaned up")
+	flag.DurationVar(&leakedIPReconcileInterval, "leaked-ip-reconcile-interval", config.LeakedIPv4ResourceReconcileInterval,
+		"The interval between two checks of each Windows node for secondary IPs and prefixes assigned to its ENIs "+
+			"but missing from its pool, set to 0 to disable the checks")
+	flag.DurationVar(&leakedIPGracePeriod, "leaked-ip-grace-period", config.LeakedIPv4ResourceGracePeriod,
+		"The minimum time a secondary IP or prefix must be missing from the pool before it's unassigned from the ENI")
+	flag.IntVar(&shardCount, "shard-count", 0,
+		"The number of shards the nodes are spread across, each active replica manages the nodes of the shards it "+
+			"owns. Leader election must be disabled if sharding is enabled. Sharding is disabled if 0")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", config.ShardLeaseNamespace,
+		"The namespace of the node sharding Leases, it must exist if sharding is enabled")
+	flag.DurationVar(&standbyRefreshInterval, "standby-refresh-interval", 5*time.Minute,
+		"The interval between two batched describes of the nodes' instances and subnets by a standby replica, "+
+			"so the details are not described one node at a time once it's elected leader. Set to 0 to disable")
+	flag.StringVar(&introspectAuth, "introspect-auth", resource.IntrospectAuthNone,
+		"The authentication of the introspection API requests - none(default), token to accept the bearer token "+
+			"read from --introspect-token-file, tokenreview to validate the bearer token with a TokenReview and "+
+			"authorize its user with a SubjectAccessReview")
+	flag.StringVar(&introspectTokenFile, "introspect-token-file", "",
+		"The path to the file with the bearer token accepted by the introspection API with token authentication")
+	flag.StringVar(&introspectTokenAudiences, "introspect-token-audiences", "",
+		"Comma separated audiences the bearer token must be issued for with tokenreview authentication")
+	flag.StringVar(&introspectTLSCertFile, "introspect-tls-cert-file", "",
+		"The path to the certificate the introspection API is served with, the API is served over plain HTTP if empty")
+	flag.StringVar(&introspectTLSKeyFile, "introspect-tls-key-file", "",
+		"The path to the private key of the introspection API certificate")
+	flag.BoolVar(&verifySGPSecurityGroups, "verify-sgp-security-groups", false,
+		"Reject the SecurityGroupPolicy objects with security groups missing from the cluster VPC, "+
+			"requires the ec2:DescribeSecurityGroups permission")
+	flag.StringVar(&fargateDefaultSecurityGroups, "fargate-default-security-groups", "",
+		"Comma separated security groups of the Fargate pods not matching any SecurityGroupPolicy, "+
+			"the pods get the security groups of their Fargate profile if empty")
+	flag.StringVar(&enableResources, "enable-resources", "",
+		"Comma separated list of the additional registered resources managed by the controller")
+	flag.DurationVar(&podWebhookBypassAuditInterval, "pod-webhook-bypass-audit-interval", 0,
+		"The interval between two audits of the pods matching a SecurityGroupPolicy admitted without the pod-eni "+
+			"resource while the pod mutating webhook was unavailable. Set to 0 to disable")
+	flag.StringVar(&podWebhookBypassAction, "pod-webhook-bypass-action", string(webhookcore.PodWebhookBypassActionCondition),
+		"The action taken on the pods bypassing the pod mutating webhook - condition(default) to set the "+
+			"vpc.amazonaws.com/WebhookBypassed condition, evict to evict the pods managed by a controller")
+	flag.BoolVar(&sgpLiveUpdate, "enable-sgp-live-update", false,
+		"Update the security groups of the branch ENIs of the pods whose matching SecurityGroupPolicy changed with "+
+			"the labels of their service account, the pods are only reported with an event if disabled")
 
 	flag.Parse()
 
@@ -200,6 +322,9 @@ func main() {
 		"BuildDate", version.BuildDate,
 	)
 
+	config.LeakedIPv4ResourceReconcileInterval = leakedIPReconcileInterval
+	config.LeakedIPv4ResourceGracePeriod = leakedIPGracePeriod
+
 	if clusterName == "" {
 		setupLog.Error(fmt.Errorf("cluster-name is a required parameter"), "unable to start the controller")
 		os.Exit(1)
@@ -210,6 +335,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	fargateDefaultSGs := splitList(fargateDefaultSecurityGroups)
+	if len(fargateDefaultSGs) > webhookcore.FargateMaxSecurityGroups {
+		setupLog.Error(fmt.Errorf("fargate-default-security-groups has more than %d security groups",
+			webhookcore.FargateMaxSecurityGroups), "unable to start the controller")
+		os.Exit(1)
+	}
+	for _, sg := range fargateDefaultSGs {
+		if !utils.IsSecurityGroupID(sg) {
+			setupLog.Error(fmt.Errorf("fargate-default-security-groups has invalid security group id %q", sg),
+				"unable to start the controller")
+			os.Exit(1)
+		}
+	}
+
+	extraResources := splitList(enableResources)
+	for _, resourceName := range extraResources {
+		// The network cards of the instance types are only known once the limits are loaded, the resource of any
+		// network card index is registered once enabled
+		if config.IsNetworkCardResourceName(resourceName) {
+			if err := resource.RegisterNetworkCard(resourceName); err != nil {
+				setupLog.Error(err, "unable to start the controller")
+				os.Exit(1)
+			}
+		}
+		if _, found := resource.GetRegistration(resourceName); !found {
+			setupLog.Error(fmt.Errorf("enable-resources has unregistered resource %q, registered resources are %v",
+				resourceName, resource.RegisteredResources()), "unable to start the controller")
+			os.Exit(1)
+		}
+	}
+
 	// Profiler disabled by default, to enable set the enableProfiling argument
 	if enableProfiling {
 		// To use the profiler - https://golang.org/pkg/net/http/pprof/
@@ -224,8 +380,14 @@ func main() {
 	kubeConfig.Burst = apiServerBurst
 	kubeConfig.UserAgent = fmt.Sprintf("%s/%s", ec2API.AppName, version.GitVersion)
 
+	if shardCount > 0 && enableLeaderElection {
+		setupLog.Error(fmt.Errorf("shard-count and leader-elect are mutually exclusive, all the replicas are "+
+			"active when the nodes are sharded"), "unable to start the controller")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting the controller with leadership setting",
-		"leader mode enabled", enableLeaderElection,
+		"leader mode enabled", enableLeaderElection, "shard count", shardCount,
 		"lease duration(s)", leaderLeaseDurationSeconds, "renew deadline(s)",
 		leaderLeaseRenewDeadline, "retry period(s)", leaderLeaseRetryPeriod)
 
@@ -248,6 +410,7 @@ func main() {
 		// ConfigMaps  - WATCH only the ConfigMap that VPC RC consumes
 		// Deployments - WATCH only the old VPC Controller deployment
 		// Daemonsets  - WATCH only the VPC CNI
+		// VPCResourceControllerConfigs - WATCH only the config used by the controller
 		Cache: cache.Options{
 			ByObject: map[client.Object]cache.ByObject{
 				&corev1.ConfigMap{}: {Field: fields.Set{
@@ -263,6 +426,9 @@ func main() {
 					"metadata.namespace": config.KubeSystemNamespace,
 				}.AsSelector(),
 				},
+				&vpcresourcesv1alpha1.VPCResourceControllerConfig{}: {Field: fields.Set{
+					"metadata.name": config.ControllerConfigName,
+				}.AsSelector()},
 			},
 			SyncPeriod: &syncPeriod,
 		},
@@ -286,18 +452,80 @@ func main() {
 
 	ctx := ctrl.SetupSignalHandler()
 
+	// sharder is nil if the nodes are not sharded
+	var sharder shard.Sharder
+	if shardCount > 0 {
+		identity, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "failed to get the hostname used as the shard holder identity")
+			os.Exit(1)
+		}
+		leaseSharder, err := shard.NewLeaseSharder(ctrl.Log.WithName("shard"), shard.Config{
+			Identity:      identity,
+			Namespace:     shardLeaseNamespace,
+			ShardCount:    shardCount,
+			LeaseDuration: leaseDuration,
+			RenewDeadline: renewDeadline,
+			RetryPeriod:   retryPeriod,
+		}, clientSet.CoordinationV1().Leases(shardLeaseNamespace), mgr.GetClient())
+		if err != nil {
+			setupLog.Error(err, "invalid sharding configuration")
+			os.Exit(1)
+		}
+		if err := leaseSharder.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to start node sharding")
+			os.Exit(1)
+		}
+		sharder = leaseSharder
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Endpoint:    tracingEndpoint,
+		Insecure:    tracingInsecure,
+		SampleRatio: tracingSampleRatio,
+	}, ctrl.Log.WithName("tracing"))
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// if the region wasn't replaced from place holder
 	// we need to make it to empty
 	if region == regionPlaceHolder {
 		region = ""
 	}
 
+	var ec2AuditLog *ec2API.AuditLog
+	if ec2AuditLogPath != "" {
+		ec2AuditLog, err = ec2API.NewAuditLog(ec2AuditLogPath, ec2AuditLogMaxSizeMB, ec2AuditLogMaxBackups)
+		if err != nil {
+			setupLog.Error(err, "unable to create ec2 audit log")
+			os.Exit(1)
+		}
+		setupLog.Info("ec2 audit log enabled", "path", ec2AuditLogPath)
+	}
+
 	ec2Wrapper, err := ec2API.NewEC2Wrapper(roleARN, clusterName, region, instanceClientQPS,
-		instanceClientBurst, userClientQPS, userClientBurst, setupLog)
+		instanceClientBurst, userClientQPS, userClientBurst, ec2AuditLog, setupLog)
 	if err != nil {
 		setupLog.Error(err, "unable to create ec2 wrapper")
 	}
 	ec2APIHelper := ec2API.NewEC2APIHelper(ec2Wrapper, clusterName)
+	// warmCache is nil if the replicas don't run as warm standby
+	var warmCache *ec2API.WarmCache
+	if enableLeaderElection && standbyRefreshInterval > 0 {
+		warmCache = ec2API.NewWarmCache(ec2Wrapper, standbyRefreshInterval)
+		ec2APIHelper = ec2API.NewWarmEC2APIHelper(ec2APIHelper, warmCache)
+	}
+	// Instance types missing from the generated limits table are looked up with DescribeInstanceTypes
+	vpc.SetLimitsFetcher(ec2APIHelper)
+	if instanceLimitsOverridesFile != "" {
+		if err := vpc.WatchLimitsOverridesFile(ctx, instanceLimitsOverridesFile,
+			vpc.DefaultLimitsOverridesReloadInterval, ctrl.Log.WithName("instance limits overrides")); err != nil {
+			setupLog.Error(err, "unable to load the instance limits overrides")
+			os.Exit(1)
+		}
+	}
 
 	sgpAPI := utils.NewSecurityGroupForPodsAPI(
 		mgr.GetClient(),
@@ -331,6 +559,12 @@ func main() {
 	} else {
 		supportedResources = []string{config.ResourceNamePodENI, config.ResourceNameIPAddress}
 	}
+	// the additional resources are registered out-of-tree and enabled by name
+	for _, resourceName := range extraResources {
+		if !slices.Contains(supportedResources, resourceName) {
+			supportedResources = append(supportedResources, resourceName)
+		}
+	}
 	resourceManager, err := resource.NewResourceManager(
 		ctx, supportedResources, apiWrapper, ctrl.Log.WithName("managers").WithName("resource"), healthzHandler, controllerConditions)
 	if err != nil {
@@ -348,6 +582,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	// podRequeuer is bound to the work queue of the pod controller so the other controllers can requeue the pods
+	podRequeuer := &custom.Requeuer{}
+
 	// IMPORTANT: The Pod Reconciler must be the first controller to Run. The controller
 	// will not allow any other controller to run till the cache has synced.
 	if err := (&corecontroller.PodReconciler{
@@ -357,21 +594,64 @@ func main() {
 		K8sAPI:          k8sApi,
 		DataStore:       dataStore,
 		Condition:       controllerConditions,
+		Sharder:         sharder,
+		Requeuer:        podRequeuer,
 	}).SetupWithManager(ctx, mgr, clientSet, listPageLimit, syncPeriod, maxPodConcurrentReconciles, healthzHandler); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "pod")
 		os.Exit(1)
 	}
 
-	if err := (&ec2API.ENICleaner{
-		EC2Wrapper:  ec2Wrapper,
+	eniCleanerScope := ec2API.ENICleanerScope{
+		IncludeSubnets: splitList(eniCleanerIncludeSubnets),
+		ExcludeSubnets: splitList(eniCleanerExcludeSubnets),
+	}
+	if eniCleanerScope.IncludeTags, err = ec2API.ParseENICleanerTags(eniCleanerIncludeTags); err != nil {
+		setupLog.Error(err, "invalid eni-cleaner-include-tags")
+		os.Exit(1)
+	}
+	if eniCleanerScope.ExcludeTags, err = ec2API.ParseENICleanerTags(eniCleanerExcludeTags); err != nil {
+		setupLog.Error(err, "invalid eni-cleaner-exclude-tags")
+		os.Exit(1)
+	}
+	eniCleaner := &ec2API.ENICleaner{
+		EC2Wrapper:  ec2API.WrapperWithAuditCaller(ec2Wrapper, ec2API.AuditCaller{Provider: "eni-cleaner"}),
 		ClusterName: clusterName,
 		Log:         ctrl.Log.WithName("eni cleaner"),
 		VPCID:       vpcID,
-	}).SetupWithManager(ctx, mgr, healthzHandler); err != nil {
+		Mode:        ec2API.ENICleanerMode(eniCleanerMode),
+		Interval:    eniCleanerInterval,
+		GracePeriod: eniCleanerGracePeriod,
+		Scope:       eniCleanerScope,
+	}
+	if sharder != nil {
+		// The replica owning the first shard cleans up the ENIs of the cluster
+		eniCleaner.IsActive = func() bool { return sharder.OwnsShard(0) }
+	}
+	if err := eniCleaner.SetupWithManager(ctx, mgr, healthzHandler); err != nil {
 		setupLog.Error(err, "unable to start eni cleaner")
 		os.Exit(1)
 	}
 
+	if podWebhookBypassAuditInterval > 0 {
+		podWebhookBypassAuditor := &webhookcore.PodWebhookBypassAuditor{
+			Log:       ctrl.Log.WithName("pod webhook bypass auditor"),
+			Reader:    mgr.GetAPIReader(),
+			PodAPI:    apiWrapper.PodAPI,
+			K8sAPI:    k8sApi,
+			Action:    webhookcore.PodWebhookBypassAction(podWebhookBypassAction),
+			Interval:  podWebhookBypassAuditInterval,
+			PageLimit: listPageLimit,
+		}
+		if sharder != nil {
+			// The replica owning the first shard audits the pods of the cluster
+			podWebhookBypassAuditor.IsActive = func() bool { return sharder.OwnsShard(0) }
+		}
+		if err := podWebhookBypassAuditor.SetupWithManager(mgr, healthzHandler); err != nil {
+			setupLog.Error(err, "unable to start pod webhook bypass auditor")
+			os.Exit(1)
+		}
+	}
+
 	if err := (&corecontroller.NodeReconciler{
 		Client:     mgr.GetClient(),
 		K8sAPI:     k8sApi,
@@ -380,6 +660,7 @@ func main() {
 		Manager:    nodeManager,
 		Conditions: controllerConditions,
 		Context:    ctx,
+		Sharder:    sharder,
 	}).SetupWithManager(mgr, maxNodeConcurrentReconciles, healthzHandler); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Node")
 		os.Exit(1)
@@ -398,6 +679,36 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := (&corecontroller.ENIConfigReconciler{
+		Log:          ctrl.Log.WithName("controllers").WithName("ENIConfig"),
+		NodeManager:  nodeManager,
+		K8sAPI:       k8sApi,
+		EC2APIHelper: ec2APIHelper,
+		VPCID:        vpcID,
+	}).SetupWithManager(mgr, healthzHandler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ENIConfig")
+		os.Exit(1)
+	}
+
+	serviceAccountReconciler := &corecontroller.ServiceAccountReconciler{
+		Log:         ctrl.Log.WithName("controllers").WithName("ServiceAccount"),
+		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
+		SGPAPI:      sgpAPI,
+		K8sAPI:      k8sApi,
+		LiveUpdate:  sgpLiveUpdate,
+		PodRequeuer: podRequeuer,
+		Sharder:     sharder,
+		PageLimit:   listPageLimit,
+	}
+	if branchProvider, found := resourceManager.GetResourceProvider(config.ResourceNamePodENI); found {
+		serviceAccountReconciler.SecurityGroupsUpdater, _ = branchProvider.(branch.SecurityGroupsUpdater)
+	}
+	if err := serviceAccountReconciler.SetupWithManager(mgr, healthzHandler); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServiceAccount")
+		os.Exit(1)
+	}
+
 	if err := (&apps.DeploymentReconciler{
 		Log:         ctrl.Log.WithName("controllers").WithName("Deployment"),
 		NodeManager: nodeManager,
@@ -408,10 +719,52 @@ func main() {
 		os.Exit(1)
 	}
 
+	if enableLeaderElection {
+		if err := (&standby.WarmStandby{
+			Log:             ctrl.Log.WithName("warm standby"),
+			Client:          mgr.GetClient(),
+			PodAPI:          apiWrapper.PodAPI,
+			Cache:           warmCache,
+			NodeManager:     nodeManager,
+			RefreshInterval: standbyRefreshInterval,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to start warm standby")
+			os.Exit(1)
+		}
+	}
+
+	var introspectAuthenticator resource.IntrospectAuthenticator
+	switch introspectAuth {
+	case resource.IntrospectAuthNone:
+	case resource.IntrospectAuthToken:
+		if introspectAuthenticator, err = resource.NewStaticTokenAuthenticator(introspectTokenFile); err != nil {
+			setupLog.Error(err, "unable to read the introspection API token")
+			os.Exit(1)
+		}
+	case resource.IntrospectAuthTokenReview:
+		introspectAuthenticator = resource.NewTokenReviewAuthenticator(clientSet.AuthenticationV1().TokenReviews(),
+			clientSet.AuthorizationV1().SubjectAccessReviews(), splitList(introspectTokenAudiences))
+	default:
+		setupLog.Error(fmt.Errorf("unknown authentication %s", introspectAuth), "invalid introspect-auth")
+		os.Exit(1)
+	}
+	if (introspectTLSCertFile == "") != (introspectTLSKeyFile == "") {
+		setupLog.Error(fmt.Errorf("both the certificate and the key must be set"), "invalid introspection API TLS")
+		os.Exit(1)
+	}
+
 	if err := (&resource.IntrospectHandler{
 		Log:             ctrl.Log.WithName("introspect"),
 		BindAddress:     introspectBindAddr,
 		ResourceManager: resourceManager,
+		EC2AuditLog:     ec2AuditLog,
+		ENICleaner:      eniCleaner,
+		Sharder:         sharder,
+		WarmCache:       warmCache,
+		NodeReader:      mgr.GetClient(),
+		Authenticator:   introspectAuthenticator,
+		TLSCertFile:     introspectTLSCertFile,
+		TLSKeyFile:      introspectTLSKeyFile,
 	}).SetupWithManager(mgr, healthzHandler); err != nil {
 		setupLog.Error(err, "unable to create introspect API")
 		os.Exit(1)
@@ -423,7 +776,8 @@ func main() {
 
 	setupLog.Info("registering webhooks to the webhook server")
 	podMutationWebhook := webhookcore.NewPodMutationWebHook(
-		sgpAPI, ctrl.Log.WithName("resource mutating webhook"), controllerConditions, admission.NewDecoder(mgr.GetScheme()), healthzHandler)
+		sgpAPI, k8sApi, fargateDefaultSGs, ctrl.Log.WithName("resource mutating webhook"), controllerConditions, admission.NewDecoder(mgr.GetScheme()), healthzHandler)
+	podMutationWebhook.InjectionRules = resource.GetInjectionRules(extraResources)
 	webhookServer.Register("/mutate-v1-pod", &webhook.Admission{
 		Handler: podMutationWebhook,
 	})
@@ -439,6 +793,17 @@ func main() {
 	webhookServer.Register("/validate-v1-pod", &webhook.Admission{
 		Handler: annotationValidator})
 
+	// Validating webhook for security group policy, the security groups are verified with EC2 if enabled
+	var sgpEC2APIHelper ec2API.EC2APIHelper
+	if verifySGPSecurityGroups {
+		sgpEC2APIHelper = ec2APIHelper
+	}
+	sgpValidator := webhookvpcresources.NewSecurityGroupPolicyValidator(apiWrapper.PodAPI, mgr.GetClient(),
+		sgpEC2APIHelper, vpcID,
+		ctrl.Log.WithName("security group policy validating webhook"), admission.NewDecoder(mgr.GetScheme()), healthzHandler)
+	webhookServer.Register("/validate-vpcresources-v1beta1-securitygrouppolicy", &webhook.Admission{
+		Handler: sgpValidator})
+
 	// Enabled each controllers' health check and aggregate them to endpoint /healthz
 	// curl localhost:61779/healthz?verbose can list all controllers' healthy status
 	err = healthzHandler.AddControllersHealthStatusChecksToManager(mgr)
@@ -453,4 +818,22 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	// Flush the spans of the requests processed before the termination signal
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "failed to flush the traces")
+	}
+}
+
+// splitList splits the comma separated list, an empty string is an empty list
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
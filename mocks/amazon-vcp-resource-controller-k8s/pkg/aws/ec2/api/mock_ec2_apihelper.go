@@ -20,6 +20,7 @@ package mock_api
 import (
 	reflect "reflect"
 
+	vpc "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	config "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	ec2 "github.com/aws/aws-sdk-go/service/ec2"
 	gomock "github.com/golang/mock/gomock"
@@ -78,34 +79,49 @@ func (mr *MockEC2APIHelperMockRecorder) AssociateBranchToTrunk(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssociateBranchToTrunk", reflect.TypeOf((*MockEC2APIHelper)(nil).AssociateBranchToTrunk), arg0, arg1, arg2)
 }
 
+// AssociateElasticIP mocks base method.
+func (m *MockEC2APIHelper) AssociateElasticIP(arg0 string, arg1 string, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssociateElasticIP", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssociateElasticIP indicates an expected call of AssociateElasticIP.
+func (mr *MockEC2APIHelperMockRecorder) AssociateElasticIP(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssociateElasticIP", reflect.TypeOf((*MockEC2APIHelper)(nil).AssociateElasticIP), arg0, arg1, arg2)
+}
+
 // AttachNetworkInterfaceToInstance mocks base method.
-func (m *MockEC2APIHelper) AttachNetworkInterfaceToInstance(arg0, arg1 *string, arg2 *int64) (*string, error) {
+func (m *MockEC2APIHelper) AttachNetworkInterfaceToInstance(arg0, arg1 *string, arg2, arg3 *int64) (*string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AttachNetworkInterfaceToInstance", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "AttachNetworkInterfaceToInstance", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(*string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AttachNetworkInterfaceToInstance indicates an expected call of AttachNetworkInterfaceToInstance.
-func (mr *MockEC2APIHelperMockRecorder) AttachNetworkInterfaceToInstance(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockEC2APIHelperMockRecorder) AttachNetworkInterfaceToInstance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachNetworkInterfaceToInstance", reflect.TypeOf((*MockEC2APIHelper)(nil).AttachNetworkInterfaceToInstance), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachNetworkInterfaceToInstance", reflect.TypeOf((*MockEC2APIHelper)(nil).AttachNetworkInterfaceToInstance), arg0, arg1, arg2, arg3)
 }
 
 // CreateAndAttachNetworkInterface mocks base method.
-func (m *MockEC2APIHelper) CreateAndAttachNetworkInterface(arg0, arg1 *string, arg2 []string, arg3 []*ec2.Tag, arg4 *int64, arg5, arg6 *string, arg7 *config.IPResourceCount) (*ec2.NetworkInterface, error) {
+func (m *MockEC2APIHelper) CreateAndAttachNetworkInterface(arg0, arg1 *string, arg2 []string, arg3 []*ec2.Tag, arg4, arg5 *int64, arg6, arg7 *string, arg8 *config.IPResourceCount) (*ec2.NetworkInterface, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAndAttachNetworkInterface", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret := m.ctrl.Call(m, "CreateAndAttachNetworkInterface", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 	ret0, _ := ret[0].(*ec2.NetworkInterface)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateAndAttachNetworkInterface indicates an expected call of CreateAndAttachNetworkInterface.
-func (mr *MockEC2APIHelperMockRecorder) CreateAndAttachNetworkInterface(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+func (mr *MockEC2APIHelperMockRecorder) CreateAndAttachNetworkInterface(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAttachNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).CreateAndAttachNetworkInterface), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAttachNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).CreateAndAttachNetworkInterface), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 }
 
 // CreateNetworkInterface mocks base method.
@@ -195,19 +211,33 @@ func (mr *MockEC2APIHelperMockRecorder) DetachNetworkInterfaceFromInstance(arg0
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachNetworkInterfaceFromInstance", reflect.TypeOf((*MockEC2APIHelper)(nil).DetachNetworkInterfaceFromInstance), arg0)
 }
 
+// DisassociateElasticIP mocks base method.
+func (m *MockEC2APIHelper) DisassociateElasticIP(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisassociateElasticIP", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisassociateElasticIP indicates an expected call of DisassociateElasticIP.
+func (mr *MockEC2APIHelperMockRecorder) DisassociateElasticIP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisassociateElasticIP", reflect.TypeOf((*MockEC2APIHelper)(nil).DisassociateElasticIP), arg0)
+}
+
 // GetBranchNetworkInterface mocks base method.
-func (m *MockEC2APIHelper) GetBranchNetworkInterface(arg0, arg1 *string) ([]*ec2.NetworkInterface, error) {
+func (m *MockEC2APIHelper) GetBranchNetworkInterface(arg0 *string) ([]*ec2.NetworkInterface, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBranchNetworkInterface", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetBranchNetworkInterface", arg0)
 	ret0, _ := ret[0].([]*ec2.NetworkInterface)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBranchNetworkInterface indicates an expected call of GetBranchNetworkInterface.
-func (mr *MockEC2APIHelperMockRecorder) GetBranchNetworkInterface(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockEC2APIHelperMockRecorder) GetBranchNetworkInterface(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).GetBranchNetworkInterface), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).GetBranchNetworkInterface), arg0)
 }
 
 // GetInstanceDetails mocks base method.
@@ -240,6 +270,66 @@ func (mr *MockEC2APIHelperMockRecorder) GetInstanceNetworkInterface(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceNetworkInterface", reflect.TypeOf((*MockEC2APIHelper)(nil).GetInstanceNetworkInterface), arg0)
 }
 
+// GetInstanceTypeLimits mocks base method.
+func (m *MockEC2APIHelper) GetInstanceTypeLimits(arg0 string) (*vpc.VPCLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceTypeLimits", arg0)
+	ret0, _ := ret[0].(*vpc.VPCLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceTypeLimits indicates an expected call of GetInstanceTypeLimits.
+func (mr *MockEC2APIHelperMockRecorder) GetInstanceTypeLimits(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceTypeLimits", reflect.TypeOf((*MockEC2APIHelper)(nil).GetInstanceTypeLimits), arg0)
+}
+
+// GetMissingSecurityGroups mocks base method.
+func (m *MockEC2APIHelper) GetMissingSecurityGroups(arg0 string, arg1 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMissingSecurityGroups", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMissingSecurityGroups indicates an expected call of GetMissingSecurityGroups.
+func (mr *MockEC2APIHelperMockRecorder) GetMissingSecurityGroups(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingSecurityGroups", reflect.TypeOf((*MockEC2APIHelper)(nil).GetMissingSecurityGroups), arg0, arg1)
+}
+
+// GetNetworkInterfaceByPrivateIP mocks base method.
+func (m *MockEC2APIHelper) GetNetworkInterfaceByPrivateIP(arg0 string, arg1 string) (*ec2.NetworkInterface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetworkInterfaceByPrivateIP", arg0, arg1)
+	ret0, _ := ret[0].(*ec2.NetworkInterface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNetworkInterfaceByPrivateIP indicates an expected call of GetNetworkInterfaceByPrivateIP.
+func (mr *MockEC2APIHelperMockRecorder) GetNetworkInterfaceByPrivateIP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkInterfaceByPrivateIP", reflect.TypeOf((*MockEC2APIHelper)(nil).GetNetworkInterfaceByPrivateIP), arg0, arg1)
+}
+
+// GetPoolElasticIPs mocks base method.
+func (m *MockEC2APIHelper) GetPoolElasticIPs(arg0 string) ([]*ec2.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPoolElasticIPs", arg0)
+	ret0, _ := ret[0].([]*ec2.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPoolElasticIPs indicates an expected call of GetPoolElasticIPs.
+func (mr *MockEC2APIHelperMockRecorder) GetPoolElasticIPs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPoolElasticIPs", reflect.TypeOf((*MockEC2APIHelper)(nil).GetPoolElasticIPs), arg0)
+}
+
 // GetSubnet mocks base method.
 func (m *MockEC2APIHelper) GetSubnet(arg0 *string) (*ec2.Subnet, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +345,21 @@ func (mr *MockEC2APIHelperMockRecorder) GetSubnet(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubnet", reflect.TypeOf((*MockEC2APIHelper)(nil).GetSubnet), arg0)
 }
 
+// GetSubnets mocks base method.
+func (m *MockEC2APIHelper) GetSubnets(arg0 []*ec2.Filter) ([]*ec2.Subnet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubnets", arg0)
+	ret0, _ := ret[0].([]*ec2.Subnet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubnets indicates an expected call of GetSubnets.
+func (mr *MockEC2APIHelperMockRecorder) GetSubnets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubnets", reflect.TypeOf((*MockEC2APIHelper)(nil).GetSubnets), arg0)
+}
+
 // SetDeleteOnTermination mocks base method.
 func (m *MockEC2APIHelper) SetDeleteOnTermination(arg0, arg1 *string) error {
 	m.ctrl.T.Helper()
@@ -269,6 +374,20 @@ func (mr *MockEC2APIHelperMockRecorder) SetDeleteOnTermination(arg0, arg1 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteOnTermination", reflect.TypeOf((*MockEC2APIHelper)(nil).SetDeleteOnTermination), arg0, arg1)
 }
 
+// SetSecurityGroups mocks base method.
+func (m *MockEC2APIHelper) SetSecurityGroups(arg0 *string, arg1 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecurityGroups", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecurityGroups indicates an expected call of SetSecurityGroups.
+func (mr *MockEC2APIHelperMockRecorder) SetSecurityGroups(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecurityGroups", reflect.TypeOf((*MockEC2APIHelper)(nil).SetSecurityGroups), arg0, arg1)
+}
+
 // UnassignIPv4Resources mocks base method.
 func (m *MockEC2APIHelper) UnassignIPv4Resources(arg0 string, arg1 config.ResourceType, arg2 []string) error {
 	m.ctrl.T.Helper()
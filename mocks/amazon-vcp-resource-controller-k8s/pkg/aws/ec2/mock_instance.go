@@ -73,6 +73,18 @@ func (mr *MockEC2InstanceMockRecorder) FreeDeviceIndex(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).FreeDeviceIndex), arg0)
 }
 
+// FreeDeviceIndexOnNetworkCard mocks base method.
+func (m *MockEC2Instance) FreeDeviceIndexOnNetworkCard(arg0, arg1 int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "FreeDeviceIndexOnNetworkCard", arg0, arg1)
+}
+
+// FreeDeviceIndexOnNetworkCard indicates an expected call of FreeDeviceIndexOnNetworkCard.
+func (mr *MockEC2InstanceMockRecorder) FreeDeviceIndexOnNetworkCard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeDeviceIndexOnNetworkCard", reflect.TypeOf((*MockEC2Instance)(nil).FreeDeviceIndexOnNetworkCard), arg0, arg1)
+}
+
 // GetCustomNetworkingSpec mocks base method.
 func (m *MockEC2Instance) GetCustomNetworkingSpec() (string, []string) {
 	m.ctrl.T.Helper()
@@ -103,6 +115,21 @@ func (mr *MockEC2InstanceMockRecorder) GetHighestUnusedDeviceIndex() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestUnusedDeviceIndex", reflect.TypeOf((*MockEC2Instance)(nil).GetHighestUnusedDeviceIndex))
 }
 
+// GetHighestUnusedDeviceIndexOnNetworkCard mocks base method.
+func (m *MockEC2Instance) GetHighestUnusedDeviceIndexOnNetworkCard(arg0 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHighestUnusedDeviceIndexOnNetworkCard", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHighestUnusedDeviceIndexOnNetworkCard indicates an expected call of GetHighestUnusedDeviceIndexOnNetworkCard.
+func (mr *MockEC2InstanceMockRecorder) GetHighestUnusedDeviceIndexOnNetworkCard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHighestUnusedDeviceIndexOnNetworkCard", reflect.TypeOf((*MockEC2Instance)(nil).GetHighestUnusedDeviceIndexOnNetworkCard), arg0)
+}
+
 // InstanceID mocks base method.
 func (m *MockEC2Instance) InstanceID() string {
 	m.ctrl.T.Helper()
@@ -18,6 +18,7 @@
 package mock_handler
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -49,31 +50,31 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 }
 
 // HandleCreate mocks base method.
-func (m *MockHandler) HandleCreate(arg0 int, arg1 *v1.Pod) (reconcile.Result, error) {
+func (m *MockHandler) HandleCreate(arg0 context.Context, arg1 int, arg2 *v1.Pod) (reconcile.Result, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HandleCreate", arg0, arg1)
+	ret := m.ctrl.Call(m, "HandleCreate", arg0, arg1, arg2)
 	ret0, _ := ret[0].(reconcile.Result)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HandleCreate indicates an expected call of HandleCreate.
-func (mr *MockHandlerMockRecorder) HandleCreate(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockHandlerMockRecorder) HandleCreate(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCreate", reflect.TypeOf((*MockHandler)(nil).HandleCreate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCreate", reflect.TypeOf((*MockHandler)(nil).HandleCreate), arg0, arg1, arg2)
 }
 
 // HandleDelete mocks base method.
-func (m *MockHandler) HandleDelete(arg0 *v1.Pod) (reconcile.Result, error) {
+func (m *MockHandler) HandleDelete(arg0 context.Context, arg1 *v1.Pod) (reconcile.Result, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HandleDelete", arg0)
+	ret := m.ctrl.Call(m, "HandleDelete", arg0, arg1)
 	ret0, _ := ret[0].(reconcile.Result)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HandleDelete indicates an expected call of HandleDelete.
-func (mr *MockHandlerMockRecorder) HandleDelete(arg0 interface{}) *gomock.Call {
+func (mr *MockHandlerMockRecorder) HandleDelete(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDelete", reflect.TypeOf((*MockHandler)(nil).HandleDelete), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDelete", reflect.TypeOf((*MockHandler)(nil).HandleDelete), arg0, arg1)
 }
@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/api/apps/v1"
 	v10 "k8s.io/api/core/v1"
 	v11 "k8s.io/api/events/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
@@ -139,6 +140,21 @@ func (mr *MockK8sWrapperMockRecorder) GetConfigMap(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockK8sWrapper)(nil).GetConfigMap), arg0, arg1)
 }
 
+// GetControllerConfigMap mocks base method.
+func (m *MockK8sWrapper) GetControllerConfigMap() (*v10.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetControllerConfigMap")
+	ret0, _ := ret[0].(*v10.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetControllerConfigMap indicates an expected call of GetControllerConfigMap.
+func (mr *MockK8sWrapperMockRecorder) GetControllerConfigMap() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetControllerConfigMap", reflect.TypeOf((*MockK8sWrapper)(nil).GetControllerConfigMap))
+}
+
 // GetDaemonSet mocks base method.
 func (m *MockK8sWrapper) GetDaemonSet(arg0, arg1 string) (*v1.DaemonSet, error) {
 	m.ctrl.T.Helper()
@@ -228,3 +244,17 @@ func (mr *MockK8sWrapperMockRecorder) ListNodes() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNodes", reflect.TypeOf((*MockK8sWrapper)(nil).ListNodes))
 }
+
+// SetNodeCondition mocks base method.
+func (m *MockK8sWrapper) SetNodeCondition(arg0 string, arg1 v12.Condition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNodeCondition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNodeCondition indicates an expected call of SetNodeCondition.
+func (mr *MockK8sWrapperMockRecorder) SetNodeCondition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNodeCondition", reflect.TypeOf((*MockK8sWrapper)(nil).SetNodeCondition), arg0, arg1)
+}
@@ -63,6 +63,20 @@ func (mr *MockPodClientAPIWrapperMockRecorder) AnnotatePod(arg0, arg1, arg2, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnotatePod", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).AnnotatePod), arg0, arg1, arg2, arg3, arg4)
 }
 
+// EvictPod mocks base method.
+func (m *MockPodClientAPIWrapper) EvictPod(arg0 *v1.Pod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvictPod", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EvictPod indicates an expected call of EvictPod.
+func (mr *MockPodClientAPIWrapperMockRecorder) EvictPod(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvictPod", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).EvictPod), arg0)
+}
+
 // GetPod mocks base method.
 func (m *MockPodClientAPIWrapper) GetPod(arg0, arg1 string) (*v1.Pod, error) {
 	m.ctrl.T.Helper()
@@ -108,6 +122,21 @@ func (mr *MockPodClientAPIWrapperMockRecorder) GetRunningPodsOnNode(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRunningPodsOnNode", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).GetRunningPodsOnNode), arg0)
 }
 
+// ListNamespacePods mocks base method.
+func (m *MockPodClientAPIWrapper) ListNamespacePods(arg0 string) (*v1.PodList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNamespacePods", arg0)
+	ret0, _ := ret[0].(*v1.PodList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNamespacePods indicates an expected call of ListNamespacePods.
+func (mr *MockPodClientAPIWrapperMockRecorder) ListNamespacePods(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNamespacePods", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).ListNamespacePods), arg0)
+}
+
 // ListPods mocks base method.
 func (m *MockPodClientAPIWrapper) ListPods(arg0 string) (*v1.PodList, error) {
 	m.ctrl.T.Helper()
@@ -122,3 +151,17 @@ func (mr *MockPodClientAPIWrapperMockRecorder) ListPods(arg0 interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPods", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).ListPods), arg0)
 }
+
+// SetPodCondition mocks base method.
+func (m *MockPodClientAPIWrapper) SetPodCondition(arg0 *v1.Pod, arg1 v1.PodCondition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPodCondition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPodCondition indicates an expected call of SetPodCondition.
+func (mr *MockPodClientAPIWrapperMockRecorder) SetPodCondition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPodCondition", reflect.TypeOf((*MockPodClientAPIWrapper)(nil).SetPodCondition), arg0, arg1)
+}
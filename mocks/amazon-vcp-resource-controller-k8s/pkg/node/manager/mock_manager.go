@@ -21,6 +21,7 @@ import (
 	reflect "reflect"
 
 	node "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
+	manager "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -102,6 +103,32 @@ func (mr *MockManagerMockRecorder) GetNode(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNode", reflect.TypeOf((*MockManager)(nil).GetNode), arg0)
 }
 
+// ReleaseNode mocks base method.
+func (m *MockManager) ReleaseNode(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseNode", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseNode indicates an expected call of ReleaseNode.
+func (mr *MockManagerMockRecorder) ReleaseNode(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseNode", reflect.TypeOf((*MockManager)(nil).ReleaseNode), arg0)
+}
+
+// SetENIConfigValidation mocks base method.
+func (m *MockManager) SetENIConfigValidation(arg0 string, arg1 *manager.ENIConfigValidation) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetENIConfigValidation", arg0, arg1)
+}
+
+// SetENIConfigValidation indicates an expected call of SetENIConfigValidation.
+func (mr *MockManagerMockRecorder) SetENIConfigValidation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetENIConfigValidation", reflect.TypeOf((*MockManager)(nil).SetENIConfigValidation), arg0, arg1)
+}
+
 // SkipHealthCheck mocks base method.
 func (m *MockManager) SkipHealthCheck() bool {
 	m.ctrl.T.Helper()
@@ -174,6 +174,34 @@ func (mr *MockNodeMockRecorder) IsReady() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsReady", reflect.TypeOf((*MockNode)(nil).IsReady))
 }
 
+// IsTrunkingCompatible mocks base method.
+func (m *MockNode) IsTrunkingCompatible() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTrunkingCompatible")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsTrunkingCompatible indicates an expected call of IsTrunkingCompatible.
+func (mr *MockNodeMockRecorder) IsTrunkingCompatible() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTrunkingCompatible", reflect.TypeOf((*MockNode)(nil).IsTrunkingCompatible))
+}
+
+// ReleaseResources mocks base method.
+func (m *MockNode) ReleaseResources(arg0 resource.ResourceManager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseResources", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseResources indicates an expected call of ReleaseResources.
+func (mr *MockNodeMockRecorder) ReleaseResources(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseResources", reflect.TypeOf((*MockNode)(nil).ReleaseResources), arg0)
+}
+
 // SetNextReconciliationTime mocks base method.
 func (m *MockNode) SetNextReconciliationTime(arg0 time.Time) {
 	m.ctrl.T.Helper()
@@ -18,6 +18,7 @@
 package mock_trunk
 
 import (
+	context "context"
 	reflect "reflect"
 
 	ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
@@ -50,18 +51,18 @@ func (m *MockTrunkENI) EXPECT() *MockTrunkENIMockRecorder {
 }
 
 // CreateAndAssociateBranchENIs mocks base method.
-func (m *MockTrunkENI) CreateAndAssociateBranchENIs(arg0 *v1.Pod, arg1 []string, arg2 int) ([]*trunk.ENIDetails, error) {
+func (m *MockTrunkENI) CreateAndAssociateBranchENIs(arg0 context.Context, arg1 *v1.Pod, arg2 []string, arg3 int) ([]*trunk.ENIDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAndAssociateBranchENIs", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "CreateAndAssociateBranchENIs", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]*trunk.ENIDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateAndAssociateBranchENIs indicates an expected call of CreateAndAssociateBranchENIs.
-func (mr *MockTrunkENIMockRecorder) CreateAndAssociateBranchENIs(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockTrunkENIMockRecorder) CreateAndAssociateBranchENIs(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAssociateBranchENIs", reflect.TypeOf((*MockTrunkENI)(nil).CreateAndAssociateBranchENIs), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAndAssociateBranchENIs", reflect.TypeOf((*MockTrunkENI)(nil).CreateAndAssociateBranchENIs), arg0, arg1, arg2, arg3)
 }
 
 // DeleteAllBranchENIs mocks base method.
@@ -88,6 +89,20 @@ func (mr *MockTrunkENIMockRecorder) DeleteCooledDownENIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCooledDownENIs", reflect.TypeOf((*MockTrunkENI)(nil).DeleteCooledDownENIs))
 }
 
+// DeleteQueuedENIsIfUnused mocks base method.
+func (m *MockTrunkENI) DeleteQueuedENIsIfUnused() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQueuedENIsIfUnused")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DeleteQueuedENIsIfUnused indicates an expected call of DeleteQueuedENIsIfUnused.
+func (mr *MockTrunkENIMockRecorder) DeleteQueuedENIsIfUnused() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQueuedENIsIfUnused", reflect.TypeOf((*MockTrunkENI)(nil).DeleteQueuedENIsIfUnused))
+}
+
 // InitTrunk mocks base method.
 func (m *MockTrunkENI) InitTrunk(arg0 ec2.EC2Instance, arg1 []v1.Pod) error {
 	m.ctrl.T.Helper()
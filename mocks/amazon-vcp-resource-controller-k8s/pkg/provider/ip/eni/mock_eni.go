@@ -80,6 +80,21 @@ func (mr *MockENIManagerMockRecorder) DeleteIPV4Resource(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIPV4Resource", reflect.TypeOf((*MockENIManager)(nil).DeleteIPV4Resource), arg0, arg1, arg2, arg3)
 }
 
+// GetIPv4Resources mocks base method.
+func (m *MockENIManager) GetIPv4Resources(arg0 config.ResourceType, arg1 api.EC2APIHelper) ([]eni.ENIResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPv4Resources", arg0, arg1)
+	ret0, _ := ret[0].([]eni.ENIResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPv4Resources indicates an expected call of GetIPv4Resources.
+func (mr *MockENIManagerMockRecorder) GetIPv4Resources(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPv4Resources", reflect.TypeOf((*MockENIManager)(nil).GetIPv4Resources), arg0, arg1)
+}
+
 // InitResources mocks base method.
 func (m *MockENIManager) InitResources(arg0 api.EC2APIHelper) (*eni.IPv4Resource, error) {
 	m.ctrl.T.Helper()
@@ -94,3 +109,18 @@ func (mr *MockENIManagerMockRecorder) InitResources(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitResources", reflect.TypeOf((*MockENIManager)(nil).InitResources), arg0)
 }
+
+// UnassignENIResources mocks base method.
+func (m *MockENIManager) UnassignENIResources(arg0 []eni.ENIResource, arg1 config.ResourceType, arg2 api.EC2APIHelper, arg3 logr.Logger) ([]eni.ENIResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignENIResources", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]eni.ENIResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnassignENIResources indicates an expected call of UnassignENIResources.
+func (mr *MockENIManagerMockRecorder) UnassignENIResources(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignENIResources", reflect.TypeOf((*MockENIManager)(nil).UnassignENIResources), arg0, arg1, arg2, arg3)
+}
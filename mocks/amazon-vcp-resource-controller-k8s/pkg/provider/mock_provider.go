@@ -192,6 +192,20 @@ func (mr *MockResourceProviderMockRecorder) ReconcileNode(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNode", reflect.TypeOf((*MockResourceProvider)(nil).ReconcileNode), arg0)
 }
 
+// ReleaseResource mocks base method.
+func (m *MockResourceProvider) ReleaseResource(arg0 ec2.EC2Instance) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseResource", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseResource indicates an expected call of ReleaseResource.
+func (mr *MockResourceProviderMockRecorder) ReleaseResource(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseResource", reflect.TypeOf((*MockResourceProvider)(nil).ReleaseResource), arg0)
+}
+
 // SubmitAsyncJob mocks base method.
 func (m *MockResourceProvider) SubmitAsyncJob(arg0 interface{}) {
 	m.ctrl.T.Helper()
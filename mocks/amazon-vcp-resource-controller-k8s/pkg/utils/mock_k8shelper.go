@@ -61,3 +61,34 @@ func (mr *MockSecurityGroupForPodsAPIMockRecorder) GetMatchingSecurityGroupForPo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingSecurityGroupForPods", reflect.TypeOf((*MockSecurityGroupForPodsAPI)(nil).GetMatchingSecurityGroupForPods), arg0)
 }
+
+// GetMatchingSecurityGroupForServiceAccount mocks base method.
+func (m *MockSecurityGroupForPodsAPI) GetMatchingSecurityGroupForServiceAccount(arg0 *v1.Pod, arg1 *v1.ServiceAccount) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMatchingSecurityGroupForServiceAccount", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMatchingSecurityGroupForServiceAccount indicates an expected call of GetMatchingSecurityGroupForServiceAccount.
+func (mr *MockSecurityGroupForPodsAPIMockRecorder) GetMatchingSecurityGroupForServiceAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingSecurityGroupForServiceAccount", reflect.TypeOf((*MockSecurityGroupForPodsAPI)(nil).GetMatchingSecurityGroupForServiceAccount), arg0, arg1)
+}
+
+// GetMatchingSecurityGroupPoliciesForPods mocks base method.
+func (m *MockSecurityGroupForPodsAPI) GetMatchingSecurityGroupPoliciesForPods(arg0 *v1.Pod) ([]string, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMatchingSecurityGroupPoliciesForPods", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMatchingSecurityGroupPoliciesForPods indicates an expected call of GetMatchingSecurityGroupPoliciesForPods.
+func (mr *MockSecurityGroupForPodsAPIMockRecorder) GetMatchingSecurityGroupPoliciesForPods(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatchingSecurityGroupPoliciesForPods", reflect.TypeOf((*MockSecurityGroupForPodsAPI)(nil).GetMatchingSecurityGroupPoliciesForPods), arg0)
+}
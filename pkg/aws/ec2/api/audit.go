@@ -0,0 +1,281 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// AuditLogStdout is the audit log path that writes the audit entries to the standard output
+	AuditLogStdout = "stdout"
+	// DefaultAuditRecentEntries is the number of most recent audit entries kept in memory for introspection
+	DefaultAuditRecentEntries = 1000
+
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditCaller identifies the controller code path on whose behalf a mutating EC2 API call is made
+type AuditCaller struct {
+	// Provider is the resource provider or the routine making the call
+	Provider string `json:"provider,omitempty"`
+	// NodeName is the name of the node the call is made for
+	NodeName string `json:"nodeName,omitempty"`
+	// PodUID is the UID of the pod the call is made for
+	PodUID string `json:"podUID,omitempty"`
+}
+
+// merge returns the caller with the non empty fields of the other caller overriding the existing fields
+func (c AuditCaller) merge(other AuditCaller) AuditCaller {
+	if other.Provider != "" {
+		c.Provider = other.Provider
+	}
+	if other.NodeName != "" {
+		c.NodeName = other.NodeName
+	}
+	if other.PodUID != "" {
+		c.PodUID = other.PodUID
+	}
+	return c
+}
+
+// AuditEntry is a single record of a mutating EC2 API call
+type AuditEntry struct {
+	Timestamp   time.Time   `json:"timestamp"`
+	Operation   string      `json:"operation"`
+	Caller      AuditCaller `json:"caller"`
+	RequestID   string      `json:"requestID,omitempty"`
+	ResourceIDs []string    `json:"resourceIDs,omitempty"`
+	Result      string      `json:"result"`
+	Error       string      `json:"error,omitempty"`
+	LatencyMs   float64     `json:"latencyMs"`
+}
+
+// AuditLog writes the audit entries as JSON lines to the configured output and keeps the most recent
+// entries in memory so they can be queried from the introspection API
+type AuditLog struct {
+	lock   sync.Mutex
+	writer io.Writer
+	// recent is a ring buffer of the most recent entries, next is the index the next entry is written to
+	recent []AuditEntry
+	next   int
+	full   bool
+}
+
+// NewAuditLog returns a new audit log writing to the given path. If the path is AuditLogStdout the entries are
+// written to the standard output, otherwise the file is rotated once it grows beyond maxSizeMB, keeping up to
+// maxBackups rotated files.
+func NewAuditLog(path string, maxSizeMB int, maxBackups int) (*AuditLog, error) {
+	var writer io.Writer
+	if path == AuditLogStdout {
+		writer = os.Stdout
+	} else {
+		if maxSizeMB <= 0 {
+			return nil, fmt.Errorf("audit log max size must be positive, got %d", maxSizeMB)
+		}
+		fileWriter, err := newRotatingFileWriter(path, int64(maxSizeMB)*1024*1024, maxBackups)
+		if err != nil {
+			return nil, err
+		}
+		writer = fileWriter
+	}
+	return newAuditLog(writer, DefaultAuditRecentEntries), nil
+}
+
+func newAuditLog(writer io.Writer, recentEntries int) *AuditLog {
+	return &AuditLog{
+		writer: writer,
+		recent: make([]AuditEntry, recentEntries),
+	}
+}
+
+// Record writes the audit entry to the output and adds it to the list of recent entries
+func (a *AuditLog) Record(entry AuditEntry) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(a.recent) > 0 {
+		a.recent[a.next] = entry
+		a.next = (a.next + 1) % len(a.recent)
+		if a.next == 0 {
+			a.full = true
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Audit failures must not fail the EC2 API call, the entry is still available in the recent entries
+	_, _ = a.writer.Write(append(data, '\n'))
+}
+
+// Recent returns up to limit most recent entries in chronological order, all the entries are returned if
+// the limit is not positive
+func (a *AuditLog) Recent(limit int) []AuditEntry {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var entries []AuditEntry
+	if a.full {
+		entries = append(entries, a.recent[a.next:]...)
+	}
+	entries = append(entries, a.recent[:a.next]...)
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// WithAuditCaller returns an EC2 API helper that attributes the mutating EC2 calls to the given caller. The non
+// empty fields of the caller are added to any caller the helper is already scoped to. Helpers that are not backed
+// by the controller's EC2 wrapper are returned as is.
+func WithAuditCaller(helper EC2APIHelper, caller AuditCaller) EC2APIHelper {
+	h, ok := helper.(*ec2APIHelper)
+	if !ok {
+		return helper
+	}
+	return &ec2APIHelper{ec2Wrapper: WrapperWithAuditCaller(h.ec2Wrapper, caller)}
+}
+
+// WrapperWithAuditCaller is the EC2Wrapper counterpart of WithAuditCaller
+func WrapperWithAuditCaller(wrapper EC2Wrapper, caller AuditCaller) EC2Wrapper {
+	w, ok := wrapper.(*ec2Wrapper)
+	if !ok {
+		return wrapper
+	}
+	scoped := *w
+	scoped.caller = w.caller.merge(caller)
+	return &scoped
+}
+
+// captureRequest returns a request option that stores the SDK request so the request ID can be read after the
+// call completes
+func captureRequest(req **request.Request) request.Option {
+	return func(r *request.Request) {
+		*req = r
+	}
+}
+
+// recordAudit adds an audit entry for a mutating call if the audit log is enabled
+func (e *ec2Wrapper) recordAudit(operation string, req *request.Request, start time.Time, err error,
+	resourceIDs ...string) {
+	if e.auditLog == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Timestamp: start,
+		Operation: operation,
+		Caller:    e.caller,
+		Result:    AuditResultSuccess,
+		LatencyMs: timeSinceMs(start),
+	}
+	for _, id := range resourceIDs {
+		if id != "" {
+			entry.ResourceIDs = append(entry.ResourceIDs, id)
+		}
+	}
+	if req != nil {
+		entry.RequestID = req.RequestID
+	}
+	if err != nil {
+		entry.Result = AuditResultFailure
+		entry.Error = err.Error()
+	}
+	e.auditLog.Record(entry)
+}
+
+// rotatingFileWriter writes to a file and rotates it once it exceeds the maximum size. The rotated files are
+// suffixed with .1 to .maxBackups, .1 being the most recent one
+type rotatingFileWriter struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFileWriter(path string, maxBytes int64, maxBackups int) (*rotatingFileWriter, error) {
+	w := &rotatingFileWriter{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file %s: %w", w.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log file %s: %w", w.path, err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write is not safe for concurrent use, the AuditLog serializes the writes
+func (w *rotatingFileWriter) Write(p []byte) (int, error) {
+	if w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+	// Shift the existing backups by one, the oldest backup is overwritten
+	for i := w.maxBackups - 1; i > 0; i-- {
+		src := fmt.Sprintf("%s.%d", w.path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, fmt.Sprintf("%s.%d", w.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
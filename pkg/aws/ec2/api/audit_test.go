@@ -0,0 +1,142 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mockAuditCaller = AuditCaller{Provider: "vpc.amazonaws.com/pod-eni", NodeName: "node-1"}
+)
+
+// TestAuditLog_Record tests the entries are written as JSON lines and returned as recent entries
+func TestAuditLog_Record(t *testing.T) {
+	buf := &bytes.Buffer{}
+	auditLog := newAuditLog(buf, 10)
+
+	entry := AuditEntry{Operation: "delete_network_interface", Caller: mockAuditCaller,
+		ResourceIDs: []string{mockNetworkInterfaceId1}, Result: AuditResultSuccess}
+	auditLog.Record(entry)
+
+	got := AuditEntry{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, entry.Operation, got.Operation)
+	assert.Equal(t, entry.Caller, got.Caller)
+	assert.Equal(t, entry.ResourceIDs, got.ResourceIDs)
+	assert.Equal(t, []AuditEntry{entry}, auditLog.Recent(0))
+}
+
+// TestAuditLog_Recent tests the recent entries are returned in chronological order once the buffer wraps around
+func TestAuditLog_Recent(t *testing.T) {
+	auditLog := newAuditLog(&bytes.Buffer{}, 3)
+	for i := 0; i < 5; i++ {
+		auditLog.Record(AuditEntry{Operation: fmt.Sprintf("op-%d", i)})
+	}
+
+	var operations []string
+	for _, entry := range auditLog.Recent(0) {
+		operations = append(operations, entry.Operation)
+	}
+	assert.Equal(t, []string{"op-2", "op-3", "op-4"}, operations)
+
+	recent := auditLog.Recent(2)
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "op-4", recent[1].Operation)
+}
+
+// TestNewAuditLog_Rotation tests the audit log file is rotated once it exceeds the max size
+func TestNewAuditLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writer, err := newRotatingFileWriter(path, 100, 2)
+	assert.NoError(t, err)
+
+	line := append(bytes.Repeat([]byte("a"), 59), '\n')
+	for i := 0; i < 4; i++ {
+		_, err = writer.Write(line)
+		assert.NoError(t, err)
+	}
+
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(line)), info.Size())
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestNewAuditLog_InvalidSize tests an error is returned for a non positive max size
+func TestNewAuditLog_InvalidSize(t *testing.T) {
+	_, err := NewAuditLog(filepath.Join(t.TempDir(), "audit.log"), 0, 1)
+	assert.Error(t, err)
+}
+
+// TestEc2Wrapper_RecordAudit tests the entry has the caller, request id, resource ids and error of the call
+func TestEc2Wrapper_RecordAudit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wrapper := &ec2Wrapper{auditLog: newAuditLog(buf, 10)}
+	scoped := WrapperWithAuditCaller(WrapperWithAuditCaller(wrapper, mockAuditCaller),
+		AuditCaller{PodUID: "pod-uid"}).(*ec2Wrapper)
+
+	scoped.recordAudit("create_network_interface", &request.Request{RequestID: "request-id"},
+		time.Now(), fmt.Errorf("mock error"), mockNetworkInterfaceId1, "")
+
+	scanner := bufio.NewScanner(buf)
+	assert.True(t, scanner.Scan())
+	got := AuditEntry{}
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+	assert.Equal(t, AuditCaller{Provider: mockAuditCaller.Provider, NodeName: mockAuditCaller.NodeName,
+		PodUID: "pod-uid"}, got.Caller)
+	assert.Equal(t, "request-id", got.RequestID)
+	assert.Equal(t, []string{mockNetworkInterfaceId1}, got.ResourceIDs)
+	assert.Equal(t, AuditResultFailure, got.Result)
+	assert.Equal(t, "mock error", got.Error)
+	// The caller of the original wrapper must not be modified
+	assert.Equal(t, AuditCaller{}, wrapper.caller)
+}
+
+// TestEc2Wrapper_RecordAudit_Disabled tests no entry is recorded when the audit log is disabled
+func TestEc2Wrapper_RecordAudit_Disabled(t *testing.T) {
+	wrapper := &ec2Wrapper{}
+	wrapper.recordAudit("delete_network_interface", nil, time.Now(), nil, mockNetworkInterfaceId1)
+}
+
+// TestWithAuditCaller_Mock tests helpers and wrappers not backed by the ec2 wrapper are returned as is
+func TestWithAuditCaller_Mock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWrapper := mock_api.NewMockEC2Wrapper(ctrl)
+	mockHelper := mock_api.NewMockEC2APIHelper(ctrl)
+
+	assert.Equal(t, mockWrapper, WrapperWithAuditCaller(mockWrapper, mockAuditCaller))
+	assert.Equal(t, mockHelper, WithAuditCaller(mockHelper, mockAuditCaller))
+
+	helper := WithAuditCaller(NewEC2APIHelper(mockWrapper, mockClusterName), mockAuditCaller)
+	assert.Equal(t, mockWrapper, helper.(*ec2APIHelper).ec2Wrapper)
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
@@ -32,16 +33,91 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 )
 
+// ENICleanerMode is the action the ENI cleaner takes on the available ENIs in scope
+type ENICleanerMode string
+
+const (
+	// ENICleanerModeDelete deletes the ENIs that have been available for longer than the grace period
+	ENICleanerModeDelete ENICleanerMode = "delete"
+	// ENICleanerModeDryRun reports the ENIs that would be deleted without deleting them
+	ENICleanerModeDryRun ENICleanerMode = "dry-run"
+	// ENICleanerModeReport only reports the available ENIs in scope, they are not considered for deletion
+	ENICleanerModeReport ENICleanerMode = "report"
+)
+
+// ENICleanerScope restricts the available ENIs the cleaner acts on. An ENI is in scope if it matches the include
+// lists that are set and doesn't match any of the exclude lists.
+type ENICleanerScope struct {
+	// IncludeTags are the tags of which the ENI must have at least one, an empty value matches any value of the key
+	IncludeTags map[string]string
+	// ExcludeTags are the tags the ENI must not have, an empty value matches any value of the key
+	ExcludeTags map[string]string
+	// IncludeSubnets are the subnets of which the ENI must be in one
+	IncludeSubnets []string
+	// ExcludeSubnets are the subnets the ENI must not be in
+	ExcludeSubnets []string
+}
+
+// ENICleanupReport is the outcome of an ENI clean up cycle
+type ENICleanupReport struct {
+	StartTime time.Time      `json:"startTime"`
+	EndTime   time.Time      `json:"endTime"`
+	Mode      ENICleanerMode `json:"mode"`
+	// Seen are all the available ENIs tagged for the cluster, with the reason they are not deleted if any
+	Seen []ENICleanupReportEntry `json:"seen"`
+	// WouldDelete are the ENIs eligible for deletion in this cycle
+	WouldDelete []ENICleanupReportEntry `json:"wouldDelete"`
+	// Deleted are the ENIs deleted in this cycle
+	Deleted []ENICleanupReportEntry `json:"deleted"`
+	// Failed are the ENIs that failed to be deleted in this cycle, their deletion is retried in the next cycle
+	Failed []ENICleanupReportEntry `json:"failed"`
+	// Error is the error that aborted the cycle, if any
+	Error string `json:"error,omitempty"`
+}
+
+// ENICleanupReportEntry is an available ENI in the clean up report
+type ENICleanupReportEntry struct {
+	ID        string    `json:"id"`
+	SubnetID  string    `json:"subnetId,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	Reason    string    `json:"reason,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+const (
+	eniNotDeletedReasonOutOfScope  = "out of scope"
+	eniNotDeletedReasonFirstSeen   = "first seen in this cycle"
+	eniNotDeletedReasonGracePeriod = "within grace period"
+	eniNotDeletedReasonReportMode  = "report mode"
+	eniNotDeletedReasonOwner       = "not created by VPC-CNI/VPC-RC"
+)
+
 type ENICleaner struct {
 	EC2Wrapper  EC2Wrapper
 	ClusterName string
 	Log         logr.Logger
 	VPCID       string
+	// Mode is the action taken on the available ENIs, defaults to ENICleanerModeDelete
+	Mode ENICleanerMode
+	// Interval is the time between two clean up cycles, defaults to config.ENICleanUpInterval
+	Interval time.Duration
+	// GracePeriod is the minimum time an ENI must have been seen available before it's deleted. The ENI is never
+	// deleted in the cycle it's first seen in.
+	GracePeriod time.Duration
+	Scope       ENICleanerScope
+	// IsActive returns false if the clean up cycle must be skipped because another replica runs it, the cycle always
+	// runs if it's not set
+	IsActive func() bool
 
-	availableENIs     map[string]struct{}
+	// availableENIs are the ENIs in scope seen available in the previous cycle and the time they were first seen
+	availableENIs     map[string]time.Time
 	shutdown          bool
 	clusterNameTagKey string
 	ctx               context.Context
+
+	reportLock sync.RWMutex
+	lastReport *ENICleanupReport
 }
 
 var (
@@ -66,8 +142,23 @@ var (
 )
 
 func (e *ENICleaner) SetupWithManager(ctx context.Context, mgr ctrl.Manager, healthzHandler *rcHealthz.HealthzHandler) error {
+	switch e.Mode {
+	case "":
+		e.Mode = ENICleanerModeDelete
+	case ENICleanerModeDelete, ENICleanerModeDryRun, ENICleanerModeReport:
+	default:
+		return fmt.Errorf("invalid eni cleaner mode %q, must be one of %s, %s or %s", e.Mode,
+			ENICleanerModeDelete, ENICleanerModeDryRun, ENICleanerModeReport)
+	}
+	if e.Interval == 0 {
+		e.Interval = config.ENICleanUpInterval
+	}
+	if e.Interval < 0 || e.GracePeriod < 0 {
+		return fmt.Errorf("eni cleaner interval and grace period must not be negative")
+	}
+
 	e.clusterNameTagKey = fmt.Sprintf(config.ClusterNameTagKeyFormat, e.ClusterName)
-	e.availableENIs = make(map[string]struct{})
+	e.availableENIs = make(map[string]time.Time)
 	e.ctx = ctx
 
 	healthzHandler.AddControllersHealthCheckers(
@@ -81,7 +172,8 @@ func (e *ENICleaner) SetupWithManager(ctx context.Context, mgr ctrl.Manager, hea
 
 // StartENICleaner starts the ENI Cleaner routine that cleans up dangling ENIs created by the controller
 func (e *ENICleaner) Start(ctx context.Context) error {
-	e.Log.Info("starting eni clean up routine")
+	e.Log.Info("starting eni clean up routine", "mode", e.Mode, "interval", e.Interval,
+		"grace period", e.GracePeriod)
 	// Start routine to listen for shut down signal, on receiving the signal it set shutdown to true
 	go func() {
 		<-ctx.Done()
@@ -90,13 +182,26 @@ func (e *ENICleaner) Start(ctx context.Context) error {
 	// Perform ENI cleanup after fixed time intervals till shut down variable is set to true on receiving the shutdown
 	// signal
 	for !e.shutdown {
-		e.cleanUpAvailableENIs()
-		time.Sleep(config.ENICleanUpInterval)
+		if e.IsActive == nil || e.IsActive() {
+			e.cleanUpAvailableENIs()
+		} else {
+			// Forget the ENIs seen by the previous cycles as another replica may have run the cycles since
+			e.availableENIs = map[string]time.Time{}
+			e.Log.V(1).Info("skipping eni clean up cycle run by another replica")
+		}
+		time.Sleep(e.Interval)
 	}
 
 	return nil
 }
 
+// LastReport returns the report of the last clean up cycle, nil if no cycle has run yet
+func (e *ENICleaner) LastReport() *ENICleanupReport {
+	e.reportLock.RLock()
+	defer e.reportLock.RUnlock()
+	return e.lastReport
+}
+
 // cleanUpAvailableENIs describes all the network interfaces in available status that are created by the controller,
 // on seeing the a network interface for the first time, it is added to the map of available network interfaces, on
 // seeing the network interface for the second time the network interface is deleted. This ensures that we are deleting
@@ -109,11 +214,22 @@ func (e *ENICleaner) Start(ctx context.Context) error {
 // In the second cycle we can conclude that Interface 2 and 3 are leaked because they have been sitting for the time
 // interval between cycle 1 and 2 and hence can be safely deleted. And we can also conclude that Interface 1 was
 // created but not attached at the the time when 1st cycle ran and hence it should not be deleted.
+// The network interfaces out of scope are never deleted, and the network interfaces are only reported in the dry-run
+// and report modes.
 func (e *ENICleaner) cleanUpAvailableENIs() {
 	vpcrcAvailableCount := 0
 	vpccniAvailableCount := 0
 	leakedENICount := 0
 
+	now := time.Now()
+	report := &ENICleanupReport{StartTime: now, Mode: e.Mode}
+	defer func() {
+		report.EndTime = time.Now()
+		e.reportLock.Lock()
+		e.lastReport = report
+		e.reportLock.Unlock()
+	}()
+
 	describeNetworkInterfaceIp := &ec2.DescribeNetworkInterfacesInput{
 		Filters: []*ec2.Filter{
 			{
@@ -136,56 +252,98 @@ func (e *ENICleaner) cleanUpAvailableENIs() {
 		},
 	}
 
-	availableENIs := make(map[string]struct{})
+	availableENIs := make(map[string]time.Time)
 
 	for {
 		describeNetworkInterfaceOp, err := e.EC2Wrapper.DescribeNetworkInterfaces(describeNetworkInterfaceIp)
 		if err != nil {
 			e.Log.Error(err, "failed to describe network interfaces, will retry")
+			report.Error = err.Error()
 			return
 		}
 
 		for _, networkInterface := range describeNetworkInterfaceOp.NetworkInterfaces {
-			if _, exists := e.availableENIs[*networkInterface.NetworkInterfaceId]; exists {
-				// Increment promethues metrics for number of leaked ENIs cleaned up
-				if tagIdx := slices.IndexFunc(networkInterface.TagSet, func(tag *ec2.Tag) bool {
-					return *tag.Key == config.NetworkInterfaceOwnerTagKey
-				}); tagIdx != -1 {
-					switch *networkInterface.TagSet[tagIdx].Value {
-					case config.NetworkInterfaceOwnerTagValue:
-						vpcrcAvailableCount += 1
-					case config.NetworkInterfaceOwnerVPCCNITagValue:
-						vpccniAvailableCount += 1
-					default:
-						// We should not hit this case as we only filter for relevant tag values, log error and continue if unexpected ENIs found
-						e.Log.Error(fmt.Errorf("found available ENI not created by VPC-CNI/VPC-RC"), "eniID", *networkInterface.NetworkInterfaceId)
-						continue
-					}
-				}
+			entry := ENICleanupReportEntry{
+				ID:        aws.StringValue(networkInterface.NetworkInterfaceId),
+				SubnetID:  aws.StringValue(networkInterface.SubnetId),
+				Owner:     getTagValue(networkInterface.TagSet, config.NetworkInterfaceOwnerTagKey),
+				FirstSeen: now,
+			}
 
-				// The ENI in available state has been sitting for at least the eni clean up interval and it should
-				// be removed
-				_, err := e.EC2Wrapper.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{
-					NetworkInterfaceId: networkInterface.NetworkInterfaceId,
-				})
-				if err != nil {
-					if !strings.Contains(err.Error(), ec2Errors.NotFoundInterfaceID) { // ignore InvalidNetworkInterfaceID.NotFound error
-						// append err and continue, we will retry deletion in the next period/reconcile
-						leakedENICount += 1
-
-						e.Log.Error(err, "failed to delete the dangling network interface",
-							"id", *networkInterface.NetworkInterfaceId)
-					}
-					continue
-				}
-				e.Log.Info("deleted dangling ENI successfully",
-					"eni id", networkInterface.NetworkInterfaceId)
-			} else {
+			if !e.Scope.inScope(networkInterface) {
+				entry.Reason = eniNotDeletedReasonOutOfScope
+				report.Seen = append(report.Seen, entry)
+				continue
+			}
+			if e.Mode == ENICleanerModeReport {
+				entry.Reason = eniNotDeletedReasonReportMode
+				report.Seen = append(report.Seen, entry)
+				continue
+			}
+
+			firstSeen, exists := e.availableENIs[entry.ID]
+			if !exists {
 				// Seeing the ENI for the first time, add it to the new list of available network interfaces
-				availableENIs[*networkInterface.NetworkInterfaceId] = struct{}{}
+				availableENIs[entry.ID] = now
+				entry.Reason = eniNotDeletedReasonFirstSeen
+				report.Seen = append(report.Seen, entry)
 				e.Log.V(1).Info("adding eni to to the map of available ENIs, will be removed if present in "+
-					"next run too", "id", *networkInterface.NetworkInterfaceId)
+					"next run too", "id", entry.ID)
+				continue
 			}
+			entry.FirstSeen = firstSeen
+			if now.Sub(firstSeen) < e.GracePeriod {
+				availableENIs[entry.ID] = firstSeen
+				entry.Reason = eniNotDeletedReasonGracePeriod
+				report.Seen = append(report.Seen, entry)
+				continue
+			}
+
+			// Increment promethues metrics for number of leaked ENIs cleaned up
+			switch entry.Owner {
+			case config.NetworkInterfaceOwnerTagValue:
+				vpcrcAvailableCount += 1
+			case config.NetworkInterfaceOwnerVPCCNITagValue:
+				vpccniAvailableCount += 1
+			case "":
+			default:
+				// We should not hit this case as we only filter for relevant tag values, log error and continue if unexpected ENIs found
+				e.Log.Error(fmt.Errorf("found available ENI not created by VPC-CNI/VPC-RC"), "skipping eni",
+					"eniID", entry.ID, "owner", entry.Owner)
+				entry.Reason = eniNotDeletedReasonOwner
+				report.Seen = append(report.Seen, entry)
+				continue
+			}
+			report.Seen = append(report.Seen, entry)
+			report.WouldDelete = append(report.WouldDelete, entry)
+
+			if e.Mode == ENICleanerModeDryRun {
+				// Keep tracking the ENI so it's reported again in the next cycle
+				availableENIs[entry.ID] = firstSeen
+				e.Log.Info("dry-run, would delete dangling ENI", "eni id", entry.ID)
+				continue
+			}
+
+			// The ENI in available state has been sitting for at least the eni clean up interval and it should
+			// be removed
+			_, err := e.EC2Wrapper.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{
+				NetworkInterfaceId: networkInterface.NetworkInterfaceId,
+			})
+			if err != nil {
+				if !strings.Contains(err.Error(), ec2Errors.NotFoundInterfaceID) { // ignore InvalidNetworkInterfaceID.NotFound error
+					// append err and continue, we will retry deletion in the next period/reconcile
+					leakedENICount += 1
+					entry.Error = err.Error()
+					report.Failed = append(report.Failed, entry)
+
+					e.Log.Error(err, "failed to delete the dangling network interface",
+						"id", entry.ID)
+				}
+				continue
+			}
+			report.Deleted = append(report.Deleted, entry)
+			e.Log.Info("deleted dangling ENI successfully",
+				"eni id", entry.ID)
 		}
 
 		if describeNetworkInterfaceOp.NextToken == nil {
@@ -202,3 +360,54 @@ func (e *ENICleaner) cleanUpAvailableENIs() {
 	// Set the available ENIs to the list of ENIs seen in the current cycle
 	e.availableENIs = availableENIs
 }
+
+// inScope returns true if the network interface matches the include lists that are set and none of the exclude lists
+func (s ENICleanerScope) inScope(networkInterface *ec2.NetworkInterface) bool {
+	subnetID := aws.StringValue(networkInterface.SubnetId)
+	if len(s.IncludeSubnets) > 0 && !slices.Contains(s.IncludeSubnets, subnetID) {
+		return false
+	}
+	if slices.Contains(s.ExcludeSubnets, subnetID) {
+		return false
+	}
+	if len(s.IncludeTags) > 0 && !hasAnyTag(networkInterface.TagSet, s.IncludeTags) {
+		return false
+	}
+	return !hasAnyTag(networkInterface.TagSet, s.ExcludeTags)
+}
+
+// hasAnyTag returns true if any of the tags matches, an empty value matches any value of the key
+func hasAnyTag(tagSet []*ec2.Tag, tags map[string]string) bool {
+	for _, tag := range tagSet {
+		value, found := tags[aws.StringValue(tag.Key)]
+		if found && (value == "" || value == aws.StringValue(tag.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func getTagValue(tagSet []*ec2.Tag, key string) string {
+	for _, tag := range tagSet {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+// ParseENICleanerTags parses a comma separated list of key=value tags, the value can be omitted to match any value
+func ParseENICleanerTags(tags string) (map[string]string, error) {
+	parsed := map[string]string{}
+	if tags == "" {
+		return parsed, nil
+	}
+	for _, tag := range strings.Split(tags, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid tag %q, must be key=value or key", tag)
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
@@ -16,8 +16,8 @@ package api
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"testing"
+	"time"
 
 	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
@@ -26,6 +26,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
@@ -78,7 +79,9 @@ func getMockENICleaner(ctrl *gomock.Controller) (*ENICleaner, *mock_api.MockEC2W
 	mockEC2Wrapper := mock_api.NewMockEC2Wrapper(ctrl)
 	return &ENICleaner{
 		EC2Wrapper:        mockEC2Wrapper,
-		availableENIs:     map[string]struct{}{},
+		Mode:              ENICleanerModeDelete,
+		Interval:          config.ENICleanUpInterval,
+		availableENIs:     map[string]time.Time{},
 		Log:               zap.New(zap.UseDevMode(true)),
 		VPCID:             mockVPCID,
 		clusterNameTagKey: mockClusterNameTagKey,
@@ -104,13 +107,171 @@ func TestENICleaner_cleanUpAvailableENIs(t *testing.T) {
 
 	// Run 1st cycle, network interface 1 and 2 should be added to the map of available ENIs
 	eniCleaner.cleanUpAvailableENIs()
-	assert.True(t, reflect.DeepEqual(
-		map[string]struct{}{mockNetworkInterfaceId1: {}, mockNetworkInterfaceId2: {}}, eniCleaner.availableENIs))
+	assert.ElementsMatch(t, []string{mockNetworkInterfaceId1, mockNetworkInterfaceId2},
+		maps.Keys(eniCleaner.availableENIs))
 
 	// Run the second cycle, this time network interface 1 should be deleted and network interface 3 added to list
 	eniCleaner.cleanUpAvailableENIs()
-	assert.True(t, reflect.DeepEqual(
-		map[string]struct{}{mockNetworkInterfaceId3: {}}, eniCleaner.availableENIs))
+	assert.ElementsMatch(t, []string{mockNetworkInterfaceId3}, maps.Keys(eniCleaner.availableENIs))
+
+	report := eniCleaner.LastReport()
+	assert.Equal(t, ENICleanerModeDelete, report.Mode)
+	assert.Len(t, report.Seen, 2)
+	assert.Equal(t, mockNetworkInterfaceId1, report.WouldDelete[0].ID)
+	assert.Equal(t, mockNetworkInterfaceId1, report.Deleted[0].ID)
+	assert.Empty(t, report.Failed)
+}
+
+// TestENICleaner_cleanUpAvailableENIs_DryRun tests the ENIs are reported in each cycle but never deleted
+func TestENICleaner_cleanUpAvailableENIs_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+	eniCleaner.Mode = ENICleanerModeDryRun
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(mockDescribeInterfaceOpWith1And2, nil).Times(3)
+
+	eniCleaner.cleanUpAvailableENIs()
+	assert.Empty(t, eniCleaner.LastReport().WouldDelete)
+
+	for i := 0; i < 2; i++ {
+		eniCleaner.cleanUpAvailableENIs()
+		report := eniCleaner.LastReport()
+		assert.Len(t, report.WouldDelete, 2)
+		assert.Empty(t, report.Deleted)
+		assert.ElementsMatch(t, []string{mockNetworkInterfaceId1, mockNetworkInterfaceId2},
+			maps.Keys(eniCleaner.availableENIs))
+	}
+}
+
+// TestENICleaner_cleanUpAvailableENIs_Report tests the ENIs are only reported as seen in report mode
+func TestENICleaner_cleanUpAvailableENIs_Report(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+	eniCleaner.Mode = ENICleanerModeReport
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(mockDescribeInterfaceOpWith1And2, nil).Times(2)
+
+	eniCleaner.cleanUpAvailableENIs()
+	eniCleaner.cleanUpAvailableENIs()
+
+	report := eniCleaner.LastReport()
+	assert.Len(t, report.Seen, 2)
+	assert.Equal(t, eniNotDeletedReasonReportMode, report.Seen[0].Reason)
+	assert.Empty(t, report.WouldDelete)
+	assert.Empty(t, eniCleaner.availableENIs)
+}
+
+// TestENICleaner_cleanUpAvailableENIs_GracePeriod tests the ENIs are not deleted before the grace period elapses
+func TestENICleaner_cleanUpAvailableENIs_GracePeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+	eniCleaner.GracePeriod = time.Hour
+	eniCleaner.availableENIs = map[string]time.Time{
+		mockNetworkInterfaceId1: time.Now().Add(-time.Minute),
+		mockNetworkInterfaceId2: time.Now().Add(-time.Hour * 2),
+	}
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(mockDescribeInterfaceOpWith1And2, nil)
+	mockWrapper.EXPECT().DeleteNetworkInterface(
+		&ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: &mockNetworkInterfaceId2}).Return(nil, nil)
+
+	eniCleaner.cleanUpAvailableENIs()
+
+	report := eniCleaner.LastReport()
+	assert.Equal(t, eniNotDeletedReasonGracePeriod, report.Seen[0].Reason)
+	assert.Equal(t, mockNetworkInterfaceId2, report.Deleted[0].ID)
+	assert.ElementsMatch(t, []string{mockNetworkInterfaceId1}, maps.Keys(eniCleaner.availableENIs))
+}
+
+// TestENICleaner_cleanUpAvailableENIs_UnexpectedOwner tests the ENIs with an unexpected owner are reported as seen
+// and never deleted
+func TestENICleaner_cleanUpAvailableENIs_UnexpectedOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+	eniCleaner.availableENIs = map[string]time.Time{mockNetworkInterfaceId1: time.Now().Add(-time.Hour)}
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(&ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: []*ec2.NetworkInterface{{
+				NetworkInterfaceId: &mockNetworkInterfaceId1,
+				TagSet: []*ec2.Tag{{Key: aws.String(config.NetworkInterfaceOwnerTagKey),
+					Value: aws.String("other")}},
+			}},
+		}, nil)
+
+	eniCleaner.cleanUpAvailableENIs()
+
+	report := eniCleaner.LastReport()
+	assert.Len(t, report.Seen, 1)
+	assert.Equal(t, mockNetworkInterfaceId1, report.Seen[0].ID)
+	assert.Equal(t, "other", report.Seen[0].Owner)
+	assert.Equal(t, eniNotDeletedReasonOwner, report.Seen[0].Reason)
+	assert.Empty(t, report.WouldDelete)
+	assert.Empty(t, report.Deleted)
+}
+
+// TestENICleaner_cleanUpAvailableENIs_DescribeError tests the error is reported and the tracked ENIs are kept
+func TestENICleaner_cleanUpAvailableENIs_DescribeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eniCleaner, mockWrapper := getMockENICleaner(ctrl)
+	eniCleaner.availableENIs = map[string]time.Time{mockNetworkInterfaceId1: time.Now()}
+
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(mockDescribeNetworkInterfaceIp).
+		Return(nil, fmt.Errorf("describe failed"))
+
+	eniCleaner.cleanUpAvailableENIs()
+
+	assert.Equal(t, "describe failed", eniCleaner.LastReport().Error)
+	assert.Len(t, eniCleaner.availableENIs, 1)
+}
+
+func TestENICleanerScope_inScope(t *testing.T) {
+	subnet1, subnet2 := "subnet-1", "subnet-2"
+	networkInterface := &ec2.NetworkInterface{
+		SubnetId: &subnet1,
+		TagSet: []*ec2.Tag{
+			{Key: aws.String(config.NetworkInterfaceOwnerTagKey), Value: aws.String(config.NetworkInterfaceOwnerTagValue)},
+			{Key: aws.String("team"), Value: aws.String("a")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		scope    ENICleanerScope
+		expected bool
+	}{
+		{name: "empty scope", scope: ENICleanerScope{}, expected: true},
+		{name: "included subnet", scope: ENICleanerScope{IncludeSubnets: []string{subnet1}}, expected: true},
+		{name: "not included subnet", scope: ENICleanerScope{IncludeSubnets: []string{subnet2}}, expected: false},
+		{name: "excluded subnet", scope: ENICleanerScope{ExcludeSubnets: []string{subnet1}}, expected: false},
+		{name: "included tag with any value", scope: ENICleanerScope{IncludeTags: map[string]string{"team": ""}}, expected: true},
+		{name: "included tag with other value", scope: ENICleanerScope{IncludeTags: map[string]string{"team": "b"}}, expected: false},
+		{name: "excluded tag", scope: ENICleanerScope{ExcludeTags: map[string]string{"team": "a"}}, expected: false},
+		{name: "included subnet and excluded tag", scope: ENICleanerScope{IncludeSubnets: []string{subnet1},
+			ExcludeTags: map[string]string{"team": ""}}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.scope.inScope(networkInterface))
+		})
+	}
+}
+
+func TestParseENICleanerTags(t *testing.T) {
+	tags, err := ParseENICleanerTags("team=a, keep")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "a", "keep": ""}, tags)
+
+	tags, err = ParseENICleanerTags("")
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = ParseENICleanerTags("=a")
+	assert.Error(t, err)
 }
 
 // TestENICleaner_StartENICleaner_Shutdown tests that ENICleaner would not start if shutdown is set to true.
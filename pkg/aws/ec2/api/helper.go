@@ -18,15 +18,20 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 )
 
 const (
 	CreateENIDescriptionPrefix = "aws-k8s-"
+
+	// errCodeAssociationNotFound is the error code of the calls referencing an association that doesn't exist
+	errCodeAssociationNotFound = "InvalidAssociationID.NotFound"
 )
 
 var (
@@ -79,20 +84,28 @@ type EC2APIHelper interface {
 		ipResourceCount *config.IPResourceCount, interfaceType *string) (*ec2.NetworkInterface, error)
 	DeleteNetworkInterface(interfaceId *string) error
 	GetSubnet(subnetId *string) (*ec2.Subnet, error)
-	GetBranchNetworkInterface(trunkID, subnetID *string) ([]*ec2.NetworkInterface, error)
+	GetSubnets(filters []*ec2.Filter) ([]*ec2.Subnet, error)
+	GetBranchNetworkInterface(trunkID *string) ([]*ec2.NetworkInterface, error)
 	GetInstanceNetworkInterface(instanceId *string) ([]*ec2.InstanceNetworkInterface, error)
 	DescribeNetworkInterfaces(nwInterfaceIds []*string) ([]*ec2.NetworkInterface, error)
 	DescribeTrunkInterfaceAssociation(trunkInterfaceId *string) ([]*ec2.TrunkInterfaceAssociation, error)
 	CreateAndAttachNetworkInterface(instanceId *string, subnetId *string, securityGroups []string, tags []*ec2.Tag, deviceIndex *int64,
-		description *string, interfaceType *string, ipResourceCount *config.IPResourceCount) (*ec2.NetworkInterface, error)
-	AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64) (*string, error)
+		networkCardIndex *int64, description *string, interfaceType *string, ipResourceCount *config.IPResourceCount) (*ec2.NetworkInterface, error)
+	AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64, networkCardIndex *int64) (*string, error)
 	SetDeleteOnTermination(attachmentId *string, eniId *string) error
+	SetSecurityGroups(eniId *string, securityGroups []string) error
 	DetachNetworkInterfaceFromInstance(attachmentId *string) error
 	DetachAndDeleteNetworkInterface(attachmentId *string, nwInterfaceId *string) error
 	WaitForNetworkInterfaceStatusChange(networkInterfaceId *string, desiredStatus string) error
 	GetInstanceDetails(instanceId *string) (*ec2.Instance, error)
 	AssignIPv4ResourcesAndWaitTillReady(eniID string, resourceType config.ResourceType, count int) ([]string, error)
 	UnassignIPv4Resources(eniID string, resourceType config.ResourceType, resources []string) error
+	GetInstanceTypeLimits(instanceType string) (*vpc.VPCLimits, error)
+	GetMissingSecurityGroups(vpcID string, groupIDs []string) ([]string, error)
+	GetNetworkInterfaceByPrivateIP(instanceID string, privateIP string) (*ec2.NetworkInterface, error)
+	GetPoolElasticIPs(pool string) ([]*ec2.Address, error)
+	AssociateElasticIP(allocationID string, eniID string, privateIP string) (string, error)
+	DisassociateElasticIP(associationID string) error
 }
 
 // CreateNetworkInterface creates a new network interface
@@ -197,6 +210,26 @@ func (h *ec2APIHelper) GetSubnet(subnetId *string) (*ec2.Subnet, error) {
 	return describeSubnetOutput.Subnets[0], nil
 }
 
+// GetSubnets returns all the subnets matching the given filters
+func (h *ec2APIHelper) GetSubnets(filters []*ec2.Filter) ([]*ec2.Subnet, error) {
+	describeSubnetInput := &ec2.DescribeSubnetsInput{
+		Filters: filters,
+	}
+
+	var subnets []*ec2.Subnet
+	for {
+		describeSubnetOutput, err := h.ec2Wrapper.DescribeSubnets(describeSubnetInput)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, describeSubnetOutput.Subnets...)
+		if describeSubnetOutput.NextToken == nil {
+			return subnets, nil
+		}
+		describeSubnetInput.NextToken = describeSubnetOutput.NextToken
+	}
+}
+
 // DeleteNetworkInterface deletes a network interface with retries with exponential back offs
 func (h *ec2APIHelper) DeleteNetworkInterface(interfaceId *string) error {
 	deleteNetworkInterface := &ec2.DeleteNetworkInterfaceInput{
@@ -307,10 +340,12 @@ func (h *ec2APIHelper) AssociateBranchToTrunk(trunkInterfaceId *string, branchIn
 		*associateTrunkInterfaceIP)
 }
 
-// CreateAndAttachNetworkInterface creates and attaches the network interface to the instance. The function will
-// wait till the interface is successfully attached
+// CreateAndAttachNetworkInterface creates and attaches the network interface to the instance at the device index of
+// the network card, the default network card is used if the network card index is nil. The function will wait till
+// the interface is successfully attached
 func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subnetId *string, securityGroups []string,
-	tags []*ec2.Tag, deviceIndex *int64, description *string, interfaceType *string, ipResourceCount *config.IPResourceCount) (*ec2.NetworkInterface, error) {
+	tags []*ec2.Tag, deviceIndex *int64, networkCardIndex *int64, description *string, interfaceType *string,
+	ipResourceCount *config.IPResourceCount) (*ec2.NetworkInterface, error) {
 
 	nwInterface, err := h.CreateNetworkInterface(description, subnetId, securityGroups, tags, ipResourceCount, interfaceType)
 	if err != nil {
@@ -319,7 +354,8 @@ func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subne
 
 	var attachmentId *string
 
-	attachmentId, err = h.AttachNetworkInterfaceToInstance(instanceId, nwInterface.NetworkInterfaceId, deviceIndex)
+	attachmentId, err = h.AttachNetworkInterfaceToInstance(instanceId, nwInterface.NetworkInterfaceId, deviceIndex,
+		networkCardIndex)
 	if err != nil {
 		errDelete := h.DeleteNetworkInterface(nwInterface.NetworkInterfaceId)
 		if errDelete != nil {
@@ -349,7 +385,18 @@ func (h *ec2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subne
 		return nil, fmt.Errorf("waiting for network attachement, %w", err)
 	}
 
-	return nwInterface, nil
+	// The interface was described before it was attached
+	attachedInterface := *nwInterface
+	attachedInterface.Attachment = &ec2.NetworkInterfaceAttachment{
+		AttachmentId:        attachmentId,
+		DeleteOnTermination: aws.Bool(true),
+		DeviceIndex:         deviceIndex,
+		InstanceId:          instanceId,
+		NetworkCardIndex:    networkCardIndex,
+		Status:              aws.String(ec2.AttachmentStatusAttached),
+	}
+
+	return &attachedInterface, nil
 }
 
 // SetDeleteOnTermination sets the deletion on termination of the network interface to true
@@ -367,10 +414,25 @@ func (h *ec2APIHelper) SetDeleteOnTermination(attachmentId *string, eniId *strin
 	return err
 }
 
-// AttachNetworkInterfaceToInstance attaches the network interface to the instance
-func (h *ec2APIHelper) AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64) (*string, error) {
+// SetSecurityGroups replaces the security groups of the network interface
+func (h *ec2APIHelper) SetSecurityGroups(eniId *string, securityGroups []string) error {
+	modifyNetworkInterfaceInput := &ec2.ModifyNetworkInterfaceAttributeInput{
+		Groups:             aws.StringSlice(securityGroups),
+		NetworkInterfaceId: eniId,
+	}
+
+	_, err := h.ec2Wrapper.ModifyNetworkInterfaceAttribute(modifyNetworkInterfaceInput)
+
+	return err
+}
+
+// AttachNetworkInterfaceToInstance attaches the network interface to the instance at the device index of the network
+// card, the interface is attached to the default network card if the network card index is nil
+func (h *ec2APIHelper) AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string, deviceIndex *int64,
+	networkCardIndex *int64) (*string, error) {
 	attachNetworkInterfaceInput := &ec2.AttachNetworkInterfaceInput{
 		DeviceIndex:        deviceIndex,
+		NetworkCardIndex:   networkCardIndex,
 		InstanceId:         instanceId,
 		NetworkInterfaceId: nwInterfaceId,
 	}
@@ -445,6 +507,52 @@ func (h *ec2APIHelper) GetInstanceDetails(instanceId *string) (*ec2.Instance, er
 	return nil, fmt.Errorf("failed to find instance details for input %v", *describeInstanceInput)
 }
 
+// GetInstanceTypeLimits returns the network limits of the instance type from EC2. The EC2 API exposes neither the
+// trunking support nor the branch interface limit, the instance type is therefore reported as not compatible with
+// trunking until the limits are overridden with isTrunkingCompatible and branchInterface.
+func (h *ec2APIHelper) GetInstanceTypeLimits(instanceType string) (*vpc.VPCLimits, error) {
+	describeInstanceTypesInput := &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{aws.String(instanceType)},
+	}
+
+	describeInstanceTypesOutput, err := h.ec2Wrapper.DescribeInstanceTypes(describeInstanceTypesInput)
+	if err != nil {
+		return nil, err
+	}
+	if describeInstanceTypesOutput == nil || len(describeInstanceTypesOutput.InstanceTypes) == 0 ||
+		describeInstanceTypesOutput.InstanceTypes[0].NetworkInfo == nil {
+		return nil, fmt.Errorf("failed to find network info for instance type %s", instanceType)
+	}
+
+	instanceTypeInfo := describeInstanceTypesOutput.InstanceTypes[0]
+	networkInfo := instanceTypeInfo.NetworkInfo
+
+	limits := &vpc.VPCLimits{
+		Interface:               int(aws.Int64Value(networkInfo.MaximumNetworkInterfaces)),
+		IPv4PerInterface:        int(aws.Int64Value(networkInfo.Ipv4AddressesPerInterface)),
+		DefaultNetworkCardIndex: int(aws.Int64Value(networkInfo.DefaultNetworkCardIndex)),
+		Hypervisor:              aws.StringValue(instanceTypeInfo.Hypervisor),
+		IsBareMetal:             aws.BoolValue(instanceTypeInfo.BareMetal),
+	}
+	for _, card := range networkInfo.NetworkCards {
+		limits.NetworkCards = append(limits.NetworkCards, vpc.NetworkCard{
+			MaximumNetworkInterfaces: aws.Int64Value(card.MaximumNetworkInterfaces),
+			NetworkCardIndex:         aws.Int64Value(card.NetworkCardIndex),
+			NetworkPerformance:       aws.StringValue(card.NetworkPerformance),
+		})
+	}
+	// Instance types with a single network card may not report the card
+	if len(limits.NetworkCards) == 0 {
+		limits.NetworkCards = []vpc.NetworkCard{{
+			MaximumNetworkInterfaces: int64(limits.Interface),
+			NetworkCardIndex:         int64(limits.DefaultNetworkCardIndex),
+			NetworkPerformance:       aws.StringValue(networkInfo.NetworkPerformance),
+		}}
+	}
+
+	return limits, nil
+}
+
 func (h *ec2APIHelper) AssignIPv4ResourcesAndWaitTillReady(eniID string, resourceType config.ResourceType, count int) ([]string, error) {
 	var assignedResources []string
 	input := &ec2.AssignPrivateIpAddressesInput{}
@@ -562,16 +670,14 @@ func (h *ec2APIHelper) UnassignIPv4Resources(eniID string, resourceType config.R
 	return err
 }
 
-func (h *ec2APIHelper) GetBranchNetworkInterface(trunkID, subnetID *string) ([]*ec2.NetworkInterface, error) {
+// GetBranchNetworkInterface returns the branch interfaces tagged with the trunk interface ID, in any subnet as the
+// branch interfaces can be created in the subnets selected by the pods or in a previous subnet of the node
+func (h *ec2APIHelper) GetBranchNetworkInterface(trunkID *string) ([]*ec2.NetworkInterface, error) {
 	filters := []*ec2.Filter{
 		{
 			Name:   aws.String("tag:" + config.TrunkENIIDTag),
 			Values: []*string{trunkID},
 		},
-		{
-			Name:   aws.String("subnet-id"),
-			Values: []*string{subnetID},
-		},
 	}
 
 	describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfacesInput{Filters: filters}
@@ -623,3 +729,103 @@ func (h *ec2APIHelper) DetachAndDeleteNetworkInterface(attachmentID *string, nwI
 	}
 	return nil
 }
+
+// GetMissingSecurityGroups returns the security groups from the given list that don't exist in the VPC
+func (h *ec2APIHelper) GetMissingSecurityGroups(vpcID string, groupIDs []string) ([]string, error) {
+	describeSecurityGroupsInput := &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{aws.String(vpcID)}},
+			{Name: aws.String("group-id"), Values: aws.StringSlice(groupIDs)},
+		},
+	}
+
+	found := make(map[string]struct{})
+	for {
+		describeSecurityGroupsOutput, err := h.ec2Wrapper.DescribeSecurityGroups(describeSecurityGroupsInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, securityGroup := range describeSecurityGroupsOutput.SecurityGroups {
+			found[aws.StringValue(securityGroup.GroupId)] = struct{}{}
+		}
+		if describeSecurityGroupsOutput.NextToken == nil {
+			break
+		}
+		describeSecurityGroupsInput.NextToken = describeSecurityGroupsOutput.NextToken
+	}
+
+	var missing []string
+	for _, groupID := range groupIDs {
+		if _, ok := found[groupID]; !ok {
+			missing = append(missing, groupID)
+		}
+	}
+	return missing, nil
+}
+
+// GetNetworkInterfaceByPrivateIP returns the network interface attached to the instance with the private IP address
+func (h *ec2APIHelper) GetNetworkInterfaceByPrivateIP(instanceID string, privateIP string) (*ec2.NetworkInterface,
+	error) {
+	describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("attachment.instance-id"), Values: []*string{aws.String(instanceID)}},
+			{Name: aws.String("addresses.private-ip-address"), Values: []*string{aws.String(privateIP)}},
+		},
+	}
+	describeNetworkInterfacesOutput, err := h.ec2Wrapper.DescribeNetworkInterfaces(describeNetworkInterfacesInput)
+	if err != nil {
+		return nil, err
+	}
+	if describeNetworkInterfacesOutput == nil || len(describeNetworkInterfacesOutput.NetworkInterfaces) == 0 {
+		return nil, fmt.Errorf("no network interface of instance %s has the private IP address %s", instanceID,
+			privateIP)
+	}
+	return describeNetworkInterfacesOutput.NetworkInterfaces[0], nil
+}
+
+// GetPoolElasticIPs returns the Elastic IP addresses of the pool with their tags, including the associated addresses
+func (h *ec2APIHelper) GetPoolElasticIPs(pool string) ([]*ec2.Address, error) {
+	describeAddressesInput := &ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("tag:" + config.ElasticIPPoolTagKey), Values: []*string{aws.String(pool)}},
+			{Name: aws.String("domain"), Values: []*string{aws.String(ec2.DomainTypeVpc)}},
+		},
+	}
+	describeAddressesOutput, err := h.ec2Wrapper.DescribeAddresses(describeAddressesInput)
+	if err != nil {
+		return nil, err
+	}
+	return describeAddressesOutput.Addresses, nil
+}
+
+// AssociateElasticIP associates the Elastic IP address with the private IP address of the network interface and
+// returns the association ID, the call fails if the Elastic IP address is already associated
+func (h *ec2APIHelper) AssociateElasticIP(allocationID string, eniID string, privateIP string) (string, error) {
+	associateAddressInput := &ec2.AssociateAddressInput{
+		AllocationId:       aws.String(allocationID),
+		NetworkInterfaceId: aws.String(eniID),
+		PrivateIpAddress:   aws.String(privateIP),
+		AllowReassociation: aws.Bool(false),
+	}
+	associateAddressOutput, err := h.ec2Wrapper.AssociateAddress(associateAddressInput)
+	if err != nil {
+		return "", err
+	}
+	if associateAddressOutput == nil || associateAddressOutput.AssociationId == nil {
+		return "", fmt.Errorf("no association id returned for the Elastic IP address %s", allocationID)
+	}
+	return *associateAddressOutput.AssociationId, nil
+}
+
+// DisassociateElasticIP disassociates the Elastic IP address, the association deleted already with its network
+// interface is not an error
+func (h *ec2APIHelper) DisassociateElasticIP(associationID string) error {
+	disassociateAddressInput := &ec2.DisassociateAddressInput{
+		AssociationId: aws.String(associationID),
+	}
+	_, err := h.ec2Wrapper.DisassociateAddress(disassociateAddressInput)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeAssociationNotFound {
+		return nil
+	}
+	return err
+}
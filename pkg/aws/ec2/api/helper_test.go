@@ -19,9 +19,11 @@ import (
 	"time"
 
 	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -185,10 +187,6 @@ var (
 				Name:   aws.String("tag:" + config.TrunkENIIDTag),
 				Values: []*string{&trunkInterfaceId},
 			},
-			{
-				Name:   aws.String("subnet-id"),
-				Values: aws.StringSlice([]string{subnetId}),
-			},
 		},
 	}
 	describeTrunkInterfaceInput2 = &ec2.DescribeNetworkInterfacesInput{
@@ -197,10 +195,6 @@ var (
 				Name:   aws.String("tag:" + config.TrunkENIIDTag),
 				Values: []*string{&trunkInterfaceId},
 			},
-			{
-				Name:   aws.String("subnet-id"),
-				Values: aws.StringSlice([]string{subnetId}),
-			},
 		},
 		NextToken: &tokenID,
 	}
@@ -640,6 +634,82 @@ func TestEc2APIHelper_GetSubnet_Error(t *testing.T) {
 	assert.Error(t, mockError, err)
 }
 
+// TestEc2APIHelper_GetSubnets tests that all the pages of subnets matching the filters are returned
+func TestEc2APIHelper_GetSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filters := []*ec2.Filter{{Name: aws.String("tag:routing"), Values: []*string{aws.String("strict")}}}
+	subnet1 := &ec2.Subnet{SubnetId: aws.String("subnet-1")}
+	subnet2 := &ec2.Subnet{SubnetId: aws.String("subnet-2")}
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	gomock.InOrder(
+		mockWrapper.EXPECT().DescribeSubnets(&ec2.DescribeSubnetsInput{Filters: filters}).
+			Return(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{subnet1}, NextToken: aws.String("token")}, nil),
+		mockWrapper.EXPECT().DescribeSubnets(&ec2.DescribeSubnetsInput{Filters: filters, NextToken: aws.String("token")}).
+			Return(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{subnet2}}, nil),
+	)
+
+	subnets, err := ec2ApiHelper.GetSubnets(filters)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ec2.Subnet{subnet1, subnet2}, subnets)
+}
+
+// TestEc2APIHelper_GetSubnets_Error tests that the error from ec2 api call is propagated to the caller
+func TestEc2APIHelper_GetSubnets_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeSubnets(&ec2.DescribeSubnetsInput{}).Return(nil, mockError)
+
+	_, err := ec2ApiHelper.GetSubnets(nil)
+	assert.Error(t, err)
+}
+
+// TestEc2APIHelper_GetInstanceTypeLimits tests the network info of the instance type is converted to the limits
+func TestEc2APIHelper_GetInstanceTypeLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{aws.String("new.large")},
+	}).Return(&ec2.DescribeInstanceTypesOutput{InstanceTypes: []*ec2.InstanceTypeInfo{{
+		Hypervisor: aws.String("nitro"),
+		BareMetal:  aws.Bool(false),
+		NetworkInfo: &ec2.NetworkInfo{
+			MaximumNetworkInterfaces:  aws.Int64(3),
+			Ipv4AddressesPerInterface: aws.Int64(10),
+			DefaultNetworkCardIndex:   aws.Int64(0),
+			NetworkCards: []*ec2.NetworkCardInfo{
+				{MaximumNetworkInterfaces: aws.Int64(3), NetworkCardIndex: aws.Int64(0)},
+			},
+		},
+	}}}, nil)
+
+	limits, err := ec2ApiHelper.GetInstanceTypeLimits("new.large")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, limits.Interface)
+	assert.Equal(t, 10, limits.IPv4PerInterface)
+	assert.Equal(t, "nitro", limits.Hypervisor)
+	assert.False(t, limits.IsTrunkingCompatible)
+	assert.Equal(t, []vpc.NetworkCard{{MaximumNetworkInterfaces: 3, NetworkCardIndex: 0}}, limits.NetworkCards)
+}
+
+// TestEc2APIHelper_GetInstanceTypeLimits_NotFound tests an error is returned if the instance type is not returned
+func TestEc2APIHelper_GetInstanceTypeLimits_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeInstanceTypes(gomock.Any()).Return(&ec2.DescribeInstanceTypesOutput{}, nil)
+
+	_, err := ec2ApiHelper.GetInstanceTypeLimits("new.large")
+	assert.Error(t, err)
+}
+
 // TestEc2APIHelper_GetNetworkInterfaceOfInstance tests that describe network interface returns no errors
 // under valid input
 func TestEc2APIHelper_GetNetworkInterfaceOfInstance(t *testing.T) {
@@ -764,13 +834,15 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface(t *testing.T) {
 		Return(describeNetworkInterfaceOutputUsingOneInterfaceId, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, nil)
+		&deviceIndex, nil, &eniDescription, nil, nil)
 
 	// Clean up
 	describeNetworkInterfaceOutputUsingOneInterfaceId.NetworkInterfaces[0].Attachment.Status = oldStatus
 
 	assert.NoError(t, err)
 	assert.Equal(t, branchInterfaceId, *nwInterface.NetworkInterfaceId)
+	assert.Equal(t, attachmentId, *nwInterface.Attachment.AttachmentId)
+	assert.Equal(t, deviceIndex, *nwInterface.Attachment.DeviceIndex)
 }
 
 // TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnAttachFailed tests that delete is invoked if the attach
@@ -788,7 +860,7 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnAttachFailed(t *te
 	mockWrapper.EXPECT().DeleteNetworkInterface(deleteNetworkInterfaceInput).Return(nil, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, nil)
+		&deviceIndex, nil, &eniDescription, nil, nil)
 
 	assert.NotNil(t, err)
 	assert.Nil(t, nwInterface)
@@ -813,7 +885,7 @@ func TestEc2APIHelper_CreateAndAttachNetworkInterface_DeleteOnSetTerminationFail
 	mockWrapper.EXPECT().DeleteNetworkInterface(deleteNetworkInterfaceInput).Return(nil, nil)
 
 	nwInterface, err := ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceId, &subnetId, securityGroups, tags,
-		&deviceIndex, &eniDescription, nil, nil)
+		&deviceIndex, nil, &eniDescription, nil, nil)
 
 	assert.NotNil(t, err)
 	assert.Nil(t, nwInterface)
@@ -847,6 +919,22 @@ func TestEc2APIHelper_SetDeleteOnTermination_Error(t *testing.T) {
 	assert.Error(t, mockError, err)
 }
 
+// TestEc2APIHelper_SetSecurityGroups tests the security groups of the network interface are replaced
+func TestEc2APIHelper_SetSecurityGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	mockWrapper.EXPECT().ModifyNetworkInterfaceAttribute(&ec2.ModifyNetworkInterfaceAttributeInput{
+		Groups:             aws.StringSlice(securityGroups),
+		NetworkInterfaceId: &branchInterfaceId,
+	}).Return(nil, nil)
+
+	err := ec2ApiHelper.SetSecurityGroups(&branchInterfaceId, securityGroups)
+	assert.NoError(t, err)
+}
+
 // TestEC2APIHelper_AttachNetworkInterfaceToInstance no error is returned when valid inputs are passed
 func TestEC2APIHelper_AttachNetworkInterfaceToInstance(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -857,7 +945,28 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance(t *testing.T) {
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).
 		Return(attachNetworkInterfaceOutput, nil)
 
-	id, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	id, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, attachmentId, *id)
+}
+
+// TestEC2APIHelper_AttachNetworkInterfaceToInstance_NetworkCard tests the network interface is attached to the given
+// network card
+func TestEC2APIHelper_AttachNetworkInterfaceToInstance_NetworkCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+
+	mockWrapper.EXPECT().AttachNetworkInterface(&ec2.AttachNetworkInterfaceInput{
+		InstanceId:         &instanceId,
+		NetworkInterfaceId: &branchInterfaceId,
+		DeviceIndex:        &deviceIndex,
+		NetworkCardIndex:   aws.Int64(1),
+	}).Return(attachNetworkInterfaceOutput, nil)
+
+	id, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex,
+		aws.Int64(1))
 	assert.NoError(t, err)
 	assert.Equal(t, attachmentId, *id)
 }
@@ -873,7 +982,7 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance_NoAttachmentId(t *testing
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).
 		Return(&ec2.AttachNetworkInterfaceOutput{AttachmentId: nil}, nil)
 
-	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex, nil)
 	assert.NotNil(t, err)
 }
 
@@ -886,7 +995,7 @@ func TestEC2APIHelper_AttachNetworkInterfaceToInstance_Error(t *testing.T) {
 
 	mockWrapper.EXPECT().AttachNetworkInterface(attachNetworkInterfaceInput).Return(nil, mockError)
 
-	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex)
+	_, err := ec2ApiHelper.AttachNetworkInterfaceToInstance(&instanceId, &branchInterfaceId, &deviceIndex, nil)
 	assert.Error(t, mockError, err)
 }
 
@@ -1199,7 +1308,118 @@ func TestEc2APIHelper_GetBranchNetworkInterface_PaginatedResults(t *testing.T) {
 	mockWrapper.EXPECT().DescribeNetworkInterfaces(describeTrunkInterfaceInput1).Return(describeTrunkInterfaceOutput1, nil)
 	mockWrapper.EXPECT().DescribeNetworkInterfaces(describeTrunkInterfaceInput2).Return(describeTrunkInterfaceOutput2, nil)
 
-	branchInterfaces, err := ec2ApiHelper.GetBranchNetworkInterface(&trunkInterfaceId, &subnetId)
+	branchInterfaces, err := ec2ApiHelper.GetBranchNetworkInterface(&trunkInterfaceId)
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []*ec2.NetworkInterface{&networkInterface1, &networkInterface2}, branchInterfaces)
 }
+
+// TestEc2APIHelper_GetMissingSecurityGroups tests the security groups not returned by EC2 for the VPC are missing
+func TestEc2APIHelper_GetMissingSecurityGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{aws.String("vpc-1")}},
+			{Name: aws.String("group-id"), Values: aws.StringSlice([]string{"sg-1", "sg-2", "sg-3"})},
+		},
+	}).Return(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+		{GroupId: aws.String("sg-1")}, {GroupId: aws.String("sg-3")}}}, nil)
+
+	missing, err := ec2ApiHelper.GetMissingSecurityGroups("vpc-1", []string{"sg-1", "sg-2", "sg-3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sg-2"}, missing)
+}
+
+// TestEc2APIHelper_GetMissingSecurityGroups_Error tests the error from ec2 api call is propagated to the caller
+func TestEc2APIHelper_GetMissingSecurityGroups_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().DescribeSecurityGroups(gomock.Any()).Return(nil, mockError)
+
+	_, err := ec2ApiHelper.GetMissingSecurityGroups("vpc-1", []string{"sg-1"})
+	assert.Error(t, err)
+}
+
+// TestEc2APIHelper_GetPoolElasticIPs tests the Elastic IP addresses of the pool are returned, including the
+// associated addresses
+func TestEc2APIHelper_GetPoolElasticIPs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	available := &ec2.Address{AllocationId: aws.String("eipalloc-1")}
+	associated := &ec2.Address{AllocationId: aws.String("eipalloc-2"), AssociationId: aws.String("eipassoc-2")}
+	mockWrapper.EXPECT().DescribeAddresses(&ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("tag:" + config.ElasticIPPoolTagKey), Values: []*string{aws.String("egress")}},
+			{Name: aws.String("domain"), Values: []*string{aws.String(ec2.DomainTypeVpc)}},
+		},
+	}).Return(&ec2.DescribeAddressesOutput{Addresses: []*ec2.Address{available, associated}}, nil)
+
+	addresses, err := ec2ApiHelper.GetPoolElasticIPs("egress")
+	assert.NoError(t, err)
+	assert.Equal(t, []*ec2.Address{available, associated}, addresses)
+}
+
+// TestEc2APIHelper_AssociateElasticIP tests the Elastic IP address is associated without reassociation and the
+// association id is returned
+func TestEc2APIHelper_AssociateElasticIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	mockWrapper.EXPECT().AssociateAddress(&ec2.AssociateAddressInput{
+		AllocationId:       aws.String("eipalloc-1"),
+		NetworkInterfaceId: &branchInterfaceId,
+		PrivateIpAddress:   aws.String("192.168.0.10"),
+		AllowReassociation: aws.Bool(false),
+	}).Return(&ec2.AssociateAddressOutput{AssociationId: aws.String("eipassoc-1")}, nil)
+
+	associationID, err := ec2ApiHelper.AssociateElasticIP("eipalloc-1", branchInterfaceId, "192.168.0.10")
+	assert.NoError(t, err)
+	assert.Equal(t, "eipassoc-1", associationID)
+}
+
+// TestEc2APIHelper_DisassociateElasticIP_NotFound tests the association that doesn't exist anymore is not an error
+func TestEc2APIHelper_DisassociateElasticIP_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	input := &ec2.DisassociateAddressInput{AssociationId: aws.String("eipassoc-1")}
+	mockWrapper.EXPECT().DisassociateAddress(input).
+		Return(nil, awserr.New(errCodeAssociationNotFound, "not found", nil))
+	mockWrapper.EXPECT().DisassociateAddress(input).Return(nil, mockError)
+
+	assert.NoError(t, ec2ApiHelper.DisassociateElasticIP("eipassoc-1"))
+	assert.Error(t, ec2ApiHelper.DisassociateElasticIP("eipassoc-1"))
+}
+
+// TestEc2APIHelper_GetNetworkInterfaceByPrivateIP tests the network interface of the instance with the private IP
+// address is returned, and an error if there is none
+func TestEc2APIHelper_GetNetworkInterfaceByPrivateIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("attachment.instance-id"), Values: []*string{&instanceId}},
+			{Name: aws.String("addresses.private-ip-address"), Values: []*string{aws.String("192.168.0.10")}},
+		},
+	}
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(input).Return(&ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: []*ec2.NetworkInterface{&networkInterface1}}, nil)
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(input).Return(&ec2.DescribeNetworkInterfacesOutput{}, nil)
+
+	nwInterface, err := ec2ApiHelper.GetNetworkInterfaceByPrivateIP(instanceId, "192.168.0.10")
+	assert.NoError(t, err)
+	assert.Equal(t, &networkInterface1, nwInterface)
+
+	_, err = ec2ApiHelper.GetNetworkInterfaceByPrivateIP(instanceId, "192.168.0.10")
+	assert.Error(t, err)
+}
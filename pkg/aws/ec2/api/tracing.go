@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTraceContext returns an EC2 API helper that makes the EC2 calls with the given context, so the spans of
+// the calls are children of the span in the context. Helpers that are not backed by the controller's EC2 wrapper
+// are returned as is.
+func WithTraceContext(helper EC2APIHelper, ctx context.Context) EC2APIHelper {
+	h, ok := helper.(*ec2APIHelper)
+	if !ok {
+		return helper
+	}
+	return &ec2APIHelper{ec2Wrapper: WrapperWithTraceContext(h.ec2Wrapper, ctx)}
+}
+
+// WrapperWithTraceContext is the EC2Wrapper counterpart of WithTraceContext
+func WrapperWithTraceContext(wrapper EC2Wrapper, ctx context.Context) EC2Wrapper {
+	w, ok := wrapper.(*ec2Wrapper)
+	if !ok {
+		return wrapper
+	}
+	scoped := *w
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// context returns the context the EC2 calls are made with
+func (e *ec2Wrapper) context() aws.Context {
+	if e.ctx == nil {
+		return aws.BackgroundContext()
+	}
+	return e.ctx
+}
+
+// awsCallSpanKey is the context key of the span of an AWS API call
+type awsCallSpanKey struct{}
+
+// injectTracing adds the handlers that record a span for each AWS API call made by the clients of the session
+func injectTracing(handlers *request.Handlers) {
+	handlers.Build.PushFrontNamed(request.NamedHandler{
+		Name: fmt.Sprintf("%s/start-span", AppName),
+		Fn: func(r *request.Request) {
+			ctx, span := tracing.StartSpan(r.Context(),
+				fmt.Sprintf("%s.%s", r.ClientInfo.ServiceID, r.Operation.Name),
+				attribute.String("rpc.system", "aws-api"),
+				attribute.String("rpc.service", r.ClientInfo.ServiceID),
+				attribute.String("rpc.method", r.Operation.Name))
+			r.SetContext(context.WithValue(ctx, awsCallSpanKey{}, span))
+		},
+	})
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: fmt.Sprintf("%s/end-span", AppName),
+		Fn: func(r *request.Request) {
+			// The span is looked up by its own key, the request may complete without being built if the
+			// input fails the validation, the span in the context would then be the caller's span
+			span, ok := r.Context().Value(awsCallSpanKey{}).(trace.Span)
+			if !ok {
+				return
+			}
+			span.SetAttributes(
+				attribute.String("aws.request_id", r.RequestID),
+				attribute.Int("aws.retry_count", r.RetryCount))
+			tracing.EndSpan(span, r.Error)
+		},
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestInjectTracing tests a span is recorded for the call as a child of the span in the request context
+func TestInjectTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	defer parent.End()
+
+	handlers := request.Handlers{}
+	injectTracing(&handlers)
+	req := request.New(aws.Config{}, metadata.ClientInfo{ServiceID: "EC2"}, handlers, nil,
+		&request.Operation{Name: "CreateTags"}, nil, nil)
+	req.SetContext(parentCtx)
+	req.RequestID = "request-id"
+	req.Error = fmt.Errorf("failed")
+
+	req.Handlers.Build.Run(req)
+	req.Handlers.Complete.Run(req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "EC2.CreateTags", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+// TestInjectTracing_NotBuilt tests the caller's span is not ended if the request completes without being built
+func TestInjectTracing_NotBuilt(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	defer parent.End()
+
+	handlers := request.Handlers{}
+	injectTracing(&handlers)
+	req := request.New(aws.Config{}, metadata.ClientInfo{ServiceID: "EC2"}, handlers, nil,
+		&request.Operation{Name: "CreateTags"}, nil, nil)
+	req.SetContext(parentCtx)
+
+	req.Handlers.Complete.Run(req)
+
+	assert.Empty(t, recorder.Ended())
+}
+
+// TestWrapperWithTraceContext tests the calls of the scoped wrapper are made with the context
+func TestWrapperWithTraceContext(t *testing.T) {
+	wrapper := &ec2Wrapper{}
+	assert.Equal(t, aws.BackgroundContext(), wrapper.context())
+
+	ctx := context.WithValue(context.Background(), awsCallSpanKey{}, "value")
+	scoped := WrapperWithTraceContext(wrapper, ctx).(*ec2Wrapper)
+	assert.Equal(t, ctx, scoped.context())
+	assert.Nil(t, wrapper.ctx)
+}
@@ -0,0 +1,586 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// warmCacheBatchSize is the number of instances, subnets or trunk ENIs described per call
+const warmCacheBatchSize = 100
+
+var (
+	warmCacheMetricsRegistered = false
+
+	warmCacheInstanceCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "warm_cache_instance_count",
+			Help: "The number of nodes in the view pre-fetched by the standby replica and not initialized yet",
+		},
+	)
+
+	warmCacheHitCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "warm_cache_hit_count",
+			Help: "The number of EC2 lookups served from the view pre-fetched by the standby replica",
+		},
+		[]string{"resource"},
+	)
+
+	warmCacheDescribedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "warm_cache_reconciled_instance_count",
+			Help: "The number of instances described again when the standby replica is elected leader",
+		},
+	)
+)
+
+func warmCacheRegisterMetrics() {
+	if !warmCacheMetricsRegistered {
+		metrics.Registry.MustRegister(
+			warmCacheInstanceCount,
+			warmCacheHitCount,
+			warmCacheDescribedCount,
+		)
+		warmCacheMetricsRegistered = true
+	}
+}
+
+// warmNode is the view of a node pre-fetched by the standby replica
+type warmNode struct {
+	// instance has the network interfaces attached to the instance with their IPv4 addresses and prefixes
+	instance *ec2.Instance
+	// trunkENIID is the trunk ENI attached to the instance, empty if the instance has none
+	trunkENIID string
+	// branches are the branch ENIs associated with the trunk ENI
+	branches []*ec2.NetworkInterface
+	// podsVersion is the version of the node's pods when the node was described
+	podsVersion string
+	// settled is true if the node's pods didn't change since the refresh before the node was described, so the
+	// changes made to the ENIs for the earlier pods are in the view
+	settled   bool
+	fetchTime time.Time
+}
+
+type warmSubnet struct {
+	subnet    *ec2.Subnet
+	fetchTime time.Time
+}
+
+// WarmNodeView is the read-only view of a node pre-fetched by the standby replica
+type WarmNodeView struct {
+	InstanceID string
+	TrunkENIID string
+	BranchENIs []string
+	// NetworkInterfaces are the network interfaces attached to the instance
+	NetworkInterfaces []string
+	// SecondaryIPv4Addresses and IPv4Prefixes are the warm pool of the instance
+	SecondaryIPv4Addresses int
+	IPv4Prefixes           int
+	Settled                bool
+	FetchTime              time.Time
+}
+
+// WarmCache holds the view of the nodes described in batches by a standby replica: the instance with its network
+// interfaces, IPv4 addresses and prefixes, and the branch ENIs of its trunk ENI. Once elected leader, the replica
+// describes only the nodes whose view is missing or outdated, and initializes the other nodes from their view till
+// they are ready.
+type WarmCache struct {
+	ec2Wrapper EC2Wrapper
+	// maxAge is the duration the view is used for after it's described
+	maxAge time.Duration
+	clock  func() time.Time
+
+	// lock guards the following
+	lock  sync.Mutex
+	nodes map[string]*warmNode
+	// owners maps the network interfaces and attachments of the nodes, including the trunk and branch ENIs, to the
+	// instance ID of their node
+	owners  map[string]string
+	subnets map[string]warmSubnet
+}
+
+// NewWarmCache returns a new empty warm cache, the view is used for up to max age after it's described
+func NewWarmCache(ec2Wrapper EC2Wrapper, maxAge time.Duration) *WarmCache {
+	warmCacheRegisterMetrics()
+
+	return &WarmCache{
+		ec2Wrapper: ec2Wrapper,
+		maxAge:     maxAge,
+		clock:      time.Now,
+		nodes:      map[string]*warmNode{},
+		owners:     map[string]string{},
+		subnets:    map[string]warmSubnet{},
+	}
+}
+
+// Refresh describes the nodes in batches and replaces the view. The nodes map the instance ID to the version of the
+// node's pods, a node is settled if its pods have the same version as on the previous refresh.
+func (c *WarmCache) Refresh(nodes map[string]string) error {
+	instanceIDs := make([]string, 0, len(nodes))
+	for instanceID := range nodes {
+		instanceIDs = append(instanceIDs, instanceID)
+	}
+	described, subnets, err := c.describe(instanceIDs, nodes)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for instanceID, node := range described {
+		previous, found := c.nodes[instanceID]
+		node.settled = found && previous.podsVersion == node.podsVersion
+	}
+	c.nodes = described
+	c.subnets = subnets
+	c.index()
+	return nil
+}
+
+// Reconcile updates the view with the difference since the last refresh, it's called once the replica is elected
+// leader. The nodes that no longer exist are removed and only the nodes missing from the view, described longer than
+// the max age ago, not settled or whose pods changed since are described again. Returns the number of nodes described.
+func (c *WarmCache) Reconcile(nodes map[string]string) (int, error) {
+	c.lock.Lock()
+	var outdated []string
+	for instanceID, podsVersion := range nodes {
+		node, found := c.getNode(instanceID)
+		if !found || !node.settled || node.podsVersion != podsVersion {
+			outdated = append(outdated, instanceID)
+		}
+	}
+	c.lock.Unlock()
+	sort.Strings(outdated)
+
+	described, subnets, err := c.describe(outdated, nodes)
+	if err != nil {
+		return 0, err
+	}
+	warmCacheDescribedCount.Add(float64(len(outdated)))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for instanceID := range c.nodes {
+		if _, found := nodes[instanceID]; !found {
+			delete(c.nodes, instanceID)
+		}
+	}
+	for instanceID, node := range described {
+		// The node was described after the replica was elected, nothing changed it since
+		node.settled = true
+		c.nodes[instanceID] = node
+	}
+	// The described instances that no longer exist are not in the view
+	for _, instanceID := range outdated {
+		if _, found := described[instanceID]; !found {
+			delete(c.nodes, instanceID)
+		}
+	}
+	for subnetID, subnet := range subnets {
+		c.subnets[subnetID] = subnet
+	}
+	c.index()
+	return len(outdated), nil
+}
+
+// describe describes the instances, their subnets and the branch ENIs of their trunk ENI in batches. The instances
+// are described with a filter so the instances that no longer exist don't fail the batch.
+func (c *WarmCache) describe(instanceIDs []string, podsVersions map[string]string) (map[string]*warmNode,
+	map[string]warmSubnet, error) {
+	now := c.clock()
+	nodes := map[string]*warmNode{}
+	trunks := map[string]*warmNode{}
+	subnetIDs := map[string]struct{}{}
+	for start := 0; start < len(instanceIDs); start += warmCacheBatchSize {
+		end := min(start+warmCacheBatchSize, len(instanceIDs))
+		output, err := c.ec2Wrapper.DescribeInstances(&ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{{
+				Name:   aws.String("instance-id"),
+				Values: aws.StringSlice(instanceIDs[start:end]),
+			}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				instanceID := aws.StringValue(instance.InstanceId)
+				node := &warmNode{instance: instance, podsVersion: podsVersions[instanceID], fetchTime: now}
+				for _, nwInterface := range instance.NetworkInterfaces {
+					if aws.StringValue(nwInterface.InterfaceType) == ec2.NetworkInterfaceTypeTrunk {
+						node.trunkENIID = aws.StringValue(nwInterface.NetworkInterfaceId)
+						trunks[node.trunkENIID] = node
+					}
+				}
+				nodes[instanceID] = node
+				if instance.SubnetId != nil {
+					subnetIDs[*instance.SubnetId] = struct{}{}
+				}
+			}
+		}
+	}
+
+	var trunkIDs []string
+	for trunkID := range trunks {
+		trunkIDs = append(trunkIDs, trunkID)
+	}
+	sort.Strings(trunkIDs)
+	for start := 0; start < len(trunkIDs); start += warmCacheBatchSize {
+		end := min(start+warmCacheBatchSize, len(trunkIDs))
+		input := &ec2.DescribeNetworkInterfacesInput{
+			Filters: []*ec2.Filter{{
+				Name:   aws.String("tag:" + config.TrunkENIIDTag),
+				Values: aws.StringSlice(trunkIDs[start:end]),
+			}},
+		}
+		for {
+			output, err := c.ec2Wrapper.DescribeNetworkInterfaces(input)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, nwInterface := range output.NetworkInterfaces {
+				for _, tag := range nwInterface.TagSet {
+					if aws.StringValue(tag.Key) != config.TrunkENIIDTag {
+						continue
+					}
+					if node, found := trunks[aws.StringValue(tag.Value)]; found {
+						// Only keep the details used to load the trunk ENI, as GetBranchNetworkInterface does
+						node.branches = append(node.branches, &ec2.NetworkInterface{
+							NetworkInterfaceId: nwInterface.NetworkInterfaceId,
+							TagSet:             nwInterface.TagSet,
+						})
+					}
+				}
+			}
+			if output.NextToken == nil {
+				break
+			}
+			input.NextToken = output.NextToken
+		}
+	}
+
+	var subnetIDList []string
+	for subnetID := range subnetIDs {
+		subnetIDList = append(subnetIDList, subnetID)
+	}
+	sort.Strings(subnetIDList)
+	subnets := map[string]warmSubnet{}
+	for start := 0; start < len(subnetIDList); start += warmCacheBatchSize {
+		end := min(start+warmCacheBatchSize, len(subnetIDList))
+		output, err := c.ec2Wrapper.DescribeSubnets(&ec2.DescribeSubnetsInput{
+			Filters: []*ec2.Filter{{
+				Name:   aws.String("subnet-id"),
+				Values: aws.StringSlice(subnetIDList[start:end]),
+			}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, subnet := range output.Subnets {
+			subnets[aws.StringValue(subnet.SubnetId)] = warmSubnet{subnet: subnet, fetchTime: now}
+		}
+	}
+	return nodes, subnets, nil
+}
+
+// index rebuilds the owners of the network interfaces and updates the instance count, the lock must be held
+func (c *WarmCache) index() {
+	c.owners = map[string]string{}
+	for instanceID, node := range c.nodes {
+		for _, nwInterface := range node.instance.NetworkInterfaces {
+			c.owners[aws.StringValue(nwInterface.NetworkInterfaceId)] = instanceID
+			if nwInterface.Attachment != nil {
+				c.owners[aws.StringValue(nwInterface.Attachment.AttachmentId)] = instanceID
+			}
+		}
+		for _, branch := range node.branches {
+			c.owners[aws.StringValue(branch.NetworkInterfaceId)] = instanceID
+		}
+	}
+	warmCacheInstanceCount.Set(float64(len(c.nodes)))
+}
+
+// getNode returns the view of the node if it was described within the max age, the lock must be held
+func (c *WarmCache) getNode(instanceID string) (*warmNode, bool) {
+	node, found := c.nodes[instanceID]
+	if !found || c.clock().Sub(node.fetchTime) > c.maxAge {
+		return nil, false
+	}
+	return node, true
+}
+
+// GetInstance returns the details of the instance if they were described within the max age
+func (c *WarmCache) GetInstance(instanceID string) (*ec2.Instance, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	node, found := c.getNode(instanceID)
+	if !found {
+		return nil, false
+	}
+	warmCacheHitCount.WithLabelValues("instance").Inc()
+	return node.instance, true
+}
+
+// GetBranchNetworkInterfaces returns the branch ENIs of the trunk ENI if they were described within the max age
+func (c *WarmCache) GetBranchNetworkInterfaces(trunkID string) ([]*ec2.NetworkInterface, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	node, found := c.getNode(c.owners[trunkID])
+	if !found || node.trunkENIID != trunkID {
+		return nil, false
+	}
+	warmCacheHitCount.WithLabelValues("branch_interfaces").Inc()
+	return node.branches, true
+}
+
+// HasAttachmentStatus returns true if the network interface of a node was described with the attachment status
+// within the max age
+func (c *WarmCache) HasAttachmentStatus(nwInterfaceID string, status string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	node, found := c.getNode(c.owners[nwInterfaceID])
+	if !found {
+		return false
+	}
+	for _, nwInterface := range node.instance.NetworkInterfaces {
+		if aws.StringValue(nwInterface.NetworkInterfaceId) == nwInterfaceID && nwInterface.Attachment != nil &&
+			aws.StringValue(nwInterface.Attachment.Status) == status {
+			warmCacheHitCount.WithLabelValues("attachment_status").Inc()
+			return true
+		}
+	}
+	return false
+}
+
+// GetSubnet returns the details of the subnet if they were described within the max age
+func (c *WarmCache) GetSubnet(subnetID string) (*ec2.Subnet, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, found := c.subnets[subnetID]
+	if !found || c.clock().Sub(entry.fetchTime) > c.maxAge {
+		return nil, false
+	}
+	warmCacheHitCount.WithLabelValues("subnet").Inc()
+	return entry.subnet, true
+}
+
+// Forget removes the view of the node, it's called once the node is initialized so the later lookups see the
+// changes made to the instance
+func (c *WarmCache) Forget(instanceID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.forget(instanceID)
+}
+
+// ForgetOwner removes the view of the node owning the network interface or attachment, it's called before the
+// network interface is changed
+func (c *WarmCache) ForgetOwner(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if instanceID, found := c.owners[id]; found {
+		c.forget(instanceID)
+	}
+}
+
+// forget removes the view of the node and its network interfaces, the lock must be held
+func (c *WarmCache) forget(instanceID string) {
+	node, found := c.nodes[instanceID]
+	if !found {
+		return
+	}
+	delete(c.nodes, instanceID)
+	for _, nwInterface := range node.instance.NetworkInterfaces {
+		delete(c.owners, aws.StringValue(nwInterface.NetworkInterfaceId))
+		if nwInterface.Attachment != nil {
+			delete(c.owners, aws.StringValue(nwInterface.Attachment.AttachmentId))
+		}
+	}
+	for _, branch := range node.branches {
+		delete(c.owners, aws.StringValue(branch.NetworkInterfaceId))
+	}
+	warmCacheInstanceCount.Set(float64(len(c.nodes)))
+}
+
+// Clear removes the view of all the nodes and subnets
+func (c *WarmCache) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.nodes = map[string]*warmNode{}
+	c.subnets = map[string]warmSubnet{}
+	c.index()
+}
+
+// Len returns the number of nodes in the view
+func (c *WarmCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.nodes)
+}
+
+// Introspect returns the view of the nodes by instance ID
+func (c *WarmCache) Introspect() map[string]WarmNodeView {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	response := make(map[string]WarmNodeView, len(c.nodes))
+	for instanceID, node := range c.nodes {
+		view := WarmNodeView{
+			InstanceID: instanceID,
+			TrunkENIID: node.trunkENIID,
+			Settled:    node.settled,
+			FetchTime:  node.fetchTime,
+		}
+		for _, nwInterface := range node.instance.NetworkInterfaces {
+			view.NetworkInterfaces = append(view.NetworkInterfaces, aws.StringValue(nwInterface.NetworkInterfaceId))
+			for _, address := range nwInterface.PrivateIpAddresses {
+				if !aws.BoolValue(address.Primary) {
+					view.SecondaryIPv4Addresses++
+				}
+			}
+			view.IPv4Prefixes += len(nwInterface.Ipv4Prefixes)
+		}
+		for _, branch := range node.branches {
+			view.BranchENIs = append(view.BranchENIs, aws.StringValue(branch.NetworkInterfaceId))
+		}
+		response[instanceID] = view
+	}
+	return response
+}
+
+// warmEC2APIHelper serves the lookups made to initialize the nodes from the warm cache when possible, and removes
+// the view of the nodes whose network interfaces are changed
+type warmEC2APIHelper struct {
+	EC2APIHelper
+	cache *WarmCache
+}
+
+// NewWarmEC2APIHelper returns an EC2 API helper that looks up the nodes and subnets in the warm cache before
+// describing them
+func NewWarmEC2APIHelper(helper EC2APIHelper, cache *WarmCache) EC2APIHelper {
+	return &warmEC2APIHelper{EC2APIHelper: helper, cache: cache}
+}
+
+// GetInstanceDetails returns the pre-fetched details of the instance, or describes the instance
+func (h *warmEC2APIHelper) GetInstanceDetails(instanceId *string) (*ec2.Instance, error) {
+	if instance, found := h.cache.GetInstance(aws.StringValue(instanceId)); found {
+		return instance, nil
+	}
+	return h.EC2APIHelper.GetInstanceDetails(instanceId)
+}
+
+// GetInstanceNetworkInterface returns the pre-fetched network interfaces of the instance, or describes the instance
+func (h *warmEC2APIHelper) GetInstanceNetworkInterface(instanceId *string) ([]*ec2.InstanceNetworkInterface, error) {
+	if instance, found := h.cache.GetInstance(aws.StringValue(instanceId)); found &&
+		instance.NetworkInterfaces != nil {
+		return instance.NetworkInterfaces, nil
+	}
+	return h.EC2APIHelper.GetInstanceNetworkInterface(instanceId)
+}
+
+// GetBranchNetworkInterface returns the pre-fetched branch ENIs of the trunk ENI, or describes them
+func (h *warmEC2APIHelper) GetBranchNetworkInterface(trunkID *string) ([]*ec2.NetworkInterface, error) {
+	if branches, found := h.cache.GetBranchNetworkInterfaces(aws.StringValue(trunkID)); found {
+		return branches, nil
+	}
+	return h.EC2APIHelper.GetBranchNetworkInterface(trunkID)
+}
+
+// WaitForNetworkInterfaceStatusChange returns at once if the network interface was pre-fetched with the status
+func (h *warmEC2APIHelper) WaitForNetworkInterfaceStatusChange(networkInterfaceId *string,
+	desiredStatus string) error {
+	if h.cache.HasAttachmentStatus(aws.StringValue(networkInterfaceId), desiredStatus) {
+		return nil
+	}
+	return h.EC2APIHelper.WaitForNetworkInterfaceStatusChange(networkInterfaceId, desiredStatus)
+}
+
+// GetSubnet returns the pre-fetched details of the subnet, or describes the subnet
+func (h *warmEC2APIHelper) GetSubnet(subnetId *string) (*ec2.Subnet, error) {
+	if subnet, found := h.cache.GetSubnet(aws.StringValue(subnetId)); found {
+		return subnet, nil
+	}
+	return h.EC2APIHelper.GetSubnet(subnetId)
+}
+
+func (h *warmEC2APIHelper) CreateAndAttachNetworkInterface(instanceId *string, subnetId *string,
+	securityGroups []string, tags []*ec2.Tag, deviceIndex *int64, networkCardIndex *int64, description *string,
+	interfaceType *string, ipResourceCount *config.IPResourceCount) (*ec2.NetworkInterface, error) {
+	h.cache.Forget(aws.StringValue(instanceId))
+	return h.EC2APIHelper.CreateAndAttachNetworkInterface(instanceId, subnetId, securityGroups, tags, deviceIndex,
+		networkCardIndex, description, interfaceType, ipResourceCount)
+}
+
+func (h *warmEC2APIHelper) AttachNetworkInterfaceToInstance(instanceId *string, nwInterfaceId *string,
+	deviceIndex *int64, networkCardIndex *int64) (*string, error) {
+	h.cache.Forget(aws.StringValue(instanceId))
+	return h.EC2APIHelper.AttachNetworkInterfaceToInstance(instanceId, nwInterfaceId, deviceIndex, networkCardIndex)
+}
+
+func (h *warmEC2APIHelper) AssociateBranchToTrunk(trunkInterfaceId *string, branchInterfaceId *string,
+	vlanId int) (*ec2.AssociateTrunkInterfaceOutput, error) {
+	h.cache.ForgetOwner(aws.StringValue(trunkInterfaceId))
+	return h.EC2APIHelper.AssociateBranchToTrunk(trunkInterfaceId, branchInterfaceId, vlanId)
+}
+
+func (h *warmEC2APIHelper) DeleteNetworkInterface(interfaceId *string) error {
+	h.cache.ForgetOwner(aws.StringValue(interfaceId))
+	return h.EC2APIHelper.DeleteNetworkInterface(interfaceId)
+}
+
+func (h *warmEC2APIHelper) DetachNetworkInterfaceFromInstance(attachmentId *string) error {
+	h.cache.ForgetOwner(aws.StringValue(attachmentId))
+	return h.EC2APIHelper.DetachNetworkInterfaceFromInstance(attachmentId)
+}
+
+func (h *warmEC2APIHelper) DetachAndDeleteNetworkInterface(attachmentId *string, nwInterfaceId *string) error {
+	h.cache.ForgetOwner(aws.StringValue(nwInterfaceId))
+	return h.EC2APIHelper.DetachAndDeleteNetworkInterface(attachmentId, nwInterfaceId)
+}
+
+func (h *warmEC2APIHelper) SetSecurityGroups(eniId *string, securityGroups []string) error {
+	h.cache.ForgetOwner(aws.StringValue(eniId))
+	return h.EC2APIHelper.SetSecurityGroups(eniId, securityGroups)
+}
+
+func (h *warmEC2APIHelper) AssignIPv4ResourcesAndWaitTillReady(eniID string, resourceType config.ResourceType,
+	count int) ([]string, error) {
+	h.cache.ForgetOwner(eniID)
+	return h.EC2APIHelper.AssignIPv4ResourcesAndWaitTillReady(eniID, resourceType, count)
+}
+
+func (h *warmEC2APIHelper) UnassignIPv4Resources(eniID string, resourceType config.ResourceType,
+	resources []string) error {
+	h.cache.ForgetOwner(eniID)
+	return h.EC2APIHelper.UnassignIPv4Resources(eniID, resourceType, resources)
+}
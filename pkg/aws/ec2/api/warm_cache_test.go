@@ -0,0 +1,266 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	warmInstanceID2 = "i-00000000000000002"
+	warmInstanceID3 = "i-00000000000000003"
+
+	// warmInstance has the trunk ENI with a secondary IPv4 address
+	warmInstance = &ec2.Instance{
+		InstanceId: &instanceId,
+		SubnetId:   &subnetId,
+		NetworkInterfaces: []*ec2.InstanceNetworkInterface{{
+			NetworkInterfaceId: &trunkInterfaceId,
+			InterfaceType:      aws.String(ec2.NetworkInterfaceTypeTrunk),
+			Attachment: &ec2.InstanceNetworkInterfaceAttachment{AttachmentId: aws.String("attach-trunk"),
+				Status: aws.String(ec2.AttachmentStatusAttached)},
+			PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{Primary: aws.Bool(true)}, {Primary: aws.Bool(false)}},
+		}},
+	}
+	warmInstanceOutput = &ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{warmInstance}}},
+	}
+	warmSubnetInput = &ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{{Name: aws.String("subnet-id"), Values: []*string{&subnetId}}},
+	}
+	warmBranchInput = &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("tag:" + config.TrunkENIIDTag),
+			Values: []*string{&trunkInterfaceId}}},
+	}
+	warmBranches = []*ec2.NetworkInterface{
+		{NetworkInterfaceId: &branchInterfaceId, TagSet: []*ec2.Tag{{Key: aws.String(config.TrunkENIIDTag),
+			Value: &trunkInterfaceId}}},
+		{NetworkInterfaceId: &branchInterfaceId2, TagSet: []*ec2.Tag{{Key: aws.String(config.TrunkENIIDTag),
+			Value: &trunkInterfaceId}}},
+	}
+)
+
+func getWarmCache(ctrl *gomock.Controller, now *time.Time) (*WarmCache, *mock_api.MockEC2Wrapper) {
+	mockWrapper := mock_api.NewMockEC2Wrapper(ctrl)
+	cache := NewWarmCache(mockWrapper, time.Minute)
+	cache.clock = func() time.Time { return *now }
+	return cache, mockWrapper
+}
+
+// instancesInput returns the input describing the instances
+func instancesInput(instanceIDs ...string) *ec2.DescribeInstancesInput {
+	return &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: aws.StringSlice(instanceIDs)}},
+	}
+}
+
+// expectDescribeWarmInstance expects the instance with the trunk ENI to be described with its branch ENIs and subnet
+func expectDescribeWarmInstance(mockWrapper *mock_api.MockEC2Wrapper, input *ec2.DescribeInstancesInput) {
+	mockWrapper.EXPECT().DescribeInstances(input).Return(warmInstanceOutput, nil)
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(warmBranchInput).Return(
+		&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: warmBranches}, nil)
+	mockWrapper.EXPECT().DescribeSubnets(warmSubnetInput).Return(describeSubnetOutput, nil)
+}
+
+// TestWarmCache_Refresh tests the instances are described in batches, the instances that no longer exist are
+// skipped, and the branch ENIs of the trunk ENIs and the subnets are described once
+func TestWarmCache_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	cache, mockWrapper := getWarmCache(ctrl, &now)
+
+	nodes := map[string]string{instanceId: "v1"}
+	for i := 0; i < 150; i++ {
+		nodes[fmt.Sprintf("i-1%016d", i)] = "v1"
+	}
+
+	var batchSizes []int
+	mockWrapper.EXPECT().DescribeInstances(gomock.Any()).DoAndReturn(
+		func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+			batchSizes = append(batchSizes, len(input.Filters[0].Values))
+			for _, instanceID := range input.Filters[0].Values {
+				if *instanceID == instanceId {
+					return warmInstanceOutput, nil
+				}
+			}
+			return &ec2.DescribeInstancesOutput{}, nil
+		}).Times(2)
+	// The branch ENIs are paginated
+	paginatedInput := &ec2.DescribeNetworkInterfacesInput{Filters: warmBranchInput.Filters,
+		NextToken: aws.String("token")}
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(warmBranchInput).Return(&ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: warmBranches[:1], NextToken: aws.String("token")}, nil)
+	mockWrapper.EXPECT().DescribeNetworkInterfaces(paginatedInput).Return(&ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: warmBranches[1:]}, nil)
+	mockWrapper.EXPECT().DescribeSubnets(warmSubnetInput).Return(describeSubnetOutput, nil)
+
+	assert.NoError(t, cache.Refresh(nodes))
+	assert.ElementsMatch(t, []int{100, 51}, batchSizes)
+	assert.Equal(t, 1, cache.Len())
+
+	assert.Equal(t, map[string]WarmNodeView{instanceId: {
+		InstanceID:             instanceId,
+		TrunkENIID:             trunkInterfaceId,
+		BranchENIs:             []string{branchInterfaceId, branchInterfaceId2},
+		NetworkInterfaces:      []string{trunkInterfaceId},
+		SecondaryIPv4Addresses: 1,
+		FetchTime:              now,
+	}}, cache.Introspect())
+
+	subnet, found := cache.GetSubnet(subnetId)
+	assert.True(t, found)
+	assert.Equal(t, subnetId, *subnet.SubnetId)
+	branches, found := cache.GetBranchNetworkInterfaces(trunkInterfaceId)
+	assert.True(t, found)
+	assert.Len(t, branches, 2)
+	assert.True(t, cache.HasAttachmentStatus(trunkInterfaceId, ec2.AttachmentStatusAttached))
+	assert.False(t, cache.HasAttachmentStatus(trunkInterfaceId, ec2.AttachmentStatusDetached))
+}
+
+// TestWarmCache_Reconcile tests only the nodes missing from the view, not settled or whose pods changed are
+// described again once elected, and the nodes that no longer exist are removed
+func TestWarmCache_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	cache, mockWrapper := getWarmCache(ctrl, &now)
+	instance2 := &ec2.Instance{InstanceId: &warmInstanceID2}
+	instance3 := &ec2.Instance{InstanceId: &warmInstanceID3}
+
+	// The first node and the second node are settled after two refreshes with the same pods
+	for i := 0; i < 2; i++ {
+		mockWrapper.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{warmInstance, instance2, instance3}}},
+		}, nil)
+		mockWrapper.EXPECT().DescribeNetworkInterfaces(warmBranchInput).Return(
+			&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: warmBranches}, nil)
+		mockWrapper.EXPECT().DescribeSubnets(warmSubnetInput).Return(describeSubnetOutput, nil)
+	}
+	assert.NoError(t, cache.Refresh(map[string]string{instanceId: "v1", warmInstanceID2: "v1",
+		warmInstanceID3: "v1"}))
+	assert.NoError(t, cache.Refresh(map[string]string{instanceId: "v1", warmInstanceID2: "v1",
+		warmInstanceID3: "v1"}))
+
+	// The pods of the second node changed, the third node was deleted and a new node joined
+	newInstanceID := "i-00000000000000004"
+	mockWrapper.EXPECT().DescribeInstances(instancesInput(warmInstanceID2, newInstanceID)).Return(
+		&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance2}}}}, nil)
+	described, err := cache.Reconcile(map[string]string{instanceId: "v1", warmInstanceID2: "v2",
+		newInstanceID: "v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, described)
+
+	view := cache.Introspect()
+	assert.Len(t, view, 2)
+	assert.True(t, view[instanceId].Settled)
+	assert.True(t, view[warmInstanceID2].Settled)
+	_, found := cache.GetInstance(warmInstanceID3)
+	assert.False(t, found)
+	_, found = cache.GetInstance(newInstanceID)
+	assert.False(t, found)
+}
+
+// TestWarmCache_Reconcile_NotSettled tests the nodes refreshed once are described again once elected, as the view
+// might miss the changes made for the pods created just before the refresh
+func TestWarmCache_Reconcile_NotSettled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	cache, mockWrapper := getWarmCache(ctrl, &now)
+
+	expectDescribeWarmInstance(mockWrapper, instancesInput(instanceId))
+	assert.NoError(t, cache.Refresh(map[string]string{instanceId: "v1"}))
+	assert.False(t, cache.Introspect()[instanceId].Settled)
+
+	expectDescribeWarmInstance(mockWrapper, instancesInput(instanceId))
+	described, err := cache.Reconcile(map[string]string{instanceId: "v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, described)
+	assert.True(t, cache.Introspect()[instanceId].Settled)
+}
+
+// TestWarmCache_MaxAge tests the view described longer than the max age ago is not used
+func TestWarmCache_MaxAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	cache, mockWrapper := getWarmCache(ctrl, &now)
+
+	expectDescribeWarmInstance(mockWrapper, instancesInput(instanceId))
+	assert.NoError(t, cache.Refresh(map[string]string{instanceId: "v1"}))
+	_, found := cache.GetInstance(instanceId)
+	assert.True(t, found)
+
+	now = now.Add(2 * time.Minute)
+	_, found = cache.GetInstance(instanceId)
+	assert.False(t, found)
+	_, found = cache.GetBranchNetworkInterfaces(trunkInterfaceId)
+	assert.False(t, found)
+	_, found = cache.GetSubnet(subnetId)
+	assert.False(t, found)
+}
+
+// TestWarmEC2APIHelper tests the lookups made to initialize the node are served from the view, and the node is
+// described again once its network interfaces are changed
+func TestWarmEC2APIHelper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2ApiHelper, mockWrapper := getMockWrapper(ctrl)
+	cache := NewWarmCache(mockWrapper, time.Minute)
+	helper := NewWarmEC2APIHelper(ec2ApiHelper, cache)
+
+	expectDescribeWarmInstance(mockWrapper, instancesInput(instanceId))
+	assert.NoError(t, cache.Refresh(map[string]string{instanceId: "v1"}))
+
+	instance, err := helper.GetInstanceDetails(&instanceId)
+	assert.NoError(t, err)
+	assert.Equal(t, instanceId, *instance.InstanceId)
+	nwInterfaces, err := helper.GetInstanceNetworkInterface(&instanceId)
+	assert.NoError(t, err)
+	assert.Equal(t, warmInstance.NetworkInterfaces, nwInterfaces)
+	assert.NoError(t, helper.WaitForNetworkInterfaceStatusChange(&trunkInterfaceId, ec2.AttachmentStatusAttached))
+	branches, err := helper.GetBranchNetworkInterface(&trunkInterfaceId)
+	assert.NoError(t, err)
+	assert.Len(t, branches, 2)
+	subnet, err := helper.GetSubnet(&subnetId)
+	assert.NoError(t, err)
+	assert.Equal(t, subnetId, *subnet.SubnetId)
+
+	// Deleting a branch ENI removes the view of its node, the node is described again
+	mockWrapper.EXPECT().DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{
+		NetworkInterfaceId: &branchInterfaceId}).Return(nil, nil)
+	assert.NoError(t, helper.DeleteNetworkInterface(&branchInterfaceId))
+	assert.Equal(t, 0, cache.Len())
+
+	mockWrapper.EXPECT().DescribeInstances(describeInstanceInput).Return(describeInstanceOutput, nil)
+	instance, err = helper.GetInstanceDetails(&instanceId)
+	assert.NoError(t, err)
+	assert.Equal(t, instanceType, *instance.InstanceType)
+}
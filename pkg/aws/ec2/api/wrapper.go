@@ -14,6 +14,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -57,6 +58,11 @@ type EC2Wrapper interface {
 	DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (*ec2.DescribeTrunkInterfaceAssociationsOutput, error)
 	ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error)
 	CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (*ec2.CreateNetworkInterfacePermissionOutput, error)
+	DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error)
+	DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeAddresses(input *ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error)
+	AssociateAddress(input *ec2.AssociateAddressInput) (*ec2.AssociateAddressOutput, error)
+	DisassociateAddress(input *ec2.DisassociateAddressInput) (*ec2.DisassociateAddressOutput, error)
 }
 
 var (
@@ -242,6 +248,76 @@ var (
 		},
 	)
 
+	ec2DescribeInstanceTypesAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_instance_types_api_req_count",
+			Help: "The number of calls made to EC2 for describing instance types",
+		},
+	)
+
+	ec2DescribeInstanceTypesAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_instance_types_api_err_count",
+			Help: "The number of errors encountered while describing instance types",
+		},
+	)
+
+	ec2DescribeSecurityGroupsAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_security_groups_api_req_count",
+			Help: "The number of calls made to EC2 for describing security groups",
+		},
+	)
+
+	ec2DescribeSecurityGroupsAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_security_groups_api_err_count",
+			Help: "The number of errors encountered while describing security groups",
+		},
+	)
+
+	ec2DescribeAddressesAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_addresses_api_req_count",
+			Help: "The number of calls made to EC2 for describing Elastic IP addresses",
+		},
+	)
+
+	ec2DescribeAddressesAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_describe_addresses_api_err_count",
+			Help: "The number of errors encountered while describing Elastic IP addresses",
+		},
+	)
+
+	ec2AssociateAddressAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_associate_address_api_req_count",
+			Help: "The number of calls made to EC2 for associating Elastic IP addresses",
+		},
+	)
+
+	ec2AssociateAddressAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_associate_address_api_err_count",
+			Help: "The number of errors encountered while associating Elastic IP addresses",
+		},
+	)
+
+	ec2DisassociateAddressAPICallCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_disassociate_address_api_req_count",
+			Help: "The number of calls made to EC2 for disassociating Elastic IP addresses",
+		},
+	)
+
+	ec2DisassociateAddressAPIErrCnt = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ec2_disassociate_address_api_err_count",
+			Help: "The number of errors encountered while disassociating Elastic IP addresses",
+		},
+	)
+
 	ec2AssociateTrunkInterfaceAPICallCnt = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "ec2_associate_trunk_interface_api_req_count",
@@ -337,6 +413,16 @@ func prometheusRegister() {
 			ec2DeleteNetworkInterfaceAPIErrCnt,
 			ec2DescribeSubnetsAPICallCnt,
 			ec2DescribeSubnetsAPIErrCnt,
+			ec2DescribeInstanceTypesAPICallCnt,
+			ec2DescribeInstanceTypesAPIErrCnt,
+			ec2DescribeSecurityGroupsAPICallCnt,
+			ec2DescribeSecurityGroupsAPIErrCnt,
+			ec2DescribeAddressesAPICallCnt,
+			ec2DescribeAddressesAPIErrCnt,
+			ec2AssociateAddressAPICallCnt,
+			ec2AssociateAddressAPIErrCnt,
+			ec2DisassociateAddressAPICallCnt,
+			ec2DisassociateAddressAPIErrCnt,
 			ec2AssociateTrunkInterfaceAPICallCnt,
 			ec2AssociateTrunkInterfaceAPIErrCnt,
 			ec2describeTrunkInterfaceAssociationAPICallCnt,
@@ -358,16 +444,23 @@ type ec2Wrapper struct {
 	instanceServiceClient *ec2.EC2
 	userServiceClient     *ec2.EC2
 	accountID             string
+	// auditLog records every mutating call, nil if auditing is disabled
+	auditLog *AuditLog
+	// caller is the controller code path the mutating calls are attributed to in the audit log
+	caller AuditCaller
+	// ctx is the context the calls are made with, it carries the span of the operation making the calls
+	ctx context.Context
 }
 
 // NewEC2Wrapper takes the roleARN that will be assumed to make all the EC2 API Calls, if no roleARN
-// is passed then the ec2 client will be initialized with the instance's service role account.
+// is passed then the ec2 client will be initialized with the instance's service role account. If the
+// audit log is not nil, every mutating call is recorded to it.
 func NewEC2Wrapper(roleARN, clusterName, region string, instanceClientQPS, instanceClientBurst,
-	userClientQPS, userClientBurst int, log logr.Logger) (EC2Wrapper, error) {
+	userClientQPS, userClientBurst int, auditLog *AuditLog, log logr.Logger) (EC2Wrapper, error) {
 	// Register the metrics
 	prometheusRegister()
 
-	ec2Wrapper := &ec2Wrapper{log: log}
+	ec2Wrapper := &ec2Wrapper{log: log, auditLog: auditLog}
 
 	instanceSession, err := ec2Wrapper.getInstanceSession()
 	if err != nil {
@@ -413,6 +506,7 @@ func (e *ec2Wrapper) getInstanceSession() (instanceSession *session.Session, err
 	// Create a new session
 	instanceSession = session.Must(session.NewSession())
 	injectUserAgent(&instanceSession.Handlers)
+	injectTracing(&instanceSession.Handlers)
 
 	// Get the region from the ec2 Metadata if the region is missing in the session config
 	ec2Metadata := ec2metadata.New(instanceSession)
@@ -447,6 +541,7 @@ func (e *ec2Wrapper) getClientUsingAssumedRole(instanceRegion, roleARN, clusterN
 	userStsSession := session.Must(session.NewSession())
 	userStsSession.Config.Region = &instanceRegion
 	injectUserAgent(&userStsSession.Handlers)
+	injectTracing(&userStsSession.Handlers)
 
 	// Create a rate limited http client for the
 	client, err := utils.NewRateLimitedClient(qps, burst)
@@ -540,7 +635,7 @@ func timeSinceMs(start time.Time) float64 {
 
 func (e *ec2Wrapper) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 	start := time.Now()
-	describeInstancesOutput, err := e.userServiceClient.DescribeInstances(input)
+	describeInstancesOutput, err := e.userServiceClient.DescribeInstancesWithContext(e.context(), input)
 	ec2APICallLatencies.WithLabelValues("describe_instances").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -557,8 +652,14 @@ func (e *ec2Wrapper) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.
 
 func (e *ec2Wrapper) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInput) (*ec2.CreateNetworkInterfaceOutput, error) {
 	start := time.Now()
-	createNetworkInterfaceOutput, err := e.userServiceClient.CreateNetworkInterface(input)
+	var req *request.Request
+	createNetworkInterfaceOutput, err := e.userServiceClient.CreateNetworkInterfaceWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("create_network_interface").Observe(timeSinceMs(start))
+	var nwInterfaceID string
+	if createNetworkInterfaceOutput != nil && createNetworkInterfaceOutput.NetworkInterface != nil {
+		nwInterfaceID = aws.StringValue(createNetworkInterfaceOutput.NetworkInterface.NetworkInterfaceId)
+	}
+	e.recordAudit("create_network_interface", req, start, err, nwInterfaceID)
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -575,8 +676,11 @@ func (e *ec2Wrapper) CreateNetworkInterface(input *ec2.CreateNetworkInterfaceInp
 
 func (e *ec2Wrapper) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInput) (*ec2.AttachNetworkInterfaceOutput, error) {
 	start := time.Now()
-	attachNetworkInterfaceOutput, err := e.userServiceClient.AttachNetworkInterface(input)
+	var req *request.Request
+	attachNetworkInterfaceOutput, err := e.userServiceClient.AttachNetworkInterfaceWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("attach_network_interface").Observe(timeSinceMs(start))
+	e.recordAudit("attach_network_interface", req, start, err, aws.StringValue(input.NetworkInterfaceId),
+		aws.StringValue(input.InstanceId))
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -592,8 +696,10 @@ func (e *ec2Wrapper) AttachNetworkInterface(input *ec2.AttachNetworkInterfaceInp
 
 func (e *ec2Wrapper) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInput) (*ec2.DeleteNetworkInterfaceOutput, error) {
 	start := time.Now()
-	deleteNetworkInterfaceOutput, err := e.userServiceClient.DeleteNetworkInterface(input)
+	var req *request.Request
+	deleteNetworkInterfaceOutput, err := e.userServiceClient.DeleteNetworkInterfaceWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("delete_network_interface").Observe(timeSinceMs(start))
+	e.recordAudit("delete_network_interface", req, start, err, aws.StringValue(input.NetworkInterfaceId))
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -609,8 +715,10 @@ func (e *ec2Wrapper) DeleteNetworkInterface(input *ec2.DeleteNetworkInterfaceInp
 
 func (e *ec2Wrapper) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInput) (*ec2.DetachNetworkInterfaceOutput, error) {
 	start := time.Now()
-	detachNetworkInterfaceOutput, err := e.userServiceClient.DetachNetworkInterface(input)
+	var req *request.Request
+	detachNetworkInterfaceOutput, err := e.userServiceClient.DetachNetworkInterfaceWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("detach_network_interface").Observe(timeSinceMs(start))
+	e.recordAudit("detach_network_interface", req, start, err, aws.StringValue(input.AttachmentId))
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -626,7 +734,7 @@ func (e *ec2Wrapper) DetachNetworkInterface(input *ec2.DetachNetworkInterfaceInp
 
 func (e *ec2Wrapper) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error) {
 	start := time.Now()
-	describeNetworkInterfacesOutput, err := e.userServiceClient.DescribeNetworkInterfaces(input)
+	describeNetworkInterfacesOutput, err := e.userServiceClient.DescribeNetworkInterfacesWithContext(e.context(), input)
 	ec2APICallLatencies.WithLabelValues("describe_network_interface").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -643,8 +751,19 @@ func (e *ec2Wrapper) DescribeNetworkInterfaces(input *ec2.DescribeNetworkInterfa
 
 func (e *ec2Wrapper) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (*ec2.AssignPrivateIpAddressesOutput, error) {
 	start := time.Now()
-	assignPrivateIPAddressesOutput, err := e.userServiceClient.AssignPrivateIpAddresses(input)
+	var req *request.Request
+	assignPrivateIPAddressesOutput, err := e.userServiceClient.AssignPrivateIpAddressesWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("assign_private_ip").Observe(timeSinceMs(start))
+	resourceIDs := []string{aws.StringValue(input.NetworkInterfaceId)}
+	if assignPrivateIPAddressesOutput != nil {
+		for _, ip := range assignPrivateIPAddressesOutput.AssignedPrivateIpAddresses {
+			resourceIDs = append(resourceIDs, aws.StringValue(ip.PrivateIpAddress))
+		}
+		for _, prefix := range assignPrivateIPAddressesOutput.AssignedIpv4Prefixes {
+			resourceIDs = append(resourceIDs, aws.StringValue(prefix.Ipv4Prefix))
+		}
+	}
+	e.recordAudit("assign_private_ip", req, start, err, resourceIDs...)
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -668,8 +787,12 @@ func (e *ec2Wrapper) AssignPrivateIPAddresses(input *ec2.AssignPrivateIpAddresse
 
 func (e *ec2Wrapper) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddressesInput) (*ec2.UnassignPrivateIpAddressesOutput, error) {
 	start := time.Now()
-	unAssignPrivateIPAddressesOutput, err := e.userServiceClient.UnassignPrivateIpAddresses(input)
+	var req *request.Request
+	unAssignPrivateIPAddressesOutput, err := e.userServiceClient.UnassignPrivateIpAddressesWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("unassign_private_ip").Observe(timeSinceMs(start))
+	resourceIDs := append([]string{aws.StringValue(input.NetworkInterfaceId)}, aws.StringValueSlice(input.PrivateIpAddresses)...)
+	resourceIDs = append(resourceIDs, aws.StringValueSlice(input.Ipv4Prefixes)...)
+	e.recordAudit("unassign_private_ip", req, start, err, resourceIDs...)
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -690,8 +813,10 @@ func (e *ec2Wrapper) UnassignPrivateIPAddresses(input *ec2.UnassignPrivateIpAddr
 
 func (e *ec2Wrapper) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
 	start := time.Now()
-	createTagsOutput, err := e.userServiceClient.CreateTags(input)
+	var req *request.Request
+	createTagsOutput, err := e.userServiceClient.CreateTagsWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("create_tags").Observe(timeSinceMs(start))
+	e.recordAudit("create_tags", req, start, err, aws.StringValueSlice(input.Resources)...)
 
 	// Metric updates
 	ec2APICallCnt.Inc()
@@ -707,7 +832,7 @@ func (e *ec2Wrapper) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutp
 
 func (e *ec2Wrapper) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
 	start := time.Now()
-	output, err := e.userServiceClient.DescribeSubnets(input)
+	output, err := e.userServiceClient.DescribeSubnetsWithContext(e.context(), input)
 	ec2APICallLatencies.WithLabelValues("describe_subnets").Observe(timeSinceMs(start))
 
 	// Metric updates
@@ -722,10 +847,100 @@ func (e *ec2Wrapper) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.Desc
 	return output, err
 }
 
+func (e *ec2Wrapper) DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	start := time.Now()
+	output, err := e.userServiceClient.DescribeInstanceTypesWithContext(e.context(), input)
+	ec2APICallLatencies.WithLabelValues("describe_instance_types").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2DescribeInstanceTypesAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2DescribeInstanceTypesAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
+func (e *ec2Wrapper) DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	start := time.Now()
+	output, err := e.userServiceClient.DescribeSecurityGroupsWithContext(e.context(), input)
+	ec2APICallLatencies.WithLabelValues("describe_security_groups").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2DescribeSecurityGroupsAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2DescribeSecurityGroupsAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
+func (e *ec2Wrapper) DescribeAddresses(input *ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error) {
+	start := time.Now()
+	output, err := e.userServiceClient.DescribeAddressesWithContext(e.context(), input)
+	ec2APICallLatencies.WithLabelValues("describe_addresses").Observe(timeSinceMs(start))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2DescribeAddressesAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2DescribeAddressesAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
+func (e *ec2Wrapper) AssociateAddress(input *ec2.AssociateAddressInput) (*ec2.AssociateAddressOutput, error) {
+	start := time.Now()
+	var req *request.Request
+	output, err := e.userServiceClient.AssociateAddressWithContext(e.context(), input, captureRequest(&req))
+	ec2APICallLatencies.WithLabelValues("associate_address").Observe(timeSinceMs(start))
+	e.recordAudit("associate_address", req, start, err, aws.StringValue(input.AllocationId),
+		aws.StringValue(input.NetworkInterfaceId))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2AssociateAddressAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2AssociateAddressAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
+func (e *ec2Wrapper) DisassociateAddress(input *ec2.DisassociateAddressInput) (*ec2.DisassociateAddressOutput, error) {
+	start := time.Now()
+	var req *request.Request
+	output, err := e.userServiceClient.DisassociateAddressWithContext(e.context(), input, captureRequest(&req))
+	ec2APICallLatencies.WithLabelValues("disassociate_address").Observe(timeSinceMs(start))
+	e.recordAudit("disassociate_address", req, start, err, aws.StringValue(input.AssociationId))
+
+	// Metric updates
+	ec2APICallCnt.Inc()
+	ec2DisassociateAddressAPICallCnt.Inc()
+
+	if err != nil {
+		ec2APIErrCnt.Inc()
+		ec2DisassociateAddressAPIErrCnt.Inc()
+	}
+
+	return output, err
+}
+
 // DescribeTrunkInterfaceAssociations cannot be used as it's not public yet.
 func (e *ec2Wrapper) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunkInterfaceAssociationsInput) (*ec2.DescribeTrunkInterfaceAssociationsOutput, error) {
 	start := time.Now()
-	describeTrunkInterfaceAssociationInput, err := e.instanceServiceClient.DescribeTrunkInterfaceAssociations(input)
+	describeTrunkInterfaceAssociationInput, err := e.instanceServiceClient.DescribeTrunkInterfaceAssociationsWithContext(e.context(), input)
 	ec2APICallLatencies.WithLabelValues("describe_trunk_association").Observe(timeSinceMs(start))
 
 	// Metric Update
@@ -742,8 +957,11 @@ func (e *ec2Wrapper) DescribeTrunkInterfaceAssociations(input *ec2.DescribeTrunk
 
 func (e *ec2Wrapper) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceInput) (*ec2.AssociateTrunkInterfaceOutput, error) {
 	start := time.Now()
-	associateTrunkInterfaceOutput, err := e.instanceServiceClient.AssociateTrunkInterface(input)
+	var req *request.Request
+	associateTrunkInterfaceOutput, err := e.instanceServiceClient.AssociateTrunkInterfaceWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("associate_trunk_to_branch").Observe(timeSinceMs(start))
+	e.recordAudit("associate_trunk_to_branch", req, start, err, aws.StringValue(input.TrunkInterfaceId),
+		aws.StringValue(input.BranchInterfaceId))
 
 	// Metric Update
 	ec2APICallCnt.Inc()
@@ -759,8 +977,10 @@ func (e *ec2Wrapper) AssociateTrunkInterface(input *ec2.AssociateTrunkInterfaceI
 
 func (e *ec2Wrapper) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
 	start := time.Now()
-	modifyNetworkInterfaceAttributeOutput, err := e.userServiceClient.ModifyNetworkInterfaceAttribute(input)
+	var req *request.Request
+	modifyNetworkInterfaceAttributeOutput, err := e.userServiceClient.ModifyNetworkInterfaceAttributeWithContext(e.context(), input, captureRequest(&req))
 	ec2APICallLatencies.WithLabelValues("modify_network_interface_attribute").Observe(timeSinceMs(start))
+	e.recordAudit("modify_network_interface_attribute", req, start, err, aws.StringValue(input.NetworkInterfaceId))
 
 	// Metric Update
 	ec2APICallCnt.Inc()
@@ -777,7 +997,10 @@ func (e *ec2Wrapper) ModifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInt
 func (e *ec2Wrapper) CreateNetworkInterfacePermission(input *ec2.CreateNetworkInterfacePermissionInput) (*ec2.CreateNetworkInterfacePermissionOutput, error) {
 	// Add the account ID of the instance running the controller
 	input.AwsAccountId = &e.accountID
-	output, err := e.userServiceClient.CreateNetworkInterfacePermission(input)
+	start := time.Now()
+	var req *request.Request
+	output, err := e.userServiceClient.CreateNetworkInterfacePermissionWithContext(e.context(), input, captureRequest(&req))
+	e.recordAudit("create_network_interface_permission", req, start, err, aws.StringValue(input.NetworkInterfaceId))
 
 	// Metric Update
 	ec2APICallCnt.Inc()
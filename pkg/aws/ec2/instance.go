@@ -50,8 +50,12 @@ type ec2Instance struct {
 	// subnetMask is the mask of the subnet CIDR block
 	subnetMask   string
 	subnetV6Mask string
-	// deviceIndexes is the list of indexes used by the EC2 Instance
+	// deviceIndexes is the list of indexes used by the EC2 Instance on the default network card
 	deviceIndexes []bool
+	// defaultNetworkCardIndex is the index of the network card of the primary network interface
+	defaultNetworkCardIndex int64
+	// networkCardDeviceIndexes is the map of the other network cards' index to the list of indexes used on the card
+	networkCardDeviceIndexes map[int64][]bool
 	// primaryENIGroups is the security group used by the primary network interface
 	primaryENISecurityGroups []string
 	// primaryENIID is the ID of the primary network interface of the instance
@@ -67,6 +71,8 @@ type EC2Instance interface {
 	LoadDetails(ec2APIHelper api.EC2APIHelper) error
 	GetHighestUnusedDeviceIndex() (int64, error)
 	FreeDeviceIndex(index int64)
+	GetHighestUnusedDeviceIndexOnNetworkCard(networkCardIndex int64) (int64, error)
+	FreeDeviceIndexOnNetworkCard(networkCardIndex int64, index int64)
 	Name() string
 	Os() string
 	Type() string
@@ -127,7 +133,7 @@ func (i *ec2Instance) LoadDetails(ec2APIHelper api.EC2APIHelper) error {
 	}
 
 	i.instanceType = *instance.InstanceType
-	limits, ok := vpc.Limits[i.instanceType]
+	limits, ok := vpc.GetLimits(i.instanceType)
 	if !ok {
 		return fmt.Errorf("unsupported instance type, couldn't find ENI Limit for instance %s, error: %w", i.instanceType, utils.ErrNotFound)
 	}
@@ -144,14 +150,27 @@ func (i *ec2Instance) LoadDetails(ec2APIHelper api.EC2APIHelper) error {
 		return fmt.Errorf("didn't find valid network card with max interface limit from limit file for instance type %s", i.instanceType)
 	}
 
-	// currently CNI and this controller both only support single network card
-	// we want to make sure to use the smaller number between instance max supported interfaces and the default card max supported interfaces
+	// the CNI only uses the default network card, we want to make sure to use the smaller number between instance max
+	// supported interfaces and the default card max supported interfaces
 	maxInterfaces := utils.Minimum(int64(limits.Interface), defaultNetworkCardLimit)
 
 	i.deviceIndexes = make([]bool, int(maxInterfaces))
+	i.defaultNetworkCardIndex = int64(defaultCardIdx)
+	i.networkCardDeviceIndexes = make(map[int64][]bool)
+	for _, card := range limits.NetworkCards {
+		if card.NetworkCardIndex != i.defaultNetworkCardIndex {
+			i.networkCardDeviceIndexes[card.NetworkCardIndex] = make([]bool, int(card.MaximumNetworkInterfaces))
+		}
+	}
 	for _, nwInterface := range instance.NetworkInterfaces {
+		networkCardIndex := i.defaultNetworkCardIndex
+		if nwInterface.Attachment.NetworkCardIndex != nil {
+			networkCardIndex = *nwInterface.Attachment.NetworkCardIndex
+		}
 		index := nwInterface.Attachment.DeviceIndex
-		i.deviceIndexes[*index] = true
+		if deviceIndexes, found := i.getDeviceIndexes(networkCardIndex); found && *index < int64(len(deviceIndexes)) {
+			deviceIndexes[*index] = true
+		}
 
 		// Load the Security group of the primary network interface
 		if i.primaryENISecurityGroups == nil && (nwInterface.PrivateIpAddress != nil && instance.PrivateIpAddress != nil && *nwInterface.PrivateIpAddress == *instance.PrivateIpAddress) {
@@ -222,22 +241,16 @@ func (i *ec2Instance) CurrentInstanceSecurityGroups() []string {
 	return i.currentInstanceSecurityGroups
 }
 
-// GetHighestUnusedDeviceIndex assigns a free device index from the end of the list since IPAMD assigns indexes from
-// the beginning of the list
+// GetHighestUnusedDeviceIndex assigns a free device index of the default network card from the end of the list since
+// IPAMD assigns indexes from the beginning of the list
 func (i *ec2Instance) GetHighestUnusedDeviceIndex() (int64, error) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
-	for index := len(i.deviceIndexes) - 1; index >= 0; index-- {
-		if i.deviceIndexes[index] == false {
-			i.deviceIndexes[index] = true
-			return int64(index), nil
-		}
-	}
-	return 0, fmt.Errorf("no free device index found")
+	return getHighestUnusedDeviceIndex(i.deviceIndexes)
 }
 
-// FreeDeviceIndex frees a device index from the list of managed index
+// FreeDeviceIndex frees a device index of the default network card from the list of managed index
 func (i *ec2Instance) FreeDeviceIndex(index int64) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
@@ -245,6 +258,49 @@ func (i *ec2Instance) FreeDeviceIndex(index int64) {
 	i.deviceIndexes[index] = false
 }
 
+// GetHighestUnusedDeviceIndexOnNetworkCard assigns a free device index of the network card from the end of the list
+func (i *ec2Instance) GetHighestUnusedDeviceIndexOnNetworkCard(networkCardIndex int64) (int64, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	deviceIndexes, found := i.getDeviceIndexes(networkCardIndex)
+	if !found {
+		return 0, fmt.Errorf("network card %d not found on instance type %s", networkCardIndex, i.instanceType)
+	}
+	return getHighestUnusedDeviceIndex(deviceIndexes)
+}
+
+// FreeDeviceIndexOnNetworkCard frees a device index of the network card from the list of managed index
+func (i *ec2Instance) FreeDeviceIndexOnNetworkCard(networkCardIndex int64, index int64) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if deviceIndexes, found := i.getDeviceIndexes(networkCardIndex); found && index < int64(len(deviceIndexes)) {
+		deviceIndexes[index] = false
+	}
+}
+
+// getDeviceIndexes returns the list of indexes used on the network card, returns false if the instance doesn't have
+// the network card. The caller must hold the lock
+func (i *ec2Instance) getDeviceIndexes(networkCardIndex int64) ([]bool, bool) {
+	if networkCardIndex == i.defaultNetworkCardIndex {
+		return i.deviceIndexes, true
+	}
+	deviceIndexes, found := i.networkCardDeviceIndexes[networkCardIndex]
+	return deviceIndexes, found
+}
+
+// getHighestUnusedDeviceIndex marks the highest unused index of the list as used and returns it
+func getHighestUnusedDeviceIndex(deviceIndexes []bool) (int64, error) {
+	for index := len(deviceIndexes) - 1; index >= 0; index-- {
+		if !deviceIndexes[index] {
+			deviceIndexes[index] = true
+			return int64(index), nil
+		}
+	}
+	return 0, fmt.Errorf("no free device index found")
+}
+
 func (i *ec2Instance) SubnetMask() string {
 	i.lock.Lock()
 	defer i.lock.Unlock()
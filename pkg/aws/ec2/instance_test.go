@@ -305,6 +305,73 @@ func TestEc2Instance_FreeDeviceIndex(t *testing.T) {
 	assert.False(t, ec2Instance.deviceIndexes[2])
 }
 
+// TestEc2Instance_LoadDetails_NetworkCards tests the device indexes of the network interfaces are loaded on their
+// network card
+func TestEc2Instance_LoadDetails_NetworkCards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, mockEC2ApiHelper := getMockInstance(ctrl)
+
+	multiCardInstance := &ec2.Instance{
+		InstanceId:       &instanceID,
+		InstanceType:     aws.String("p4d.24xlarge"),
+		SubnetId:         &subnetID,
+		PrivateIpAddress: &privateIPAddr,
+		NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+			{
+				NetworkInterfaceId: &primaryInterfaceID,
+				PrivateIpAddress:   &privateIPAddr,
+				Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: &deviceIndex0,
+					NetworkCardIndex: aws.Int64(0)},
+			},
+			{
+				PrivateIpAddress: aws.String("192.168.1.2"),
+				Attachment: &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(14),
+					NetworkCardIndex: aws.Int64(1)},
+			},
+		},
+	}
+
+	mockEC2ApiHelper.EXPECT().GetInstanceDetails(&instanceID).Return(multiCardInstance, nil)
+	mockEC2ApiHelper.EXPECT().GetSubnet(&subnetID).Return(subnet, nil)
+
+	err := ec2Instance.LoadDetails(mockEC2ApiHelper)
+	assert.NoError(t, err)
+	assert.Len(t, ec2Instance.networkCardDeviceIndexes, 3)
+	assert.True(t, ec2Instance.deviceIndexes[0])
+	assert.False(t, ec2Instance.deviceIndexes[14])
+	assert.True(t, ec2Instance.networkCardDeviceIndexes[1][14])
+
+	// The highest index of network card 1 is used by the secondary network interface
+	index, err := ec2Instance.GetHighestUnusedDeviceIndexOnNetworkCard(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(13), index)
+	assert.True(t, ec2Instance.networkCardDeviceIndexes[1][13])
+
+	ec2Instance.FreeDeviceIndexOnNetworkCard(1, index)
+	assert.False(t, ec2Instance.networkCardDeviceIndexes[1][13])
+
+	// The default network card shares the device indexes with GetHighestUnusedDeviceIndex
+	index, err = ec2Instance.GetHighestUnusedDeviceIndexOnNetworkCard(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(14), index)
+	assert.True(t, ec2Instance.deviceIndexes[14])
+}
+
+// TestEc2Instance_GetHighestUnusedDeviceIndexOnNetworkCard_NotFound tests error is returned if the instance doesn't
+// have the network card
+func TestEc2Instance_GetHighestUnusedDeviceIndexOnNetworkCard_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2Instance, _ := getMockInstance(ctrl)
+	ec2Instance.deviceIndexes = []bool{true, false, true}
+
+	_, err := ec2Instance.GetHighestUnusedDeviceIndexOnNetworkCard(1)
+	assert.Error(t, err)
+}
+
 // TestEc2Instance_E2E tests end to end workflow of loading the instance details and then assigning a free index and
 // finally releasing a used device index
 func TestEc2Instance_E2E(t *testing.T) {
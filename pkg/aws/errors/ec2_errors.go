@@ -3,4 +3,9 @@ package errors
 const (
 	NotFoundAssociationID = "InvalidAssociationID.NotFound"
 	NotFoundInterfaceID   = "InvalidNetworkInterfaceID.NotFound"
+	NotFoundSubnetID      = "InvalidSubnetID.NotFound"
+	// InsufficientFreeAddresses is returned when the subnet doesn't have free addresses for a new network interface
+	InsufficientFreeAddresses = "InsufficientFreeAddressesInSubnet"
+	// AlreadyAssociated is returned when the Elastic IP address is already associated and can't be reassociated
+	AlreadyAssociated = "Resource.AlreadyAssociated"
 )
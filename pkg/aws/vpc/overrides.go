@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultLimitsOverridesReloadInterval is the default interval the limits overrides file is checked for changes
+const DefaultLimitsOverridesReloadInterval = time.Second * 30
+
+// LimitsOverride patches the limits of an instance type, only the fields that are set are overridden
+type LimitsOverride struct {
+	Interface            *int  `json:"interface,omitempty"`
+	IPv4PerInterface     *int  `json:"ipv4PerInterface,omitempty"`
+	IsTrunkingCompatible *bool `json:"isTrunkingCompatible,omitempty"`
+	BranchInterface      *int  `json:"branchInterface,omitempty"`
+}
+
+// validate returns an error if the override applied to the base limits would result in limits the controller
+// can't work with
+func (o LimitsOverride) validate(base *VPCLimits) error {
+	if o.Interface != nil && *o.Interface < 1 {
+		return fmt.Errorf("interface must be at least 1, got %d", *o.Interface)
+	}
+	// The primary IP of the ENI is not available to the pods
+	if o.IPv4PerInterface != nil && *o.IPv4PerInterface < 2 {
+		return fmt.Errorf("ipv4PerInterface must be at least 2, got %d", *o.IPv4PerInterface)
+	}
+	if o.BranchInterface != nil && *o.BranchInterface < 0 {
+		return fmt.Errorf("branchInterface must not be negative, got %d", *o.BranchInterface)
+	}
+	if limits := o.apply(base); limits.IsTrunkingCompatible && limits.BranchInterface < 1 {
+		return fmt.Errorf("branchInterface must be positive if the instance type is trunking compatible, got %d",
+			limits.BranchInterface)
+	}
+	return nil
+}
+
+// apply returns a copy of the limits with the override applied
+func (o LimitsOverride) apply(limits *VPCLimits) *VPCLimits {
+	overridden := *limits
+	if o.Interface != nil {
+		overridden.Interface = *o.Interface
+	}
+	if o.IPv4PerInterface != nil {
+		overridden.IPv4PerInterface = *o.IPv4PerInterface
+	}
+	if o.IsTrunkingCompatible != nil {
+		overridden.IsTrunkingCompatible = *o.IsTrunkingCompatible
+	}
+	if o.BranchInterface != nil {
+		overridden.BranchInterface = *o.BranchInterface
+	}
+	return &overridden
+}
+
+// ParseLimitsOverrides parses the YAML or JSON map of instance type to limits override and validates each override
+func ParseLimitsOverrides(data []byte) (map[string]LimitsOverride, error) {
+	overrides := map[string]LimitsOverride{}
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse the instance limits overrides: %w", err)
+	}
+	for instanceType, override := range overrides {
+		base, found := Limits[instanceType]
+		if !found {
+			// The limits fetched from the EC2 API are never trunking compatible and have no branch interface
+			base = &VPCLimits{}
+		}
+		if err := override.validate(base); err != nil {
+			return nil, fmt.Errorf("invalid instance limits override for %s: %w", instanceType, err)
+		}
+	}
+	return overrides, nil
+}
+
+// SetLimitsOverrides replaces the overrides applied to the limits returned by GetLimits
+func SetLimitsOverrides(overrides map[string]LimitsOverride) {
+	defaultLimitsProvider.lock.Lock()
+	defer defaultLimitsProvider.lock.Unlock()
+	defaultLimitsProvider.overrides = overrides
+}
+
+// WatchLimitsOverridesFile loads the limits overrides from the file and reloads them whenever the file content
+// changes until the context is done. An error is returned if the initial load fails, the overrides from the last
+// valid file are kept if a reload fails. The capacity already advertised on the nodes is not updated on reload.
+func WatchLimitsOverridesFile(ctx context.Context, path string, interval time.Duration, log logr.Logger) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the instance limits overrides file %s: %w", path, err)
+	}
+	overrides, err := ParseLimitsOverrides(data)
+	if err != nil {
+		return err
+	}
+	SetLimitsOverrides(overrides)
+	log.Info("loaded instance limits overrides", "path", path, "instance types", len(overrides))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				data = reloadLimitsOverrides(path, data, log)
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadLimitsOverrides sets the overrides from the file if its content changed and returns the content of the
+// file the current overrides were loaded from
+func reloadLimitsOverrides(path string, loaded []byte, log logr.Logger) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Error(err, "failed to read the instance limits overrides file, keeping the current overrides",
+			"path", path)
+		return loaded
+	}
+	if bytes.Equal(data, loaded) {
+		return loaded
+	}
+	overrides, err := ParseLimitsOverrides(data)
+	if err != nil {
+		log.Error(err, "invalid instance limits overrides, keeping the current overrides", "path", path)
+		// Don't report the same invalid content again on the next reload
+		return data
+	}
+	SetLimitsOverrides(overrides)
+	log.Info("reloaded instance limits overrides", "path", path, "instance types", len(overrides))
+	return data
+}
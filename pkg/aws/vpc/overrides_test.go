@@ -0,0 +1,108 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// TestParseLimitsOverrides tests the overrides are parsed from YAML
+func TestParseLimitsOverrides(t *testing.T) {
+	overrides, err := ParseLimitsOverrides([]byte(`
+m5.large:
+  branchInterface: 4
+new.large:
+  isTrunkingCompatible: true
+  branchInterface: 10
+  ipv4PerInterface: 5
+`))
+	assert.NoError(t, err)
+	assert.Len(t, overrides, 2)
+	assert.Equal(t, 4, *overrides["m5.large"].BranchInterface)
+	assert.Nil(t, overrides["m5.large"].Interface)
+	assert.True(t, *overrides["new.large"].IsTrunkingCompatible)
+	assert.Equal(t, 5, *overrides["new.large"].IPv4PerInterface)
+}
+
+// TestParseLimitsOverrides_Invalid tests invalid overrides are rejected
+func TestParseLimitsOverrides_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":          "m5.large:\n  branchInterfaces: 4\n",
+		"no interface":           "m5.large:\n  interface: 0\n",
+		"no secondary ip":        "m5.large:\n  ipv4PerInterface: 1\n",
+		"negative branch":        "m5.large:\n  branchInterface: -1\n",
+		"trunking and no branch": "m5.large:\n  isTrunkingCompatible: true\n  branchInterface: 0\n",
+		"trunking on base":       "c1.medium:\n  isTrunkingCompatible: true\n",
+		"trunking on fetched":    "new.large:\n  isTrunkingCompatible: true\n",
+		"no branch on trunking":  "m5.large:\n  branchInterface: 0\n",
+		"not a map":              "- m5.large\n",
+	} {
+		_, err := ParseLimitsOverrides([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+// TestParseLimitsOverrides_MergedLimits tests the overrides are validated once applied to the known limits
+func TestParseLimitsOverrides_MergedLimits(t *testing.T) {
+	_, err := ParseLimitsOverrides([]byte(`
+c1.medium:
+  isTrunkingCompatible: true
+  branchInterface: 3
+m5.large:
+  isTrunkingCompatible: false
+  branchInterface: 0
+`))
+	assert.NoError(t, err)
+}
+
+// TestReloadLimitsOverrides tests the overrides are replaced only if the file changed and is valid
+func TestReloadLimitsOverrides(t *testing.T) {
+	defer SetLimitsOverrides(nil)
+	log := zap.New()
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+
+	valid := []byte("m5.large:\n  branchInterface: 4\n")
+	assert.NoError(t, os.WriteFile(path, valid, 0600))
+	loaded := reloadLimitsOverrides(path, nil, log)
+	assert.Equal(t, valid, loaded)
+	limits, _ := GetLimits("m5.large")
+	assert.Equal(t, 4, limits.BranchInterface)
+
+	// The current overrides are kept if the new content is invalid
+	invalid := []byte("m5.large:\n  branchInterface: -1\n")
+	assert.NoError(t, os.WriteFile(path, invalid, 0600))
+	loaded = reloadLimitsOverrides(path, loaded, log)
+	assert.Equal(t, invalid, loaded)
+	limits, _ = GetLimits("m5.large")
+	assert.Equal(t, 4, limits.BranchInterface)
+
+	// The overrides are removed once the file is emptied
+	assert.NoError(t, os.WriteFile(path, []byte{}, 0600))
+	reloadLimitsOverrides(path, loaded, log)
+	limits, _ = GetLimits("m5.large")
+	assert.Equal(t, Limits["m5.large"].BranchInterface, limits.BranchInterface)
+}
+
+// TestWatchLimitsOverridesFile_Missing tests an error is returned if the file can't be loaded initially
+func TestWatchLimitsOverridesFile_Missing(t *testing.T) {
+	err := WatchLimitsOverridesFile(context.TODO(), filepath.Join(t.TempDir(), "missing.yaml"),
+		DefaultLimitsOverridesReloadInterval, zap.New())
+	assert.Error(t, err)
+}
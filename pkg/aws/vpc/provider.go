@@ -0,0 +1,170 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// LimitsSourceStatic is the source of the limits found in the generated Limits table
+	LimitsSourceStatic = "static"
+	// LimitsSourceDescribeInstanceTypes is the source of the limits fetched at runtime from EC2
+	LimitsSourceDescribeInstanceTypes = "describe_instance_types"
+
+	// unknownTypeRetryInterval is the minimum interval between two attempts to fetch the limits of an instance
+	// type that could not be fetched
+	unknownTypeRetryInterval = time.Minute
+)
+
+var (
+	prometheusRegistered = false
+
+	instanceTypeLimitsSourceCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "instance_type_limits_source_count",
+			Help: "The number of instance types whose limits are served from each source",
+		},
+		[]string{"source"},
+	)
+)
+
+// LimitsFetcher fetches the limits of an instance type that is missing from the generated Limits table
+type LimitsFetcher interface {
+	GetInstanceTypeLimits(instanceType string) (*VPCLimits, error)
+}
+
+type limitsProvider struct {
+	lock    sync.RWMutex
+	fetcher LimitsFetcher
+	// fetched are the limits of the instance types fetched at runtime
+	fetched map[string]*VPCLimits
+	// failed is the time of the last failed attempt to fetch the limits of an instance type
+	failed map[string]time.Time
+	// served is the source the limits of each instance type were last served from
+	served map[string]string
+	// overrides are the operator supplied patches applied on top of the limits from any source
+	overrides map[string]LimitsOverride
+}
+
+var defaultLimitsProvider = newLimitsProvider()
+
+func newLimitsProvider() *limitsProvider {
+	return &limitsProvider{
+		fetched: map[string]*VPCLimits{},
+		failed:  map[string]time.Time{},
+		served:  map[string]string{},
+	}
+}
+
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(instanceTypeLimitsSourceCount)
+		prometheusRegistered = true
+	}
+}
+
+// SetLimitsFetcher sets the fetcher used to lazily get the limits of the instance types missing from the
+// generated Limits table. The fetched limits are cached for the lifetime of the controller.
+func SetLimitsFetcher(fetcher LimitsFetcher) {
+	prometheusRegister()
+
+	defaultLimitsProvider.lock.Lock()
+	defer defaultLimitsProvider.lock.Unlock()
+	defaultLimitsProvider.fetcher = fetcher
+}
+
+// GetLimits returns the limits of the instance type from the generated Limits table and falls back to the
+// limits fetched at runtime for instance types released after the table was generated. The limits overrides,
+// if any, are applied to the returned limits.
+func GetLimits(instanceType string) (*VPCLimits, bool) {
+	return defaultLimitsProvider.getLimits(instanceType)
+}
+
+func (p *limitsProvider) getLimits(instanceType string) (*VPCLimits, bool) {
+	limits, found := p.getSourceLimits(instanceType)
+	if !found {
+		return nil, false
+	}
+
+	p.lock.RLock()
+	override, overridden := p.overrides[instanceType]
+	p.lock.RUnlock()
+	if overridden {
+		return override.apply(limits), true
+	}
+	return limits, true
+}
+
+// getSourceLimits returns the limits of the instance type before the overrides are applied
+func (p *limitsProvider) getSourceLimits(instanceType string) (*VPCLimits, bool) {
+	if limits, found := Limits[instanceType]; found {
+		p.recordSource(instanceType, LimitsSourceStatic)
+		return limits, true
+	}
+
+	p.lock.RLock()
+	limits, found := p.fetched[instanceType]
+	fetcher := p.fetcher
+	lastFailure, hasFailed := p.failed[instanceType]
+	p.lock.RUnlock()
+
+	if found {
+		return limits, true
+	}
+	if fetcher == nil || (hasFailed && time.Since(lastFailure) < unknownTypeRetryInterval) {
+		return nil, false
+	}
+
+	// The limits may be fetched more than once if requested concurrently, the results are the same
+	limits, err := fetcher.GetInstanceTypeLimits(instanceType)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err != nil || limits == nil {
+		p.failed[instanceType] = time.Now()
+		return nil, false
+	}
+	delete(p.failed, instanceType)
+	p.fetched[instanceType] = limits
+	p.recordSourceLocked(instanceType, LimitsSourceDescribeInstanceTypes)
+
+	return limits, true
+}
+
+func (p *limitsProvider) recordSource(instanceType string, source string) {
+	p.lock.RLock()
+	current := p.served[instanceType]
+	p.lock.RUnlock()
+	if current == source {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.recordSourceLocked(instanceType, source)
+}
+
+// recordSourceLocked counts the instance type for the source the first time its limits are served from it
+func (p *limitsProvider) recordSourceLocked(instanceType string, source string) {
+	if p.served[instanceType] == source {
+		return
+	}
+	p.served[instanceType] = source
+	instanceTypeLimitsSourceCount.WithLabelValues(source).Inc()
+}
@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	runtimeInstanceType = "new.large"
+	runtimeLimits       = &VPCLimits{
+		Interface:        3,
+		IPv4PerInterface: 10,
+		NetworkCards:     []NetworkCard{{MaximumNetworkInterfaces: 3}},
+		Hypervisor:       "nitro",
+	}
+)
+
+// fakeLimitsFetcher returns the configured limits and counts the number of calls
+type fakeLimitsFetcher struct {
+	limits map[string]*VPCLimits
+	calls  int
+}
+
+func (f *fakeLimitsFetcher) GetInstanceTypeLimits(instanceType string) (*VPCLimits, error) {
+	f.calls++
+	if limits, found := f.limits[instanceType]; found {
+		return limits, nil
+	}
+	return nil, fmt.Errorf("instance type %s not found", instanceType)
+}
+
+// TestLimitsProvider_Static tests the limits in the generated table are served without calling the fetcher
+func TestLimitsProvider_Static(t *testing.T) {
+	fetcher := &fakeLimitsFetcher{}
+	provider := newLimitsProvider()
+	provider.fetcher = fetcher
+
+	before := testutil.ToFloat64(instanceTypeLimitsSourceCount.WithLabelValues(LimitsSourceStatic))
+	limits, found := provider.getLimits("m5.large")
+	assert.True(t, found)
+	assert.Equal(t, Limits["m5.large"], limits)
+	// The instance type is counted once for the source
+	provider.getLimits("m5.large")
+	assert.Equal(t, before+1, testutil.ToFloat64(instanceTypeLimitsSourceCount.WithLabelValues(LimitsSourceStatic)))
+	assert.Zero(t, fetcher.calls)
+}
+
+// TestLimitsProvider_Fetched tests the limits of an instance type missing from the table are fetched once and cached
+func TestLimitsProvider_Fetched(t *testing.T) {
+	fetcher := &fakeLimitsFetcher{limits: map[string]*VPCLimits{runtimeInstanceType: runtimeLimits}}
+	provider := newLimitsProvider()
+	provider.fetcher = fetcher
+
+	before := testutil.ToFloat64(instanceTypeLimitsSourceCount.WithLabelValues(LimitsSourceDescribeInstanceTypes))
+	for i := 0; i < 2; i++ {
+		limits, found := provider.getLimits(runtimeInstanceType)
+		assert.True(t, found)
+		assert.Equal(t, runtimeLimits, limits)
+	}
+	assert.Equal(t, 1, fetcher.calls)
+	assert.Equal(t, before+1,
+		testutil.ToFloat64(instanceTypeLimitsSourceCount.WithLabelValues(LimitsSourceDescribeInstanceTypes)))
+}
+
+// TestLimitsProvider_FetchFailed tests a failed instance type is not fetched again before the retry interval
+func TestLimitsProvider_FetchFailed(t *testing.T) {
+	fetcher := &fakeLimitsFetcher{}
+	provider := newLimitsProvider()
+	provider.fetcher = fetcher
+
+	_, found := provider.getLimits(runtimeInstanceType)
+	assert.False(t, found)
+	_, found = provider.getLimits(runtimeInstanceType)
+	assert.False(t, found)
+	assert.Equal(t, 1, fetcher.calls)
+
+	// Once the retry interval elapses the limits are fetched again
+	provider.failed[runtimeInstanceType] = time.Now().Add(-unknownTypeRetryInterval)
+	fetcher.limits = map[string]*VPCLimits{runtimeInstanceType: runtimeLimits}
+	limits, found := provider.getLimits(runtimeInstanceType)
+	assert.True(t, found)
+	assert.Equal(t, runtimeLimits, limits)
+	assert.Equal(t, 2, fetcher.calls)
+}
+
+// TestLimitsProvider_NoFetcher tests the instance types missing from the table are not found without a fetcher
+func TestLimitsProvider_NoFetcher(t *testing.T) {
+	_, found := newLimitsProvider().getLimits(runtimeInstanceType)
+	assert.False(t, found)
+}
+
+// TestLimitsProvider_Override tests the override is applied to a copy of the limits from the source
+func TestLimitsProvider_Override(t *testing.T) {
+	branchInterface := 4
+	provider := newLimitsProvider()
+	provider.overrides = map[string]LimitsOverride{"m5.large": {BranchInterface: &branchInterface}}
+
+	limits, found := provider.getLimits("m5.large")
+	assert.True(t, found)
+	assert.Equal(t, branchInterface, limits.BranchInterface)
+	assert.Equal(t, Limits["m5.large"].IPv4PerInterface, limits.IPv4PerInterface)
+	assert.NotEqual(t, branchInterface, Limits["m5.large"].BranchInterface)
+}
@@ -104,7 +104,7 @@ func (c *condition) IsWindowsIPAMEnabled() bool {
 	}
 
 	// Return false if configmap not present/any errors
-	vpcCniConfigMap, err := c.K8sAPI.GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace)
+	vpcCniConfigMap, err := c.K8sAPI.GetControllerConfigMap()
 
 	if err == nil && vpcCniConfigMap.Data != nil {
 		if val, ok := vpcCniConfigMap.Data[config.EnableWindowsIPAMKey]; ok {
@@ -131,7 +131,7 @@ func (c *condition) IsWindowsPrefixDelegationEnabled() bool {
 	}
 
 	// Return false if configmap not present/any errors
-	vpcCniConfigMap, err := c.K8sAPI.GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace)
+	vpcCniConfigMap, err := c.K8sAPI.GetControllerConfigMap()
 
 	if err == nil && vpcCniConfigMap.Data != nil {
 		if ipamVal, ok := vpcCniConfigMap.Data[config.EnableWindowsIPAMKey]; ok {
@@ -80,8 +80,7 @@ func TestCondition_IsWindowsIPAMEnabled(t *testing.T) {
 				mock.EXPECT().GetDeployment(config.KubeSystemNamespace,
 					config.OldVPCControllerDeploymentName).Return(nil, notFoundErr)
 
-				mock.EXPECT().GetConfigMap(config.VpcCniConfigMapName,
-					config.KubeSystemNamespace).Return(nil, otherErr)
+				mock.EXPECT().GetControllerConfigMap().Return(nil, otherErr)
 			},
 		},
 		{
@@ -94,8 +93,7 @@ func TestCondition_IsWindowsIPAMEnabled(t *testing.T) {
 				noData := vpcCNIConfig.DeepCopy()
 				noData.Data = nil
 
-				mock.EXPECT().GetConfigMap(config.VpcCniConfigMapName,
-					config.KubeSystemNamespace).Return(noData, nil)
+				mock.EXPECT().GetControllerConfigMap().Return(noData, nil)
 			},
 		},
 		{
@@ -108,8 +106,7 @@ func TestCondition_IsWindowsIPAMEnabled(t *testing.T) {
 				falseData := vpcCNIConfig.DeepCopy()
 				falseData.Data[config.EnableWindowsIPAMKey] = "false"
 
-				mock.EXPECT().GetConfigMap(config.VpcCniConfigMapName,
-					config.KubeSystemNamespace).Return(falseData, nil)
+				mock.EXPECT().GetControllerConfigMap().Return(falseData, nil)
 			},
 		},
 		{
@@ -122,8 +119,7 @@ func TestCondition_IsWindowsIPAMEnabled(t *testing.T) {
 				nonParsable := vpcCNIConfig.DeepCopy()
 				nonParsable.Data[config.EnableWindowsIPAMKey] = "trued"
 
-				mock.EXPECT().GetConfigMap(config.VpcCniConfigMapName,
-					config.KubeSystemNamespace).Return(nonParsable, nil)
+				mock.EXPECT().GetControllerConfigMap().Return(nonParsable, nil)
 			},
 		},
 		{
@@ -133,8 +129,7 @@ func TestCondition_IsWindowsIPAMEnabled(t *testing.T) {
 				mock.EXPECT().GetDeployment(config.KubeSystemNamespace,
 					config.OldVPCControllerDeploymentName).Return(nil, notFoundErr)
 
-				mock.EXPECT().GetConfigMap(config.VpcCniConfigMapName,
-					config.KubeSystemNamespace).Return(vpcCNIConfig, nil)
+				mock.EXPECT().GetControllerConfigMap().Return(vpcCNIConfig, nil)
 			},
 		},
 	}
@@ -0,0 +1,71 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	subnetIDPrefix       = "subnet-"
+	subnetSelectorPrefix = "tag:"
+)
+
+// BranchENISubnetSelector selects the subnets a branch ENI can be created in, either by subnet ID or by subnet tag.
+// An empty TagValue matches all the subnets having the TagKey.
+type BranchENISubnetSelector struct {
+	SubnetID string
+	TagKey   string
+	TagValue string
+}
+
+// String returns the selector in the format it is written in the annotation
+func (s BranchENISubnetSelector) String() string {
+	if s.SubnetID != "" {
+		return s.SubnetID
+	}
+	if s.TagValue == "" {
+		return subnetSelectorPrefix + s.TagKey
+	}
+	return subnetSelectorPrefix + s.TagKey + "=" + s.TagValue
+}
+
+// ParseBranchENISubnets parses the value of the BranchENISubnetsAnnotation. The value is a comma separated list of
+// subnet IDs (subnet-0123) or tag selectors (tag:Key=Value or tag:Key), in the order of preference.
+func ParseBranchENISubnets(value string) ([]BranchENISubnetSelector, error) {
+	var selectors []BranchENISubnetSelector
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			return nil, fmt.Errorf("empty entry in %s %q", BranchENISubnetsAnnotation, value)
+		case strings.HasPrefix(entry, subnetIDPrefix):
+			if len(entry) == len(subnetIDPrefix) || strings.ContainsAny(entry, " =") {
+				return nil, fmt.Errorf("invalid subnet id %q in %s", entry, BranchENISubnetsAnnotation)
+			}
+			selectors = append(selectors, BranchENISubnetSelector{SubnetID: entry})
+		case strings.HasPrefix(entry, subnetSelectorPrefix):
+			key, tagValue, _ := strings.Cut(strings.TrimPrefix(entry, subnetSelectorPrefix), "=")
+			if key == "" {
+				return nil, fmt.Errorf("missing tag key in %q in %s", entry, BranchENISubnetsAnnotation)
+			}
+			selectors = append(selectors, BranchENISubnetSelector{TagKey: key, TagValue: tagValue})
+		default:
+			return nil, fmt.Errorf("%q in %s is neither a subnet id nor a tag selector", entry,
+				BranchENISubnetsAnnotation)
+		}
+	}
+	return selectors, nil
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBranchENISubnets(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             string
+		expectedSelectors []BranchENISubnetSelector
+		expectError       bool
+	}{
+		{
+			name:  "subnet ids and tag selectors in order",
+			value: "subnet-1, tag:routing=strict,tag:branch",
+			expectedSelectors: []BranchENISubnetSelector{
+				{SubnetID: "subnet-1"},
+				{TagKey: "routing", TagValue: "strict"},
+				{TagKey: "branch"},
+			},
+		},
+		{
+			name:        "empty value",
+			value:       "",
+			expectError: true,
+		},
+		{
+			name:        "empty entry",
+			value:       "subnet-1,,subnet-2",
+			expectError: true,
+		},
+		{
+			name:        "missing subnet id",
+			value:       "subnet-",
+			expectError: true,
+		},
+		{
+			name:        "missing tag key",
+			value:       "tag:=value",
+			expectError: true,
+		},
+		{
+			name:        "unknown entry",
+			value:       "10.0.0.0/24",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selectors, err := ParseBranchENISubnets(test.value)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedSelectors, selectors)
+		})
+	}
+}
+
+func TestBranchENISubnetSelector_String(t *testing.T) {
+	assert.Equal(t, "subnet-1", BranchENISubnetSelector{SubnetID: "subnet-1"}.String())
+	assert.Equal(t, "tag:routing=strict", BranchENISubnetSelector{TagKey: "routing", TagValue: "strict"}.String())
+	assert.Equal(t, "tag:branch", BranchENISubnetSelector{TagKey: "branch"}.String())
+}
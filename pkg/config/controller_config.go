@@ -0,0 +1,180 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ControllerConfigName is the name of the cluster scoped VPCResourceControllerConfig used by the controller
+	ControllerConfigName = "default"
+
+	// MinBranchENICooldownPeriodSeconds is the minimum branch ENI cool down period
+	MinBranchENICooldownPeriodSeconds = 30
+)
+
+// ValidateControllerConfig returns the spec with the invalid fields cleared and an error for each invalid field.
+// The cleared fields fall back to the amazon-vpc-cni ConfigMap and the defaults like the fields that are not set.
+func ValidateControllerConfig(spec v1alpha1.VPCResourceControllerConfigSpec) (v1alpha1.VPCResourceControllerConfigSpec,
+	[]error) {
+	valid := *spec.DeepCopy()
+	var errs []error
+
+	if valid.BranchENICooldownPeriodSeconds != nil && *valid.BranchENICooldownPeriodSeconds < MinBranchENICooldownPeriodSeconds {
+		errs = append(errs, fmt.Errorf("spec.branchENICooldownPeriodSeconds must be at least %d, got %d",
+			MinBranchENICooldownPeriodSeconds, *valid.BranchENICooldownPeriodSeconds))
+		valid.BranchENICooldownPeriodSeconds = nil
+	}
+
+	windows := valid.Windows
+	if windows == nil {
+		return valid, errs
+	}
+	for _, target := range []struct {
+		name  string
+		value **int32
+	}{
+		{"warmIPTarget", &windows.WarmIPTarget},
+		{"minimumIPTarget", &windows.MinimumIPTarget},
+		{"warmPrefixTarget", &windows.WarmPrefixTarget},
+	} {
+		if *target.value != nil && **target.value < 0 {
+			errs = append(errs, fmt.Errorf("spec.windows.%s must not be negative, got %d", target.name, **target.value))
+			*target.value = nil
+		}
+	}
+
+	pdDisabled := windows.EnablePrefixDelegation != nil && !*windows.EnablePrefixDelegation
+	if pdDisabled && windows.WarmPrefixTarget != nil {
+		errs = append(errs, fmt.Errorf("spec.windows.warmPrefixTarget is only used if enablePrefixDelegation is true"))
+		windows.WarmPrefixTarget = nil
+	}
+	// The warm pool must never be empty in secondary IP mode
+	if pdDisabled && windows.WarmIPTarget != nil && *windows.WarmIPTarget == 0 {
+		errs = append(errs, fmt.Errorf("spec.windows.warmIPTarget must be positive if enablePrefixDelegation is false"))
+		windows.WarmIPTarget = nil
+	}
+	// On demand IP allocation is not supported
+	if isZero(windows.WarmIPTarget) && isZero(windows.MinimumIPTarget) &&
+		(windows.WarmPrefixTarget == nil || isZero(windows.WarmPrefixTarget)) {
+		errs = append(errs, fmt.Errorf("spec.windows.warmIPTarget and spec.windows.minimumIPTarget must not both be zero"))
+		windows.WarmIPTarget, windows.MinimumIPTarget, windows.WarmPrefixTarget = nil, nil, nil
+	}
+
+	return valid, errs
+}
+
+// ApplyControllerConfig returns a copy of the amazon-vpc-cni ConfigMap with the keys overwritten by the fields set
+// in the validated spec of the VPCResourceControllerConfig, so the ConfigMap keys are only used as a fallback for
+// the fields that are not set.
+func ApplyControllerConfig(vpcCniConfigMap *v1.ConfigMap, spec v1alpha1.VPCResourceControllerConfigSpec) *v1.ConfigMap {
+	applied := vpcCniConfigMap.DeepCopy()
+	if applied.Data == nil {
+		applied.Data = map[string]string{}
+	}
+	for key, value := range controllerConfigValues(spec) {
+		applied.Data[key] = value
+	}
+	if len(applied.Data) == 0 {
+		applied.Data = nil
+	}
+	return applied
+}
+
+// ConfigMapFallbackKeys returns the deprecated amazon-vpc-cni ConfigMap keys that are used because the corresponding
+// field of the validated spec is not set
+func ConfigMapFallbackKeys(vpcCniConfigMap *v1.ConfigMap, spec v1alpha1.VPCResourceControllerConfigSpec) []string {
+	values := controllerConfigValues(spec)
+	var fallbackKeys []string
+	for _, keys := range controllerConfigKeys {
+		if _, set := values[keys[0]]; set {
+			continue
+		}
+		for _, key := range keys {
+			if _, found := vpcCniConfigMap.Data[key]; found {
+				fallbackKeys = append(fallbackKeys, key)
+			}
+		}
+	}
+	return fallbackKeys
+}
+
+// EffectiveWindowsIPAMConfig returns the Windows configuration in use for the amazon-vpc-cni ConfigMap with the
+// VPCResourceControllerConfig applied
+func EffectiveWindowsIPAMConfig(log logr.Logger, vpcCniConfigMap *v1.ConfigMap) *v1alpha1.WindowsIPAMConfig {
+	isIPAMEnabled, _ := strconv.ParseBool(vpcCniConfigMap.Data[EnableWindowsIPAMKey])
+	warmIPTarget, minIPTarget, warmPrefixTarget, isPDEnabled := ParseWinIPTargetConfigs(log, vpcCniConfigMap)
+	return &v1alpha1.WindowsIPAMConfig{
+		EnableIPAM:             &isIPAMEnabled,
+		EnablePrefixDelegation: &isPDEnabled,
+		WarmIPTarget:           int32Ptr(warmIPTarget),
+		MinimumIPTarget:        int32Ptr(minIPTarget),
+		WarmPrefixTarget:       int32Ptr(warmPrefixTarget),
+	}
+}
+
+// controllerConfigKeys are the amazon-vpc-cni ConfigMap keys of each field of the spec, the key set from the spec
+// comes first and takes precedence over the others when the ConfigMap is parsed
+var controllerConfigKeys = [][]string{
+	{EnableWindowsIPAMKey},
+	{EnableWindowsPrefixDelegationKey},
+	{WarmIPTarget, WinWarmIPTarget},
+	{MinimumIPTarget, WinMinimumIPTarget},
+	{WarmPrefixTarget, WinWarmPrefixTarget},
+	{BranchENICooldownPeriodKey},
+}
+
+// controllerConfigValues returns the amazon-vpc-cni ConfigMap values of the fields set in the spec
+func controllerConfigValues(spec v1alpha1.VPCResourceControllerConfigSpec) map[string]string {
+	values := map[string]string{}
+	if spec.BranchENICooldownPeriodSeconds != nil {
+		values[BranchENICooldownPeriodKey] = strconv.Itoa(int(*spec.BranchENICooldownPeriodSeconds))
+	}
+	windows := spec.Windows
+	if windows == nil {
+		return values
+	}
+	if windows.EnableIPAM != nil {
+		values[EnableWindowsIPAMKey] = strconv.FormatBool(*windows.EnableIPAM)
+	}
+	if windows.EnablePrefixDelegation != nil {
+		values[EnableWindowsPrefixDelegationKey] = strconv.FormatBool(*windows.EnablePrefixDelegation)
+	}
+	if windows.WarmIPTarget != nil {
+		values[WarmIPTarget] = strconv.Itoa(int(*windows.WarmIPTarget))
+	}
+	if windows.MinimumIPTarget != nil {
+		values[MinimumIPTarget] = strconv.Itoa(int(*windows.MinimumIPTarget))
+	}
+	if windows.WarmPrefixTarget != nil {
+		values[WarmPrefixTarget] = strconv.Itoa(int(*windows.WarmPrefixTarget))
+	}
+	return values
+}
+
+func isZero(value *int32) bool {
+	return value != nil && *value == 0
+}
+
+func int32Ptr(value int) *int32 {
+	v := int32(value)
+	return &v
+}
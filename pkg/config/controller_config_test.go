@@ -0,0 +1,126 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func TestValidateControllerConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          v1alpha1.VPCResourceControllerConfigSpec
+		expectedSpec  v1alpha1.VPCResourceControllerConfigSpec
+		expectedError []string
+	}{
+		{
+			name: "valid spec",
+			spec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{EnablePrefixDelegation: boolPtr(true),
+					WarmIPTarget: int32Ptr(0), MinimumIPTarget: int32Ptr(0), WarmPrefixTarget: int32Ptr(1)},
+				BranchENICooldownPeriodSeconds: int32Ptr(30),
+			},
+			expectedSpec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{EnablePrefixDelegation: boolPtr(true),
+					WarmIPTarget: int32Ptr(0), MinimumIPTarget: int32Ptr(0), WarmPrefixTarget: int32Ptr(1)},
+				BranchENICooldownPeriodSeconds: int32Ptr(30),
+			},
+		},
+		{
+			name: "cool down period too short and negative target",
+			spec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows:                        &v1alpha1.WindowsIPAMConfig{EnableIPAM: boolPtr(true), MinimumIPTarget: int32Ptr(-1)},
+				BranchENICooldownPeriodSeconds: int32Ptr(10),
+			},
+			expectedSpec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{EnableIPAM: boolPtr(true)},
+			},
+			expectedError: []string{"spec.branchENICooldownPeriodSeconds must be at least 30, got 10",
+				"spec.windows.minimumIPTarget must not be negative, got -1"},
+		},
+		{
+			name: "warm prefix target and zero warm IP target in secondary IP mode",
+			spec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{EnablePrefixDelegation: boolPtr(false),
+					WarmIPTarget: int32Ptr(0), MinimumIPTarget: int32Ptr(2), WarmPrefixTarget: int32Ptr(1)},
+			},
+			expectedSpec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{EnablePrefixDelegation: boolPtr(false),
+					MinimumIPTarget: int32Ptr(2)},
+			},
+			expectedError: []string{"spec.windows.warmPrefixTarget is only used if enablePrefixDelegation is true",
+				"spec.windows.warmIPTarget must be positive if enablePrefixDelegation is false"},
+		},
+		{
+			name: "all targets zero",
+			spec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{WarmIPTarget: int32Ptr(0), MinimumIPTarget: int32Ptr(0)},
+			},
+			expectedSpec: v1alpha1.VPCResourceControllerConfigSpec{
+				Windows: &v1alpha1.WindowsIPAMConfig{},
+			},
+			expectedError: []string{"spec.windows.warmIPTarget and spec.windows.minimumIPTarget must not both be zero"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec, errs := ValidateControllerConfig(test.spec)
+			assert.Equal(t, test.expectedSpec, spec)
+			var messages []string
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			assert.Equal(t, test.expectedError, messages)
+		})
+	}
+}
+
+// TestApplyControllerConfig tests the fields set in the spec take precedence over the ConfigMap keys
+func TestApplyControllerConfig(t *testing.T) {
+	vpcCniConfigMap := &v1.ConfigMap{Data: map[string]string{
+		EnableWindowsIPAMKey:       "false",
+		WinWarmIPTarget:            "1",
+		WinMinimumIPTarget:         "4",
+		BranchENICooldownPeriodKey: "60",
+	}}
+	spec := v1alpha1.VPCResourceControllerConfigSpec{
+		Windows:                        &v1alpha1.WindowsIPAMConfig{EnableIPAM: boolPtr(true), WarmIPTarget: int32Ptr(5)},
+		BranchENICooldownPeriodSeconds: int32Ptr(90),
+	}
+
+	applied := ApplyControllerConfig(vpcCniConfigMap, spec)
+	assert.Equal(t, "false", vpcCniConfigMap.Data[EnableWindowsIPAMKey])
+	assert.Equal(t, "true", applied.Data[EnableWindowsIPAMKey])
+	assert.Equal(t, "90", applied.Data[BranchENICooldownPeriodKey])
+
+	effective := EffectiveWindowsIPAMConfig(zap.New(), applied)
+	assert.True(t, *effective.EnableIPAM)
+	assert.False(t, *effective.EnablePrefixDelegation)
+	assert.Equal(t, int32(5), *effective.WarmIPTarget)
+	assert.Equal(t, int32(4), *effective.MinimumIPTarget)
+
+	assert.Equal(t, []string{WinMinimumIPTarget}, ConfigMapFallbackKeys(vpcCniConfigMap, spec))
+	assert.Nil(t, ApplyControllerConfig(&v1.ConfigMap{}, v1alpha1.VPCResourceControllerConfigSpec{}).Data)
+}
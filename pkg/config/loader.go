@@ -28,6 +28,15 @@ const (
 	// Default Configuration for Pod ENI resource type
 	PodENIDefaultWorker = 30
 
+	// Default Configuration for the dedicated Pod ENI resource type
+	DedicatedPodENIDefaultWorker = 4
+
+	// Default Configuration for Elastic IP resource type
+	ElasticIPDefaultWorker = 4
+
+	// Default Configuration for the dedicated ENI resource type of each network card
+	NetworkCardDefaultWorker = 2
+
 	// Default Windows Configuration for IPv4 resource type
 	IPv4DefaultWinWorkerCount  = 2
 	IPv4DefaultWinWarmIPTarget = 3
@@ -177,6 +186,15 @@ func getDefaultResourceConfig() map[string]ResourceConfig {
 	}
 	config[ResourceNamePodENI] = podENIConfig
 
+	// Create default configuration for the dedicated Pod ENI Resource
+	dedicatedPodENIConfig := ResourceConfig{
+		Name:           ResourceNameDedicatedPodENI,
+		WorkerCount:    DedicatedPodENIDefaultWorker,
+		SupportedOS:    map[string]bool{OSWindows: false, OSLinux: true},
+		WarmPoolConfig: nil,
+	}
+	config[ResourceNameDedicatedPodENI] = dedicatedPodENIConfig
+
 	// Create default configuration for IPv4 Resource
 	ipV4WarmPoolConfig := WarmPoolConfig{
 		DesiredSize:  IPv4DefaultWinWarmIPTarget,
@@ -210,5 +228,14 @@ func getDefaultResourceConfig() map[string]ResourceConfig {
 	}
 	config[ResourceNameIPAddressFromPrefix] = prefixIPv4Config
 
+	// Create default configuration for Elastic IP Resource
+	elasticIPConfig := ResourceConfig{
+		Name:           ResourceNameElasticIP,
+		WorkerCount:    ElasticIPDefaultWorker,
+		SupportedOS:    map[string]bool{OSWindows: false, OSLinux: true},
+		WarmPoolConfig: nil,
+	}
+	config[ResourceNameElasticIP] = elasticIPConfig
+
 	return config
 }
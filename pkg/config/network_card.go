@@ -0,0 +1,55 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NetworkCardResourceName returns the extended resource name for the dedicated ENIs attached to the network card,
+// the pods are annotated with the ENIs under the same name
+func NetworkCardResourceName(networkCardIndex int64) string {
+	return ResourceNameNetworkCardPrefix + strconv.FormatInt(networkCardIndex, 10)
+}
+
+// IsNetworkCardResourceName returns true if the resource is the dedicated ENI resource of a network card
+func IsNetworkCardResourceName(resourceName string) bool {
+	_, found := NetworkCardIndex(resourceName)
+	return found
+}
+
+// NetworkCardIndex returns the index of the network card of the dedicated ENI resource, the index must not have
+// leading zeros so the resource name is the name returned by NetworkCardResourceName
+func NetworkCardIndex(resourceName string) (int64, bool) {
+	index, found := strings.CutPrefix(resourceName, ResourceNameNetworkCardPrefix)
+	if !found {
+		return 0, false
+	}
+	networkCardIndex, err := strconv.ParseInt(index, 10, 64)
+	if err != nil || networkCardIndex < 0 || NetworkCardResourceName(networkCardIndex) != resourceName {
+		return 0, false
+	}
+	return networkCardIndex, true
+}
+
+// NetworkCardResourceConfig returns the default configuration of the dedicated ENI resource of the network card
+func NetworkCardResourceConfig(networkCardIndex int64) ResourceConfig {
+	return ResourceConfig{
+		Name:           NetworkCardResourceName(networkCardIndex),
+		WorkerCount:    NetworkCardDefaultWorker,
+		SupportedOS:    map[string]bool{OSWindows: false, OSLinux: true},
+		WarmPoolConfig: nil,
+	}
+}
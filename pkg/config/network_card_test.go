@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkCardResourceName(t *testing.T) {
+	assert.Equal(t, "vpc.amazonaws.com/network-card-1", NetworkCardResourceName(1))
+	assert.Equal(t, NetworkCardResourceName(3), NetworkCardResourceConfig(3).Name)
+}
+
+func TestIsNetworkCardResourceName(t *testing.T) {
+	assert.True(t, IsNetworkCardResourceName(NetworkCardResourceName(0)))
+	assert.True(t, IsNetworkCardResourceName(NetworkCardResourceName(31)))
+	assert.False(t, IsNetworkCardResourceName(ResourceNameNetworkCardPrefix))
+	assert.False(t, IsNetworkCardResourceName(ResourceNameNetworkCardPrefix+"-1"))
+	assert.False(t, IsNetworkCardResourceName(ResourceNamePodENI))
+}
+
+func TestNetworkCardIndex(t *testing.T) {
+	index, found := NetworkCardIndex(NetworkCardResourceName(15))
+	assert.True(t, found)
+	assert.Equal(t, int64(15), index)
+
+	_, found = NetworkCardIndex(ResourceNameNetworkCardPrefix + "01")
+	assert.False(t, found)
+	_, found = NetworkCardIndex(ResourceNameNetworkCardPrefix + "+1")
+	assert.False(t, found)
+}
@@ -29,6 +29,39 @@ const (
 	ResourceNameIPAddress = VPCResourcePrefix + "PrivateIPv4Address"
 	// ResourceNameIPAddressFromPrefix is the resource name for prefix-deconstructed IP addresses, not a pod annotation
 	ResourceNameIPAddressFromPrefix = VPCResourcePrefix + "PrivateIPv4AddressFromPrefix"
+	// ResourceNameDedicatedPodENI is the resource name for the dedicated ENIs given to the pods requesting pod-eni on
+	// the instances not supporting trunking, not a pod annotation
+	ResourceNameDedicatedPodENI = VPCResourcePrefix + "DedicatedPodENI"
+	// ResourceNameElasticIP is the extended resource name for the Elastic IP addresses associated with the pods
+	ResourceNameElasticIP = VPCResourcePrefix + "elastic-ip"
+	// ElasticIPPoolAnnotation is the name of the pool the Elastic IP address of the pod is taken from, the Elastic IP
+	// addresses of the pool are tagged with ElasticIPPoolTagKey
+	ElasticIPPoolAnnotation = VPCResourcePrefix + "elastic-ip-pool"
+	// ResourceNameNetworkCardPrefix is the prefix of the extended resources for the dedicated ENIs attached to the
+	// network cards of the instance, see NetworkCardResourceName
+	ResourceNameNetworkCardPrefix = VPCResourcePrefix + "network-card-"
+	// NetworkInterfaceTypeAnnotation is the type of the dedicated ENIs attached to the network cards for the pod,
+	// interface or efa. The ENIs are created with the interface type if not set
+	NetworkInterfaceTypeAnnotation = VPCResourcePrefix + "network-interface-type"
+	// BranchENISubnetsAnnotation is the ordered, comma separated list of subnet IDs or subnet tag selectors the
+	// branch ENIs of the pod are created in, see ParseBranchENISubnets
+	BranchENISubnetsAnnotation = VPCResourcePrefix + "branch-eni-subnets"
+	// ResourceInjectionContainerAnnotation is the name of the container the VPC resources are injected into by the
+	// mutating webhook, a regular container or a sidecar init container. The first container is used if not set
+	ResourceInjectionContainerAnnotation = VPCResourcePrefix + "resource-injection-container"
+	// SecurityGroupPoliciesAnnotation is the comma separated list of the SecurityGroupPolicy names matched by the
+	// Fargate pod when it was created
+	SecurityGroupPoliciesAnnotation = VPCResourcePrefix + "security-group-policies"
+)
+
+// K8s Pod Conditions
+const (
+	// PodConditionNetworkingReady is the condition set on the pods requesting VPC resources, true once the resources
+	// are allocated and annotated on the pod. It can be used as readiness gate of the pod.
+	PodConditionNetworkingReady = VPCResourcePrefix + "NetworkingReady"
+	// PodConditionWebhookBypassed is the condition set on the pods matching a SecurityGroupPolicy that were admitted
+	// without the pod-eni resource, as the pod mutating webhook was unavailable when the pods were created
+	PodConditionWebhookBypassed = VPCResourcePrefix + "WebhookBypassed"
 )
 
 // K8s Labels
@@ -53,11 +86,29 @@ const (
 	OSLinux = "linux"
 )
 
+// K8s Taints denoting the node is being drained before it's terminated
+const (
+	// UnschedulableTaint is the taint added to the cordoned nodes
+	UnschedulableTaint = "node.kubernetes.io/unschedulable"
+	// KarpenterDisruptedTaint is the taint added by Karpenter to the nodes it's disrupting
+	KarpenterDisruptedTaint = "karpenter.sh/disrupted"
+	// KarpenterDisruptionTaint is the taint added by Karpenter v1beta1 to the nodes it's disrupting
+	KarpenterDisruptionTaint = "karpenter.sh/disruption"
+	// SpotInterruptionTaint is the taint added by the AWS Node Termination Handler to the nodes receiving a spot
+	// interruption notice
+	SpotInterruptionTaint = "aws-node-termination-handler/spot-itn"
+	// ASGLifecycleTerminationTaint is the taint added by the AWS Node Termination Handler to the nodes being
+	// terminated by their auto scaling group
+	ASGLifecycleTerminationTaint = "aws-node-termination-handler/asg-lifecycle-termination"
+)
+
 // EC2 Tags
 const (
 	ControllerTagPrefix = "vpcresources.k8s.aws/"
 	VLandIDTag          = ControllerTagPrefix + "vlan-id"
 	TrunkENIIDTag       = ControllerTagPrefix + "trunk-eni-id"
+	// ElasticIPPoolTagKey is the tag of the Elastic IP addresses with the name of their pool as value
+	ElasticIPPoolTagKey = ControllerTagPrefix + "elastic-ip-pool"
 
 	ClusterNameTagKeyFormat = "kubernetes.io/cluster/%s"
 	ClusterNameTagValue     = "owned"
@@ -65,8 +116,23 @@ const (
 	NetworkInterfaceOwnerTagKey         = "eks:eni:owner"
 	NetworkInterfaceOwnerTagValue       = "eks-vpc-resource-controller"
 	NetworkInterfaceOwnerVPCCNITagValue = "amazon-vpc-cni"
+
+	// NoManageTagKey is the tag of the ENIs that the CNI must not manage, it's set on the ENIs attached for the pods
+	NoManageTagKey   = "node.k8s.amazonaws.com/no_manage"
+	NoManageTagValue = "true"
 )
 
+const (
+	// ElasticIPPoolNamespacesTagKey is the optional tag of the Elastic IP addresses with the comma separated
+	// namespaces of the pods they can be associated with, the addresses without the tag are available to all the
+	// namespaces
+	ElasticIPPoolNamespacesTagKey = ControllerTagPrefix + "elastic-ip-pool-namespaces"
+)
+
+// ShardLeaseNamespace is the default namespace of the node sharding Leases, it's dedicated to them so the controller
+// isn't granted access to the other Leases of kube-system
+const ShardLeaseNamespace = "vpc-resource-controller-shards"
+
 const (
 	LeaderElectionKey                = "cp-vpc-resource-controller"
 	LeaderElectionNamespace          = "kube-system"
@@ -112,6 +178,16 @@ const (
 	TrunkAttached                     = "vpc.amazonaws.com/has-trunk-attached=true"
 )
 
+// States of the resources filtered by the introspection list endpoints
+const (
+	// IntrospectStateUsed are the resources allocated to pods
+	IntrospectStateUsed = "used"
+	// IntrospectStateWarm are the resources in the warm pool
+	IntrospectStateWarm = "warm"
+	// IntrospectStateCooling are the resources in cool down, for branch ENIs the ENIs in the delete queue
+	IntrospectStateCooling = "cooling"
+)
+
 // customized configurations for BigCache
 const (
 	InstancesCacheTTL     = 30 * time.Minute // scaling < 1k nodes should be under 20 minutes
@@ -124,6 +200,12 @@ var (
 	CoolDownPeriod = time.Second * 30
 	// ENICleanUpInterval is the time interval between each dangling ENI clean up task
 	ENICleanUpInterval = time.Minute * 30
+	// LeakedIPv4ResourceReconcileInterval is the time interval between each check of a Windows node for secondary IPs
+	// and prefixes assigned to its ENIs but missing from its pool, the check is disabled if zero
+	LeakedIPv4ResourceReconcileInterval = time.Minute * 10
+	// LeakedIPv4ResourceGracePeriod is the minimum time an IPv4 resource must be missing from the pool before it's
+	// unassigned from the ENI
+	LeakedIPv4ResourceGracePeriod = time.Minute * 30
 )
 
 // ResourceConfig is the configuration for each resource type
@@ -14,6 +14,8 @@
 package handler
 
 import (
+	"context"
+
 	v1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -24,6 +26,6 @@ import (
 // processed on demand would fit into this category. For instance, Branch ENIs are tied to the Security
 // Group required by the pod which we would know only after receiving the pod request.
 type Handler interface {
-	HandleCreate(requestCount int, pod *v1.Pod) (ctrl.Result, error)
-	HandleDelete(pod *v1.Pod) (ctrl.Result, error)
+	HandleCreate(ctx context.Context, requestCount int, pod *v1.Pod) (ctrl.Result, error)
+	HandleDelete(ctx context.Context, pod *v1.Pod) (ctrl.Result, error)
 }
@@ -14,6 +14,8 @@
 package handler
 
 import (
+	"context"
+
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
@@ -39,16 +41,20 @@ func NewOnDemandHandler(log logr.Logger, resourceName string,
 }
 
 // HandleCreate provides the resource to the on demand resource by passing the Create Job to the respective Worker
-func (h *onDemandResourceHandler) HandleCreate(requestCount int, pod *v1.Pod) (ctrl.Result, error) {
+func (h *onDemandResourceHandler) HandleCreate(ctx context.Context, requestCount int, pod *v1.Pod) (ctrl.Result, error) {
 	job := worker.NewOnDemandCreateJob(pod.Namespace, pod.Name, requestCount)
+	worker.WithJobContext(ctx, job)
 	h.resourceProvider.SubmitAsyncJob(job)
 
 	return ctrl.Result{}, nil
 }
 
 // HandleDelete reclaims the on demand resource by passing the Delete Job to the respective Worker
-func (h *onDemandResourceHandler) HandleDelete(pod *v1.Pod) (ctrl.Result, error) {
+func (h *onDemandResourceHandler) HandleDelete(ctx context.Context, pod *v1.Pod) (ctrl.Result, error) {
 	deleteJob := worker.NewOnDemandDeletedJob(pod.Spec.NodeName, pod.UID)
+	// The providers keeping a state by pod name clear it with the name of the deleted pod
+	deleteJob.PodNamespace, deleteJob.PodName = pod.Namespace, pod.Name
+	worker.WithJobContext(ctx, deleteJob)
 	h.resourceProvider.SubmitAsyncJob(deleteJob)
 
 	return ctrl.Result{}, nil
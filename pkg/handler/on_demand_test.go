@@ -14,6 +14,7 @@
 package handler
 
 import (
+	"context"
 	"testing"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
@@ -56,9 +57,11 @@ var (
 	}
 
 	deletedJob = worker.OnDemandJob{
-		Operation: worker.OperationDeleted,
-		NodeName:  mockNodeName,
-		UID:       mockUID,
+		Operation:    worker.OperationDeleted,
+		NodeName:     mockNodeName,
+		UID:          mockUID,
+		PodName:      mockPodName,
+		PodNamespace: mockPodNamespace,
 	}
 
 	deletingJob = worker.OnDemandJob{
@@ -98,7 +101,7 @@ func Test_HandleCreate(t *testing.T) {
 
 	mockProvider.EXPECT().SubmitAsyncJob(createJob)
 
-	_, err := handler.HandleCreate(1, mockPod)
+	_, err := handler.HandleCreate(context.TODO(), 1, mockPod)
 	assert.NoError(t, err)
 }
 
@@ -111,6 +114,6 @@ func Test_HandleDeleted(t *testing.T) {
 
 	mockProvider.EXPECT().SubmitAsyncJob(deletedJob)
 
-	_, err := handler.HandleDelete(mockPod)
+	_, err := handler.HandleDelete(context.TODO(), mockPod)
 	assert.NoError(t, err)
 }
@@ -19,8 +19,10 @@ import (
 	"time"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	"github.com/go-logr/logr"
@@ -56,13 +58,14 @@ func NewWarmResourceHandler(log logr.Logger, wrapper api.Wrapper,
 	}
 }
 
-func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, error) {
+func (w *warmResourceHandler) HandleCreate(ctx context.Context, _ int, pod *v1.Pod) (ctrl.Result, error) {
 	resourcePool, err := w.getResourcePool(pod.Spec.NodeName)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	if _, present := pod.Annotations[w.resourceName]; present {
 		// Pod has already been allocated the resource, skip the event
+		w.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 		return ctrl.Result{}, nil
 	}
 
@@ -72,19 +75,23 @@ func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, err
 	resID, shouldReconcile, err := resourcePool.AssignResource(string(pod.UID))
 	if err != nil {
 		// Reconcile the pool before retrying or returning an error
-		w.reconcilePool(shouldReconcile, resourcePool)
+		w.reconcilePool(ctx, shouldReconcile, resourcePool)
 		switch err {
 		case pool.ErrResourceAreBeingCooledDown:
 			log.V(1).Info("resources are currently being cooled down, will retry")
+			message := fmt.Sprintf("Resource %s are being cooled down, will retry in %s",
+				w.resourceName, RequeueAfterWhenResourceCooling)
 			w.APIWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocationFailed,
-				fmt.Sprintf("Resource %s are being cooled down, will retry in %s",
-					w.resourceName, RequeueAfterWhenResourceCooling), v1.EventTypeWarning)
+				tracing.WithTraceID(ctx, message), v1.EventTypeWarning)
+			w.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonResourceAllocationFailed, message)
 			return ctrl.Result{Requeue: true, RequeueAfter: RequeueAfterWhenResourceCooling}, nil
 		case pool.ErrResourcesAreBeingCreated, pool.ErrWarmPoolEmpty:
 			log.V(1).Info("resources are currently being created or warm pool is empty, will retry")
+			message := fmt.Sprintf("Warm pool for resource %s is currently empty, will retry in %s",
+				w.resourceName, RequeueAfterWhenWPEmpty)
 			w.APIWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocationFailed,
-				fmt.Sprintf("Warm pool for resource %s is currently empty, will retry in %s",
-					w.resourceName, RequeueAfterWhenWPEmpty), v1.EventTypeWarning)
+				tracing.WithTraceID(ctx, message), v1.EventTypeWarning)
+			w.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonResourceAllocationFailed, message)
 			return ctrl.Result{Requeue: true, RequeueAfter: RequeueAfterWhenWPEmpty}, nil
 		case pool.ErrResourceAlreadyAssigned:
 			// The Pod may already have the request annotated, however the cache may not have
@@ -98,49 +105,77 @@ func (w *warmResourceHandler) HandleCreate(_ int, pod *v1.Pod) (ctrl.Result, err
 				log.Info("cache had stale entry, pod already has resource",
 					"resource from annotation", resourceID,
 					"resource from data store", resID)
+				w.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 				return ctrl.Result{}, nil
 			}
 			return ctrl.Result{}, err
 		case pool.ErrInsufficientCidrBlocks:
 			log.V(1).Info("prefix is not available in subnet, will retry")
+			message := fmt.Sprintf("Warm pool for resource %s is currently empty because the specified "+
+				"subnet does not have enough free cidr blocks, will retry in %s", w.resourceName,
+				RequeueAfterWhenPrefixNotAvailable)
 			w.APIWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocationFailed,
-				fmt.Sprintf("Warm pool for resource %s is currently empty because the specified subnet does not have enough "+
-					"free cidr blocks, will retry in %s", w.resourceName, RequeueAfterWhenPrefixNotAvailable), v1.EventTypeWarning)
+				tracing.WithTraceID(ctx, message), v1.EventTypeWarning)
+			w.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonResourceAllocationFailed, message)
 			return ctrl.Result{Requeue: true, RequeueAfter: RequeueAfterWhenPrefixNotAvailable}, nil
 		default:
+			w.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonResourceAllocationFailed,
+				fmt.Sprintf("failed to allocate resource %s: %v", w.resourceName, err))
 			return ctrl.Result{}, err
 		}
 	}
 
 	err = w.APIWrapper.PodAPI.AnnotatePod(pod.Namespace, pod.Name, pod.UID, w.resourceName, resID)
 	if err != nil {
+		w.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonResourceAllocationFailed,
+			fmt.Sprintf("failed to annotate pod with resource %s: %v", w.resourceName, err))
 		_, errFree := resourcePool.FreeResource(string(pod.UID), resID)
 		if errFree != nil {
 			err = fmt.Errorf("failed to annotate %v, failed to free %v", err, errFree)
 		}
+	} else {
+		w.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 	}
 
 	w.APIWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocated,
-		fmt.Sprintf("Allocated Resource %s: %s to the pod", w.resourceName, resID), v1.EventTypeNormal)
+		tracing.WithTraceID(ctx, fmt.Sprintf("Allocated Resource %s: %s to the pod", w.resourceName, resID)),
+		v1.EventTypeNormal)
 
 	log.Info("successfully allocated and annotated resource", "resource id", resID)
 
-	w.reconcilePool(shouldReconcile, resourcePool)
+	w.reconcilePool(ctx, shouldReconcile, resourcePool)
 
 	return ctrl.Result{}, err
 }
 
-func (w *warmResourceHandler) reconcilePool(shouldReconcile bool, resourcePool pool.Pool) {
+// setNetworkingReadyCondition sets the networking ready condition on the pod, the failure to set the condition is
+// only logged as it doesn't affect the resource allocated to the pod
+func (w *warmResourceHandler) setNetworkingReadyCondition(pod *v1.Pod, status v1.ConditionStatus, reason string,
+	message string) {
+	err := w.APIWrapper.PodAPI.SetPodCondition(pod, v1.PodCondition{
+		Type:    config.PodConditionNetworkingReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if err != nil {
+		w.log.Error(err, "failed to set the networking ready condition on the pod", "namespace", pod.Namespace,
+			"name", pod.Name, "status", status)
+	}
+}
+
+func (w *warmResourceHandler) reconcilePool(ctx context.Context, shouldReconcile bool, resourcePool pool.Pool) {
 	if shouldReconcile {
 		job := resourcePool.ReconcilePool()
 		if job.Operations != worker.Operations("") {
+			worker.WithJobContext(ctx, job)
 			w.resourceProvider.SubmitAsyncJob(job)
 		}
 	}
 }
 
 // HandleDelete deletes the resource used by the pod
-func (w *warmResourceHandler) HandleDelete(pod *v1.Pod) (ctrl.Result, error) {
+func (w *warmResourceHandler) HandleDelete(ctx context.Context, pod *v1.Pod) (ctrl.Result, error) {
 	resourcePool, err := w.getResourcePool(pod.Spec.NodeName)
 	if err != nil {
 		w.log.Error(err, "failed to find resource pool for node",
@@ -175,7 +210,7 @@ func (w *warmResourceHandler) HandleDelete(pod *v1.Pod) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
-	w.reconcilePool(shouldReconcile, resourcePool)
+	w.reconcilePool(ctx, shouldReconcile, resourcePool)
 
 	log.Info("successfully freed resource")
 
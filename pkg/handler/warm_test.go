@@ -14,6 +14,7 @@
 package handler
 
 import (
+	"context"
 	"testing"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
@@ -73,13 +74,14 @@ func TestWarmResourceHandler_HandleCreate(t *testing.T) {
 	mockPool.EXPECT().AssignResource(uid).Return(ipAddress, true, nil)
 	mockPodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, types.UID(uid), resourceName, ipAddress).Return(nil)
 	mockK8sWrapper.EXPECT().BroadcastEvent(podCopy, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+	expectNetworkingReadyCondition(t, mockPodAPI, podCopy, v1.ConditionTrue, ReasonResourceAllocated)
 
 	mockPool.EXPECT().ReconcilePool().Return(job)
 	mockProvider.EXPECT().SubmitAsyncJob(job)
 
 	delete(podCopy.Annotations, config.ResourceNameIPAddress)
 
-	_, err := handler.HandleCreate(1, podCopy)
+	_, err := handler.HandleCreate(context.TODO(), 1, podCopy)
 	assert.NoError(t, err)
 }
 
@@ -87,18 +89,19 @@ func TestWarmResourceHandler_PoolEmpty(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	handler, mockK8sWrapper, _, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+	handler, mockK8sWrapper, mockPodAPI, mockProvider, mockPool := getHandlerAndMocks(ctrl)
 	podCopy := pod.DeepCopy()
 
 	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
 	mockPool.EXPECT().AssignResource(uid).Return("", true, pool.ErrWarmPoolEmpty)
 	mockK8sWrapper.EXPECT().BroadcastEvent(podCopy, ReasonResourceAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+	expectNetworkingReadyCondition(t, mockPodAPI, podCopy, v1.ConditionFalse, ReasonResourceAllocationFailed)
 	mockPool.EXPECT().ReconcilePool().Return(job)
 	mockProvider.EXPECT().SubmitAsyncJob(job)
 
 	delete(podCopy.Annotations, config.ResourceNameIPAddress)
 
-	rslt, err := handler.HandleCreate(1, podCopy)
+	rslt, err := handler.HandleCreate(context.TODO(), 1, podCopy)
 	assert.NoError(t, err)
 	assert.Equal(t, k8sctrl.Result{
 		Requeue:      true,
@@ -112,11 +115,12 @@ func TestWarmResourceHandler_HandleCreate_AlreadyAnnotated(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	handler, _, _, mockProvider, mockPool := getHandlerAndMocks(ctrl)
+	handler, _, mockPodAPI, mockProvider, mockPool := getHandlerAndMocks(ctrl)
 
 	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
+	expectNetworkingReadyCondition(t, mockPodAPI, pod, v1.ConditionTrue, ReasonResourceAllocated)
 
-	_, err := handler.HandleCreate(1, pod)
+	_, err := handler.HandleCreate(context.TODO(), 1, pod)
 	assert.NoError(t, err)
 }
 
@@ -130,7 +134,7 @@ func TestWarmResourceHandler_HandleDelete(t *testing.T) {
 	mockProvider.EXPECT().GetPool(nodeName).Return(mockPool, true)
 	mockPool.EXPECT().FreeResource(uid, ipAddress).Return(false, nil)
 
-	_, err := handler.HandleDelete(pod)
+	_, err := handler.HandleDelete(context.TODO(), pod)
 	assert.NoError(t, err)
 }
 
@@ -146,7 +150,7 @@ func TestWarmResourceHandler_HandleDelete_ReconcileAfter(t *testing.T) {
 	mockPool.EXPECT().ReconcilePool().Return(job)
 	mockProvider.EXPECT().SubmitAsyncJob(job)
 
-	_, err := handler.HandleDelete(pod)
+	_, err := handler.HandleDelete(context.TODO(), pod)
 	assert.NoError(t, err)
 }
 
@@ -164,7 +168,7 @@ func TestWarmResourceHandler_HandleDelete_ResourceNotExist(t *testing.T) {
 
 	mockPool.EXPECT().GetAssignedResource(uid).Return("", false)
 
-	_, err := handler.HandleDelete(podCopy)
+	_, err := handler.HandleDelete(context.TODO(), podCopy)
 	assert.NoError(t, err)
 }
 
@@ -182,7 +186,7 @@ func TestWarmResourceHandler_HandleDelete_ResourceExistsInDataStoreOnly(t *testi
 	mockPool.EXPECT().GetAssignedResource(string(podCopy.UID)).Return(ipAddress, true)
 	mockPool.EXPECT().FreeResource(string(podCopy.UID), ipAddress).Return(false, nil)
 
-	_, err := handler.HandleDelete(podCopy)
+	_, err := handler.HandleDelete(context.TODO(), podCopy)
 	assert.NoError(t, err)
 }
 
@@ -195,7 +199,7 @@ func TestWarmResourceHandler_HandleDelete_Error(t *testing.T) {
 
 	mockProvider.EXPECT().GetPool(nodeName).Return(nil, false)
 
-	_, err := handler.HandleDelete(pod)
+	_, err := handler.HandleDelete(context.TODO(), pod)
 	assert.Nil(t, err)
 }
 
@@ -248,3 +252,15 @@ func getHandlerAndMocks(ctrl *gomock.Controller) (*warmResourceHandler, *mock_k8
 
 	return handler, mockWrapper, mockPodAPI, mockProvider, mockPool
 }
+
+// expectNetworkingReadyCondition expects the networking ready condition to be set on the pod with the status and reason
+func expectNetworkingReadyCondition(t *testing.T, mockPodAPI *mock_pod.MockPodClientAPIWrapper, pod *v1.Pod,
+	status v1.ConditionStatus, reason string) {
+	mockPodAPI.EXPECT().SetPodCondition(pod, gomock.Any()).DoAndReturn(
+		func(_ *v1.Pod, condition v1.PodCondition) error {
+			assert.Equal(t, v1.PodConditionType(config.PodConditionNetworkingReady), condition.Type)
+			assert.Equal(t, status, condition.Status)
+			assert.Equal(t, reason, condition.Reason)
+			return nil
+		})
+}
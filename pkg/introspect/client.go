@@ -0,0 +1,250 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package introspect
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+)
+
+// maxRecordSize is the maximum size of a JSON Lines record, the record of a node with many pods can be large
+const maxRecordSize = 16 * 1024 * 1024
+
+// Resources are the branch ENIs and the IPv4 pools of the nodes managed by the controller
+type Resources struct {
+	// Trunks are the trunk ENIs with their branch ENIs by node name
+	Trunks map[string]trunk.IntrospectResponse
+	// Pools are the pools by resource name and by node name
+	Pools map[string]map[string]pool.IntrospectResponse
+}
+
+// Summary is the count of the branch ENIs and of the IPv4 pool resources of the nodes managed by the controller
+type Summary struct {
+	// Trunks are the trunk ENI summaries by node name
+	Trunks map[string]trunk.IntrospectSummaryResponse
+	// Pools are the pool summaries by resource name and by node name
+	Pools map[string]map[string]pool.IntrospectSummaryResponse
+}
+
+// ListOptions filters the nodes returned by the list endpoints
+type ListOptions struct {
+	// Resource is the name of the resource, all the resources if empty
+	Resource string
+	// NodeSelector is the label selector of the nodes, all the nodes if empty
+	NodeSelector string
+}
+
+// Client reads the introspection API of the controller
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the introspection API served at the endpoint, the token is sent as bearer token if
+// not empty
+func NewClient(endpoint string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// GetResources returns the branch ENIs and the pools of the nodes
+func (c *Client) GetResources(ctx context.Context, options ListOptions) (*Resources, error) {
+	resources := &Resources{
+		Trunks: map[string]trunk.IntrospectResponse{},
+		Pools:  map[string]map[string]pool.IntrospectResponse{},
+	}
+	err := c.streamRecords(ctx, resource.GetAllResourcesPath, options, func(record rawRecord) error {
+		return resources.add(record.Resource, record.Node, record.Details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetSummary returns the summary of the branch ENIs and the pools of the nodes
+func (c *Client) GetSummary(ctx context.Context, options ListOptions) (*Summary, error) {
+	summary := &Summary{
+		Trunks: map[string]trunk.IntrospectSummaryResponse{},
+		Pools:  map[string]map[string]pool.IntrospectSummaryResponse{},
+	}
+	err := c.streamRecords(ctx, resource.GetResourcesSummaryPath, options, func(record rawRecord) error {
+		switch {
+		case record.Resource == config.ResourceNamePodENI:
+			details := trunk.IntrospectSummaryResponse{}
+			if err := json.Unmarshal(record.Details, &details); err != nil {
+				return err
+			}
+			summary.Trunks[record.Node] = details
+		case isPoolResource(record.Resource):
+			details := pool.IntrospectSummaryResponse{}
+			if err := json.Unmarshal(record.Details, &details); err != nil {
+				return err
+			}
+			if summary.Pools[record.Resource] == nil {
+				summary.Pools[record.Resource] = map[string]pool.IntrospectSummaryResponse{}
+			}
+			summary.Pools[record.Resource][record.Node] = details
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetNode returns the branch ENIs and the pools of the node
+func (c *Client) GetNode(ctx context.Context, nodeName string) (*Resources, error) {
+	body, err := c.get(ctx, resource.GetNodeResourcesPath+url.PathEscape(nodeName), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	response := map[string]json.RawMessage{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode the resources of node %s: %v", nodeName, err)
+	}
+	resources := &Resources{
+		Trunks: map[string]trunk.IntrospectResponse{},
+		Pools:  map[string]map[string]pool.IntrospectResponse{},
+	}
+	for resourceName, details := range response {
+		if err := resources.add(resourceName, nodeName, details); err != nil {
+			return nil, err
+		}
+	}
+	// The providers return an empty response for the nodes they don't manage
+	if trunkENI, found := resources.Trunks[nodeName]; found && trunkENI.TrunkENIID == "" {
+		delete(resources.Trunks, nodeName)
+	}
+	for resourceName, pools := range resources.Pools {
+		if details := pools[nodeName]; details.UsedResources == nil && details.WarmResources == nil &&
+			details.CoolingResources == nil {
+			delete(resources.Pools, resourceName)
+		}
+	}
+	return resources, nil
+}
+
+// rawRecord is a record of the JSON Lines response with the details not decoded yet
+type rawRecord struct {
+	Resource string
+	Node     string
+	Details  json.RawMessage
+}
+
+// isPoolResource returns true if the resource is managed with a warm pool
+func isPoolResource(resourceName string) bool {
+	return resourceName == config.ResourceNameIPAddress || resourceName == config.ResourceNameIPAddressFromPrefix
+}
+
+// add decodes the details of the resource on the node, the unknown resources are ignored
+func (r *Resources) add(resourceName string, nodeName string, details json.RawMessage) error {
+	switch {
+	case resourceName == config.ResourceNamePodENI:
+		trunkENI := trunk.IntrospectResponse{}
+		if err := json.Unmarshal(details, &trunkENI); err != nil {
+			return fmt.Errorf("failed to decode the trunk of node %s: %v", nodeName, err)
+		}
+		r.Trunks[nodeName] = trunkENI
+	case isPoolResource(resourceName):
+		resourcePool := pool.IntrospectResponse{}
+		if err := json.Unmarshal(details, &resourcePool); err != nil {
+			return fmt.Errorf("failed to decode the %s pool of node %s: %v", resourceName, nodeName, err)
+		}
+		if r.Pools[resourceName] == nil {
+			r.Pools[resourceName] = map[string]pool.IntrospectResponse{}
+		}
+		r.Pools[resourceName][nodeName] = resourcePool
+	}
+	return nil
+}
+
+// streamRecords reads the records of the list endpoint as JSON Lines and calls the handler for each record
+func (c *Client) streamRecords(ctx context.Context, path string, options ListOptions,
+	handle func(record rawRecord) error) error {
+	query := url.Values{}
+	query.Set(resource.OutputQueryParam, resource.OutputJSONLines)
+	if options.Resource != "" {
+		query.Set(resource.ResourceQueryParam, options.Resource)
+	}
+	if options.NodeSelector != "" {
+		query.Set(resource.NodeSelectorQueryParam, options.NodeSelector)
+	}
+
+	body, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	for scanner.Scan() {
+		record := rawRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("failed to decode record: %v", err)
+		}
+		if err := handle(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// get returns the body of the response to the request, the caller must close it
+func (c *Client) get(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	requestURL := c.endpoint + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("%s returned %s: %s", path, response.Status, strings.TrimSpace(string(message)))
+	}
+	return response.Body, nil
+}
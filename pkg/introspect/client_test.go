@@ -0,0 +1,126 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package introspect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
+	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName  = "ip-192-168-1-2.us-west-2.compute.internal"
+	podUID    = "pod-uid"
+	branchENI = trunk.ENIDetails{ID: "eni-00000000000000002", VlanID: 1, IPV4Addr: "192.168.1.10"}
+	trunks    = map[string]trunk.IntrospectResponse{nodeName: {
+		TrunkENIID:     "eni-00000000000000001",
+		InstanceID:     "i-00000000000000001",
+		PodToBranchENI: map[string][]trunk.ENIDetails{podUID: {branchENI}},
+	}}
+	pools = map[string]pool.IntrospectResponse{nodeName: {
+		UsedResources: map[string]pool.Resource{podUID: {GroupID: "192.168.1.20", ResourceID: "192.168.1.20"}},
+		WarmResources: map[string][]pool.Resource{},
+	}}
+)
+
+// getServer returns a server of the introspection API, with the handler of the controller, requiring the token
+func getServer(ctrl *gomock.Controller) *httptest.Server {
+	mockManager := mock_resource.NewMockResourceManager(ctrl)
+	mockBranchProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mockIPProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mockManager.EXPECT().GetResourceProviders().Return(map[string]provider.ResourceProvider{
+		config.ResourceNamePodENI:    mockBranchProvider,
+		config.ResourceNameIPAddress: mockIPProvider,
+	}).AnyTimes()
+	mockBranchProvider.EXPECT().Introspect().Return(provider.IntrospectNodes[trunk.IntrospectResponse](trunks)).AnyTimes()
+	mockIPProvider.EXPECT().Introspect().Return(provider.IntrospectNodes[pool.IntrospectResponse](pools)).AnyTimes()
+	mockBranchProvider.EXPECT().IntrospectSummary().Return(provider.IntrospectNodes[trunk.IntrospectSummaryResponse]{
+		nodeName: {TrunkENIID: "eni-00000000000000001", BranchENICount: 1},
+	}).AnyTimes()
+	mockIPProvider.EXPECT().IntrospectSummary().Return(provider.IntrospectNodes[pool.IntrospectSummaryResponse]{
+		nodeName: {UsedResourcesCount: 1},
+	}).AnyTimes()
+	mockBranchProvider.EXPECT().IntrospectNode(nodeName).Return(trunks[nodeName]).AnyTimes()
+	mockIPProvider.EXPECT().IntrospectNode(nodeName).Return(struct{}{}).AnyTimes()
+
+	handler := &resource.IntrospectHandler{Log: zap.New(), ResourceManager: mockManager}
+	mux := http.NewServeMux()
+	mux.HandleFunc(resource.GetAllResourcesPath, handler.ResourceHandler)
+	mux.HandleFunc(resource.GetResourcesSummaryPath, handler.ResourceSummaryHandler)
+	mux.HandleFunc(resource.GetNodeResourcesPath, handler.NodeResourceHandler)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestClient_GetResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := getServer(ctrl)
+	defer server.Close()
+
+	resources, err := NewClient(server.URL, "token", nil).GetResources(context.Background(), ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, trunks, resources.Trunks)
+	assert.Equal(t, map[string]map[string]pool.IntrospectResponse{config.ResourceNameIPAddress: pools},
+		resources.Pools)
+
+	_, err = NewClient(server.URL, "", nil).GetResources(context.Background(), ListOptions{})
+	assert.Error(t, err)
+}
+
+func TestClient_GetSummary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := getServer(ctrl)
+	defer server.Close()
+
+	summary, err := NewClient(server.URL+"/", "token", nil).GetSummary(context.Background(), ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Trunks[nodeName].BranchENICount)
+	assert.Equal(t, 1, summary.Pools[config.ResourceNameIPAddress][nodeName].UsedResourcesCount)
+}
+
+// TestClient_GetNode tests the empty response of the providers not managing the node are dropped
+func TestClient_GetNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := getServer(ctrl)
+	defer server.Close()
+
+	resources, err := NewClient(server.URL, "token", nil).GetNode(context.Background(), nodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, trunks, resources.Trunks)
+	assert.Empty(t, resources.Pools)
+}
@@ -0,0 +1,195 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package introspect
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ProblemInvalidAnnotation is a pod annotation that can't be decoded
+	ProblemInvalidAnnotation = "invalid annotation"
+	// ProblemMissingFromController is a pod annotation without the resource in the controller state
+	ProblemMissingFromController = "annotated but not held by the controller"
+	// ProblemDifferent is a pod annotation with a different resource than in the controller state
+	ProblemDifferent = "annotation differs from the controller"
+	// ProblemPodNotFound is a resource held by the controller for a pod that doesn't exist
+	ProblemPodNotFound = "held by the controller for a pod that doesn't exist"
+	// ProblemNotAnnotated is a resource held by the controller for a pod without the annotation
+	ProblemNotAnnotated = "held by the controller but the pod is not annotated"
+	// ProblemPodCompleted is a resource still held by the controller for a pod that completed
+	ProblemPodCompleted = "held by the controller for a completed pod"
+)
+
+// Mismatch is a difference between the annotation of a pod and the state of the controller
+type Mismatch struct {
+	// Namespace and Name are empty if the pod doesn't exist
+	Namespace string
+	Name      string
+	UID       string
+	Node      string
+	Resource  string
+	// Annotation is the resource in the annotation of the pod
+	Annotation string
+	// Controller is the resource held by the controller for the pod
+	Controller string
+	Problem    string
+}
+
+// CrossCheck returns the differences between the branch ENIs and IPv4 addresses annotated on the pods and the ones
+// held by the controller. Only the pods on the nodes with a trunk ENI or a pool in the resources are checked.
+func CrossCheck(pods []corev1.Pod, resources *Resources) []Mismatch {
+	podsByUID := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByUID[string(pods[i].UID)] = &pods[i]
+	}
+
+	var mismatches []Mismatch
+	for i := range pods {
+		mismatches = append(mismatches, checkPod(&pods[i], resources)...)
+	}
+
+	// The resources held by the controller for the pods that don't have them annotated
+	for nodeName, trunkENI := range resources.Trunks {
+		for uid, branchENIs := range trunkENI.PodToBranchENI {
+			mismatches = appendUnannotated(mismatches, podsByUID[uid], uid, nodeName, config.ResourceNamePodENI,
+				branchENIIDs(branchENIs))
+		}
+	}
+	for _, pools := range resources.Pools {
+		for nodeName, resourcePool := range pools {
+			for uid, used := range resourcePool.UsedResources {
+				mismatches = appendUnannotated(mismatches, podsByUID[uid], uid, nodeName,
+					config.ResourceNameIPAddress, used.ResourceID)
+			}
+		}
+	}
+
+	sort.Slice(mismatches, func(a, b int) bool {
+		if mismatches[a].Node != mismatches[b].Node {
+			return mismatches[a].Node < mismatches[b].Node
+		}
+		if mismatches[a].UID != mismatches[b].UID {
+			return mismatches[a].UID < mismatches[b].UID
+		}
+		return mismatches[a].Resource < mismatches[b].Resource
+	})
+	return mismatches
+}
+
+// checkPod returns the differences between the annotations of the pod and the resources held by the controller
+func checkPod(pod *corev1.Pod, resources *Resources) []Mismatch {
+	if isCompleted(pod) {
+		return nil
+	}
+	nodeName := pod.Spec.NodeName
+	uid := string(pod.UID)
+	mismatch := Mismatch{Namespace: pod.Namespace, Name: pod.Name, UID: uid, Node: nodeName}
+
+	var mismatches []Mismatch
+	if trunkENI, found := resources.Trunks[nodeName]; found {
+		if annotation, annotated := pod.Annotations[config.ResourceNamePodENI]; annotated {
+			mismatch := mismatch
+			mismatch.Resource = config.ResourceNamePodENI
+			mismatch.Annotation = annotation
+
+			var annotated []trunk.ENIDetails
+			if err := json.Unmarshal([]byte(annotation), &annotated); err != nil {
+				mismatch.Problem = ProblemInvalidAnnotation
+				mismatches = append(mismatches, mismatch)
+			} else if held, found := trunkENI.PodToBranchENI[uid]; !found {
+				mismatch.Annotation = branchENIIDs(annotated)
+				mismatch.Problem = ProblemMissingFromController
+				mismatches = append(mismatches, mismatch)
+			} else if branchENIIDs(annotated) != branchENIIDs(held) {
+				mismatch.Annotation = branchENIIDs(annotated)
+				mismatch.Controller = branchENIIDs(held)
+				mismatch.Problem = ProblemDifferent
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+
+	if annotation, annotated := pod.Annotations[config.ResourceNameIPAddress]; annotated {
+		var held *pool.Resource
+		var hasPool bool
+		for _, pools := range resources.Pools {
+			resourcePool, found := pools[nodeName]
+			if !found {
+				continue
+			}
+			hasPool = true
+			if used, found := resourcePool.UsedResources[uid]; found {
+				held = &used
+			}
+		}
+		if hasPool {
+			mismatch.Resource = config.ResourceNameIPAddress
+			mismatch.Annotation = annotation
+			if held == nil {
+				mismatch.Problem = ProblemMissingFromController
+				mismatches = append(mismatches, mismatch)
+			} else if held.ResourceID != annotation {
+				mismatch.Controller = held.ResourceID
+				mismatch.Problem = ProblemDifferent
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+	return mismatches
+}
+
+// appendUnannotated appends a mismatch if the pod holding the resource in the controller doesn't exist, completed
+// or isn't annotated
+func appendUnannotated(mismatches []Mismatch, pod *corev1.Pod, uid string, nodeName string, resourceName string,
+	held string) []Mismatch {
+	mismatch := Mismatch{UID: uid, Node: nodeName, Resource: resourceName, Controller: held}
+	switch {
+	case pod == nil:
+		mismatch.Problem = ProblemPodNotFound
+	case isCompleted(pod):
+		mismatch.Namespace, mismatch.Name = pod.Namespace, pod.Name
+		mismatch.Problem = ProblemPodCompleted
+	default:
+		if _, annotated := pod.Annotations[resourceName]; annotated {
+			return mismatches
+		}
+		mismatch.Namespace, mismatch.Name = pod.Namespace, pod.Name
+		mismatch.Problem = ProblemNotAnnotated
+	}
+	return append(mismatches, mismatch)
+}
+
+// branchENIIDs returns the sorted IDs of the branch ENIs joined with commas
+func branchENIIDs(branchENIs []trunk.ENIDetails) string {
+	ids := make([]string, 0, len(branchENIs))
+	for _, branchENI := range branchENIs {
+		ids = append(ids, branchENI.ID)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// isCompleted returns true if all the containers of the pod terminated and won't restart
+func isCompleted(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
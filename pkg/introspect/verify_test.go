@@ -0,0 +1,96 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package introspect
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func getPod(name string, uid string, annotations map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(uid), Annotations: annotations},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestCrossCheck_NoMismatch(t *testing.T) {
+	resources := &Resources{Trunks: trunks, Pools: map[string]map[string]pool.IntrospectResponse{
+		config.ResourceNameIPAddress: pools}}
+	pods := []corev1.Pod{getPod("pod", podUID, map[string]string{
+		config.ResourceNamePodENI:    `[{"eniId":"eni-00000000000000002","vlanId":1}]`,
+		config.ResourceNameIPAddress: "192.168.1.20",
+	})}
+
+	assert.Empty(t, CrossCheck(pods, resources))
+}
+
+func TestCrossCheck_Mismatches(t *testing.T) {
+	resources := &Resources{
+		Trunks: map[string]trunk.IntrospectResponse{nodeName: {PodToBranchENI: map[string][]trunk.ENIDetails{
+			"uid-1": {{ID: "eni-1"}},
+			"uid-2": {{ID: "eni-2"}},
+			"uid-3": {{ID: "eni-3"}},
+			"uid-4": {{ID: "eni-4"}},
+		}}},
+		Pools: map[string]map[string]pool.IntrospectResponse{config.ResourceNameIPAddress: {nodeName: {
+			UsedResources: map[string]pool.Resource{"uid-5": {ResourceID: "192.168.1.1"}},
+		}}},
+	}
+	completed := getPod("completed", "uid-3", nil)
+	completed.Status.Phase = corev1.PodSucceeded
+	pods := []corev1.Pod{
+		// Different branch ENI
+		getPod("different", "uid-1", map[string]string{config.ResourceNamePodENI: `[{"eniId":"eni-9"}]`}),
+		// Not annotated
+		getPod("not-annotated", "uid-2", nil),
+		completed,
+		// Annotated but not in the pool, and invalid branch ENI annotation
+		getPod("missing", "uid-6", map[string]string{
+			config.ResourceNameIPAddress: "192.168.1.2",
+			config.ResourceNamePodENI:    "invalid",
+		}),
+		// Different IP
+		getPod("different-ip", "uid-5", map[string]string{config.ResourceNameIPAddress: "192.168.1.3"}),
+		// The node isn't managed by the controller
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-node", UID: "uid-7",
+				Annotations: map[string]string{config.ResourceNameIPAddress: "192.168.1.4"}},
+			Spec: corev1.PodSpec{NodeName: "other-node"},
+		},
+	}
+
+	var problems []string
+	for _, mismatch := range CrossCheck(pods, resources) {
+		problems = append(problems, mismatch.UID+" "+mismatch.Problem)
+	}
+	assert.Equal(t, []string{
+		"uid-1 " + ProblemDifferent,
+		"uid-2 " + ProblemNotAnnotated,
+		"uid-3 " + ProblemPodCompleted,
+		"uid-4 " + ProblemPodNotFound,
+		"uid-5 " + ProblemDifferent,
+		"uid-6 " + ProblemMissingFromController,
+		"uid-6 " + ProblemInvalidAnnotation,
+	}, problems)
+}
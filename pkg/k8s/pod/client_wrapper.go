@@ -15,10 +15,12 @@ package pod
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -34,6 +36,11 @@ const (
 	NodeNameSpec = "nodeName"
 )
 
+const (
+	// NamespaceSpec indexes the pods of the data store by namespace
+	NamespaceSpec = "namespace"
+)
+
 var (
 	prometheusRegistered = false
 
@@ -66,14 +73,47 @@ var (
 			Help: "The number of requests that failed to get the pod directly from API Server",
 		},
 	)
+
+	setPodConditionCallCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "set_pod_condition_call_count",
+			Help: "The number of requests to set a condition on the pod status",
+		},
+		[]string{"condition_type", "status"},
+	)
+
+	setPodConditionErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "set_pod_condition_err_count",
+			Help: "The number of requests that failed to set a condition on the pod status",
+		},
+		[]string{"condition_type", "status"},
+	)
+
+	evictPodCallCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "evict_pod_call_count",
+			Help: "The number of requests to evict a pod",
+		},
+	)
+
+	evictPodErrCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "evict_pod_err_count",
+			Help: "The number of requests that failed to evict a pod",
+		},
+	)
 )
 
 type PodClientAPIWrapper interface {
 	GetPod(namespace string, name string) (*v1.Pod, error)
 	ListPods(nodeName string) (*v1.PodList, error)
+	ListNamespacePods(namespace string) (*v1.PodList, error)
 	AnnotatePod(podNamespace string, podName string, uid types.UID, key string, val string) error
 	GetPodFromAPIServer(ctx context.Context, namespace string, name string) (*v1.Pod, error)
 	GetRunningPodsOnNode(nodeName string) ([]v1.Pod, error)
+	SetPodCondition(pod *v1.Pod, condition v1.PodCondition) error
+	EvictPod(pod *v1.Pod) error
 }
 
 type podClientAPIWrapper struct {
@@ -94,7 +134,11 @@ func prometheusRegister() {
 		annotatePodRequestCallCount,
 		annotatePodRequestErrCount,
 		getPodFromAPIServeCallCount,
-		getPodFromAPIServeErrCount)
+		getPodFromAPIServeErrCount,
+		setPodConditionCallCount,
+		setPodConditionErrCount,
+		evictPodCallCount,
+		evictPodErrCount)
 
 	prometheusRegistered = true
 }
@@ -144,6 +188,20 @@ func (p *podClientAPIWrapper) ListPods(nodeName string) (*v1.PodList, error) {
 	return podList, nil
 }
 
+// ListNamespacePods lists the pods of the namespace from the data store. The pods are stripped down, only the pods
+// with a branch ENI keep their labels
+func (p *podClientAPIWrapper) ListNamespacePods(namespace string) (*v1.PodList, error) {
+	items, err := p.dataStore.ByIndex(NamespaceSpec, namespace)
+	if err != nil {
+		return nil, err
+	}
+	podList := &v1.PodList{}
+	for _, item := range items {
+		podList.Items = append(podList.Items, *item.(*v1.Pod))
+	}
+	return podList, nil
+}
+
 // AnnotatePod annotates the pod with the provided key and value
 func (p *podClientAPIWrapper) AnnotatePod(podNamespace string, podName string, uid types.UID,
 	key string, val string) error {
@@ -205,3 +263,70 @@ func (p *podClientAPIWrapper) GetPodFromAPIServer(ctx context.Context, namespace
 
 	return pod, err
 }
+
+// SetPodCondition sets the condition on the status of the pod. The pod is not patched if it already has the condition
+// with the same status, reason and message, and the transition time is only updated when the status changes.
+func (p *podClientAPIWrapper) SetPodCondition(pod *v1.Pod, condition v1.PodCondition) error {
+	// Compare with the latest copy of the pod from cache, the pod passed by the caller could be stale
+	if cachedPod, err := p.GetPod(pod.Namespace, pod.Name); err == nil && cachedPod.UID == pod.UID {
+		pod = cachedPod
+	}
+
+	condition.LastTransitionTime = metav1.Now()
+	for _, existing := range pod.Status.Conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason &&
+			existing.Message == condition.Message {
+			return nil
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		break
+	}
+
+	setPodConditionCallCount.WithLabelValues(string(condition.Type), string(condition.Status)).Inc()
+
+	// The UID makes the patch fail if the Pod was re-created with the same namespace/name. The conditions are
+	// merged by type, so the conditions set by the kubelet and other controllers are left untouched.
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"uid": pod.UID,
+		},
+		"status": map[string]interface{}{
+			"conditions": []v1.PodCondition{condition},
+		},
+	})
+	if err == nil {
+		_, err = p.coreV1.Pods(pod.Namespace).Patch(context.Background(), pod.Name, types.StrategicMergePatchType,
+			patch, metav1.PatchOptions{}, "status")
+	}
+	if err != nil {
+		setPodConditionErrCount.WithLabelValues(string(condition.Type), string(condition.Status)).Inc()
+	}
+
+	return err
+}
+
+// EvictPod evicts the pod using the eviction API, so the pod disruption budgets of the pod are respected. The
+// eviction fails if the pod was re-created with the same namespace/name.
+func (p *podClientAPIWrapper) EvictPod(pod *v1.Pod) error {
+	evictPodCallCount.Inc()
+
+	uid := pod.UID
+	err := p.coreV1.Pods(pod.Namespace).EvictV1(context.Background(), &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &uid},
+		},
+	})
+	if err != nil {
+		evictPodErrCount.Inc()
+	}
+	return err
+}
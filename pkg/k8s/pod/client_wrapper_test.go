@@ -19,11 +19,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	fakeClientSet "k8s.io/client-go/kubernetes/fake"
+	fakeCoreV1 "k8s.io/client-go/kubernetes/typed/core/v1/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -103,6 +106,9 @@ func getFakeDataStore() cache.Indexer {
 	indexer[NodeNameSpec] = func(obj interface{}) (strings []string, err error) {
 		return []string{obj.(*v1.Pod).Spec.NodeName}, nil
 	}
+	indexer[NamespaceSpec] = func(obj interface{}) (strings []string, err error) {
+		return []string{obj.(*v1.Pod).Namespace}, nil
+	}
 	store := cache.NewIndexer(func(obj interface{}) (s string, err error) {
 		pod := obj.(*v1.Pod)
 		return types.NamespacedName{
@@ -154,6 +160,19 @@ func TestPodAPI_ListPods(t *testing.T) {
 	assert.ElementsMatch(t, podList.Items, []v1.Pod{*runningPod, *completedPod, *failedPod})
 }
 
+// TestPodAPI_ListNamespacePods tests that list namespace pods returns the pods of the namespace only
+func TestPodAPI_ListNamespacePods(t *testing.T) {
+	podAPI, _ := getMockPodAPIWithClient()
+
+	podList, err := podAPI.ListNamespacePods(podNamespace)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, podList.Items, []v1.Pod{*runningPod, *completedPod, *failedPod})
+
+	podList, err = podAPI.ListNamespacePods(podNamespace + "-other")
+	assert.NoError(t, err)
+	assert.Empty(t, podList.Items)
+}
+
 func TestPodAPI_AnnotatePod_UID_Changed(t *testing.T) {
 	podAPI, _ := getMockPodAPIWithClient()
 
@@ -205,3 +224,74 @@ func TestPodAPI_GetPod_NotExists(t *testing.T) {
 	_, err := podAPI.GetPod(podNamespace, "not-exist")
 	assert.NotNil(t, err)
 }
+
+// TestPodAPI_SetPodCondition tests the condition is added to the pod status and the transition time is only updated
+// when the status of the condition changes
+func TestPodAPI_SetPodCondition(t *testing.T) {
+	podAPI, _ := getMockPodAPIWithClient()
+	clientSet := podAPI.(*podClientAPIWrapper).coreV1
+
+	condition := v1.PodCondition{
+		Type:    "vpc.amazonaws.com/NetworkingReady",
+		Status:  v1.ConditionFalse,
+		Reason:  "ResourceAllocationFailed",
+		Message: "warm pool is empty",
+	}
+	err := podAPI.SetPodCondition(runningPod, condition)
+	assert.NoError(t, err)
+
+	updatedPod, err := clientSet.Pods(podNamespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updatedPod.Status.Conditions, 1)
+	assert.Equal(t, condition.Status, updatedPod.Status.Conditions[0].Status)
+	assert.Equal(t, condition.Message, updatedPod.Status.Conditions[0].Message)
+	assert.False(t, updatedPod.Status.Conditions[0].LastTransitionTime.IsZero())
+
+	condition.Status = v1.ConditionTrue
+	condition.Reason = "ResourceAllocated"
+	condition.Message = ""
+	err = podAPI.SetPodCondition(updatedPod, condition)
+	assert.NoError(t, err)
+
+	updatedPod, err = clientSet.Pods(podNamespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updatedPod.Status.Conditions, 1)
+	assert.Equal(t, v1.ConditionTrue, updatedPod.Status.Conditions[0].Status)
+	assert.Equal(t, "ResourceAllocated", updatedPod.Status.Conditions[0].Reason)
+}
+
+// TestPodAPI_SetPodCondition_Unchanged tests the pod is not patched if it already has the condition
+func TestPodAPI_SetPodCondition_Unchanged(t *testing.T) {
+	podAPI, _ := getMockPodAPIWithClient()
+
+	condition := v1.PodCondition{
+		Type:   "vpc.amazonaws.com/NetworkingReady",
+		Status: v1.ConditionTrue,
+		Reason: "ResourceAllocated",
+	}
+	// The pod doesn't exist in the API Server, so the patch would fail
+	pod := runningPod.DeepCopy()
+	pod.Name = "not-in-api-server"
+	pod.Status.Conditions = []v1.PodCondition{condition}
+
+	assert.NoError(t, podAPI.SetPodCondition(pod, condition))
+
+	pod.Status.Conditions = nil
+	assert.Error(t, podAPI.SetPodCondition(pod, condition))
+}
+
+// TestPodAPI_EvictPod tests the pod is evicted with the eviction API
+func TestPodAPI_EvictPod(t *testing.T) {
+	podAPI, _ := getMockPodAPIWithClient()
+	clientSet := podAPI.(*podClientAPIWrapper).coreV1.(*fakeCoreV1.FakeCoreV1)
+
+	assert.NoError(t, podAPI.EvictPod(runningPod))
+
+	actions := clientSet.Actions()
+	assert.Len(t, actions, 1)
+	assert.True(t, actions[0].Matches("create", "pods"))
+	assert.Equal(t, "eviction", actions[0].GetSubresource())
+	eviction := actions[0].(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+	assert.Equal(t, podName, eviction.Name)
+	assert.Equal(t, runningPod.UID, *eviction.DeleteOptions.Preconditions.UID)
+}
@@ -73,12 +73,15 @@ func (c *PodConverter) ResourceType() runtime.Object {
 	return c.K8sResourceType
 }
 
-// NodeNameIndexer returns indexer to index in the data store using node name
+// NodeNameIndexer returns indexer to index in the data store using node name, and using namespace
 func NodeNameIndexer() cache.Indexers {
 	indexer := map[string]cache.IndexFunc{}
 	indexer[NodeNameSpec] = func(obj interface{}) (strings []string, err error) {
 		return []string{obj.(*v1.Pod).Spec.NodeName}, nil
 	}
+	indexer[NamespaceSpec] = func(obj interface{}) (strings []string, err error) {
+		return []string{obj.(*v1.Pod).Namespace}, nil
+	}
 	return indexer
 }
 
@@ -101,18 +104,42 @@ func (c *PodConverter) StripDownPod(pod *v1.Pod) *v1.Pod {
 			UID:               pod.UID,
 			DeletionTimestamp: pod.DeletionTimestamp,
 			Annotations:       getVPCControllerAnnotations(pod.Annotations),
+			Labels:            getBranchENIPodLabels(pod),
 		},
 		Spec: v1.PodSpec{
 			Containers:         getContainersWithVPCLimits(pod.Spec.Containers),
+			InitContainers:     getContainersWithVPCLimits(pod.Spec.InitContainers),
 			ServiceAccountName: pod.Spec.ServiceAccountName,
 			NodeName:           pod.Spec.NodeName,
 		},
 		Status: v1.PodStatus{
-			Phase: pod.Status.Phase,
+			Phase:      pod.Status.Phase,
+			Conditions: getVPCControllerConditions(pod.Status.Conditions),
 		},
 	}
 }
 
+// getVPCControllerConditions returns only the conditions that were set by VPC
+// Resource controller
+func getVPCControllerConditions(conditions []v1.PodCondition) []v1.PodCondition {
+	var strippedDownConditions []v1.PodCondition
+	for _, condition := range conditions {
+		if strings.HasPrefix(string(condition.Type), config.VPCResourcePrefix) {
+			strippedDownConditions = append(strippedDownConditions, condition)
+		}
+	}
+	return strippedDownConditions
+}
+
+// getBranchENIPodLabels returns the labels of the pods with a branch ENI, which are matched against the selectors of
+// the SecurityGroupPolicy when the policy is updated
+func getBranchENIPodLabels(pod *v1.Pod) map[string]string {
+	if _, ok := pod.Annotations[config.ResourceNamePodENI]; !ok {
+		return nil
+	}
+	return pod.Labels
+}
+
 // getVPCControllerAnnotations returns only the annotations that were marked by VPC
 // Resource controller
 func getVPCControllerAnnotations(annotations map[string]string) map[string]string {
@@ -126,7 +153,7 @@ func getVPCControllerAnnotations(annotations map[string]string) map[string]strin
 }
 
 // getContainersWithVPCLimits returns only the container limits for vpc controller
-// resources
+// resources, along with the restart policy identifying the sidecar init containers
 func getContainersWithVPCLimits(containers []v1.Container) []v1.Container {
 	var strippedContainers []v1.Container
 	for _, container := range containers {
@@ -135,6 +162,7 @@ func getContainersWithVPCLimits(containers []v1.Container) []v1.Container {
 			Resources: v1.ResourceRequirements{
 				Requests: v1.ResourceList{},
 			},
+			RestartPolicy: container.RestartPolicy,
 		}
 		for limitKey, limitVal := range container.Resources.Requests {
 			if strings.HasPrefix(limitKey.String(), config.VPCResourcePrefix) {
@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	eventsv1 "k8s.io/api/events/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -57,12 +58,30 @@ var (
 		},
 		[]string{"resource_name"},
 	)
+
+	setNodeConditionRequestCallCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "set_node_condition_request_call_count",
+			Help: "The number of request to set a condition on the CNINode and the Node",
+		},
+		[]string{"condition_type", "status"},
+	)
+
+	setNodeConditionRequestErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "set_node_condition_request_err_count",
+			Help: "The number of request that failed to set a condition on the CNINode and the Node",
+		},
+		[]string{"condition_type", "status"},
+	)
 )
 
 func prometheusRegister() {
 	metrics.Registry.MustRegister(
 		advertiseResourceRequestErrCount,
-		advertiseResourceRequestCallCount)
+		advertiseResourceRequestCallCount,
+		setNodeConditionRequestCallCount,
+		setNodeConditionRequestErrCount)
 
 	prometheusRegistered = true
 }
@@ -76,11 +95,13 @@ type K8sWrapper interface {
 	GetDeployment(namespace string, name string) (*appv1.Deployment, error)
 	BroadcastEvent(obj runtime.Object, reason string, message string, eventType string)
 	GetConfigMap(configMapName string, configMapNamespace string) (*v1.ConfigMap, error)
+	GetControllerConfigMap() (*v1.ConfigMap, error)
 	ListNodes() (*v1.NodeList, error)
 	AddLabelToManageNode(node *v1.Node, labelKey string, labelValue string) (bool, error)
 	ListEvents(ops []client.ListOption) (*eventsv1.EventList, error)
 	GetCNINode(namespacedName types.NamespacedName) (*rcv1alpha1.CNINode, error)
 	CreateCNINode(node *v1.Node) error
+	SetNodeCondition(nodeName string, condition metav1.Condition) error
 }
 
 // k8sWrapper is the wrapper object with the client
@@ -200,6 +221,29 @@ func (k *k8sWrapper) GetConfigMap(configMapName string, configMapNamespace strin
 	return configMap, err
 }
 
+// GetControllerConfigMap returns the amazon-vpc-cni ConfigMap with the valid fields of the VPCResourceControllerConfig
+// applied. The ConfigMap is returned as is if the VPCResourceControllerConfig doesn't exist, and an empty ConfigMap
+// with the fields applied is returned if only the VPCResourceControllerConfig exists.
+func (k *k8sWrapper) GetControllerConfigMap() (*v1.ConfigMap, error) {
+	vpcCniConfigMap, err := k.GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace)
+
+	controllerConfig := &rcv1alpha1.VPCResourceControllerConfig{}
+	if configErr := k.cacheClient.Get(k.context, types.NamespacedName{
+		Name: config.ControllerConfigName,
+	}, controllerConfig); configErr != nil {
+		return vpcCniConfigMap, err
+	}
+
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		vpcCniConfigMap = &v1.ConfigMap{}
+	}
+	spec, _ := config.ValidateControllerConfig(controllerConfig.Spec)
+	return config.ApplyControllerConfig(vpcCniConfigMap, spec), nil
+}
+
 func (k *k8sWrapper) ListNodes() (*v1.NodeList, error) {
 	nodeList := &v1.NodeList{}
 	err := k.cacheClient.List(k.context, nodeList)
@@ -254,3 +298,78 @@ func (k *k8sWrapper) CreateCNINode(node *v1.Node) error {
 	// TODO: need think more if we should retry on "already exists" error.
 	return client.IgnoreAlreadyExists(k.cacheClient.Create(k.context, cniNode))
 }
+
+// SetNodeCondition sets the condition on the status of the CNINode and mirrors it to the Node with the type prefixed
+// with vpc.amazonaws.com/. The objects are only patched if the status, reason or message of the condition changed, and
+// the transition time is only updated when the status changes.
+func (k *k8sWrapper) SetNodeCondition(nodeName string, condition metav1.Condition) error {
+	var changed bool
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cniNode := &rcv1alpha1.CNINode{}
+		if err := k.cacheClient.Get(k.context, types.NamespacedName{Name: nodeName}, cniNode); err != nil {
+			// The CNINode is created asynchronously, the condition is only set on the Node until it exists
+			return client.IgnoreNotFound(err)
+		}
+		newCNINode := cniNode.DeepCopy()
+		if !meta.SetStatusCondition(&newCNINode.Status.Conditions, condition) {
+			return nil
+		}
+		changed = true
+		return k.cacheClient.Status().Patch(k.context, newCNINode,
+			client.MergeFromWithOptions(cniNode, client.MergeFromWithOptimisticLock{}))
+	})
+	if err == nil {
+		err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			node := &v1.Node{}
+			if err := k.cacheClient.Get(k.context, types.NamespacedName{Name: nodeName}, node); err != nil {
+				return err
+			}
+			newNode := node.DeepCopy()
+			if !setNodeCondition(newNode, condition) {
+				return nil
+			}
+			changed = true
+			// The strategic merge patch only updates the condition of the controller, leaving the conditions of the
+			// kubelet untouched
+			return k.cacheClient.Status().Patch(k.context, newNode, client.StrategicMergeFrom(node))
+		})
+	}
+
+	if changed {
+		setNodeConditionRequestCallCount.WithLabelValues(condition.Type, string(condition.Status)).Inc()
+	}
+	if err != nil {
+		setNodeConditionRequestErrCount.WithLabelValues(condition.Type, string(condition.Status)).Inc()
+	}
+
+	return err
+}
+
+// setNodeCondition sets the condition prefixed with vpc.amazonaws.com/ on the Node and returns true if the Node
+// changed
+func setNodeCondition(node *v1.Node, condition metav1.Condition) bool {
+	nodeCondition := v1.NodeCondition{
+		Type:               v1.NodeConditionType(config.VPCResourcePrefix + condition.Type),
+		Status:             v1.ConditionStatus(condition.Status),
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+		LastHeartbeatTime:  metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+	}
+	for i, existing := range node.Status.Conditions {
+		if existing.Type != nodeCondition.Type {
+			continue
+		}
+		if existing.Status == nodeCondition.Status && existing.Reason == nodeCondition.Reason &&
+			existing.Message == nodeCondition.Message {
+			return false
+		}
+		if existing.Status == nodeCondition.Status {
+			nodeCondition.LastTransitionTime = existing.LastTransitionTime
+		}
+		node.Status.Conditions[i] = nodeCondition
+		return true
+	}
+	node.Status.Conditions = append(node.Status.Conditions, nodeCondition)
+	return true
+}
@@ -215,3 +215,59 @@ func TestK8sWrapper_CreateCNINode_NoError(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, mockNode.Name, cniNode.Name)
 }
+
+// TestK8sWrapper_SetNodeCondition tests the condition is set on the CNINode and mirrored to the Node, and the transition
+// time is kept when only the message changes
+func TestK8sWrapper_SetNodeCondition(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	k8sClient := fakeClient.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(mockNode, mockCNINode).
+		WithStatusSubresource(&v1.Node{}, &v1alpha1.CNINode{}).Build()
+	wrapper := &k8sWrapper{cacheClient: k8sClient, context: context.Background()}
+
+	condition := metav1.Condition{
+		Type:    v1alpha1.CNINodeConditionSubnetExhausted,
+		Status:  metav1.ConditionTrue,
+		Reason:  "InsufficientCidrBlocks",
+		Message: "not enough free cidr blocks",
+	}
+	assert.NoError(t, wrapper.SetNodeCondition(nodeName, condition))
+
+	cniNode := &v1alpha1.CNINode{}
+	assert.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: nodeName}, cniNode))
+	assert.Len(t, cniNode.Status.Conditions, 1)
+	assert.Equal(t, metav1.ConditionTrue, cniNode.Status.Conditions[0].Status)
+	assert.Equal(t, condition.Reason, cniNode.Status.Conditions[0].Reason)
+
+	node := &v1.Node{}
+	assert.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: nodeName}, node))
+	assert.Len(t, node.Status.Conditions, 1)
+	assert.Equal(t, v1.NodeConditionType("vpc.amazonaws.com/SubnetExhausted"), node.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionTrue, node.Status.Conditions[0].Status)
+	transitionTime := node.Status.Conditions[0].LastTransitionTime
+
+	condition.Message = "still not enough free cidr blocks"
+	assert.NoError(t, wrapper.SetNodeCondition(nodeName, condition))
+
+	assert.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: nodeName}, node))
+	assert.Equal(t, condition.Message, node.Status.Conditions[0].Message)
+	assert.Equal(t, transitionTime, node.Status.Conditions[0].LastTransitionTime)
+}
+
+// TestK8sWrapper_SetNodeCondition_NoCNINode tests the condition is set on the Node when the CNINode doesn't exist yet
+func TestK8sWrapper_SetNodeCondition_NoCNINode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	wrapper, k8sClient, _ := getMockK8sWrapperWithClient(ctrl, []runtime.Object{mockNode})
+
+	assert.NoError(t, wrapper.SetNodeCondition(nodeName, metav1.Condition{
+		Type:   v1alpha1.CNINodeConditionTrunkReady,
+		Status: metav1.ConditionTrue,
+		Reason: "NodeTrunkInitiated",
+	}))
+
+	node := &v1.Node{}
+	assert.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: nodeName}, node))
+	assert.Len(t, node.Status.Conditions, 1)
+	assert.Equal(t, v1.NodeConditionType("vpc.amazonaws.com/TrunkReady"), node.Status.Conditions[0].Type)
+}
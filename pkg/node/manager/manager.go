@@ -27,6 +27,7 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	asyncWorker "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
@@ -38,6 +39,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
+	crdv1alpha1 "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 )
@@ -58,6 +60,8 @@ type manager struct {
 	conditions        condition.Conditions
 	controllerVersion string
 	stopHealthCheckAt time.Time
+	// eniConfigValidations is the result of the validation of the ENIConfigs by the ENIConfig controller
+	eniConfigValidations map[string]ENIConfigValidation
 }
 
 // Manager to perform operation on list of managed/un-managed node
@@ -66,8 +70,19 @@ type Manager interface {
 	AddNode(nodeName string) error
 	UpdateNode(nodeName string) error
 	DeleteNode(nodeName string) error
+	ReleaseNode(nodeName string) error
 	CheckNodeForLeakedENIs(nodeName string)
 	SkipHealthCheck() bool
+	SetENIConfigValidation(eniConfigName string, validation *ENIConfigValidation)
+}
+
+// ENIConfigValidation is the result of the validation of a generation of an ENIConfig
+type ENIConfigValidation struct {
+	Generation int64
+	// InvalidReason is the reason the ENIConfig is invalid, empty if the ENIConfig is valid
+	InvalidReason string
+	// Zone is the availability zone of the ENIConfig subnet
+	Zone string
 }
 
 // AsyncOperation is operation on a node after the lock has been released.
@@ -80,6 +95,8 @@ const (
 	Init   = AsyncOperation("Init")
 	Update = AsyncOperation("Update")
 	Delete = AsyncOperation("Delete")
+	// Release stops managing the node without deleting its resources, so another replica can take it over
+	Release = AsyncOperation("Release")
 )
 
 // NodeUpdateStatus represents the status of the Node on Update operation.
@@ -96,6 +113,8 @@ type AsyncOperationJob struct {
 	op       AsyncOperation
 	node     node.Node
 	nodeName string
+	// k8sNode is the node object of the event that submitted the job, if any
+	k8sNode *v1.Node
 }
 
 const pausingHealthCheckDuration = 10 * time.Minute
@@ -132,23 +151,27 @@ func (m *manager) CheckNodeForLeakedENIs(nodeName string) {
 	// Only start a goroutine when need to
 	if time.Now().After(cachedNode.GetNextReconciliationTime()) {
 		go func() {
-			if resourceProvider, found := m.resourceManager.GetResourceProvider(config.ResourceNamePodENI); found {
-				foundLeakedENI := resourceProvider.ReconcileNode(nodeName)
-				if foundLeakedENI {
-					cachedNode.SetReconciliationInterval(node.NodeInitialCleanupInterval)
-				} else {
-					interval := wait.Jitter(cachedNode.GetReconciliationInterval(), 5)
-					if interval > node.MaxNodeReconciliationInterval {
-						interval = node.MaxNodeReconciliationInterval
-					}
-					cachedNode.SetReconciliationInterval(interval)
-				}
-				cachedNode.SetNextReconciliationTime(time.Now().Add(cachedNode.GetReconciliationInterval()))
-				m.Log.Info("reconciled node to cleanup leaked branch ENIs", "NodeName", nodeName, "NextInterval", cachedNode.GetReconciliationInterval(), "NextReconciliationTime", cachedNode.GetNextReconciliationTime())
-			} else {
-				// no SGP provider enabled
+			// Each provider releases the resources of the pods deleted without a delete event, the providers
+			// not tracking the node have nothing to release
+			resourceProviders := m.resourceManager.GetResourceProviders()
+			if len(resourceProviders) == 0 {
 				return
 			}
+			foundLeakedENI := false
+			for _, resourceProvider := range resourceProviders {
+				foundLeakedENI = resourceProvider.ReconcileNode(nodeName) || foundLeakedENI
+			}
+			if foundLeakedENI {
+				cachedNode.SetReconciliationInterval(node.NodeInitialCleanupInterval)
+			} else {
+				interval := wait.Jitter(cachedNode.GetReconciliationInterval(), 5)
+				if interval > node.MaxNodeReconciliationInterval {
+					interval = node.MaxNodeReconciliationInterval
+				}
+				cachedNode.SetReconciliationInterval(interval)
+			}
+			cachedNode.SetNextReconciliationTime(time.Now().Add(cachedNode.GetReconciliationInterval()))
+			m.Log.Info("reconciled node to cleanup leaked pod ENIs", "NodeName", nodeName, "NextInterval", cachedNode.GetReconciliationInterval(), "NextReconciliationTime", cachedNode.GetNextReconciliationTime())
 		}()
 	}
 }
@@ -218,6 +241,7 @@ func (m *manager) AddNode(nodeName string) error {
 		op:       op,
 		node:     newNode,
 		nodeName: nodeName,
+		k8sNode:  k8sNode,
 	})
 	return nil
 }
@@ -303,6 +327,7 @@ func (m *manager) UpdateNode(nodeName string) error {
 		op:       op,
 		node:     cachedNode,
 		nodeName: nodeName,
+		k8sNode:  k8sNode,
 	})
 	return nil
 }
@@ -356,6 +381,70 @@ func (m *manager) DeleteNode(nodeName string) error {
 	return nil
 }
 
+// ReleaseNode removes the node from the cache and releases the resources used by all the resource providers without
+// deleting them, it's called when the node is owned by another replica
+func (m *manager) ReleaseNode(nodeName string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	log := m.Log.WithValues("node name", nodeName, "request", "release")
+
+	cachedNode, nodeFound := m.dataStore[nodeName]
+	if !nodeFound {
+		return nil
+	}
+
+	delete(m.dataStore, nodeName)
+
+	if !cachedNode.IsManaged() {
+		log.V(1).Info("un managed node released from data store")
+		return nil
+	}
+
+	m.worker.SubmitJob(AsyncOperationJob{
+		op:       Release,
+		node:     cachedNode,
+		nodeName: nodeName,
+	})
+
+	log.Info("node released from data store")
+
+	return nil
+}
+
+// SetENIConfigValidation stores the result of the validation of the ENIConfig, the invalid ENIConfigs are not applied
+// to the nodes. A nil validation removes the result of the deleted ENIConfig.
+func (m *manager) SetENIConfigValidation(eniConfigName string, validation *ENIConfigValidation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if validation == nil {
+		delete(m.eniConfigValidations, eniConfigName)
+		return
+	}
+	if m.eniConfigValidations == nil {
+		m.eniConfigValidations = make(map[string]ENIConfigValidation)
+	}
+	m.eniConfigValidations[eniConfigName] = *validation
+}
+
+// getENIConfigInvalidReason returns the reason the ENIConfig can't be applied to the node, empty if the ENIConfig is
+// valid or if its current generation isn't validated yet
+func (m *manager) getENIConfigInvalidReason(eniConfig *crdv1alpha1.ENIConfig, k8sNode *v1.Node) string {
+	validation, found := m.eniConfigValidations[eniConfig.Name]
+	if !found || validation.Generation != eniConfig.Generation {
+		return ""
+	}
+	if validation.InvalidReason != "" {
+		return validation.InvalidReason
+	}
+	if zone, ok := k8sNode.Labels[v1.LabelTopologyZone]; ok && zone != validation.Zone {
+		return fmt.Sprintf("subnet %s is in %s instead of the node availability zone %s", eniConfig.Spec.Subnet,
+			validation.Zone, zone)
+	}
+	return ""
+}
+
 // updateSubnetIfUsingENIConfig updates the subnet id for the node to the subnet specified in ENIConfig if the node is
 // using custom networking
 func (m *manager) updateSubnetIfUsingENIConfig(cachedNode node.Node, k8sNode *v1.Node) error {
@@ -388,6 +477,12 @@ func (m *manager) updateSubnetIfUsingENIConfig(cachedNode node.Node, k8sNode *v1
 			return fmt.Errorf("failed to find the ENIConfig %s: %v", eniConfigName, err)
 		}
 		if eniConfig.Spec.Subnet != "" {
+			if reason := m.getENIConfigInvalidReason(eniConfig, k8sNode); reason != "" {
+				// The node keeps the subnet and the security groups of the last valid ENIConfig
+				m.Log.V(1).Info("not applying the invalid eniconfig to the node", "node", k8sNode.Name,
+					"eniconfig", eniConfigName, "reason", reason)
+				return nil
+			}
 			m.Log.V(1).Info("node is using custom networking, updating the subnet", "node", k8sNode.Name,
 				"subnet", eniConfig.Spec.Subnet)
 			cachedNode.UpdateCustomNetworkingSpecs(eniConfig.Spec.Subnet, eniConfig.Spec.SecurityGroups)
@@ -446,9 +541,12 @@ func (m *manager) performAsyncOperation(job interface{}) (ctrl.Result, error) {
 		asyncJob.op = Update
 		return m.performAsyncOperation(asyncJob)
 	case Update:
+		m.updateNodeState(asyncJob.k8sNode)
 		err = asyncJob.node.UpdateResources(m.resourceManager)
 	case Delete:
 		err = asyncJob.node.DeleteResources(m.resourceManager)
+	case Release:
+		err = asyncJob.node.ReleaseResources(m.resourceManager)
 	default:
 		m.Log.V(1).Info("no operation operation requested",
 			"node", asyncJob.nodeName)
@@ -464,6 +562,18 @@ func (m *manager) performAsyncOperation(job interface{}) (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
+// updateNodeState passes the node object to the resource providers that depend on its state
+func (m *manager) updateNodeState(k8sNode *v1.Node) {
+	if k8sNode == nil {
+		return
+	}
+	for _, resourceProvider := range m.resourceManager.GetResourceProviders() {
+		if handler, ok := resourceProvider.(provider.NodeUpdateHandler); ok {
+			handler.UpdateNodeState(k8sNode)
+		}
+	}
+}
+
 // isSelectedForManagement returns true if the node should be managed by the controller
 func (m *manager) isSelectedForManagement(v1node *v1.Node) (bool, error) {
 	os := GetNodeOS(v1node)
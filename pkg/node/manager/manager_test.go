@@ -25,12 +25,14 @@ import (
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	mock_node "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node"
+	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
 	mock_worker "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 
 	"github.com/golang/mock/gomock"
@@ -107,7 +109,8 @@ func (m *AsyncJobMatcher) Matches(actual interface{}) bool {
 	actualJob := actual.(AsyncOperationJob)
 	return actualJob.op == m.expected.op &&
 		actualJob.nodeName == m.expected.nodeName &&
-		actualJob.node.IsManaged() == m.expected.node.IsManaged()
+		actualJob.node.IsManaged() == m.expected.node.IsManaged() &&
+		(m.expected.k8sNode == nil || actualJob.k8sNode == m.expected.k8sNode)
 }
 
 func (m *AsyncJobMatcher) String() string {
@@ -441,6 +444,7 @@ func Test_UpdateNode_Managed(t *testing.T) {
 		op:       Update,
 		nodeName: nodeName,
 		node:     managedNode,
+		k8sNode:  v1Node,
 	}
 
 	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(v1Node, nil)
@@ -457,6 +461,48 @@ func Test_UpdateNode_Managed(t *testing.T) {
 	assert.True(t, AreNodesEqual(mock.Manager.dataStore[nodeName], managedNode))
 }
 
+// Test_updateSubnetIfUsingENIConfig_Validation tests the ENIConfig is applied to the node only if its current
+// generation isn't invalid and its subnet is in the availability zone of the node
+func Test_updateSubnetIfUsingENIConfig_Validation(t *testing.T) {
+	nodeWithENIConfig := v1Node.DeepCopy()
+	nodeWithENIConfig.Labels[config.CustomNetworkingLabel] = eniConfigName
+	nodeWithENIConfig.Labels[v1.LabelTopologyZone] = "us-west-2a"
+
+	updatedENIConfig := eniConfig.DeepCopy()
+	updatedENIConfig.Generation = 2
+
+	tests := []struct {
+		name       string
+		validation *ENIConfigValidation
+		applied    bool
+	}{
+		{name: "not validated yet", applied: true},
+		{name: "valid", validation: &ENIConfigValidation{Generation: 2, Zone: "us-west-2a"}, applied: true},
+		{name: "invalid", validation: &ENIConfigValidation{Generation: 2, InvalidReason: "subnet not found"}},
+		{name: "invalid previous generation", validation: &ENIConfigValidation{Generation: 1,
+			InvalidReason: "subnet not found"}, applied: true},
+		{name: "subnet in another zone", validation: &ENIConfigValidation{Generation: 2, Zone: "us-west-2b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := NewMock(ctrl, map[string]node.Node{})
+			mock.Manager.SetENIConfigValidation(eniConfigName, test.validation)
+
+			mock.MockK8sAPI.EXPECT().GetENIConfig(eniConfigName).Return(updatedENIConfig, nil)
+			if test.applied {
+				mock.MockNode.EXPECT().UpdateCustomNetworkingSpecs(subnetID, []string{securityGroupId})
+			}
+
+			err := mock.Manager.updateSubnetIfUsingENIConfig(mock.MockNode, nodeWithENIConfig)
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func Test_UpdateNode_UnManaged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -617,6 +663,32 @@ func Test_DeleteNode_UnManaged(t *testing.T) {
 	assert.NotContains(t, mock.Manager.dataStore, nodeName)
 }
 
+// Test_ReleaseNode tests the managed node is removed from the data store and its resources are released
+func Test_ReleaseNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dataStore := map[string]node.Node{v1Node.Name: managedNode}
+
+	mock := NewMock(ctrl, dataStore)
+
+	job := AsyncOperationJob{
+		op:       Release,
+		nodeName: v1Node.Name,
+		node:     managedNode,
+	}
+
+	mock.MockWorker.EXPECT().SubmitJob(gomock.All(NewAsyncOperationMatcher(job)))
+
+	err := mock.Manager.ReleaseNode(v1Node.Name)
+	assert.NoError(t, err)
+	assert.NotContains(t, mock.Manager.dataStore, nodeName)
+
+	// The node is no longer in the data store
+	err = mock.Manager.ReleaseNode(v1Node.Name)
+	assert.NoError(t, err)
+}
+
 func Test_DeleteNode_AlreadyDeleted(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -659,11 +731,88 @@ func Test_performAsyncOperation(t *testing.T) {
 	_, err = mock.Manager.performAsyncOperation(job)
 	assert.NoError(t, err)
 
+	job.op = Release
+	mock.MockNode.EXPECT().ReleaseResources(mock.MockResourceManager).Return(nil)
+	_, err = mock.Manager.performAsyncOperation(job)
+	assert.NoError(t, err)
+
 	job.op = ""
 	_, err = mock.Manager.performAsyncOperation(job)
 	assert.NoError(t, err)
 }
 
+// nodeUpdateHandler is a resource provider recording the node objects it's updated with
+type nodeUpdateHandler struct {
+	*mock_provider.MockResourceProvider
+	nodes []*v1.Node
+}
+
+func (h *nodeUpdateHandler) UpdateNodeState(node *v1.Node) {
+	h.nodes = append(h.nodes, node)
+}
+
+// Test_performAsyncOperation_UpdateNodeState tests the node object of the update is passed to the providers that
+// depend on its state
+func Test_performAsyncOperation_UpdateNodeState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{nodeName: managedNode})
+	handler := &nodeUpdateHandler{MockResourceProvider: mock_provider.NewMockResourceProvider(ctrl)}
+
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(map[string]provider.ResourceProvider{
+		config.ResourceNamePodENI:    handler,
+		config.ResourceNameIPAddress: mock_provider.NewMockResourceProvider(ctrl),
+	})
+	mock.MockNode.EXPECT().UpdateResources(mock.MockResourceManager).Return(nil).Times(2)
+
+	_, err := mock.Manager.performAsyncOperation(AsyncOperationJob{op: Update, node: mock.MockNode,
+		nodeName: nodeName, k8sNode: v1Node})
+	assert.NoError(t, err)
+	assert.Equal(t, []*v1.Node{v1Node}, handler.nodes)
+
+	// The providers are not updated without the node object
+	_, err = mock.Manager.performAsyncOperation(AsyncOperationJob{op: Update, node: mock.MockNode,
+		nodeName: nodeName})
+	assert.NoError(t, err)
+	assert.Len(t, handler.nodes, 1)
+}
+
+// Test_CheckNodeForLeakedENIs tests every resource provider reconciles the node, and the node is reconciled again
+// after the initial interval if any provider found leaked resources
+func Test_CheckNodeForLeakedENIs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, map[string]node.Node{})
+	mock.Manager.dataStore[nodeName] = mock.MockNode
+
+	branchProvider := mock_provider.NewMockResourceProvider(ctrl)
+	elasticIPProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(map[string]provider.ResourceProvider{
+		config.ResourceNamePodENI:    branchProvider,
+		config.ResourceNameElasticIP: elasticIPProvider,
+	})
+	branchProvider.EXPECT().ReconcileNode(nodeName).Return(false)
+	elasticIPProvider.EXPECT().ReconcileNode(nodeName).Return(true)
+
+	done := make(chan struct{})
+	mock.MockNode.EXPECT().IsManaged().Return(true)
+	mock.MockNode.EXPECT().GetNextReconciliationTime().Return(time.Now().Add(-time.Minute))
+	mock.MockNode.EXPECT().SetReconciliationInterval(node.NodeInitialCleanupInterval)
+	mock.MockNode.EXPECT().GetReconciliationInterval().Return(node.NodeInitialCleanupInterval).AnyTimes()
+	mock.MockNode.EXPECT().SetNextReconciliationTime(gomock.Any())
+	mock.MockNode.EXPECT().GetNextReconciliationTime().Do(func() { close(done) }).Return(time.Now())
+
+	mock.Manager.CheckNodeForLeakedENIs(nodeName)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "node not reconciled")
+	}
+}
+
 func Test_performAsyncOperation_fail(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
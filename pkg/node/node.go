@@ -19,13 +19,16 @@ import (
 	"sync"
 	"time"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/go-logr/logr"
 )
@@ -70,12 +73,14 @@ func (e *ErrInitResources) Error() string {
 type Node interface {
 	InitResources(resourceManager resource.ResourceManager) error
 	DeleteResources(resourceManager resource.ResourceManager) error
+	ReleaseResources(resourceManager resource.ResourceManager) error
 	UpdateResources(resourceManager resource.ResourceManager) error
 
 	UpdateCustomNetworkingSpecs(subnetID string, securityGroup []string)
 	IsReady() bool
 	IsManaged() bool
 	IsNitroInstance() bool
+	IsTrunkingCompatible() bool
 
 	GetNodeInstanceID() string
 	HasInstance() bool
@@ -157,31 +162,40 @@ func (n *node) InitResources(resourceManager resource.ResourceManager) error {
 			// Send a node event for users' visibility
 			msg := fmt.Sprintf("The instance type %s is not supported yet by the vpc resource controller", n.instance.Type())
 			utils.SendNodeEventWithNodeName(n.k8sAPI, n.instance.Name(), utils.UnsupportedInstanceTypeReason, msg, v1.EventTypeWarning, n.log)
+			utils.SetNodeCondition(n.k8sAPI, n.instance.Name(), rcv1alpha1.CNINodeConditionInstanceTypeUnsupported,
+				metav1.ConditionTrue, utils.UnsupportedInstanceTypeReason, msg, n.log)
 		}
 		return &ErrInitResources{
 			Message: "failed to load instance details",
 			Err:     err,
 		}
 	}
+	utils.SetNodeCondition(n.k8sAPI, n.instance.Name(), rcv1alpha1.CNINodeConditionInstanceTypeUnsupported,
+		metav1.ConditionFalse, utils.SupportedInstanceTypeReason,
+		fmt.Sprintf("The instance type %s is supported by the vpc resource controller", n.instance.Type()), n.log)
 
-	var initializedProviders []provider.ResourceProvider
+	var initializedProviders []string
 	var errInit error
-	for _, resourceProvider := range resourceManager.GetResourceProviders() {
+	var failedProvider string
+	resourceProviders := resourceManager.GetResourceProviders()
+	for resourceName, resourceProvider := range resourceProviders {
 		// Check if the instance is supported and then initialize the provider
 		if resourceProvider.IsInstanceSupported(n.instance) {
 			errInit = resourceProvider.InitResource(n.instance)
 			if errInit != nil {
+				failedProvider = resourceName
 				break
 			}
-			initializedProviders = append(initializedProviders, resourceProvider)
+			initializedProviders = append(initializedProviders, resourceName)
 		}
 	}
 
 	if errInit != nil {
+		n.setProviderCondition(failedProvider, metav1.ConditionFalse, errInit.Error())
 		// de-init all the providers that were already initialized, we will retry initialization
 		// in next resync period
-		for _, resourceProvider := range initializedProviders {
-			errDeInit := resourceProvider.DeInitResource(n.instance)
+		for _, resourceName := range initializedProviders {
+			errDeInit := resourceProviders[resourceName].DeInitResource(n.instance)
 			n.log.Error(errDeInit, "failed to de initialize resource")
 		}
 		// Return ErrInitResources so that the manager removes the node from the
@@ -192,10 +206,39 @@ func (n *node) InitResources(resourceManager resource.ResourceManager) error {
 		}
 	}
 
+	for _, resourceName := range initializedProviders {
+		n.setProviderCondition(resourceName, metav1.ConditionTrue, "")
+	}
+
 	n.ready = true
 	return errInit
 }
 
+// setProviderCondition sets the condition of the node reporting the state of the resources initialized by the provider,
+// the providers without a condition are ignored
+func (n *node) setProviderCondition(resourceName string, status metav1.ConditionStatus, msg string) {
+	var conditionType, reason string
+	switch resourceName {
+	case config.ResourceNamePodENI:
+		conditionType = rcv1alpha1.CNINodeConditionTrunkReady
+		reason = utils.NodeTrunkInitiatedReason
+		if status != metav1.ConditionTrue {
+			reason = utils.NodeTrunkFailedInitializationReason
+			msg = fmt.Sprintf("The node failed initializing trunk interface: %s", msg)
+		}
+	case config.ResourceNameIPAddress, config.ResourceNameIPAddressFromPrefix:
+		conditionType = rcv1alpha1.CNINodeConditionIPPoolHealthy
+		reason = utils.IPPoolReadyReason
+		if status != metav1.ConditionTrue {
+			reason = utils.IPPoolFailedReason
+			msg = fmt.Sprintf("The node failed initializing the IPv4 pool: %s", msg)
+		}
+	default:
+		return
+	}
+	utils.SetNodeCondition(n.k8sAPI, n.instance.Name(), conditionType, status, reason, msg, n.log)
+}
+
 // DeleteResources performs clean up of all the resource pools and provider of the nodes
 func (n *node) DeleteResources(resourceManager resource.ResourceManager) error {
 	n.lock.Lock()
@@ -222,6 +265,33 @@ func (n *node) DeleteResources(resourceManager resource.ResourceManager) error {
 	return nil
 }
 
+// ReleaseResources stops managing the resources of the node without deleting them, it's used when another replica
+// takes over the node
+func (n *node) ReleaseResources(resourceManager resource.ResourceManager) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	// Mark the node as not ready to prevent processing for any further pod events
+	n.ready = false
+
+	var errRelease []error
+	for _, resourceProvider := range resourceManager.GetResourceProviders() {
+		if resourceProvider.IsInstanceSupported(n.instance) {
+			err := resourceProvider.ReleaseResource(n.instance)
+			if err != nil {
+				errRelease = append(errRelease, err)
+				n.log.Error(err, "failed to release provider")
+			}
+		}
+	}
+
+	if len(errRelease) > 0 {
+		return fmt.Errorf("failed to release the resources %v", errRelease)
+	}
+
+	return nil
+}
+
 // UpdateInstanceCustomSubnet updates current required custom subnet
 func (n *node) UpdateCustomNetworkingSpecs(subnetID string, securityGroup []string) {
 	n.instance.SetNewCustomNetworkingSpec(subnetID, securityGroup)
@@ -265,6 +335,15 @@ func (n *node) IsNitroInstance() bool {
 	return err == nil && isNitroInstance
 }
 
+// IsTrunkingCompatible returns true if the instance type of the node supports attaching a trunk ENI
+func (n *node) IsTrunkingCompatible() bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	limits, found := vpc.GetLimits(n.instance.Type())
+	return found && limits.IsTrunkingCompatible
+}
+
 func (n *node) GetNextReconciliationTime() time.Time {
 	n.lock.RLock()
 	defer n.lock.RUnlock()
@@ -18,11 +18,13 @@ import (
 	"strconv"
 	"testing"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
 	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 
@@ -68,22 +70,44 @@ func NewMock(ctrl *gomock.Controller, mockProviderCount int) Mocks {
 		convertedProvider[strconv.Itoa(i)] = mockProvider
 	}
 	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
 
 	return Mocks{
 		MockProviders:       mockProviders,
 		ResourceProvider:    convertedProvider,
 		MockResourceManager: mock_resource.NewMockResourceManager(ctrl),
 		MockEC2API:          mock_api.NewMockEC2APIHelper(ctrl),
-		MockK8sAPI:          mock_k8s.NewMockK8sWrapper(ctrl),
+		MockK8sAPI:          mockK8sAPI,
 		MockInstance:        mockInstance,
 		NodeWithMock: node{
 			log:      zap.New(zap.UseDevMode(true)).WithName("branch provider"),
 			instance: mockInstance,
+			k8sAPI:   mockK8sAPI,
 			ec2API:   mock_api.NewMockEC2APIHelper(ctrl),
 		},
 	}
 }
 
+// expectNodeCondition expects the condition to be set on the node with the status and reason
+func expectNodeCondition(t *testing.T, mock *Mocks, conditionType string, status metaV1.ConditionStatus,
+	reason string) {
+	mock.MockInstance.EXPECT().Name().Return(nodeName)
+	mock.MockK8sAPI.EXPECT().SetNodeCondition(nodeName, gomock.Any()).DoAndReturn(
+		func(_ string, condition metaV1.Condition) error {
+			assert.Equal(t, conditionType, condition.Type)
+			assert.Equal(t, status, condition.Status)
+			assert.Equal(t, reason, condition.Reason)
+			return nil
+		})
+}
+
+// expectInstanceTypeSupported expects the instance type to be reported as supported by the node condition
+func expectInstanceTypeSupported(t *testing.T, mock *Mocks) {
+	mock.MockInstance.EXPECT().Type().Return("c5.large")
+	expectNodeCondition(t, mock, rcv1alpha1.CNINodeConditionInstanceTypeUnsupported, metaV1.ConditionFalse,
+		utils.SupportedInstanceTypeReason)
+}
+
 // TestNewManagedNode tests the new node is not nil and node is managed but not ready
 func TestNewManagedNode(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -115,6 +139,7 @@ func TestNode_InitResources(t *testing.T) {
 	mock := NewMock(ctrl, 1)
 
 	mock.MockInstance.EXPECT().LoadDetails(mock.MockEC2API).Return(nil)
+	expectInstanceTypeSupported(t, &mock)
 	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
 
 	mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true)
@@ -132,6 +157,7 @@ func TestNode_InitResources_InstanceNotTrunkSupported(t *testing.T) {
 	mock := NewMock(ctrl, 1)
 
 	mock.MockInstance.EXPECT().LoadDetails(mock.MockEC2API).Return(nil)
+	expectInstanceTypeSupported(t, &mock)
 	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
 
 	mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(false)
@@ -159,6 +185,8 @@ func TestNode_InitResources_InstanceNotListed(t *testing.T) {
 	mock.MockInstance.EXPECT().Name().Return(nodeName).Times(1)
 	mock.MockK8sAPI.EXPECT().GetNode(nodeName).Return(node, nil).Times(1)
 	mock.MockK8sAPI.EXPECT().BroadcastEvent(node, "Unsupported", msg, v1.EventTypeWarning).Times(1)
+	expectNodeCondition(t, &mock, rcv1alpha1.CNINodeConditionInstanceTypeUnsupported, metaV1.ConditionTrue,
+		utils.UnsupportedInstanceTypeReason)
 	mock.MockInstance.EXPECT().LoadDetails(mock.MockEC2API).Return(fmt.Errorf("unsupported instance type, couldn't find ENI Limit for instance %s, error: %w", testInstanceType, utils.ErrNotFound))
 
 	mock.NodeWithMock.k8sAPI = mock.MockK8sAPI
@@ -188,6 +216,7 @@ func TestNode_InitResources_SecondProviderInitFails(t *testing.T) {
 	mock := NewMock(ctrl, 2)
 
 	mock.MockInstance.EXPECT().LoadDetails(mock.MockEC2API).Return(nil)
+	expectInstanceTypeSupported(t, &mock)
 	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
 
 	// Second provider throws an error
@@ -204,6 +233,42 @@ func TestNode_InitResources_SecondProviderInitFails(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+// TestNode_InitResources_ProviderConditions tests the trunk and the IPv4 pool conditions are set on the node after the
+// providers are initialized, and set to false when the initialization fails
+func TestNode_InitResources_ProviderConditions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 0)
+	branchProvider := mock_provider.NewMockResourceProvider(ctrl)
+	ipProvider := mock_provider.NewMockResourceProvider(ctrl)
+	providers := map[string]provider.ResourceProvider{
+		config.ResourceNamePodENI:    branchProvider,
+		config.ResourceNameIPAddress: ipProvider,
+	}
+
+	mock.MockInstance.EXPECT().LoadDetails(mock.MockEC2API).Return(nil).Times(2)
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(providers).Times(2)
+	branchProvider.EXPECT().IsInstanceSupported(mock.MockInstance).Return(true).Times(2)
+	ipProvider.EXPECT().IsInstanceSupported(mock.MockInstance).Return(false).AnyTimes()
+
+	expectInstanceTypeSupported(t, &mock)
+	branchProvider.EXPECT().InitResource(mock.MockInstance).Return(nil)
+	expectNodeCondition(t, &mock, rcv1alpha1.CNINodeConditionTrunkReady, metaV1.ConditionTrue,
+		utils.NodeTrunkInitiatedReason)
+
+	err := mock.NodeWithMock.InitResources(mock.MockResourceManager)
+	assert.NoError(t, err)
+
+	expectInstanceTypeSupported(t, &mock)
+	branchProvider.EXPECT().InitResource(mock.MockInstance).Return(mockError)
+	expectNodeCondition(t, &mock, rcv1alpha1.CNINodeConditionTrunkReady, metaV1.ConditionFalse,
+		utils.NodeTrunkFailedInitializationReason)
+
+	err = mock.NodeWithMock.InitResources(mock.MockResourceManager)
+	assert.Error(t, err)
+}
+
 // TestNode_DeleteResources tests that delete resources doesn't return an error when all resources are deleted without error
 func TestNode_DeleteResources(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -245,6 +310,27 @@ func TestNode_DeleteResources_SomeFail(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+// TestNode_ReleaseResources tests that the resources of all the supported providers are released and the node is no
+// longer ready
+func TestNode_ReleaseResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 2)
+	mock.NodeWithMock.ready = true
+
+	mock.MockResourceManager.EXPECT().GetResourceProviders().Return(mock.ResourceProvider)
+
+	mock.MockProviders["0"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(true)
+	mock.MockProviders["0"].EXPECT().ReleaseResource(mock.MockInstance).Return(nil)
+
+	mock.MockProviders["1"].EXPECT().IsInstanceSupported(mock.MockInstance).Return(false)
+
+	err := mock.NodeWithMock.ReleaseResources(mock.MockResourceManager)
+	assert.NoError(t, err)
+	assert.False(t, mock.NodeWithMock.ready)
+}
+
 // TestNode_UpdateResources tests that no error is returned when node is updated successfully
 func TestNode_UpdateResources(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -333,3 +419,28 @@ func TestNode_IsNitroInstance_NonNitro(t *testing.T) {
 
 	assert.False(t, mock.NodeWithMock.IsNitroInstance())
 }
+
+// TestNode_IsTrunkingCompatible tests that the instance types supporting trunk ENI are trunking compatible
+func TestNode_IsTrunkingCompatible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 1)
+	mock.MockInstance.EXPECT().Type().Return("c5.large")
+
+	assert.True(t, mock.NodeWithMock.IsTrunkingCompatible())
+}
+
+// TestNode_IsTrunkingCompatible_NotSupported tests that the instance types not supporting trunk ENI, or not listed,
+// are not trunking compatible
+func TestNode_IsTrunkingCompatible_NotSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := NewMock(ctrl, 1)
+	mock.MockInstance.EXPECT().Type().Return(nonNitroInstanceType)
+	assert.False(t, mock.NodeWithMock.IsTrunkingCompatible())
+
+	mock.MockInstance.EXPECT().Type().Return("unknown.large")
+	assert.False(t, mock.NodeWithMock.IsTrunkingCompatible())
+}
@@ -111,6 +111,23 @@ type IntrospectSummaryResponse struct {
 	CoolingResourcesCount int
 }
 
+// FilterState returns the resources of the pool in the state, returns false if there are none
+func (i IntrospectResponse) FilterState(state string) (interface{}, bool) {
+	filtered := IntrospectResponse{}
+	switch state {
+	case config.IntrospectStateUsed:
+		filtered.UsedResources = i.UsedResources
+		return filtered, len(filtered.UsedResources) > 0
+	case config.IntrospectStateWarm:
+		filtered.WarmResources = i.WarmResources
+		return filtered, len(filtered.WarmResources) > 0
+	case config.IntrospectStateCooling:
+		filtered.CoolingResources = i.CoolingResources
+		return filtered, len(filtered.CoolingResources) > 0
+	}
+	return nil, false
+}
+
 func NewResourcePool(log logr.Logger, poolConfig *config.WarmPoolConfig, usedResources map[string]Resource,
 	warmResources map[string][]Resource, nodeName string, capacity int, isPDPool bool) Pool {
 	pool := &pool{
@@ -23,7 +23,7 @@ import (
 // GetWinWarmPoolConfig retrieves Windows warmpool configuration from ConfigMap, falls back to using default values on failure
 func GetWinWarmPoolConfig(log logr.Logger, w api.Wrapper, isPDEnabled bool) *config.WarmPoolConfig {
 	var resourceConfig map[string]config.ResourceConfig
-	vpcCniConfigMap, err := w.K8sAPI.GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace)
+	vpcCniConfigMap, err := w.K8sAPI.GetControllerConfigMap()
 	if err == nil {
 		resourceConfig = config.LoadResourceConfigFromConfigMap(log, vpcCniConfigMap)
 	} else {
@@ -48,7 +48,7 @@ func TestGetWinWarmPoolConfig_PDDisabledAndAPICallSuccess_ReturnsConfig(t *testi
 	}
 
 	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(configMapToReturn, nil)
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(configMapToReturn, nil)
 	apiWrapperMock := api.Wrapper{K8sAPI: mockK8sWrapper}
 
 	actualWarmPoolConfig := GetWinWarmPoolConfig(log, apiWrapperMock, false)
@@ -77,7 +77,7 @@ func TestGetWinWarmPoolConfig_PDEnabledAndAPICallSuccess_ReturnsConfig(t *testin
 	}
 
 	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(configMapToReturn, nil)
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(configMapToReturn, nil)
 	apiWrapperMock := api.Wrapper{K8sAPI: mockK8sWrapper}
 
 	actualWarmPoolConfig := GetWinWarmPoolConfig(log, apiWrapperMock, true)
@@ -98,10 +98,7 @@ func TestGetWinWarmPoolConfig_PDDisabledAndAPICallFailure_ReturnsDefaultConfig(t
 	}
 
 	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sWrapper.EXPECT().GetConfigMap(
-		config.VpcCniConfigMapName,
-		config.KubeSystemNamespace,
-	).Return(
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(
 		configMapToReturn,
 		errorToReturn,
 	)
@@ -0,0 +1,764 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package attachedeni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	// InterfaceTypeInterface is the type of the regular ENIs
+	InterfaceTypeInterface = "interface"
+
+	// FailureAttach is the failure to create and attach the ENIs of the pod
+	FailureAttach Failure = "attach"
+	// FailureAnnotate is the failure to annotate the pod with its ENIs
+	FailureAnnotate Failure = "annotate"
+)
+
+// ErrInvalidRequest is wrapped by the errors of the pod requests that are not retried
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Failure is the step of the pod request that failed
+type Failure string
+
+// ENIDetails is an ENI attached to the instance for a pod
+type ENIDetails struct {
+	trunk.ENIDetails
+	// AttachmentID is the id of the attachment of the ENI to the instance
+	AttachmentID string `json:"attachmentId"`
+	// InterfaceType is the type of the ENI, interface or efa
+	InterfaceType string `json:"interfaceType"`
+	// NetworkCardIndex is the index of the network card the ENI is attached to
+	NetworkCardIndex int64 `json:"networkCardIndex"`
+	// DeviceIndex is the device index of the ENI on the network card
+	DeviceIndex int64 `json:"deviceIndex"`
+}
+
+// Request are the options of the ENIs requested by a pod
+type Request struct {
+	// SecurityGroups are the security groups of the ENIs
+	SecurityGroups []string
+	// InterfaceType is the type of the ENIs
+	InterfaceType string
+}
+
+// Strategy is the part of the provider specific to the resource, it decides the network card the ENIs are attached
+// to, the options of the ENIs requested by the pods and how the pods are annotated
+type Strategy interface {
+	// Name returns the name of the resource registered with the controller
+	Name() string
+	// ResourceName returns the extended resource requested by the pods, the pods are annotated with their ENIs under
+	// the same name
+	ResourceName() string
+	// Description returns the description of the ENIs, it identifies the ENIs left attached to the instance after
+	// their pod was deleted
+	Description() string
+	// NetworkCardIndex returns the network card the ENIs are attached to on the instance type, returns false if the
+	// instance type is not supported
+	NetworkCardIndex(instanceType string) (int64, bool)
+	// NewRequest returns the options of the ENIs requested by the pod, the errors wrapping ErrInvalidRequest are not
+	// retried
+	NewRequest(ctx context.Context, pod *v1.Pod, instance ec2.EC2Instance) (Request, error)
+	// MarshalENIs returns the annotation of the pod with its ENIs
+	MarshalENIs(enis []*ENIDetails) (string, error)
+	// UnmarshalENIs returns the ENIs of the pod annotation, returns no ENIs if the annotation isn't from the provider
+	UnmarshalENIs(annotation string) ([]*ENIDetails, error)
+	// Attached reports the ENIs attached to the instance and annotated on the pod
+	Attached(ctx context.Context, pod *v1.Pod, enis []*ENIDetails, annotation string)
+	// Failed reports the failure of the request of the pod
+	Failed(ctx context.Context, pod *v1.Pod, failure Failure, err error)
+	// RecordError increments the error count of the operation
+	RecordError(operation string)
+}
+
+type IntrospectResponse struct {
+	InstanceID string
+	PodToENIs  map[string][]ENIDetails
+}
+
+type IntrospectSummaryResponse struct {
+	InstanceID string
+	ENICount   int
+}
+
+// FilterState returns the ENIs of the node if they are used, the ENIs are never warm nor cooling
+func (i IntrospectResponse) FilterState(state string) (interface{}, bool) {
+	if state == config.IntrospectStateUsed {
+		return i, len(i.PodToENIs) > 0
+	}
+	return nil, false
+}
+
+// nodeENIs are the ENIs attached to the network card of a node
+type nodeENIs struct {
+	instance ec2.EC2Instance
+	// networkCardIndex is the index of the network card the ENIs are attached to
+	networkCardIndex int64
+	// unmanagedInterfaces is the number of network interfaces attached to the network card by the instance or the CNI
+	// when the node was initialized, they are not available to the pods
+	unmanagedInterfaces int
+	// podToENIs is the map of the pod UID to the ENIs of the pod
+	podToENIs map[string][]*ENIDetails
+}
+
+// attachedENIProvider creates ENIs for the pods requesting the resource of the strategy, attaches them to a network
+// card of the pod's instance and annotates the pod with them. The ENIs are deleted with the pod.
+type attachedENIProvider struct {
+	// log is the logger initialized with the provider value
+	log logr.Logger
+	// lock to prevent concurrent writes to the node map
+	lock sync.RWMutex
+	// strategy is the part of the provider specific to the resource
+	strategy Strategy
+	// nodes is the map of node name to the ENIs of the node's pods
+	nodes map[string]*nodeENIs
+	// workerPool is the worker pool and queue for submitting async job
+	workerPool worker.Worker
+	apiWrapper api.Wrapper
+	ctx        context.Context
+	checker    healthz.Checker
+}
+
+// NewAttachedENIProvider returns the provider of the ENIs attached for the resource of the strategy for all the nodes
+// across the cluster
+func NewAttachedENIProvider(logger logr.Logger, wrapper api.Wrapper, worker worker.Worker, strategy Strategy,
+	ctx context.Context) provider.ResourceProvider {
+	provider := &attachedENIProvider{
+		log:        logger,
+		strategy:   strategy,
+		nodes:      make(map[string]*nodeENIs),
+		workerPool: worker,
+		apiWrapper: wrapper,
+		ctx:        ctx,
+	}
+	provider.checker = provider.check()
+	return provider
+}
+
+// InitResource loads the ENIs annotated on the pods of the node with their attachment and deletes the ENIs of the
+// provider attached to the network card that are not used by any pod
+func (p *attachedENIProvider) InitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	log := p.log.WithValues("nodeName", nodeName)
+
+	networkCardIndex, found := p.strategy.NetworkCardIndex(instance.Type())
+	if !found {
+		p.strategy.RecordError("init")
+		return fmt.Errorf("instance type %s of node %s is not supported", instance.Type(), nodeName)
+	}
+
+	podList, err := p.apiWrapper.PodAPI.GetRunningPodsOnNode(nodeName)
+	if err != nil {
+		log.Error(err, "failed to get list of pod on node")
+		return err
+	}
+
+	instanceID := instance.InstanceID()
+	nwInterfaces, err := p.apiWrapper.EC2API.GetInstanceNetworkInterface(&instanceID)
+	if err != nil {
+		p.strategy.RecordError("get_instance_interfaces")
+		return err
+	}
+	node := &nodeENIs{
+		instance:         instance,
+		networkCardIndex: networkCardIndex,
+		podToENIs:        make(map[string][]*ENIDetails),
+	}
+	attachedInterfaces := make(map[string]*awsEC2.InstanceNetworkInterface)
+	for _, nwInterface := range nwInterfaces {
+		if p.isProviderInterface(nwInterface, networkCardIndex) {
+			attachedInterfaces[aws.StringValue(nwInterface.NetworkInterfaceId)] = nwInterface
+		} else if isNetworkCardInterface(nwInterface, networkCardIndex) {
+			node.unmanagedInterfaces++
+		}
+	}
+	for _, pod := range podList {
+		annotation, ok := pod.Annotations[p.strategy.ResourceName()]
+		if !ok {
+			continue
+		}
+		annotatedENIs, err := p.strategy.UnmarshalENIs(annotation)
+		if err != nil {
+			log.Error(err, "failed to unmarshal the ENIs of the pod", "namespace", pod.Namespace, "name", pod.Name)
+			continue
+		}
+		if len(annotatedENIs) == 0 {
+			continue
+		}
+		var enis []*ENIDetails
+		for _, eni := range annotatedENIs {
+			nwInterface, found := attachedInterfaces[eni.ID]
+			if !found {
+				log.Info("ENI of the pod is not attached to the instance", "namespace", pod.Namespace,
+					"name", pod.Name, "eni", eni.ID)
+				continue
+			}
+			delete(attachedInterfaces, eni.ID)
+			eni.AttachmentID = aws.StringValue(nwInterface.Attachment.AttachmentId)
+			eni.NetworkCardIndex = networkCardIndex
+			eni.DeviceIndex = aws.Int64Value(nwInterface.Attachment.DeviceIndex)
+			enis = append(enis, eni)
+		}
+		node.podToENIs[string(pod.UID)] = enis
+	}
+
+	ec2APIHelper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: p.strategy.Name(), NodeName: nodeName})
+	// The remaining interfaces are not used by any pod, the pod was deleted while the controller was not running,
+	// or before it was annotated
+	for eniID, nwInterface := range attachedInterfaces {
+		err := ec2APIHelper.DetachAndDeleteNetworkInterface(nwInterface.Attachment.AttachmentId,
+			nwInterface.NetworkInterfaceId)
+		if err != nil {
+			p.strategy.RecordError("delete_leaked_eni")
+			log.Error(err, "failed to delete the leaked ENI", "eni", eniID)
+			continue
+		}
+		instance.FreeDeviceIndexOnNetworkCard(networkCardIndex, aws.Int64Value(nwInterface.Attachment.DeviceIndex))
+		log.Info("deleted the leaked ENI", "eni", eniID)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.nodes[nodeName]; ok {
+		p.strategy.RecordError("init")
+		return fmt.Errorf("node %s is already initialized", nodeName)
+	}
+	p.nodes[nodeName] = node
+
+	log.Info("initialized the resource provider successfully", "pods", len(node.podToENIs))
+	return nil
+}
+
+// isProviderInterface returns true if the network interface is an ENI of the provider attached to the network card
+func (p *attachedENIProvider) isProviderInterface(nwInterface *awsEC2.InstanceNetworkInterface,
+	networkCardIndex int64) bool {
+	return isNetworkCardInterface(nwInterface, networkCardIndex) &&
+		aws.StringValue(nwInterface.Description) == ec2API.CreateENIDescriptionPrefix+p.strategy.Description()
+}
+
+// isNetworkCardInterface returns true if the network interface is attached to the network card
+func isNetworkCardInterface(nwInterface *awsEC2.InstanceNetworkInterface, networkCardIndex int64) bool {
+	return nwInterface.Attachment != nil &&
+		aws.Int64Value(nwInterface.Attachment.NetworkCardIndex) == networkCardIndex
+}
+
+// DeInitResource stops tracking the node, the ENIs are deleted with the terminated instance
+func (p *attachedENIProvider) DeInitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	p.removeNode(nodeName)
+
+	p.log.Info("de-initialized resource provider successfully", "nodeName", nodeName)
+	return nil
+}
+
+// ReleaseResource stops tracking the ENIs of the node without deleting them, the replica taking over the node loads
+// them again from the pod annotations
+func (p *attachedENIProvider) ReleaseResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	p.removeNode(nodeName)
+
+	p.log.Info("released resource provider", "node name", nodeName)
+	return nil
+}
+
+// UpdateResourceCapacity advertises the number of network interfaces of the network card, besides the network
+// interfaces attached by the instance or the CNI when the node was initialized
+func (p *attachedENIProvider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
+	instanceName := instance.Name()
+	instanceType := instance.Type()
+
+	unmanagedInterfaces, found := p.getUnmanagedInterfaces(instanceName)
+	if !found {
+		return nil
+	}
+	networkCardIndex, found := p.strategy.NetworkCardIndex(instanceType)
+	if !found {
+		return nil
+	}
+	capacity, found := networkCardCapacity(instanceType, networkCardIndex)
+	if !found {
+		return nil
+	}
+	capacity = utils.Maximum(capacity-unmanagedInterfaces, 0)
+
+	err := p.apiWrapper.K8sAPI.AdvertiseCapacityIfNotSet(instanceName, p.strategy.ResourceName(), capacity)
+	if err != nil {
+		p.strategy.RecordError("advertise_capacity")
+		return err
+	}
+	p.log.V(1).Info("advertised capacity", "instance", instanceName, "instance type", instanceType,
+		"capacity", capacity)
+	return nil
+}
+
+// networkCardCapacity returns the maximum number of network interfaces of the network card of the instance type,
+// returns false if the instance type doesn't have the card
+func networkCardCapacity(instanceType string, networkCardIndex int64) (int, bool) {
+	limits, found := vpc.GetLimits(instanceType)
+	if !found {
+		return 0, false
+	}
+	for _, networkCard := range limits.NetworkCards {
+		if networkCard.NetworkCardIndex != networkCardIndex {
+			continue
+		}
+		if networkCardIndex != int64(limits.DefaultNetworkCardIndex) {
+			return int(networkCard.MaximumNetworkInterfaces), true
+		}
+		// The default network card is limited by the number of network interfaces of the instance type, as the
+		// device indexes of the instance
+		return int(utils.Minimum(int64(limits.Interface), networkCard.MaximumNetworkInterfaces)), true
+	}
+	return 0, false
+}
+
+// SubmitAsyncJob submits the job to the k8s worker queue and returns immediately without waiting for the job to
+// complete
+func (p *attachedENIProvider) SubmitAsyncJob(job interface{}) {
+	p.workerPool.SubmitJob(job)
+}
+
+// ProcessAsyncJob is the job being executed in the worker pool routine
+func (p *attachedENIProvider) ProcessAsyncJob(job interface{}) (ctrl.Result, error) {
+	onDemandJob, isValid := job.(worker.OnDemandJob)
+	if !isValid {
+		return ctrl.Result{}, fmt.Errorf("invalid job type")
+	}
+
+	switch onDemandJob.Operation {
+	case worker.OperationCreate:
+		return p.CreateAndAnnotateResources(worker.JobContext(job), onDemandJob.PodNamespace,
+			onDemandJob.PodName, onDemandJob.RequestCount)
+	case worker.OperationDeleted:
+		return p.DeleteENIsUsedByPod(onDemandJob.NodeName, string(onDemandJob.UID))
+	}
+
+	return ctrl.Result{}, fmt.Errorf("unsupported operation type")
+}
+
+// CreateAndAnnotateResources creates the ENIs requested by the pod, attaches them to the network card of the pod's
+// instance and annotates the pod with them
+func (p *attachedENIProvider) CreateAndAnnotateResources(ctx context.Context, podNamespace string, podName string,
+	resourceCount int) (result ctrl.Result, err error) {
+	ctx, span := tracing.StartSpan(ctx, "attachedENIProvider.CreateAndAnnotateResources",
+		attribute.String("resource.name", p.strategy.Name()),
+		attribute.String("pod.namespace", podNamespace),
+		attribute.String("pod.name", podName),
+		attribute.Int("resource.count", resourceCount))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	resourceName := p.strategy.ResourceName()
+	// Get the pod from cache
+	pod, err := p.apiWrapper.PodAPI.GetPod(podNamespace, podName)
+	if err != nil {
+		p.strategy.RecordError("create_get_pod")
+		return ctrl.Result{}, err
+	}
+	if _, ok := pod.Annotations[resourceName]; ok {
+		return ctrl.Result{}, nil
+	}
+
+	// Get the pod object again directly from API Server as the cache can be stale
+	pod, err = p.apiWrapper.PodAPI.GetPodFromAPIServer(p.ctx, podNamespace, podName)
+	if err != nil {
+		p.strategy.RecordError("get_pod_api_server")
+		return ctrl.Result{}, err
+	}
+	if _, ok := pod.Annotations[resourceName]; ok {
+		return ctrl.Result{}, nil
+	}
+
+	log := p.log.WithValues("pod namespace", pod.Namespace, "pod name", pod.Name, "nodeName", pod.Spec.NodeName)
+
+	instance, networkCardIndex, found := p.getNode(pod.Spec.NodeName)
+	if !found {
+		p.strategy.RecordError("get_node_create")
+		err = fmt.Errorf("node %s of the pod is not initialized", pod.Spec.NodeName)
+		p.strategy.Failed(ctx, pod, FailureAttach, err)
+		return ctrl.Result{}, err
+	}
+
+	request, err := p.strategy.NewRequest(ctx, pod, instance)
+	if errors.Is(err, ErrInvalidRequest) {
+		log.Info("not attaching the ENIs", "reason", err.Error())
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ec2APIHelper := ec2API.WithTraceContext(ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: p.strategy.Name(), NodeName: pod.Spec.NodeName, PodUID: string(pod.UID)}), ctx)
+
+	attachedDeviceIndexes, err := p.getAttachedDeviceIndexes(ec2APIHelper, instance, networkCardIndex)
+	if err != nil {
+		p.strategy.Failed(ctx, pod, FailureAttach, err)
+		return ctrl.Result{}, err
+	}
+
+	var enis []*ENIDetails
+	for i := 0; i < resourceCount; i++ {
+		eni, err := p.createAndAttachENI(ec2APIHelper, instance, networkCardIndex, attachedDeviceIndexes, request)
+		if err != nil {
+			p.deleteENIs(ec2APIHelper, instance, enis, log)
+			p.strategy.Failed(ctx, pod, FailureAttach, err)
+			return ctrl.Result{}, err
+		}
+		enis = append(enis, eni)
+	}
+
+	annotation, err := p.strategy.MarshalENIs(enis)
+	if err == nil {
+		err = p.apiWrapper.PodAPI.AnnotatePod(pod.Namespace, pod.Name, pod.UID, resourceName, annotation)
+	}
+	if err != nil {
+		p.strategy.RecordError("annotate_eni")
+		p.deleteENIs(ec2APIHelper, instance, enis, log)
+		p.strategy.Failed(ctx, pod, FailureAnnotate, err)
+		return ctrl.Result{}, err
+	}
+
+	if !p.addPodENIs(pod.Spec.NodeName, string(pod.UID), enis) {
+		// The node was released or deleted meanwhile, its next owner loads the ENIs from the pod
+		log.Info("node removed while attaching the ENIs", "enis", len(enis))
+	}
+
+	p.strategy.Attached(ctx, pod, enis, annotation)
+	log.Info("created, attached and annotated ENIs successfully", "enis", enis)
+
+	return ctrl.Result{}, nil
+}
+
+// getAttachedDeviceIndexes returns the device indexes of the network interfaces currently attached to the network
+// card. The CNI attaches ENIs after the instance was loaded, their device indexes are not tracked by the instance.
+func (p *attachedENIProvider) getAttachedDeviceIndexes(ec2APIHelper ec2API.EC2APIHelper, instance ec2.EC2Instance,
+	networkCardIndex int64) (map[int64]struct{}, error) {
+	instanceID := instance.InstanceID()
+	nwInterfaces, err := ec2APIHelper.GetInstanceNetworkInterface(&instanceID)
+	if err != nil {
+		p.strategy.RecordError("get_instance_interfaces")
+		return nil, err
+	}
+	deviceIndexes := make(map[int64]struct{})
+	for _, nwInterface := range nwInterfaces {
+		if isNetworkCardInterface(nwInterface, networkCardIndex) {
+			deviceIndexes[aws.Int64Value(nwInterface.Attachment.DeviceIndex)] = struct{}{}
+		}
+	}
+	return deviceIndexes, nil
+}
+
+// createAndAttachENI creates an ENI in the subnet of the instance and attaches it to a free device index of the
+// network card, skipping the device indexes attached meanwhile
+func (p *attachedENIProvider) createAndAttachENI(ec2APIHelper ec2API.EC2APIHelper, instance ec2.EC2Instance,
+	networkCardIndex int64, attachedDeviceIndexes map[int64]struct{}, request Request) (*ENIDetails, error) {
+	var deviceIndex int64
+	for {
+		var err error
+		deviceIndex, err = instance.GetHighestUnusedDeviceIndexOnNetworkCard(networkCardIndex)
+		if err != nil {
+			p.strategy.RecordError("find_free_index")
+			return nil, err
+		}
+		// The skipped device index stays reserved on the instance as it's used by another network interface
+		if _, attached := attachedDeviceIndexes[deviceIndex]; !attached {
+			break
+		}
+	}
+
+	// The regular ENIs are created without interface type
+	var creationType *string
+	if request.InterfaceType != InterfaceTypeInterface {
+		creationType = aws.String(request.InterfaceType)
+	}
+	// The ENIs are attached to the network card 0 if the network card index is not set
+	var attachmentNetworkCardIndex *int64
+	if networkCardIndex != 0 {
+		attachmentNetworkCardIndex = aws.Int64(networkCardIndex)
+	}
+	instanceID := instance.InstanceID()
+	description := p.strategy.Description()
+	// The ENIs are tagged so the CNI doesn't manage their IP addresses
+	tags := []*awsEC2.Tag{{Key: aws.String(config.NoManageTagKey), Value: aws.String(config.NoManageTagValue)}}
+	nwInterface, err := ec2APIHelper.CreateAndAttachNetworkInterface(&instanceID, aws.String(instance.SubnetID()),
+		request.SecurityGroups, tags, &deviceIndex, attachmentNetworkCardIndex, &description, creationType, nil)
+	if err != nil {
+		instance.FreeDeviceIndexOnNetworkCard(networkCardIndex, deviceIndex)
+		p.strategy.RecordError("create_attach_eni")
+		return nil, err
+	}
+
+	return &ENIDetails{
+		ENIDetails: trunk.ENIDetails{
+			ID:           aws.StringValue(nwInterface.NetworkInterfaceId),
+			MACAdd:       aws.StringValue(nwInterface.MacAddress),
+			IPV4Addr:     aws.StringValue(nwInterface.PrivateIpAddress),
+			IPV6Addr:     aws.StringValue(nwInterface.Ipv6Address),
+			SubnetCIDR:   instance.SubnetCidrBlock(),
+			SubnetV6CIDR: instance.SubnetV6CidrBlock(),
+		},
+		AttachmentID:     aws.StringValue(nwInterface.Attachment.AttachmentId),
+		InterfaceType:    request.InterfaceType,
+		NetworkCardIndex: networkCardIndex,
+		DeviceIndex:      deviceIndex,
+	}, nil
+}
+
+// deleteENIs detaches and deletes the ENIs and frees their device index, returns the ENIs that failed to be deleted
+func (p *attachedENIProvider) deleteENIs(ec2APIHelper ec2API.EC2APIHelper, instance ec2.EC2Instance,
+	enis []*ENIDetails, log logr.Logger) []*ENIDetails {
+	var failed []*ENIDetails
+	for _, eni := range enis {
+		if err := ec2APIHelper.DetachAndDeleteNetworkInterface(&eni.AttachmentID, &eni.ID); err != nil {
+			p.strategy.RecordError("delete_eni")
+			log.Error(err, "failed to detach and delete the ENI", "eni", eni.ID)
+			failed = append(failed, eni)
+			continue
+		}
+		instance.FreeDeviceIndexOnNetworkCard(eni.NetworkCardIndex, eni.DeviceIndex)
+	}
+	return failed
+}
+
+// addPodENIs tracks the ENIs of the pod, returns false if the node is not tracked
+func (p *attachedENIProvider) addPodENIs(nodeName string, uid string, enis []*ENIDetails) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return false
+	}
+	node.podToENIs[uid] = enis
+	return true
+}
+
+// DeleteENIsUsedByPod detaches and deletes the ENIs of the deleted pod, the ENIs that failed to be deleted are
+// retried
+func (p *attachedENIProvider) DeleteENIsUsedByPod(nodeName string, uid string) (ctrl.Result, error) {
+	p.lock.Lock()
+	node, found := p.nodes[nodeName]
+	var enis []*ENIDetails
+	if found {
+		enis = node.podToENIs[uid]
+		delete(node.podToENIs, uid)
+	}
+	p.lock.Unlock()
+
+	if !found {
+		p.log.Info("failed to find the node of the deleted pod", "nodeName", nodeName)
+		return ctrl.Result{}, nil
+	}
+	if len(enis) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	log := p.log.WithValues("nodeName", nodeName, "pod uid", uid)
+	ec2APIHelper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: p.strategy.Name(), NodeName: nodeName, PodUID: uid})
+	failed := p.deleteENIs(ec2APIHelper, node.instance, enis, log)
+	if len(failed) > 0 {
+		if !p.addPodENIs(nodeName, uid, failed) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to delete %d ENIs of pod %s", len(failed), uid)
+	}
+
+	log.Info("deleted the ENIs of the deleted pod", "enis", len(enis))
+	return ctrl.Result{}, nil
+}
+
+// removeNode stops tracking the node and its ENIs
+func (p *attachedENIProvider) removeNode(nodeName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.nodes, nodeName)
+}
+
+// getNode returns the instance of the node and the network card of its ENIs, returns false if the node is not
+// tracked
+func (p *attachedENIProvider) getNode(nodeName string) (ec2.EC2Instance, int64, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return nil, 0, false
+	}
+	return node.instance, node.networkCardIndex, true
+}
+
+// getUnmanagedInterfaces returns the number of network interfaces attached to the network card of the node that are
+// not managed by the provider, returns false if the node is not tracked
+func (p *attachedENIProvider) getUnmanagedInterfaces(nodeName string) (int, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return 0, false
+	}
+	return node.unmanagedInterfaces, true
+}
+
+// ReconcileNode submits the delete jobs of the pods that don't exist anymore, returns true if any was found
+func (p *attachedENIProvider) ReconcileNode(nodeName string) bool {
+	podList, err := p.apiWrapper.PodAPI.ListPods(nodeName)
+	if err != nil {
+		p.log.Error(err, "failed to list pods, requeue node", "nodeName", nodeName)
+		return true
+	}
+	currentPodSet := make(map[string]struct{})
+	for _, pod := range podList.Items {
+		currentPodSet[string(pod.UID)] = struct{}{}
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return false
+	}
+	leaked := 0
+	for uid := range node.podToENIs {
+		if _, exists := currentPodSet[uid]; exists {
+			continue
+		}
+		p.workerPool.SubmitJob(worker.NewOnDemandDeletedJob(nodeName, types.UID(uid)))
+		leaked++
+		p.log.Info("deleting leaked ENIs of non-existing pod", "nodeName", nodeName, "pod uid", uid)
+	}
+	return leaked > 0
+}
+
+// GetPool is not supported for the attached ENIs
+func (p *attachedENIProvider) GetPool(_ string) (pool.Pool, bool) {
+	return nil, false
+}
+
+// IsInstanceSupported returns true for the linux nodes whose instance type is supported by the strategy
+func (p *attachedENIProvider) IsInstanceSupported(instance ec2.EC2Instance) bool {
+	if instance.Os() != config.OSLinux {
+		return false
+	}
+	_, found := p.strategy.NetworkCardIndex(instance.Type())
+	return found
+}
+
+func (p *attachedENIProvider) Introspect() interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	allResponse := provider.IntrospectNodes[IntrospectResponse]{}
+	for nodeName, node := range p.nodes {
+		allResponse[nodeName] = node.introspect()
+	}
+	return allResponse
+}
+
+func (p *attachedENIProvider) IntrospectSummary() interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	allResponse := provider.IntrospectNodes[IntrospectSummaryResponse]{}
+	for nodeName, node := range p.nodes {
+		count := 0
+		for _, enis := range node.podToENIs {
+			count += len(enis)
+		}
+		allResponse[nodeName] = IntrospectSummaryResponse{
+			InstanceID: node.instance.InstanceID(),
+			ENICount:   count,
+		}
+	}
+	return allResponse
+}
+
+func (p *attachedENIProvider) IntrospectNode(nodeName string) interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return struct{}{}
+	}
+	return node.introspect()
+}
+
+// introspect returns a copy of the ENIs of the node, the caller must hold the provider lock
+func (n *nodeENIs) introspect() IntrospectResponse {
+	response := IntrospectResponse{
+		InstanceID: n.instance.InstanceID(),
+		PodToENIs:  make(map[string][]ENIDetails),
+	}
+	for uid, enis := range n.podToENIs {
+		for _, eni := range enis {
+			response.PodToENIs[uid] = append(response.PodToENIs[uid], *eni)
+		}
+	}
+	return response
+}
+
+func (p *attachedENIProvider) check() healthz.Checker {
+	p.log.Info("attached ENI provider's healthz subpath was added", "resource", p.strategy.Name())
+	return func(req *http.Request) error {
+		err := rcHealthz.PingWithTimeout(func(c chan<- error) {
+			var ping interface{}
+			// check on job queue
+			p.SubmitAsyncJob(ping)
+			// check on node map
+			p.getNode("test-node" + uuid.New().String())
+			c <- nil
+		}, p.log)
+
+		return err
+	}
+}
+
+func (p *attachedENIProvider) GetHealthChecker() healthz.Checker {
+	return p.checker
+}
@@ -0,0 +1,429 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package attachedeni
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/providertest"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8sCtrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName         = providertest.NodeName
+	instanceID       = providertest.InstanceID
+	podUID           = providertest.PodUID
+	subnetID         = "subnet-1"
+	subnetCIDR       = "192.168.0.0/16"
+	securityGroups   = []string{"sg-1"}
+	networkCardIndex = int64(1)
+	resourceName     = config.NetworkCardResourceName(networkCardIndex)
+	description      = "test-eni"
+
+	eni1 = &ENIDetails{ENIDetails: trunk.ENIDetails{ID: "eni-1", MACAdd: "0e:00:00:00:00:01",
+		IPV4Addr: "192.168.0.1", SubnetCIDR: subnetCIDR}, AttachmentID: "eni-attach-1",
+		InterfaceType: awsEC2.NetworkInterfaceTypeEfa, NetworkCardIndex: networkCardIndex, DeviceIndex: 14}
+	eni2 = &ENIDetails{ENIDetails: trunk.ENIDetails{ID: "eni-2", MACAdd: "0e:00:00:00:00:02",
+		IPV4Addr: "192.168.0.2", SubnetCIDR: subnetCIDR}, AttachmentID: "eni-attach-2",
+		InterfaceType: awsEC2.NetworkInterfaceTypeEfa, NetworkCardIndex: networkCardIndex, DeviceIndex: 13}
+
+	noManageTags = []*awsEC2.Tag{{Key: aws.String(config.NoManageTagKey),
+		Value: aws.String(config.NoManageTagValue)}}
+
+	mockError = fmt.Errorf("mock error")
+)
+
+// testStrategy attaches the ENIs requested by the pods to the network card of the supported instance types, and
+// records the results of the requests
+type testStrategy struct {
+	// networkCards is the network card of the supported instance types
+	networkCards map[string]int64
+	// requestErr is returned by NewRequest if set
+	requestErr error
+	attached   [][]*ENIDetails
+	failures   []Failure
+}
+
+func (s *testStrategy) Name() string         { return resourceName }
+func (s *testStrategy) ResourceName() string { return resourceName }
+func (s *testStrategy) Description() string  { return description }
+
+func (s *testStrategy) NetworkCardIndex(instanceType string) (int64, bool) {
+	index, found := s.networkCards[instanceType]
+	return index, found
+}
+
+func (s *testStrategy) NewRequest(_ context.Context, _ *v1.Pod, _ ec2.EC2Instance) (Request, error) {
+	return Request{SecurityGroups: securityGroups, InterfaceType: awsEC2.NetworkInterfaceTypeEfa}, s.requestErr
+}
+
+func (s *testStrategy) MarshalENIs(enis []*ENIDetails) (string, error) {
+	jsonBytes, err := json.Marshal(enis)
+	return string(jsonBytes), err
+}
+
+func (s *testStrategy) UnmarshalENIs(annotation string) ([]*ENIDetails, error) {
+	var enis []*ENIDetails
+	err := json.Unmarshal([]byte(annotation), &enis)
+	return enis, err
+}
+
+func (s *testStrategy) Attached(_ context.Context, _ *v1.Pod, enis []*ENIDetails, _ string) {
+	s.attached = append(s.attached, enis)
+}
+
+func (s *testStrategy) Failed(_ context.Context, _ *v1.Pod, failure Failure, _ error) {
+	s.failures = append(s.failures, failure)
+}
+
+func (s *testStrategy) RecordError(_ string) {}
+
+// getProviderAndMocks returns the provider attaching the ENIs to the network card 1 of p4d.24xlarge, tracking the
+// node without any pod
+func getProviderAndMocks(ctrl *gomock.Controller) (*attachedENIProvider, *testStrategy, providertest.Mocks) {
+	m := providertest.NewMocks(ctrl)
+	strategy := &testStrategy{networkCards: map[string]int64{"p4d.24xlarge": networkCardIndex}}
+	return &attachedENIProvider{
+		log:        zap.New(zap.UseDevMode(true)).WithName("attached eni provider"),
+		strategy:   strategy,
+		apiWrapper: m.Wrapper(),
+		nodes: map[string]*nodeENIs{
+			nodeName: {instance: m.Instance, networkCardIndex: networkCardIndex,
+				podToENIs: make(map[string][]*ENIDetails)},
+		},
+		ctx: context.TODO(),
+	}, strategy, m
+}
+
+// expectCreateAndAttach expects the ENI to be created and attached at its device index of the network card
+func expectCreateAndAttach(m providertest.Mocks, eni *ENIDetails) *gomock.Call {
+	m.Instance.EXPECT().GetHighestUnusedDeviceIndexOnNetworkCard(eni.NetworkCardIndex).Return(eni.DeviceIndex, nil)
+	m.Instance.EXPECT().InstanceID().Return(instanceID)
+	m.Instance.EXPECT().SubnetID().Return(subnetID)
+	var attachmentNetworkCardIndex *int64
+	if eni.NetworkCardIndex != 0 {
+		attachmentNetworkCardIndex = &eni.NetworkCardIndex
+	}
+	return m.EC2Helper.EXPECT().CreateAndAttachNetworkInterface(&instanceID, &subnetID, securityGroups, noManageTags,
+		&eni.DeviceIndex, attachmentNetworkCardIndex, &description, aws.String(eni.InterfaceType), nil)
+}
+
+// expectAttachedInterfaces expects the network interfaces attached to the instance to be read
+func expectAttachedInterfaces(m providertest.Mocks, nwInterfaces ...*awsEC2.InstanceNetworkInterface) {
+	m.Instance.EXPECT().InstanceID().Return(instanceID)
+	m.EC2Helper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nwInterfaces, nil)
+}
+
+// newInterface returns the network interface with the description attached to the device index of the network card
+func newInterface(id string, networkCard int64, deviceIndex int64,
+	description string) *awsEC2.InstanceNetworkInterface {
+	return &awsEC2.InstanceNetworkInterface{
+		NetworkInterfaceId: aws.String(id),
+		Description:        aws.String(description),
+		Attachment: &awsEC2.InstanceNetworkInterfaceAttachment{AttachmentId: aws.String(id + "-attach"),
+			NetworkCardIndex: aws.Int64(networkCard), DeviceIndex: aws.Int64(deviceIndex)},
+	}
+}
+
+// expectSubnet expects the subnet of the instance to be read for each attached ENI
+func expectSubnet(m providertest.Mocks, times int) {
+	m.Instance.EXPECT().SubnetCidrBlock().Return(subnetCIDR).Times(times)
+	m.Instance.EXPECT().SubnetV6CidrBlock().Return("").Times(times)
+}
+
+// attachedInterface returns the network interface of the attached ENI
+func attachedInterface(eni *ENIDetails) *awsEC2.NetworkInterface {
+	return &awsEC2.NetworkInterface{
+		NetworkInterfaceId: &eni.ID,
+		MacAddress:         &eni.MACAdd,
+		PrivateIpAddress:   &eni.IPV4Addr,
+		Attachment:         &awsEC2.NetworkInterfaceAttachment{AttachmentId: &eni.AttachmentID},
+	}
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources tests the ENIs requested by the pod are attached to the network
+// card and annotated on the pod
+func TestAttachedENIProvider_CreateAndAnnotateResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, strategy, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	expectAttachedInterfaces(m)
+	expectCreateAndAttach(m, eni1).Return(attachedInterface(eni1), nil)
+	expectCreateAndAttach(m, eni2).Return(attachedInterface(eni2), nil)
+	expectSubnet(m, 2)
+
+	expectedJSON, _ := json.Marshal([]*ENIDetails{eni1, eni2})
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, resourceName, string(expectedJSON)).Return(nil)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.Equal(t, []*ENIDetails{eni1, eni2}, provider.nodes[nodeName].podToENIs[string(podUID)])
+	assert.Equal(t, [][]*ENIDetails{{eni1, eni2}}, strategy.attached)
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources_AttachedDeviceIndex tests the device indexes of the network
+// interfaces attached to the network card since the instance was loaded are skipped
+func TestAttachedENIProvider_CreateAndAnnotateResources_AttachedDeviceIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	expectAttachedInterfaces(m, newInterface("eni-cni", networkCardIndex, 14, "aws-K8S-i-00000000000000000"),
+		newInterface("eni-other-card", 2, 13, "aws-K8S-i-00000000000000000"))
+	m.Instance.EXPECT().GetHighestUnusedDeviceIndexOnNetworkCard(networkCardIndex).Return(int64(14), nil)
+	expectCreateAndAttach(m, eni2).Return(attachedInterface(eni2), nil)
+	expectSubnet(m, 1)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, resourceName, gomock.Any()).Return(nil)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ENIDetails{eni2}, provider.nodes[nodeName].podToENIs[string(podUID)])
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources_DefaultNetworkCard tests the ENIs of the default network card
+// are attached without network card index
+func TestAttachedENIProvider_CreateAndAnnotateResources_DefaultNetworkCard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+	provider.nodes[nodeName].networkCardIndex = 0
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	expectAttachedInterfaces(m)
+	eni := *eni1
+	eni.NetworkCardIndex = 0
+	expectCreateAndAttach(m, &eni).Return(attachedInterface(&eni), nil)
+	expectSubnet(m, 1)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, resourceName, gomock.Any()).Return(nil)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ENIDetails{&eni}, provider.nodes[nodeName].podToENIs[string(podUID)])
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources_InvalidRequest tests the invalid requests are not retried
+func TestAttachedENIProvider_CreateAndAnnotateResources_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, strategy, m := getProviderAndMocks(ctrl)
+	strategy.requestErr = fmt.Errorf("%w: mock", ErrInvalidRequest)
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.Empty(t, provider.nodes[nodeName].podToENIs)
+
+	strategy.requestErr = mockError
+	m.ExpectGetPod(pod)
+
+	_, err = provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.ErrorIs(t, err, mockError)
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources_AttachFailed tests the ENIs already attached for the pod are
+// deleted if an ENI fails to be attached
+func TestAttachedENIProvider_CreateAndAnnotateResources_AttachFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, strategy, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	expectAttachedInterfaces(m)
+	expectCreateAndAttach(m, eni1).Return(attachedInterface(eni1), nil)
+	expectSubnet(m, 1)
+	expectCreateAndAttach(m, eni2).Return(nil, mockError)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, eni2.DeviceIndex)
+
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(&eni1.AttachmentID, &eni1.ID).Return(nil)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, eni1.DeviceIndex)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 2)
+	assert.ErrorIs(t, err, mockError)
+	assert.Empty(t, provider.nodes[nodeName].podToENIs)
+	assert.Equal(t, []Failure{FailureAttach}, strategy.failures)
+}
+
+// TestAttachedENIProvider_CreateAndAnnotateResources_AnnotateFailed tests the ENIs are deleted if the pod fails to
+// be annotated
+func TestAttachedENIProvider_CreateAndAnnotateResources_AnnotateFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, strategy, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+	m.ExpectGetPod(pod)
+
+	expectAttachedInterfaces(m)
+	expectCreateAndAttach(m, eni1).Return(attachedInterface(eni1), nil)
+	expectSubnet(m, 1)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, resourceName, gomock.Any()).Return(mockError)
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(&eni1.AttachmentID, &eni1.ID).Return(nil)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, eni1.DeviceIndex)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.ErrorIs(t, err, mockError)
+	assert.Empty(t, provider.nodes[nodeName].podToENIs)
+	assert.Equal(t, []Failure{FailureAnnotate}, strategy.failures)
+}
+
+// TestAttachedENIProvider_DeleteENIsUsedByPod tests the ENIs of the deleted pod are deleted and the ENIs failing to
+// be deleted are retried
+func TestAttachedENIProvider_DeleteENIsUsedByPod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+	provider.nodes[nodeName].podToENIs[string(podUID)] = []*ENIDetails{eni1, eni2}
+
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(&eni1.AttachmentID, &eni1.ID).Return(nil)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, eni1.DeviceIndex)
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(&eni2.AttachmentID, &eni2.ID).Return(mockError)
+
+	_, err := provider.DeleteENIsUsedByPod(nodeName, string(podUID))
+	assert.Error(t, err)
+	assert.Equal(t, []*ENIDetails{eni2}, provider.nodes[nodeName].podToENIs[string(podUID)])
+
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(&eni2.AttachmentID, &eni2.ID).Return(nil)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, eni2.DeviceIndex)
+
+	_, err = provider.DeleteENIsUsedByPod(nodeName, string(podUID))
+	assert.NoError(t, err)
+	assert.Empty(t, provider.nodes[nodeName].podToENIs)
+}
+
+// TestAttachedENIProvider_InitResource tests the ENIs annotated on the pods are loaded with their attachment, the
+// ENIs of the provider attached to the network card that are not used by any pod are deleted and the other network
+// interfaces of the network card are counted
+func TestAttachedENIProvider_InitResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+	provider.nodes = make(map[string]*nodeENIs)
+
+	annotation, _ := json.Marshal([]*ENIDetails{{ENIDetails: eni1.ENIDetails,
+		InterfaceType: eni1.InterfaceType}})
+	pod := providertest.NewPod(map[string]string{resourceName: string(annotation)})
+	providerDescription := ec2API.CreateENIDescriptionPrefix + description
+
+	m.Instance.EXPECT().Name().Return(nodeName)
+	m.Instance.EXPECT().Type().Return("p4d.24xlarge")
+	m.Instance.EXPECT().InstanceID().Return(instanceID)
+	m.PodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return([]v1.Pod{*pod}, nil)
+	m.EC2Helper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return([]*awsEC2.InstanceNetworkInterface{
+		newInterface("eni-primary", 0, 0, "primary"),
+		newInterface(eni1.ID, networkCardIndex, eni1.DeviceIndex, providerDescription),
+		newInterface("eni-leaked", networkCardIndex, 12, providerDescription),
+		newInterface("eni-other-card", 2, 14, providerDescription),
+		newInterface("eni-other-owner", networkCardIndex, 0, "efa"),
+	}, nil)
+	m.EC2Helper.EXPECT().DetachAndDeleteNetworkInterface(aws.String("eni-leaked-attach"), aws.String("eni-leaked")).
+		Return(nil)
+	m.Instance.EXPECT().FreeDeviceIndexOnNetworkCard(networkCardIndex, int64(12))
+
+	err := provider.InitResource(m.Instance)
+	assert.NoError(t, err)
+	expected := *eni1
+	expected.AttachmentID = eni1.ID + "-attach"
+	assert.Equal(t, []*ENIDetails{&expected}, provider.nodes[nodeName].podToENIs[string(podUID)])
+	assert.Equal(t, 1, provider.nodes[nodeName].unmanagedInterfaces)
+}
+
+// TestAttachedENIProvider_IsInstanceSupported tests only the linux instance types supported by the strategy are
+// supported
+func TestAttachedENIProvider_IsInstanceSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+
+	m.Instance.EXPECT().Os().Return(config.OSLinux).Times(2)
+	m.Instance.EXPECT().Type().Return("p4d.24xlarge")
+	assert.True(t, provider.IsInstanceSupported(m.Instance))
+	m.Instance.EXPECT().Type().Return("c5.large")
+	assert.False(t, provider.IsInstanceSupported(m.Instance))
+
+	m.Instance.EXPECT().Os().Return(config.OSWindows)
+	assert.False(t, provider.IsInstanceSupported(m.Instance))
+}
+
+// TestAttachedENIProvider_UpdateResourceCapacity tests the maximum number of interfaces of the network card is
+// advertised, besides the network interfaces attached by the instance or the CNI
+func TestAttachedENIProvider_UpdateResourceCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, strategy, m := getProviderAndMocks(ctrl)
+	strategy.networkCards["c1.medium"] = 0
+
+	m.Instance.EXPECT().Name().Return(nodeName).Times(3)
+	m.Instance.EXPECT().Type().Return("p4d.24xlarge")
+	m.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, resourceName, 15).Return(nil)
+	assert.NoError(t, provider.UpdateResourceCapacity(m.Instance))
+
+	// The primary network interface is attached to the default network card
+	provider.nodes[nodeName].unmanagedInterfaces = 1
+	m.Instance.EXPECT().Type().Return("c1.medium")
+	m.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, resourceName, 1).Return(nil)
+	assert.NoError(t, provider.UpdateResourceCapacity(m.Instance))
+
+	// The CNI attached a secondary ENI as well
+	provider.nodes[nodeName].unmanagedInterfaces = 2
+	m.Instance.EXPECT().Type().Return("c1.medium")
+	m.K8sAPI.EXPECT().AdvertiseCapacityIfNotSet(nodeName, resourceName, 0).Return(nil)
+	assert.NoError(t, provider.UpdateResourceCapacity(m.Instance))
+}
+
+// TestAttachedENIProvider_UpdateResourceCapacity_NodeNotInitialized tests the capacity is not advertised before the
+// node is initialized
+func TestAttachedENIProvider_UpdateResourceCapacity_NodeNotInitialized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, m := getProviderAndMocks(ctrl)
+	provider.nodes = make(map[string]*nodeENIs)
+
+	m.Instance.EXPECT().Name().Return(nodeName)
+	m.Instance.EXPECT().Type().Return("p4d.24xlarge")
+	assert.NoError(t, provider.UpdateResourceCapacity(m.Instance))
+}
@@ -58,7 +58,7 @@ func GetCoolDown() CoolDown {
 }
 
 func GetVpcCniConfigMapCoolDownPeriodOrDefault(k8sApi k8s.K8sWrapper, log logr.Logger) (time.Duration, error) {
-	vpcCniConfigMap, err := k8sApi.GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace)
+	vpcCniConfigMap, err := k8sApi.GetControllerConfigMap()
 	if err == nil && vpcCniConfigMap.Data != nil {
 		if val, ok := vpcCniConfigMap.Data[config.BranchENICooldownPeriodKey]; ok {
 			coolDownPeriodInt, err := strconv.Atoi(val)
@@ -103,7 +103,7 @@ func TestCoolDown_InitCoolDownPeriod(t *testing.T) {
 			defer ctrl.Finish()
 		})
 		mockK8sApi := mock_k8s.NewMockK8sWrapper(ctrl)
-		mockK8sApi.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(test.args.vpcCniConfigMap, test.err)
+		mockK8sApi.EXPECT().GetControllerConfigMap().Return(test.args.vpcCniConfigMap, test.err)
 		InitCoolDownPeriod(mockK8sApi, log)
 		assert.Equal(t, test.expectedCoolDown, coolDown.GetCoolDownPeriod())
 	}
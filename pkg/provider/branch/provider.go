@@ -26,6 +26,7 @@ import (
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
@@ -33,13 +34,16 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/cooldown"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -52,12 +56,15 @@ const (
 	resourceCountLabel         = "resource_count"
 	operationLabel             = "branch_provider_operation"
 
-	ReasonSecurityGroupRequested    = "SecurityGroupRequested"
+	ReasonSecurityGroupRequested    = utils.SecurityGroupRequestedReason
 	ReasonResourceAllocated         = "ResourceAllocated"
 	ReasonBranchAllocationFailed    = "BranchAllocationFailed"
 	ReasonBranchENIAnnotationFailed = "BranchENIAnnotationFailed"
 
 	ReasonTrunkENICreationFailed = "TrunkENICreationFailed"
+	ReasonNodeDraining           = "NodeDraining"
+
+	ReasonSecurityGroupsUpdated = utils.SecurityGroupsUpdatedReason
 )
 
 var (
@@ -80,6 +87,10 @@ var (
 
 	deleteQueueRequeueRequest = ctrl.Result{RequeueAfter: time.Second * 30, Requeue: true}
 
+	// drainingNodeRequeueRequest is the request to retry creating the branch ENI of a pod on a draining node, in case
+	// the node is uncordoned
+	drainingNodeRequeueRequest = ctrl.Result{RequeueAfter: time.Second * 30, Requeue: true}
+
 	// NodeDeleteRequeueRequestDelay represents the time after which the resources belonging to a node will be cleaned
 	// up after receiving the actual node delete event.
 	NodeDeleteRequeueRequestDelay = time.Minute * 5
@@ -90,14 +101,28 @@ var (
 	ErrTrunkNotInCache   = fmt.Errorf("trunk eni not present in cache")
 )
 
+// SecurityGroupsUpdater updates the security groups of the branch ENIs already allocated to the pods
+type SecurityGroupsUpdater interface {
+	// SetSecurityGroupsOutdated marks the security groups of the pod's branch ENIs as outdated, they are updated
+	// with the security groups matching the pod the next time the pod is reconciled
+	SetSecurityGroupsOutdated(pod types.NamespacedName)
+}
+
 // branchENIProvider provides branch ENI to all nodes that support Trunk network interface
 type branchENIProvider struct {
 	// log is the logger initialized with branch eni provider value
 	log logr.Logger
-	// lock to prevent concurrent writes to the trunk eni map
+	// lock to prevent concurrent writes to the trunk eni map, the draining nodes set and the outdated security
+	// groups set
 	lock sync.RWMutex
 	// trunkENICache is the map of node name to the trunk ENI
 	trunkENICache map[string]trunk.TrunkENI
+	// drainingNodes is the set of nodes that are cordoned or tainted for termination, no branch ENI is created for
+	// the pods of these nodes and their branch ENIs are deleted as soon as no pod uses them
+	drainingNodes map[string]struct{}
+	// outdatedSecurityGroups is the set of pods whose branch ENIs must be updated with the security groups
+	// matching the pod
+	outdatedSecurityGroups map[types.NamespacedName]struct{}
 	// workerPool is the worker pool and queue for submitting async job
 	workerPool worker.Worker
 	// apiWrapper
@@ -113,11 +138,13 @@ func NewBranchENIProvider(logger logr.Logger, wrapper api.Wrapper,
 	trunk.PrometheusRegister()
 
 	provider := &branchENIProvider{
-		apiWrapper:    wrapper,
-		log:           logger,
-		workerPool:    worker,
-		trunkENICache: make(map[string]trunk.TrunkENI),
-		ctx:           ctx,
+		apiWrapper:             wrapper,
+		log:                    logger,
+		workerPool:             worker,
+		trunkENICache:          make(map[string]trunk.TrunkENI),
+		drainingNodes:          make(map[string]struct{}),
+		outdatedSecurityGroups: make(map[types.NamespacedName]struct{}),
+		ctx:                    ctx,
 	}
 	provider.checker = provider.check()
 	return provider
@@ -144,7 +171,9 @@ func timeSinceSeconds(start time.Time) float64 {
 func (b *branchENIProvider) InitResource(instance ec2.EC2Instance) error {
 	nodeName := instance.Name()
 	log := b.log.WithValues("nodeName", nodeName)
-	trunkENI := trunk.NewTrunkENI(log, instance, b.apiWrapper.EC2API)
+	ec2APIHelper := ec2API.WithAuditCaller(b.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNamePodENI, NodeName: nodeName})
+	trunkENI := trunk.NewTrunkENI(log, instance, ec2APIHelper)
 
 	// Initialize the Trunk ENI
 	start := time.Now()
@@ -208,6 +237,17 @@ func (b *branchENIProvider) DeInitResource(instance ec2.EC2Instance) error {
 	return nil
 }
 
+// ReleaseResource removes the trunk from the cache without deleting the branch ENIs, the replica taking over the node
+// loads them again from EC2
+func (b *branchENIProvider) ReleaseResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	b.removeTrunkFromCache(nodeName)
+	b.setNodeDraining(nodeName, false)
+
+	b.log.Info("released resource provider", "node name", nodeName)
+	return nil
+}
+
 // SubmitAsyncJob submits the job to the k8s worker queue and returns immediately without waiting for the job to
 // complete. Using the k8s worker queue features we can ensure that the same job is not submitted more than once.
 func (b *branchENIProvider) SubmitAsyncJob(job interface{}) {
@@ -224,8 +264,14 @@ func (b *branchENIProvider) ProcessAsyncJob(job interface{}) (ctrl.Result, error
 
 	switch onDemandJob.Operation {
 	case worker.OperationCreate:
-		return b.CreateAndAnnotateResources(onDemandJob.PodNamespace, onDemandJob.PodName, onDemandJob.RequestCount)
+		return b.CreateAndAnnotateResources(worker.JobContext(job), onDemandJob.PodNamespace, onDemandJob.PodName,
+			onDemandJob.RequestCount)
 	case worker.OperationDeleted:
+		if onDemandJob.PodName != "" {
+			// The branch ENIs of the deleted pod are not updated anymore
+			b.takeSecurityGroupsOutdated(types.NamespacedName{Namespace: onDemandJob.PodNamespace,
+				Name: onDemandJob.PodName})
+		}
 		return b.DeleteBranchUsedByPods(onDemandJob.NodeName, onDemandJob.UID)
 	case worker.OperationProcessDeleteQueue:
 		return b.ProcessDeleteQueue(onDemandJob.NodeName)
@@ -245,17 +291,29 @@ func (b *branchENIProvider) DeleteNode(nodeName string) (ctrl.Result, error) {
 
 	trunkENI.DeleteAllBranchENIs()
 	b.removeTrunkFromCache(nodeName)
+	b.setNodeDraining(nodeName, false)
 
 	b.log.Info("de-initialized resource provider successfully", "nodeName", nodeName)
 
 	return ctrl.Result{}, nil
 }
 
+// UpdateNodeState tracks whether the node is being drained, it's called on every update of a managed node
+func (b *branchENIProvider) UpdateNodeState(node *v1.Node) {
+	if draining := utils.IsNodeDraining(node); b.setNodeDraining(node.Name, draining) {
+		b.log.Info("node draining status changed, branch ENIs are not created for pods on draining nodes",
+			"node", node.Name, "draining", draining)
+	}
+}
+
 // GetResourceCapacity returns the resource capacity for the given instance.
 func (b *branchENIProvider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
 	instanceName := instance.Name()
 	instanceType := instance.Type()
-	capacity := vpc.Limits[instanceType].BranchInterface
+	var capacity int
+	if limits, found := vpc.GetLimits(instanceType); found {
+		capacity = limits.BranchInterface
+	}
 
 	if capacity != 0 {
 		err := b.apiWrapper.K8sAPI.AdvertiseCapacityIfNotSet(instanceName, config.ResourceNamePodENI, capacity)
@@ -298,13 +356,33 @@ func (b *branchENIProvider) ProcessDeleteQueue(nodeName string) (ctrl.Result, er
 		log.Info("stopping the process delete queue job")
 		return ctrl.Result{}, nil
 	}
+	if b.isNodeDraining(nodeName) {
+		b.deleteBranchENIsIfUnused(nodeName, trunkENI)
+	}
 	trunkENI.DeleteCooledDownENIs()
 	return deleteQueueRequeueRequest, nil
 }
 
+// deleteBranchENIsIfUnused deletes all the branch ENIs of a draining node, including the ENIs still cooling down,
+// once no pod on the node uses a branch ENI. This releases the trunk associations before the instance is
+// terminated instead of after the node is deleted.
+func (b *branchENIProvider) deleteBranchENIsIfUnused(nodeName string, trunkENI trunk.TrunkENI) {
+	if deleted := trunkENI.DeleteQueuedENIsIfUnused(); deleted > 0 {
+		b.log.Info("deleted the branch ENIs of the draining node as no pod uses them", "node", nodeName,
+			"branch ENIs", deleted)
+	}
+}
+
 // CreateAndAnnotateResources creates resource for the pod, the function can run concurrently for different pods without
 // any locking as long as caller guarantees this function is not called concurrently for same pods.
-func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podName string, resourceCount int) (ctrl.Result, error) {
+func (b *branchENIProvider) CreateAndAnnotateResources(ctx context.Context, podNamespace string, podName string,
+	resourceCount int) (result ctrl.Result, err error) {
+	ctx, span := tracing.StartSpan(ctx, "branchENIProvider.CreateAndAnnotateResources",
+		attribute.String("pod.namespace", podNamespace),
+		attribute.String("pod.name", podName),
+		attribute.Int("resource.count", resourceCount))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Get the pod from cache
 	pod, err := b.apiWrapper.PodAPI.GetPod(podNamespace, podName)
 	if err != nil {
@@ -313,7 +391,11 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 	}
 
 	if _, ok := pod.Annotations[config.ResourceNamePodENI]; ok {
+		if b.takeSecurityGroupsOutdated(types.NamespacedName{Namespace: podNamespace, Name: podName}) {
+			return b.updateSecurityGroups(ctx, podNamespace, podName)
+		}
 		// Pod from cache already has annotation, skip the job
+		b.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 		return ctrl.Result{}, nil
 	}
 
@@ -328,41 +410,60 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		// Pod doesn't have an annotation yet. Create Branch ENI and annotate the pod
 		b.log.Info("skipping pod event as the pod already has pod-eni allocated",
 			"namespace", pod.Namespace, "name", pod.Name)
+		b.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 		return ctrl.Result{}, nil
 	}
 
 	securityGroups, err := b.apiWrapper.SGPAPI.GetMatchingSecurityGroupForPods(pod)
 	if err != nil {
+		b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonBranchAllocationFailed,
+			fmt.Sprintf("failed to get the security groups of the pod: %v", err))
 		return ctrl.Result{}, err
 	}
 
 	if len(securityGroups) == 0 {
 		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested,
-			"Pod will get the instance security group as the pod didn't match any Security Group from "+
-				"SecurityGroupPolicy", v1.EventTypeWarning)
+			tracing.WithTraceID(ctx, "Pod will get the instance security group as the pod didn't match any "+
+				"Security Group from SecurityGroupPolicy"), v1.EventTypeWarning)
 	} else {
-		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested, fmt.Sprintf("Pod will get the following "+
-			"Security Groups %v", securityGroups), v1.EventTypeNormal)
+		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested, tracing.WithTraceID(ctx,
+			fmt.Sprintf("Pod will get the following Security Groups %v", securityGroups)), v1.EventTypeNormal)
 	}
 
 	log := b.log.WithValues("pod namespace", pod.Namespace, "pod name", pod.Name, "nodeName", pod.Spec.NodeName)
 
+	if b.isNodeDraining(pod.Spec.NodeName) {
+		// The node may be uncordoned, retry later instead of failing the request
+		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonNodeDraining, tracing.WithTraceID(ctx,
+			"Branch ENI will not be allocated as the node is draining"), v1.EventTypeWarning)
+		log.Info("not allocating branch ENI as the node is draining")
+		b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonNodeDraining,
+			"Branch ENI will not be allocated as the node is draining")
+		return drainingNodeRequeueRequest, nil
+	}
+
 	start := time.Now()
 	trunkENI, isPresent := b.getTrunkFromCache(pod.Spec.NodeName)
 	if !isPresent {
 		// This should never happen
 		branchProviderOperationsErrCount.WithLabelValues("get_trunk_create").Inc()
-		return ctrl.Result{}, fmt.Errorf("trunk not found for node %s", pod.Spec.NodeName)
+		err = fmt.Errorf("trunk not found for node %s", pod.Spec.NodeName)
+		b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonBranchAllocationFailed, err.Error())
+		return ctrl.Result{}, err
 	}
 
 	// Get the list of branch ENIs that will be allocated to the pod object
-	branchENIs, err := trunkENI.CreateAndAssociateBranchENIs(pod, securityGroups, resourceCount)
+	branchENIs, err := trunkENI.CreateAndAssociateBranchENIs(ctx, pod, securityGroups, resourceCount)
 	if err != nil {
 		if err == trunk.ErrCurrentlyAtMaxCapacity {
+			b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonBranchAllocationFailed,
+				"waiting for branch ENIs to be released as the trunk ENI is at max capacity")
 			return ctrl.Result{RequeueAfter: cooldown.GetCoolDown().GetCoolDownPeriod(), Requeue: true}, nil
 		}
 		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonBranchAllocationFailed,
-			fmt.Sprintf("failed to allocate branch ENI to pod: %v", err), v1.EventTypeWarning)
+			tracing.WithTraceID(ctx, fmt.Sprintf("failed to allocate branch ENI to pod: %v", err)), v1.EventTypeWarning)
+		b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonBranchAllocationFailed,
+			fmt.Sprintf("failed to allocate branch ENI to pod: %v", err))
 		return ctrl.Result{}, err
 	}
 
@@ -385,23 +486,117 @@ func (b *branchENIProvider) CreateAndAnnotateResources(podNamespace string, podN
 		trunkENI.PushENIsToFrontOfDeleteQueue(pod, branchENIs)
 		b.log.Info("pushed the ENIs to the delete queue as failed to annotate the pod", "ENI/s", branchENIs)
 		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonBranchENIAnnotationFailed,
-			fmt.Sprintf("failed to annotate pod with branch ENI details: %v", err), v1.EventTypeWarning)
+			tracing.WithTraceID(ctx, fmt.Sprintf("failed to annotate pod with branch ENI details: %v", err)),
+			v1.EventTypeWarning)
+		b.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonBranchENIAnnotationFailed,
+			fmt.Sprintf("failed to annotate pod with branch ENI details: %v", err))
 		branchProviderOperationsErrCount.WithLabelValues("annotate_branch_eni").Inc()
 		return ctrl.Result{}, err
 	}
 
 	// Broadcast event to indicate the resource has been successfully created and annotated to the pod object
 	b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocated,
-		fmt.Sprintf("Allocated %s to the pod", string(jsonBytes)), v1.EventTypeNormal)
+		tracing.WithTraceID(ctx, fmt.Sprintf("Allocated %s to the pod", string(jsonBytes))), v1.EventTypeNormal)
 
 	branchProviderOperationLatency.WithLabelValues(operationAnnotateBranchENI, strconv.Itoa(resourceCount)).
 		Observe(timeSinceSeconds(start))
 
 	log.Info("created and annotated branch interface/s successfully", "branches", branchENIs)
+	b.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
 
 	return ctrl.Result{}, nil
 }
 
+// updateSecurityGroups replaces the security groups of the branch ENIs allocated to the pod with the security
+// groups currently matching the pod. The branch ENIs keep their security groups if the pod doesn't match any
+// SecurityGroupPolicy anymore, as the security groups of the node cannot be applied to a branch ENI.
+func (b *branchENIProvider) updateSecurityGroups(ctx context.Context, podNamespace string,
+	podName string) (ctrl.Result, error) {
+	key := types.NamespacedName{Namespace: podNamespace, Name: podName}
+
+	// The labels of the pod are not stored in the cache
+	pod, err := b.apiWrapper.PodAPI.GetPodFromAPIServer(b.ctx, podNamespace, podName)
+	if err != nil {
+		b.SetSecurityGroupsOutdated(key)
+		branchProviderOperationsErrCount.WithLabelValues("update_sg_get_pod").Inc()
+		return ctrl.Result{}, err
+	}
+
+	securityGroups, err := b.apiWrapper.SGPAPI.GetMatchingSecurityGroupForPods(pod)
+	if err != nil {
+		b.SetSecurityGroupsOutdated(key)
+		branchProviderOperationsErrCount.WithLabelValues("update_sg_get_security_groups").Inc()
+		return ctrl.Result{}, err
+	}
+	if len(securityGroups) == 0 {
+		b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested, tracing.WithTraceID(ctx,
+			"Branch ENI keeps its security groups as the pod doesn't match any Security Group from "+
+				"SecurityGroupPolicy anymore, the pod must be re-created to use the instance security group"),
+			v1.EventTypeWarning)
+		return ctrl.Result{}, nil
+	}
+
+	var branchENIs []*trunk.ENIDetails
+	if err := json.Unmarshal([]byte(pod.Annotations[config.ResourceNamePodENI]), &branchENIs); err != nil {
+		branchProviderOperationsErrCount.WithLabelValues("update_sg_unmarshal_annotation").Inc()
+		return ctrl.Result{}, fmt.Errorf("failed to unmarshal the branch ENI details of the pod: %w", err)
+	}
+
+	ec2APIHelper := ec2API.WithTraceContext(ec2API.WithAuditCaller(b.apiWrapper.EC2API,
+		ec2API.AuditCaller{PodUID: string(pod.UID)}), ctx)
+	for _, branchENI := range branchENIs {
+		if err := ec2APIHelper.SetSecurityGroups(&branchENI.ID, securityGroups); err != nil {
+			b.SetSecurityGroupsOutdated(key)
+			branchProviderOperationsErrCount.WithLabelValues("update_sg_branch_eni").Inc()
+			b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupsUpdated, tracing.WithTraceID(ctx,
+				fmt.Sprintf("failed to update the security groups of branch ENI %s: %v", branchENI.ID, err)),
+				v1.EventTypeWarning)
+			return ctrl.Result{}, err
+		}
+	}
+
+	b.log.Info("updated the security groups of the branch interface/s", "pod namespace", pod.Namespace,
+		"pod name", pod.Name, "security groups", securityGroups)
+	b.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupsUpdated, tracing.WithTraceID(ctx,
+		fmt.Sprintf("Updated the security groups of the branch ENIs to %v", securityGroups)), v1.EventTypeNormal)
+	return ctrl.Result{}, nil
+}
+
+// SetSecurityGroupsOutdated marks the security groups of the pod's branch ENIs as outdated
+func (b *branchENIProvider) SetSecurityGroupsOutdated(pod types.NamespacedName) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.outdatedSecurityGroups[pod] = struct{}{}
+}
+
+// takeSecurityGroupsOutdated returns true if the security groups of the pod's branch ENIs are outdated and clears
+// the mark
+func (b *branchENIProvider) takeSecurityGroupsOutdated(pod types.NamespacedName) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, outdated := b.outdatedSecurityGroups[pod]
+	delete(b.outdatedSecurityGroups, pod)
+	return outdated
+}
+
+// setNetworkingReadyCondition sets the networking ready condition on the pod, the failure to set the condition is
+// only logged as it doesn't affect the branch ENIs allocated to the pod
+func (b *branchENIProvider) setNetworkingReadyCondition(pod *v1.Pod, status v1.ConditionStatus, reason string,
+	message string) {
+	err := b.apiWrapper.PodAPI.SetPodCondition(pod, v1.PodCondition{
+		Type:    config.PodConditionNetworkingReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if err != nil {
+		b.log.Error(err, "failed to set the networking ready condition on the pod", "namespace", pod.Namespace,
+			"name", pod.Name, "status", status)
+	}
+}
+
 func (b *branchENIProvider) DeleteBranchUsedByPods(nodeName string, UID string) (ctrl.Result, error) {
 	trunkENI, isPresent := b.getTrunkFromCache(nodeName)
 	if !isPresent {
@@ -453,6 +648,29 @@ func (b *branchENIProvider) removeTrunkFromCache(nodeName string) {
 	log.Info("trunk removed from cache successfully")
 }
 
+// setNodeDraining adds the node to or removes it from the set of draining nodes and returns true if the set changed
+func (b *branchENIProvider) setNodeDraining(nodeName string, draining bool) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, wasDraining := b.drainingNodes[nodeName]
+	if draining {
+		b.drainingNodes[nodeName] = struct{}{}
+	} else {
+		delete(b.drainingNodes, nodeName)
+	}
+	return wasDraining != draining
+}
+
+// isNodeDraining returns true if the node is cordoned or tainted for termination
+func (b *branchENIProvider) isNodeDraining(nodeName string) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	_, draining := b.drainingNodes[nodeName]
+	return draining
+}
+
 // getTrunkFromCache returns the trunkENI form the cache for the given node name
 func (b *branchENIProvider) getTrunkFromCache(nodeName string) (trunkENI trunk.TrunkENI, present bool) {
 	b.lock.RLock()
@@ -473,7 +691,7 @@ func (b *branchENIProvider) IsInstanceSupported(instance ec2.EC2Instance) bool {
 		return false
 	}
 
-	limits, found := vpc.Limits[instance.Type()]
+	limits, found := vpc.GetLimits(instance.Type())
 	supported := found && limits.IsTrunkingCompatible
 
 	if !supported {
@@ -489,7 +707,7 @@ func (b *branchENIProvider) Introspect() interface{} {
 	b.lock.RLock()
 	defer b.lock.RUnlock()
 
-	allResponse := make(map[string]trunk.IntrospectResponse)
+	allResponse := provider.IntrospectNodes[trunk.IntrospectResponse]{}
 
 	for nodeName, trunkENI := range b.trunkENICache {
 		response := trunkENI.Introspect()
@@ -502,7 +720,7 @@ func (b *branchENIProvider) IntrospectSummary() interface{} {
 	b.lock.RLock()
 	defer b.lock.RUnlock()
 
-	allResponse := make(map[string]trunk.IntrospectSummaryResponse)
+	allResponse := provider.IntrospectNodes[trunk.IntrospectSummaryResponse]{}
 
 	for nodeName, trunkENI := range b.trunkENICache {
 		response := trunkENI.Introspect()
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	mock_pod "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
 	mock_trunk "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider/branch/trunk"
@@ -29,6 +30,7 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
@@ -83,9 +85,11 @@ func getProviderAndMocks(ctrl *gomock.Controller) (branchENIProvider, *mock_pod.
 			SGPAPI: mockSGPAPI,
 			K8sAPI: mockK8sAPI,
 		},
-		log:           log,
-		trunkENICache: make(map[string]trunk.TrunkENI),
-		ctx:           ctx,
+		log:                    log,
+		trunkENICache:          make(map[string]trunk.TrunkENI),
+		drainingNodes:          make(map[string]struct{}),
+		outdatedSecurityGroups: make(map[types.NamespacedName]struct{}),
+		ctx:                    ctx,
 	}, mockPodAPI, mockSGPAPI, mockK8sAPI
 }
 
@@ -100,6 +104,7 @@ func getProviderAndMockK8sWrapper(ctrl *gomock.Controller) (branchENIProvider, *
 		},
 		log:           log,
 		trunkENICache: make(map[string]trunk.TrunkENI),
+		drainingNodes: make(map[string]struct{}),
 	}, mockK8sWrapper
 }
 
@@ -116,6 +121,7 @@ func getProvider() branchENIProvider {
 	return branchENIProvider{
 		log:           log,
 		trunkENICache: make(map[string]trunk.TrunkENI),
+		drainingNodes: make(map[string]struct{}),
 	}
 }
 
@@ -241,6 +247,27 @@ func TestBranchENIProvider_DeleteBranchUsedByPods_PodNotFound(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// TestBranchENIProvider_ProcessAsyncJob_Deleted tests the delete job of a pod clears the outdated security groups
+// of the pod and pushes its branch ENIs to the cool down queue
+func TestBranchENIProvider_ProcessAsyncJob_Deleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, _, _, _ := getProviderAndMocks(ctrl)
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk1
+
+	key := types.NamespacedName{Namespace: MockPodNamespace1, Name: MockPodName1}
+	provider.SetSecurityGroupsOutdated(key)
+
+	fakeTrunk1.EXPECT().PushBranchENIsToCoolDownQueue(PodUID1)
+
+	_, err := provider.ProcessAsyncJob(worker.OnDemandJob{Operation: worker.OperationDeleted, NodeName: NodeName,
+		UID: PodUID1, PodNamespace: MockPodNamespace1, PodName: MockPodName1})
+	assert.NoError(t, err)
+	assert.NotContains(t, provider.outdatedSecurityGroups, key)
+}
+
 // TestBranchENIProvider_DeInitResources verifies that resources is removed from cache after calling de init workflow
 func TestBranchENIProvider_DeInitResources(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -257,6 +284,27 @@ func TestBranchENIProvider_DeInitResources(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_ReleaseResource tests that the trunk is removed from the cache without deleting its branch
+// ENIs
+func TestBranchENIProvider_ReleaseResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := getProvider()
+	fakeTrunk := mock_trunk.NewMockTrunkENI(ctrl)
+	mockInstance := mock_ec2.NewMockEC2Instance(ctrl)
+	provider.trunkENICache[NodeName] = fakeTrunk
+	provider.drainingNodes[NodeName] = struct{}{}
+
+	mockInstance.EXPECT().Name().Return(NodeName)
+
+	err := provider.ReleaseResource(mockInstance)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, provider.trunkENICache, NodeName)
+	assert.False(t, provider.isNodeDraining(NodeName))
+}
+
 // TestBranchENIProvider_GetResourceCapacity tests that the correct capacity is returned for supported instance types
 func TestBranchENIProvider_GetResourceCapacity(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -293,6 +341,18 @@ func TestBranchENIProvider_GetResourceCapacity_NotSupported(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_UpdateNodeState tests the node is tracked as draining while it's cordoned
+func TestBranchENIProvider_UpdateNodeState(t *testing.T) {
+	provider := getProvider()
+
+	provider.UpdateNodeState(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: NodeName},
+		Spec: v1.NodeSpec{Unschedulable: true}})
+	assert.True(t, provider.isNodeDraining(NodeName))
+
+	provider.UpdateNodeState(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: NodeName}})
+	assert.False(t, provider.isNodeDraining(NodeName))
+}
+
 func TestBranchENIProvider_Supported_LabelNode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -317,6 +377,18 @@ func TestBranchENIProvider_Supported_LabelNode(t *testing.T) {
 	assert.True(t, node.Labels[config.HasTrunkAttachedLabel] == config.BooleanFalse)
 }
 
+// expectNetworkingReadyCondition expects the networking ready condition to be set on the pod with the status and reason
+func expectNetworkingReadyCondition(t *testing.T, mockPodAPI *mock_pod.MockPodClientAPIWrapper, pod *v1.Pod,
+	status v1.ConditionStatus, reason string) {
+	mockPodAPI.EXPECT().SetPodCondition(pod, gomock.Any()).DoAndReturn(
+		func(_ *v1.Pod, condition v1.PodCondition) error {
+			assert.Equal(t, v1.PodConditionType(config.PodConditionNetworkingReady), condition.Type)
+			assert.Equal(t, status, condition.Status)
+			assert.Equal(t, reason, condition.Reason)
+			return nil
+		})
+}
+
 // TestBranchENIProvider_CreateAndAnnotateResources tests that create is invoked equal to the number of resources to
 // be created
 func TestBranchENIProvider_CreateAndAnnotateResources(t *testing.T) {
@@ -335,16 +407,40 @@ func TestBranchENIProvider_CreateAndAnnotateResources(t *testing.T) {
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(MockPod1).Return(SecurityGroups, nil)
 	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
-	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(MockPod1, SecurityGroups, resCount).Return(EniDetails, nil)
+	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(gomock.Any(), MockPod1, SecurityGroups, resCount).Return(EniDetails, nil)
 	mockPodAPI.EXPECT().AnnotatePod(MockPodNamespace1, MockPodName1, MockPodUID1, config.ResourceNamePodENI,
 		string(expectedAnnotation)).Return(nil)
 	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+	expectNetworkingReadyCondition(t, mockPodAPI, MockPod1, v1.ConditionTrue, ReasonResourceAllocated)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_CreateAndAnnotateResources_NodeDraining tests no branch ENI is created for a pod on a
+// draining node and the request is retried later
+func TestBranchENIProvider_CreateAndAnnotateResources_NodeDraining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, mockSGPAPI, mockK8sAPI := getProviderAndMocks(ctrl)
+	provider.trunkENICache[NodeName] = mock_trunk.NewMockTrunkENI(ctrl)
+	provider.setNodeDraining(NodeName, true)
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
+	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(MockPod1).Return(SecurityGroups, nil)
+	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
+	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonNodeDraining, gomock.Any(), v1.EventTypeWarning)
+	expectNetworkingReadyCondition(t, mockPodAPI, MockPod1, v1.ConditionFalse, ReasonNodeDraining)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, drainingNodeRequeueRequest, result)
+}
+
 func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -360,12 +456,78 @@ func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_Cache(t *
 	mockPodWithAnnotation.Annotations[config.ResourceNamePodENI] = "EniDetails"
 
 	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil)
+	expectNetworkingReadyCondition(t, mockPodAPI, mockPodWithAnnotation, v1.ConditionTrue, ReasonResourceAllocated)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
+
+	assert.NoError(t, err)
+}
+
+// TestBranchENIProvider_CreateAndAnnotateResources_SecurityGroupsOutdated tests the security groups of the branch
+// ENIs of an annotated pod are updated once if they are marked outdated
+func TestBranchENIProvider_CreateAndAnnotateResources_SecurityGroupsOutdated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	provider, mockPodAPI, mockSGPAPI, mockK8sAPI := getProviderAndMocks(ctrl)
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+	provider.apiWrapper.EC2API = mockEC2APIHelper
+
+	expectedAnnotation, _ := json.Marshal(EniDetails)
+	mockPodWithAnnotation := MockPod1.DeepCopy()
+	mockPodWithAnnotation.Annotations[config.ResourceNamePodENI] = string(expectedAnnotation)
+
+	provider.SetSecurityGroupsOutdated(types.NamespacedName{Namespace: MockPodNamespace1, Name: MockPodName1})
+
+	gomock.InOrder(
+		mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil),
+		mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).
+			Return(mockPodWithAnnotation, nil),
+		mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(mockPodWithAnnotation).Return(SecurityGroups, nil),
+		mockEC2APIHelper.EXPECT().SetSecurityGroups(&EniDetails[0].ID, SecurityGroups).Return(nil),
+		mockK8sAPI.EXPECT().BroadcastEvent(mockPodWithAnnotation, ReasonSecurityGroupsUpdated, gomock.Any(),
+			v1.EventTypeNormal),
+		// The next reconcile of the pod doesn't update the security groups again
+		mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil),
+	)
+	expectNetworkingReadyCondition(t, mockPodAPI, mockPodWithAnnotation, v1.ConditionTrue, ReasonResourceAllocated)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, 1)
+	assert.NoError(t, err)
 
+	_, err = provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, 1)
 	assert.NoError(t, err)
 }
 
+// TestBranchENIProvider_CreateAndAnnotateResources_SecurityGroupsOutdated_Error tests the security groups stay
+// outdated if they fail to be updated, so the update is retried
+func TestBranchENIProvider_CreateAndAnnotateResources_SecurityGroupsOutdated_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, mockPodAPI, mockSGPAPI, mockK8sAPI := getProviderAndMocks(ctrl)
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+	provider.apiWrapper.EC2API = mockEC2APIHelper
+
+	expectedAnnotation, _ := json.Marshal(EniDetails)
+	mockPodWithAnnotation := MockPod1.DeepCopy()
+	mockPodWithAnnotation.Annotations[config.ResourceNamePodENI] = string(expectedAnnotation)
+
+	key := types.NamespacedName{Namespace: MockPodNamespace1, Name: MockPodName1}
+	provider.SetSecurityGroupsOutdated(key)
+
+	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil)
+	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil)
+	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(mockPodWithAnnotation).Return(SecurityGroups, nil)
+	mockEC2APIHelper.EXPECT().SetSecurityGroups(&EniDetails[0].ID, SecurityGroups).Return(MockError)
+	mockK8sAPI.EXPECT().BroadcastEvent(mockPodWithAnnotation, ReasonSecurityGroupsUpdated, gomock.Any(),
+		v1.EventTypeWarning)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, 1)
+	assert.Error(t, err)
+	assert.True(t, provider.takeSecurityGroupsOutdated(key))
+}
+
 // TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotatedFromAPIServer tests that if the pod is already
 // annotated after getting the results from the API server no new ENIs will be created for it
 func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_APIServer(t *testing.T) {
@@ -384,8 +546,9 @@ func TestBranchENIProvider_CreateAndAnnotateResources_AlreadyAnnotated_APIServer
 
 	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(mockPodWithAnnotation, nil)
+	expectNetworkingReadyCondition(t, mockPodAPI, mockPodWithAnnotation, v1.ConditionTrue, ReasonResourceAllocated)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 
 	assert.NoError(t, err)
 }
@@ -405,7 +568,7 @@ func TestBranchENIProvider_CreateAndAnnotateResources_GetPodError(t *testing.T)
 	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(nil, MockError)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 
 	assert.Equal(t, MockError, err)
 }
@@ -425,7 +588,7 @@ func TestBranchENIProvider_CreateAndAnnotateResources_TrunkNotPreset(t *testing.
 	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(nil, MockError)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 	assert.NotNil(t, err)
 }
 
@@ -442,8 +605,9 @@ func TestBranchENIProvider_CreateAndAnnotateResources_GetSecurityGroup_Error(t *
 	mockPodAPI.EXPECT().GetPod(MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(MockPod1).Return(nil, MockError)
+	expectNetworkingReadyCondition(t, mockPodAPI, MockPod1, v1.ConditionFalse, ReasonBranchAllocationFailed)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 
 	assert.Error(t, err)
 }
@@ -466,13 +630,14 @@ func TestBranchENIProvider_CreateAndAnnotateResources_Annotate_Error(t *testing.
 	mockPodAPI.EXPECT().GetPodFromAPIServer(ctx, MockPodNamespace1, MockPodName1).Return(MockPod1, nil)
 	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
 	mockSGPAPI.EXPECT().GetMatchingSecurityGroupForPods(MockPod1).Return(SecurityGroups, nil)
-	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(MockPod1, SecurityGroups, resCount).Return(EniDetails, nil)
+	fakeTrunk.EXPECT().CreateAndAssociateBranchENIs(gomock.Any(), MockPod1, SecurityGroups, resCount).Return(EniDetails, nil)
 	mockPodAPI.EXPECT().AnnotatePod(MockPodNamespace1, MockPodName1, MockPodUID1,
 		config.ResourceNamePodENI, string(expectedAnnotation)).Return(MockError)
 	mockK8sAPI.EXPECT().BroadcastEvent(MockPod1, ReasonBranchENIAnnotationFailed, gomock.Any(), v1.EventTypeWarning)
+	expectNetworkingReadyCondition(t, mockPodAPI, MockPod1, v1.ConditionFalse, ReasonBranchENIAnnotationFailed)
 	fakeTrunk.EXPECT().PushENIsToFrontOfDeleteQueue(MockPod1, EniDetails)
 
-	_, err := provider.CreateAndAnnotateResources(MockPodNamespace1, MockPodName1, resCount)
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), MockPodNamespace1, MockPodName1, resCount)
 
 	assert.Error(t, MockError, err)
 }
@@ -554,26 +719,47 @@ func TestBranchENIProvider_ProcessDeleteQueue(t *testing.T) {
 	assert.Equal(t, deleteQueueRequeueRequest, result)
 }
 
-func TestBranchENIProvider_Introspect(t *testing.T) {
+// TestBranchENIProvider_ProcessDeleteQueue_NodeDraining tests the queued branch ENIs of a draining node are deleted
+// without waiting for the cool down period
+func TestBranchENIProvider_ProcessDeleteQueue_NodeDraining(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	provider := getProvider()
 	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
 	provider.trunkENICache[NodeName] = fakeTrunk1
+	provider.setNodeDraining(NodeName, true)
+
+	gomock.InOrder(
+		fakeTrunk1.EXPECT().DeleteQueuedENIsIfUnused().Return(2),
+		fakeTrunk1.EXPECT().DeleteCooledDownENIs(),
+	)
+
+	result, err := provider.ProcessDeleteQueue(NodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, deleteQueueRequeueRequest, result)
+}
+
+func TestBranchENIProvider_Introspect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	branchProvider := getProvider()
+	fakeTrunk1 := mock_trunk.NewMockTrunkENI(ctrl)
+	branchProvider.trunkENICache[NodeName] = fakeTrunk1
 
 	expectedResponse := trunk.IntrospectResponse{}
 
 	fakeTrunk1.EXPECT().Introspect().Return(expectedResponse)
-	resp := provider.Introspect()
+	resp := branchProvider.Introspect()
 	assert.True(t, reflect.DeepEqual(resp,
-		map[string]trunk.IntrospectResponse{NodeName: expectedResponse}))
+		provider.IntrospectNodes[trunk.IntrospectResponse]{NodeName: expectedResponse}))
 
 	fakeTrunk1.EXPECT().Introspect().Return(expectedResponse)
-	resp = provider.IntrospectNode(NodeName)
+	resp = branchProvider.IntrospectNode(NodeName)
 	assert.Equal(t, resp, expectedResponse)
 
-	resp = provider.IntrospectNode("unregistered-node")
+	resp = branchProvider.IntrospectNode("unregistered-node")
 	assert.Equal(t, resp, struct{}{})
 }
 
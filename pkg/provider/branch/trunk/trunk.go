@@ -14,6 +14,8 @@
 package trunk
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -43,6 +45,8 @@ const (
 	MaxAllocatableVlanIds = 121
 	// MaxDeleteRetries is the maximum number of times the ENI will be retried before being removed from the delete queue
 	MaxDeleteRetries = 3
+	// branchSubnetsCacheTTL is the duration the subnets selected by the pod annotation are not described again
+	branchSubnetsCacheTTL = time.Minute
 )
 
 var (
@@ -93,7 +97,7 @@ type TrunkENI interface {
 	// InitTrunk initializes trunk interface
 	InitTrunk(instance ec2.EC2Instance, pods []v1.Pod) error
 	// CreateAndAssociateBranchENIs creates and associate branch interface/s to trunk interface
-	CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []string, eniCount int) ([]*ENIDetails, error)
+	CreateAndAssociateBranchENIs(ctx context.Context, pod *v1.Pod, securityGroups []string, eniCount int) ([]*ENIDetails, error)
 	// PushBranchENIsToCoolDownQueue pushes the branch interface belonging to the pod to the cool down queue
 	PushBranchENIsToCoolDownQueue(UID string)
 	// DeleteCooledDownENIs deletes the interfaces that have been sitting in the queue for cool down period
@@ -104,6 +108,9 @@ type TrunkENI interface {
 	PushENIsToFrontOfDeleteQueue(*v1.Pod, []*ENIDetails)
 	// DeleteAllBranchENIs deletes all the branch ENI associated with the trunk and also clears the cool down queue
 	DeleteAllBranchENIs()
+	// DeleteQueuedENIsIfUnused deletes the ENIs in the cool down queue without waiting for the cool down period if no
+	// pod uses a branch ENI of the trunk and returns the number of ENIs deleted
+	DeleteQueuedENIsIfUnused() int
 	// Introspect returns the state of the Trunk ENI
 	Introspect() IntrospectResponse
 }
@@ -126,6 +133,10 @@ type trunkENI struct {
 	uidToBranchENIMap map[string][]*ENIDetails
 	// deleteQueue is the queue of ENIs that are being cooled down before being deleted
 	deleteQueue []*ENIDetails
+	// branchSubnetsLock guards the branch subnets cache, it's not held while describing the subnets
+	branchSubnetsLock sync.Mutex
+	// branchSubnets is the cache of the subnets selected by each value of the pod annotation in the instance subnet
+	branchSubnets map[string]cachedBranchSubnets
 }
 
 // PodENI is a json convertible structure that stores the Branch ENI details that can be
@@ -163,6 +174,21 @@ type IntrospectSummaryResponse struct {
 	DeleteQueueLen int
 }
 
+// FilterState returns the branch ENIs of the trunk in the state, the ENIs in the delete queue are cooling, returns
+// false if there are none
+func (i IntrospectResponse) FilterState(state string) (interface{}, bool) {
+	filtered := IntrospectResponse{TrunkENIID: i.TrunkENIID, InstanceID: i.InstanceID}
+	switch state {
+	case config.IntrospectStateUsed:
+		filtered.PodToBranchENI = i.PodToBranchENI
+		return filtered, len(filtered.PodToBranchENI) > 0
+	case config.IntrospectStateCooling:
+		filtered.DeleteQueue = i.DeleteQueue
+		return filtered, len(filtered.DeleteQueue) > 0
+	}
+	return nil, false
+}
+
 // NewTrunkENI returns a new Trunk ENI interface.
 func NewTrunkENI(logger logr.Logger, instance ec2.EC2Instance, helper api.EC2APIHelper) TrunkENI {
 
@@ -231,7 +257,7 @@ func (t *trunkENI) InitTrunk(instance ec2.EC2Instance, podList []v1.Pod) error {
 		}
 
 		trunk, err := t.ec2ApiHelper.CreateAndAttachNetworkInterface(&instanceID, aws.String(t.instance.SubnetID()),
-			t.instance.CurrentInstanceSecurityGroups(), nil, &freeIndex, &TrunkEniDescription, &InterfaceTypeTrunk, nil)
+			t.instance.CurrentInstanceSecurityGroups(), nil, &freeIndex, nil, &TrunkEniDescription, &InterfaceTypeTrunk, nil)
 		if err != nil {
 			trunkENIOperationsErrCount.WithLabelValues("create_trunk_eni").Inc()
 			return err
@@ -279,7 +305,7 @@ func (t *trunkENI) InitTrunk(instance ec2.EC2Instance, podList []v1.Pod) error {
 	}
 
 	// Get the list of branch ENIs
-	branchInterfaces, err := t.ec2ApiHelper.GetBranchNetworkInterface(&t.trunkENIId, aws.String(t.instance.SubnetID()))
+	branchInterfaces, err := t.ec2ApiHelper.GetBranchNetworkInterface(&t.trunkENIId)
 	if err != nil {
 		return err
 	}
@@ -373,9 +399,162 @@ func (t *trunkENI) Reconcile(pods []v1.Pod) bool {
 	return leakedENIs > 0
 }
 
+// branchSubnet is a subnet selected by the pod for its branch ENIs
+type branchSubnet struct {
+	id     string
+	cidr   string
+	v6Cidr string
+}
+
+// cachedBranchSubnets are the subnets selected by a value of the pod annotation and the time they were described
+type cachedBranchSubnets struct {
+	subnets     []branchSubnet
+	describedAt time.Time
+}
+
+// getBranchSubnets returns the subnets selected by the BranchENISubnetsAnnotation of the pod in the order of
+// preference. It returns nil if the pod doesn't select any subnet, in which case the instance subnet is used. The
+// subnets are cached for branchSubnetsCacheTTL so they're not described on every pod create.
+func (t *trunkENI) getBranchSubnets(ec2ApiHelper api.EC2APIHelper, pod *v1.Pod) ([]branchSubnet, error) {
+	value, ok := pod.Annotations[config.BranchENISubnetsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	instanceSubnetID := t.instance.SubnetID()
+	key := instanceSubnetID + "/" + value
+
+	t.branchSubnetsLock.Lock()
+	cached, found := t.branchSubnets[key]
+	t.branchSubnetsLock.Unlock()
+	if found && time.Since(cached.describedAt) < branchSubnetsCacheTTL {
+		return cached.subnets, nil
+	}
+
+	branchSubnets, err := t.describeBranchSubnets(ec2ApiHelper, instanceSubnetID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	t.branchSubnetsLock.Lock()
+	defer t.branchSubnetsLock.Unlock()
+	if t.branchSubnets == nil {
+		t.branchSubnets = make(map[string]cachedBranchSubnets)
+	}
+	for cachedKey, cachedSubnets := range t.branchSubnets {
+		if time.Since(cachedSubnets.describedAt) >= branchSubnetsCacheTTL {
+			delete(t.branchSubnets, cachedKey)
+		}
+	}
+	t.branchSubnets[key] = cachedBranchSubnets{subnets: branchSubnets, describedAt: time.Now()}
+	return branchSubnets, nil
+}
+
+// describeBranchSubnets describes the subnets selected by the value of the BranchENISubnetsAnnotation in the
+// availability zone of the instance subnet
+func (t *trunkENI) describeBranchSubnets(ec2ApiHelper api.EC2APIHelper, instanceSubnetID string,
+	value string) ([]branchSubnet, error) {
+	selectors, err := config.ParseBranchENISubnets(value)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceSubnet, err := ec2ApiHelper.GetSubnet(aws.String(instanceSubnetID))
+	if err != nil {
+		return nil, fmt.Errorf("getting instance subnet, %w", err)
+	}
+	// Branch ENIs can only be created in the subnets of the availability zone and the VPC of the instance
+	zoneFilters := []*awsEC2.Filter{
+		{Name: aws.String("availability-zone"), Values: []*string{instanceSubnet.AvailabilityZone}},
+		{Name: aws.String("vpc-id"), Values: []*string{instanceSubnet.VpcId}},
+	}
+
+	var branchSubnets []branchSubnet
+	added := make(map[string]struct{})
+	for _, selector := range selectors {
+		var selectorFilter *awsEC2.Filter
+		switch {
+		case selector.SubnetID != "":
+			selectorFilter = &awsEC2.Filter{Name: aws.String("subnet-id"),
+				Values: []*string{aws.String(selector.SubnetID)}}
+		case selector.TagValue != "":
+			selectorFilter = &awsEC2.Filter{Name: aws.String("tag:" + selector.TagKey),
+				Values: []*string{aws.String(selector.TagValue)}}
+		default:
+			selectorFilter = &awsEC2.Filter{Name: aws.String("tag-key"),
+				Values: []*string{aws.String(selector.TagKey)}}
+		}
+
+		subnets, err := ec2ApiHelper.GetSubnets(append(slices.Clip(zoneFilters), selectorFilter))
+		if err != nil {
+			return nil, fmt.Errorf("getting subnets for %s, %w", selector, err)
+		}
+		if len(subnets) == 0 {
+			t.log.Info("no subnet in the instance availability zone matches the selector", "selector",
+				selector.String(), "availability zone", aws.StringValue(instanceSubnet.AvailabilityZone))
+			continue
+		}
+		// Prefer the subnets with the most free addresses when a tag selector matches multiple subnets
+		slices.SortStableFunc(subnets, func(a, b *awsEC2.Subnet) int {
+			return cmp.Compare(aws.Int64Value(b.AvailableIpAddressCount), aws.Int64Value(a.AvailableIpAddressCount))
+		})
+		for _, subnet := range subnets {
+			if _, ok := added[aws.StringValue(subnet.SubnetId)]; ok {
+				continue
+			}
+			added[aws.StringValue(subnet.SubnetId)] = struct{}{}
+			newSubnet := branchSubnet{id: aws.StringValue(subnet.SubnetId), cidr: aws.StringValue(subnet.CidrBlock)}
+			for _, v6CidrBlock := range subnet.Ipv6CidrBlockAssociationSet {
+				if v6CidrBlock.Ipv6CidrBlock != nil {
+					newSubnet.v6Cidr = *v6CidrBlock.Ipv6CidrBlock
+					break
+				}
+			}
+			branchSubnets = append(branchSubnets, newSubnet)
+		}
+	}
+
+	if len(branchSubnets) == 0 {
+		return nil, fmt.Errorf("no subnet in availability zone %s matches %s",
+			aws.StringValue(instanceSubnet.AvailabilityZone), value)
+	}
+	return branchSubnets, nil
+}
+
+// createBranchENI creates a branch ENI in the instance subnet, or in the first of the branch subnets having free
+// addresses, and returns it along with the CIDR blocks of its subnet
+func (t *trunkENI) createBranchENI(ec2ApiHelper api.EC2APIHelper, branchSubnets []branchSubnet,
+	securityGroups []string, tags []*awsEC2.Tag) (*awsEC2.NetworkInterface, string, string, error) {
+	if len(branchSubnets) == 0 {
+		nwInterface, err := ec2ApiHelper.CreateNetworkInterface(&BranchEniDescription,
+			aws.String(t.instance.SubnetID()), securityGroups, tags, nil, nil)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return nwInterface, t.instance.SubnetCidrBlock(), t.instance.SubnetV6CidrBlock(), nil
+	}
+
+	var err error
+	for _, subnet := range branchSubnets {
+		var nwInterface *awsEC2.NetworkInterface
+		nwInterface, err = ec2ApiHelper.CreateNetworkInterface(&BranchEniDescription, aws.String(subnet.id),
+			securityGroups, tags, nil, nil)
+		if err == nil {
+			return nwInterface, subnet.cidr, subnet.v6Cidr, nil
+		}
+		if !strings.Contains(err.Error(), ec2Errors.InsufficientFreeAddresses) {
+			return nil, "", "", err
+		}
+		// Fall back to the next subnet in the order of preference
+		t.log.Info("subnet has no free addresses for the branch ENI", "subnet", subnet.id)
+		branchENIOperationsFailureCount.WithLabelValues("branch_subnet_exhausted").Inc()
+	}
+	return nil, "", "", err
+}
+
 // CreateAndAssociateBranchToTrunk creates a new branch network interface and associates the branch to the trunk
 // network interface. It returns a Json convertible structure which has all the required details of the branch ENI
-func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []string, eniCount int) ([]*ENIDetails, error) {
+func (t *trunkENI) CreateAndAssociateBranchENIs(ctx context.Context, pod *v1.Pod, securityGroups []string,
+	eniCount int) ([]*ENIDetails, error) {
 	log := t.log.WithValues("request", "create", "pod namespace", pod.Namespace, "pod name", pod.Name)
 
 	branchENI, isPresent := t.getBranchFromCache(string(pod.UID))
@@ -397,6 +576,17 @@ func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []st
 	var err error
 	var nwInterface *awsEC2.NetworkInterface
 	var vlanID int
+	var subnetCIDR, subnetV6CIDR string
+
+	// Attribute the branch ENI calls to the pod in the EC2 audit log and trace them as part of the pod's request
+	ec2ApiHelper := api.WithTraceContext(
+		api.WithAuditCaller(t.ec2ApiHelper, api.AuditCaller{PodUID: string(pod.UID)}), ctx)
+
+	branchSubnets, err := t.getBranchSubnets(ec2ApiHelper, pod)
+	if err != nil {
+		trunkENIOperationsErrCount.WithLabelValues("get_branch_subnets").Inc()
+		return nil, fmt.Errorf("getting branch subnets, %w", err)
+	}
 
 	for i := 0; i < eniCount; i++ {
 		// Assign VLAN
@@ -419,8 +609,7 @@ func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []st
 			},
 		}
 		// Create Branch ENI
-		nwInterface, err = t.ec2ApiHelper.CreateNetworkInterface(&BranchEniDescription,
-			aws.String(t.instance.SubnetID()), securityGroups, tags, nil, nil)
+		nwInterface, subnetCIDR, subnetV6CIDR, err = t.createBranchENI(ec2ApiHelper, branchSubnets, securityGroups, tags)
 		if err != nil {
 			err = fmt.Errorf("creating network interface, %w", err)
 			t.freeVlanId(vlanID)
@@ -439,12 +628,12 @@ func (t *trunkENI) CreateAndAssociateBranchENIs(pod *v1.Pod, securityGroups []st
 			v6Addr = *nwInterface.Ipv6Address
 		}
 		newENI := &ENIDetails{ID: *nwInterface.NetworkInterfaceId, MACAdd: *nwInterface.MacAddress,
-			IPV4Addr: v4Addr, IPV6Addr: v6Addr, SubnetCIDR: t.instance.SubnetCidrBlock(),
-			SubnetV6CIDR: t.instance.SubnetV6CidrBlock(), VlanID: vlanID}
+			IPV4Addr: v4Addr, IPV6Addr: v6Addr, SubnetCIDR: subnetCIDR,
+			SubnetV6CIDR: subnetV6CIDR, VlanID: vlanID}
 		newENIs = append(newENIs, newENI)
 
 		// Associate Branch to trunk
-		_, err = t.ec2ApiHelper.AssociateBranchToTrunk(&t.trunkENIId, nwInterface.NetworkInterfaceId, vlanID)
+		_, err = ec2ApiHelper.AssociateBranchToTrunk(&t.trunkENIId, nwInterface.NetworkInterfaceId, vlanID)
 		if err != nil {
 			err = fmt.Errorf("associating branch to trunk, %w", err)
 			trunkENIOperationsErrCount.WithLabelValues("associate_branch").Inc()
@@ -543,6 +732,35 @@ func (t *trunkENI) DeleteCooledDownENIs() {
 	}
 }
 
+// DeleteQueuedENIsIfUnused drains the cool down queue and deletes its ENIs if no pod uses a branch ENI of the trunk.
+// The queue is drained under the lock so the ENIs are not deleted again by a concurrent DeleteCooledDownENIs.
+func (t *trunkENI) DeleteQueuedENIsIfUnused() int {
+	t.lock.Lock()
+	if len(t.uidToBranchENIMap) > 0 || len(t.deleteQueue) == 0 {
+		t.lock.Unlock()
+		return 0
+	}
+	enis := t.deleteQueue
+	t.deleteQueue = nil
+	t.lock.Unlock()
+
+	deleted := 0
+	for _, eni := range enis {
+		if err := t.deleteENI(eni); err != nil {
+			eni.deleteRetryCount++
+			if eni.deleteRetryCount >= MaxDeleteRetries {
+				t.log.Error(err, "forgetting eni as max retries exceeded", "eni", eni)
+				continue
+			}
+			t.log.Error(err, "failed to delete eni, will retry", "eni", eni)
+			t.pushENIToDeleteQueue(eni)
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
 // deleteENIs deletes the provided ENIs and frees up the Vlan assigned to then
 func (t *trunkENI) deleteENI(eniDetail *ENIDetails) (err error) {
 	// Delete Branch network interface first
@@ -706,7 +924,8 @@ func (t *trunkENI) canCreateMore() bool {
 		usedBranches += len(branches)
 	}
 
-	if usedBranches+len(t.deleteQueue) < vpc.Limits[t.instance.Type()].BranchInterface {
+	limits, found := vpc.GetLimits(t.instance.Type())
+	if found && usedBranches+len(t.deleteQueue) < limits.BranchInterface {
 		return true
 	}
 	return false
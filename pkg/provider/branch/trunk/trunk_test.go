@@ -14,6 +14,7 @@
 package trunk
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -442,7 +443,7 @@ func TestTrunkENI_DeleteCooledDownENIs_NotCooledDown(t *testing.T) {
 	trunkENI.deleteQueue = append(trunkENI.deleteQueue, EniDetails1, EniDetails2)
 
 	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil)
+	mockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil)
 	cooldown.InitCoolDownPeriod(mockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 
 	trunkENI.DeleteCooledDownENIs()
@@ -467,7 +468,7 @@ func TestTrunkENI_DeleteCooledDownENIs_NoDeletionTimeStamp(t *testing.T) {
 	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails2.ID).Return(nil)
 
 	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil)
+	mockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil)
 	cooldown.InitCoolDownPeriod(mockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 
 	trunkENI.DeleteCooledDownENIs()
@@ -490,7 +491,7 @@ func TestTrunkENI_DeleteCooledDownENIs_CooledDownResource(t *testing.T) {
 	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(nil)
 
 	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("30"), nil)
+	mockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("30"), nil)
 	cooldown.InitCoolDownPeriod(mockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 
 	trunkENI.DeleteCooledDownENIs()
@@ -519,13 +520,53 @@ func TestTrunkENI_DeleteCooledDownENIs_DeleteFailed(t *testing.T) {
 	)
 
 	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
-	mockK8sAPI.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(createCoolDownMockCM("60"), nil)
+	mockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("60"), nil)
 	cooldown.InitCoolDownPeriod(mockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
 
 	trunkENI.DeleteCooledDownENIs()
 	assert.Zero(t, len(trunkENI.deleteQueue))
 }
 
+// TestTrunkENI_DeleteQueuedENIsIfUnused tests the ENIs in the cool down queue are deleted exactly once without
+// waiting for the cool down period when no pod uses a branch ENI
+func TestTrunkENI_DeleteQueuedENIsIfUnused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, ec2APIHelper, _ := getMockHelperInstanceAndTrunkObject(ctrl)
+	EniDetails1.deletionTimeStamp = time.Now()
+	EniDetails2.deletionTimeStamp = time.Now()
+	trunkENI.usedVlanIds[VlanId1] = true
+	trunkENI.usedVlanIds[VlanId2] = true
+	trunkENI.deleteQueue = append(trunkENI.deleteQueue, EniDetails1, EniDetails2)
+
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails1.ID).Return(nil).Times(1)
+	ec2APIHelper.EXPECT().DeleteNetworkInterface(&EniDetails2.ID).Return(nil).Times(1)
+
+	mockK8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
+	mockK8sAPI.EXPECT().GetControllerConfigMap().Return(createCoolDownMockCM("0"), nil)
+	cooldown.InitCoolDownPeriod(mockK8sAPI, zap.New(zap.UseDevMode(true)).WithName("cooldown"))
+
+	assert.Equal(t, 2, trunkENI.DeleteQueuedENIsIfUnused())
+	assert.Zero(t, trunkENI.DeleteQueuedENIsIfUnused())
+	trunkENI.DeleteCooledDownENIs()
+
+	assert.Empty(t, trunkENI.deleteQueue)
+	assert.False(t, trunkENI.usedVlanIds[VlanId1])
+	assert.False(t, trunkENI.usedVlanIds[VlanId2])
+}
+
+// TestTrunkENI_DeleteQueuedENIsIfUnused_PodUsingBranch tests the ENIs in the cool down queue are not deleted while a
+// pod uses a branch ENI
+func TestTrunkENI_DeleteQueuedENIsIfUnused_PodUsingBranch(t *testing.T) {
+	trunkENI := getMockTrunk()
+	trunkENI.uidToBranchENIMap[PodUID] = []*ENIDetails{EniDetails1}
+	trunkENI.deleteQueue = append(trunkENI.deleteQueue, EniDetails2)
+
+	assert.Zero(t, trunkENI.DeleteQueuedENIsIfUnused())
+	assert.Equal(t, []*ENIDetails{EniDetails2}, trunkENI.deleteQueue)
+}
+
 // TestTrunkENI_PushBranchENIsToCoolDownQueue tests that ENIs are pushed to the delete queue if the pod is being deleted
 func TestTrunkENI_PushBranchENIsToCoolDownQueue(t *testing.T) {
 	trunkENI := getMockTrunk()
@@ -599,7 +640,7 @@ func TestTrunkENI_InitTrunk(t *testing.T) {
 				f.mockInstance.EXPECT().GetHighestUnusedDeviceIndex().Return(freeIndex, nil)
 				f.mockInstance.EXPECT().SubnetID().Return(SubnetId)
 				f.mockEC2APIHelper.EXPECT().CreateAndAttachNetworkInterface(&InstanceId, &SubnetId, SecurityGroups, nil,
-					&freeIndex, &TrunkEniDescription, &InterfaceTypeTrunk, nil).Return(trunkInterface, nil)
+					&freeIndex, nil, &TrunkEniDescription, &InterfaceTypeTrunk, nil).Return(trunkInterface, nil)
 			},
 			// Pass nil to set the instance to fields.mockInstance in the function later
 			args:    args{instance: nil, podList: []v1.Pod{*MockPod2}},
@@ -648,8 +689,7 @@ func TestTrunkENI_InitTrunk(t *testing.T) {
 				f.mockInstance.EXPECT().GetCustomNetworkingSpec().Return("", []string{})
 				f.mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&InstanceId).Return(instanceNwInterfaces, nil)
 				f.mockEC2APIHelper.EXPECT().WaitForNetworkInterfaceStatusChange(&trunkId, awsEc2.AttachmentStatusAttached).Return(nil)
-				f.mockInstance.EXPECT().SubnetID().Return(SubnetId)
-				f.mockEC2APIHelper.EXPECT().GetBranchNetworkInterface(&trunkId, &SubnetId).Return(branchInterfaces, nil)
+				f.mockEC2APIHelper.EXPECT().GetBranchNetworkInterface(&trunkId).Return(branchInterfaces, nil)
 			},
 			args:    args{instance: FakeInstance, podList: []v1.Pod{*MockPod1, *MockPod2}},
 			wantErr: false,
@@ -671,6 +711,30 @@ func TestTrunkENI_InitTrunk(t *testing.T) {
 				assert.False(t, isPresent)
 			},
 		},
+		{
+			name: "TrunkExists_BranchesInOtherSubnet, verifies the branches outside the instance subnet are restored",
+			prepare: func(f *fields) {
+				f.mockInstance.EXPECT().InstanceID().Return(InstanceId)
+				f.mockInstance.EXPECT().GetCustomNetworkingSpec().Return("", []string{})
+				f.mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&InstanceId).Return(instanceNwInterfaces, nil)
+				f.mockEC2APIHelper.EXPECT().WaitForNetworkInterfaceStatusChange(&trunkId, awsEc2.AttachmentStatusAttached).Return(nil)
+				// The branches were created in a subnet selected by the pod
+				f.mockEC2APIHelper.EXPECT().GetBranchNetworkInterface(&trunkId).Return([]*awsEc2.NetworkInterface{
+					{NetworkInterfaceId: &EniDetails1.ID, SubnetId: aws.String("subnet-branch"), TagSet: vlan1Tag},
+					{NetworkInterfaceId: &EniDetails2.ID, SubnetId: aws.String("subnet-branch"), TagSet: vlan2Tag},
+				}, nil)
+			},
+			args:    args{instance: FakeInstance, podList: []v1.Pod{*MockPod1}},
+			wantErr: false,
+			asserts: func(f *fields) {
+				branchENIs, isPresent := f.trunkENI.uidToBranchENIMap[PodUID]
+				assert.True(t, isPresent)
+				assert.Len(t, branchENIs, 2)
+				assert.True(t, f.trunkENI.usedVlanIds[EniDetails1.VlanID])
+				assert.True(t, f.trunkENI.usedVlanIds[EniDetails2.VlanID])
+				assert.Empty(t, f.trunkENI.deleteQueue)
+			},
+		},
 		{
 			name: "TrunkExists_DanglingENIs, verifies ENIs are pushed to delete queue if no pod exists",
 			prepare: func(f *fields) {
@@ -678,8 +742,7 @@ func TestTrunkENI_InitTrunk(t *testing.T) {
 				f.mockInstance.EXPECT().GetCustomNetworkingSpec().Return("", []string{})
 				f.mockEC2APIHelper.EXPECT().GetInstanceNetworkInterface(&InstanceId).Return(instanceNwInterfaces, nil)
 				f.mockEC2APIHelper.EXPECT().WaitForNetworkInterfaceStatusChange(&trunkId, awsEc2.AttachmentStatusAttached).Return(nil)
-				f.mockInstance.EXPECT().SubnetID().Return(SubnetId)
-				f.mockEC2APIHelper.EXPECT().GetBranchNetworkInterface(&trunkId, &SubnetId).Return(branchInterfaces, nil)
+				f.mockEC2APIHelper.EXPECT().GetBranchNetworkInterface(&trunkId).Return(branchInterfaces, nil)
 			},
 			args:    args{instance: FakeInstance, podList: []v1.Pod{*MockPod2}},
 			wantErr: false,
@@ -769,7 +832,7 @@ func TestTrunkENI_CreateAndAssociateBranchENIs(t *testing.T) {
 		nil, nil).Return(BranchInterface2, nil)
 	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch2Id, VlanId2).Return(nil, nil)
 
-	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
+	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), MockPod2, SecurityGroups, 2)
 	expectedENIDetails := []*ENIDetails{EniDetails1, EniDetails2}
 
 	assert.NoError(t, err)
@@ -803,7 +866,7 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_InstanceSecurityGroup(t *testing.
 		vlan2Tag, nil, nil).Return(BranchInterface2, nil)
 	mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch2Id, VlanId2).Return(nil, nil)
 
-	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, []string{}, 2)
+	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), MockPod2, []string{}, 2)
 	expectedENIDetails := []*ENIDetails{EniDetails1, EniDetails2}
 
 	assert.NoError(t, err)
@@ -838,7 +901,7 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_ErrorAssociate(t *testing.T) {
 		mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch2Id, VlanId2).Return(nil, MockError),
 	)
 
-	_, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
+	_, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), MockPod2, SecurityGroups, 2)
 	assert.Error(t, MockError, err)
 	assert.Equal(t, []*ENIDetails{EniDetails1, EniDetails2}, trunkENI.deleteQueue)
 }
@@ -865,11 +928,118 @@ func TestTrunkENI_CreateAndAssociateBranchENIs_ErrorCreate(t *testing.T) {
 			nil, nil).Return(nil, MockError),
 	)
 
-	_, err := trunkENI.CreateAndAssociateBranchENIs(MockPod2, SecurityGroups, 2)
+	_, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), MockPod2, SecurityGroups, 2)
 	assert.Error(t, MockError, err)
 	assert.Equal(t, []*ENIDetails{EniDetails1}, trunkENI.deleteQueue)
 }
 
+// TestTrunkENI_CreateAndAssociateBranchENIs_BranchSubnets tests the branch ENIs are created in the subnets selected by
+// the pod annotation and the next subnet is used when the preferred subnet is exhausted
+func TestTrunkENI_CreateAndAssociateBranchENIs_BranchSubnets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+
+	pod := MockPod2.DeepCopy()
+	pod.Annotations[config.BranchENISubnetsAnnotation] = "subnet-preferred,tag:routing=strict"
+
+	zoneFilters := []*awsEc2.Filter{
+		{Name: aws.String("availability-zone"), Values: []*string{aws.String("us-west-2a")}},
+		{Name: aws.String("vpc-id"), Values: []*string{aws.String("vpc-1")}},
+	}
+	preferredSubnet := &awsEc2.Subnet{SubnetId: aws.String("subnet-preferred"), CidrBlock: aws.String("10.0.0.0/24")}
+	taggedSubnet1 := &awsEc2.Subnet{SubnetId: aws.String("subnet-tagged-1"), CidrBlock: aws.String("10.0.1.0/24"),
+		AvailableIpAddressCount: aws.Int64(10)}
+	taggedSubnet2 := &awsEc2.Subnet{SubnetId: aws.String("subnet-tagged-2"), CidrBlock: aws.String("10.0.2.0/24"),
+		AvailableIpAddressCount: aws.Int64(100)}
+	exhaustedErr := fmt.Errorf("%s: There are not enough free addresses", "InsufficientFreeAddressesInSubnet")
+
+	mockInstance.EXPECT().Type().Return(InstanceType)
+	mockInstance.EXPECT().SubnetID().Return(SubnetId)
+	mockEC2APIHelper.EXPECT().GetSubnet(&SubnetId).Return(&awsEc2.Subnet{SubnetId: &SubnetId,
+		AvailabilityZone: aws.String("us-west-2a"), VpcId: aws.String("vpc-1")}, nil)
+	mockEC2APIHelper.EXPECT().GetSubnets(append(zoneFilters, &awsEc2.Filter{Name: aws.String("subnet-id"),
+		Values: []*string{aws.String("subnet-preferred")}})).Return([]*awsEc2.Subnet{preferredSubnet}, nil)
+	mockEC2APIHelper.EXPECT().GetSubnets(append(zoneFilters, &awsEc2.Filter{Name: aws.String("tag:routing"),
+		Values: []*string{aws.String("strict")}})).Return([]*awsEc2.Subnet{taggedSubnet1, taggedSubnet2}, nil)
+
+	gomock.InOrder(
+		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, aws.String("subnet-preferred"),
+			SecurityGroups, vlan1Tag, nil, nil).Return(nil, exhaustedErr),
+		mockEC2APIHelper.EXPECT().CreateNetworkInterface(&BranchEniDescription, aws.String("subnet-tagged-2"),
+			SecurityGroups, vlan1Tag, nil, nil).Return(BranchInterface1, nil),
+		mockEC2APIHelper.EXPECT().AssociateBranchToTrunk(&trunkId, &Branch1Id, VlanId1).Return(nil, nil),
+	)
+
+	eniDetails, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), pod, SecurityGroups, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, eniDetails, 1)
+	assert.Equal(t, Branch1Id, eniDetails[0].ID)
+	assert.Equal(t, "10.0.2.0/24", eniDetails[0].SubnetCIDR)
+}
+
+// TestTrunkENI_CreateAndAssociateBranchENIs_NoBranchSubnet tests an error is returned when no subnet in the availability
+// zone of the instance matches the pod annotation
+func TestTrunkENI_CreateAndAssociateBranchENIs_NoBranchSubnet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+	trunkENI.trunkENIId = trunkId
+
+	pod := MockPod2.DeepCopy()
+	pod.Annotations[config.BranchENISubnetsAnnotation] = "tag:routing"
+
+	mockInstance.EXPECT().Type().Return(InstanceType)
+	mockInstance.EXPECT().SubnetID().Return(SubnetId)
+	mockEC2APIHelper.EXPECT().GetSubnet(&SubnetId).Return(&awsEc2.Subnet{SubnetId: &SubnetId,
+		AvailabilityZone: aws.String("us-west-2a"), VpcId: aws.String("vpc-1")}, nil)
+	mockEC2APIHelper.EXPECT().GetSubnets(gomock.Any()).Return(nil, nil)
+
+	_, err := trunkENI.CreateAndAssociateBranchENIs(context.TODO(), pod, SecurityGroups, 1)
+
+	assert.Error(t, err)
+	assert.Empty(t, trunkENI.uidToBranchENIMap)
+}
+
+// TestTrunkENI_getBranchSubnets_Cached tests the subnets selected by the pod annotation are described again only once
+// the cache entry expires
+func TestTrunkENI_getBranchSubnets_Cached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trunkENI, mockEC2APIHelper, mockInstance := getMockHelperInstanceAndTrunkObject(ctrl)
+
+	pod := MockPod2.DeepCopy()
+	pod.Annotations[config.BranchENISubnetsAnnotation] = "subnet-preferred"
+	preferredSubnet := &awsEc2.Subnet{SubnetId: aws.String("subnet-preferred"), CidrBlock: aws.String("10.0.0.0/24")}
+
+	mockInstance.EXPECT().SubnetID().Return(SubnetId).Times(3)
+	mockEC2APIHelper.EXPECT().GetSubnet(&SubnetId).Return(&awsEc2.Subnet{SubnetId: &SubnetId,
+		AvailabilityZone: aws.String("us-west-2a"), VpcId: aws.String("vpc-1")}, nil).Times(2)
+	mockEC2APIHelper.EXPECT().GetSubnets(gomock.Any()).Return([]*awsEc2.Subnet{preferredSubnet}, nil).Times(2)
+
+	expected := []branchSubnet{{id: "subnet-preferred", cidr: "10.0.0.0/24"}}
+	for i := 0; i < 2; i++ {
+		branchSubnets, err := trunkENI.getBranchSubnets(mockEC2APIHelper, pod)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, branchSubnets)
+	}
+
+	// Expire the cache entry
+	key := SubnetId + "/subnet-preferred"
+	cached := trunkENI.branchSubnets[key]
+	cached.describedAt = time.Now().Add(-branchSubnetsCacheTTL)
+	trunkENI.branchSubnets[key] = cached
+
+	branchSubnets, err := trunkENI.getBranchSubnets(mockEC2APIHelper, pod)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, branchSubnets)
+}
+
 func TestTrunkENI_Introspect(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,225 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dedicated
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/attachedeni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	operationLabel = "dedicated_eni_provider_operation"
+
+	ReasonSecurityGroupRequested       = utils.SecurityGroupRequestedReason
+	ReasonResourceAllocated            = "ResourceAllocated"
+	ReasonDedicatedENIAttachFailed     = "DedicatedENIAttachFailed"
+	ReasonDedicatedENIAnnotationFailed = "DedicatedENIAnnotationFailed"
+)
+
+var (
+	// NetworkInterfaceDescription is the description of the dedicated pod ENIs, it identifies the ENIs left attached
+	// to the instance after their pod was deleted
+	NetworkInterfaceDescription = "dedicated-pod-eni"
+
+	dedicatedENIProviderOperationsErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedicated_eni_provider_operations_err_count",
+			Help: "The number of errors encountered for the dedicated pod ENI operations",
+		},
+		[]string{operationLabel},
+	)
+
+	prometheusRegistered = false
+)
+
+// dedicatedENIStrategy attaches a dedicated ENI with the pod's security groups to the default network card of the
+// instance for the pods requesting pod-eni on the instances that don't support trunking. The pods are annotated with
+// the branch ENI details of the ENIs, with the VLAN ID 0 as the ENIs are not associated with a trunk ENI.
+type dedicatedENIStrategy struct {
+	// log is the logger initialized with dedicated eni provider value
+	log        logr.Logger
+	apiWrapper api.Wrapper
+}
+
+// NewDedicatedENIProvider returns the dedicated pod ENI provider for all the nodes across the cluster
+func NewDedicatedENIProvider(logger logr.Logger, wrapper api.Wrapper, worker worker.Worker,
+	ctx context.Context) provider.ResourceProvider {
+	prometheusRegister()
+
+	return attachedeni.NewAttachedENIProvider(logger, wrapper, worker, &dedicatedENIStrategy{
+		log:        logger,
+		apiWrapper: wrapper,
+	}, ctx)
+}
+
+// prometheusRegister registers prometheus metrics
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(dedicatedENIProviderOperationsErrCount)
+
+		prometheusRegistered = true
+	}
+}
+
+// IsDedicatedENIs returns true if the branch ENI details annotated on a pod are dedicated ENIs, the dedicated ENIs
+// are annotated with the VLAN ID 0 which is never used by the branch ENIs
+func IsDedicatedENIs(enis []*trunk.ENIDetails) bool {
+	for _, eni := range enis {
+		if eni.VlanID != 0 {
+			return false
+		}
+	}
+	return len(enis) > 0
+}
+
+// PodHasDedicatedENIs returns true if the pod is annotated with dedicated ENIs
+func PodHasDedicatedENIs(pod *v1.Pod) bool {
+	annotation, ok := pod.Annotations[config.ResourceNamePodENI]
+	if !ok {
+		return false
+	}
+	var enis []*trunk.ENIDetails
+	if err := json.Unmarshal([]byte(annotation), &enis); err != nil {
+		return false
+	}
+	return IsDedicatedENIs(enis)
+}
+
+func (s *dedicatedENIStrategy) Name() string {
+	return config.ResourceNameDedicatedPodENI
+}
+
+func (s *dedicatedENIStrategy) ResourceName() string {
+	return config.ResourceNamePodENI
+}
+
+func (s *dedicatedENIStrategy) Description() string {
+	return NetworkInterfaceDescription
+}
+
+// NetworkCardIndex returns the default network card of the instance types that don't support trunking, the branch
+// ENI provider serves the pod-eni resource on the other instance types
+func (s *dedicatedENIStrategy) NetworkCardIndex(instanceType string) (int64, bool) {
+	limits, found := vpc.GetLimits(instanceType)
+	if !found || limits.IsTrunkingCompatible {
+		return 0, false
+	}
+	return int64(limits.DefaultNetworkCardIndex), true
+}
+
+// NewRequest returns the security groups of the pod, or the security groups of the instance if the pod doesn't
+// match any SecurityGroupPolicy
+func (s *dedicatedENIStrategy) NewRequest(ctx context.Context, pod *v1.Pod,
+	instance ec2.EC2Instance) (attachedeni.Request, error) {
+	securityGroups, err := s.apiWrapper.SGPAPI.GetMatchingSecurityGroupForPods(pod)
+	if err != nil {
+		s.setNetworkingReadyCondition(pod, v1.ConditionFalse, ReasonDedicatedENIAttachFailed,
+			fmt.Sprintf("failed to get the security groups of the pod: %v", err))
+		return attachedeni.Request{}, err
+	}
+	if len(securityGroups) == 0 {
+		securityGroups = instance.CurrentInstanceSecurityGroups()
+		s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested,
+			tracing.WithTraceID(ctx, "Pod will get the instance security group as the pod didn't match any "+
+				"Security Group from SecurityGroupPolicy"), v1.EventTypeWarning)
+	} else {
+		s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonSecurityGroupRequested, tracing.WithTraceID(ctx,
+			fmt.Sprintf("Pod will get the following Security Groups %v", securityGroups)), v1.EventTypeNormal)
+	}
+	return attachedeni.Request{
+		SecurityGroups: securityGroups,
+		InterfaceType:  attachedeni.InterfaceTypeInterface,
+	}, nil
+}
+
+// MarshalENIs annotates the pod with the branch ENI details of the ENIs
+func (s *dedicatedENIStrategy) MarshalENIs(enis []*attachedeni.ENIDetails) (string, error) {
+	annotatedENIs := make([]*trunk.ENIDetails, 0, len(enis))
+	for _, eni := range enis {
+		annotatedENIs = append(annotatedENIs, &eni.ENIDetails)
+	}
+	jsonBytes, err := json.Marshal(annotatedENIs)
+	return string(jsonBytes), err
+}
+
+// UnmarshalENIs returns the dedicated ENIs of the pod, the pods annotated with branch ENIs have none
+func (s *dedicatedENIStrategy) UnmarshalENIs(annotation string) ([]*attachedeni.ENIDetails, error) {
+	var annotatedENIs []*trunk.ENIDetails
+	if err := json.Unmarshal([]byte(annotation), &annotatedENIs); err != nil {
+		return nil, err
+	}
+	if !IsDedicatedENIs(annotatedENIs) {
+		return nil, nil
+	}
+	enis := make([]*attachedeni.ENIDetails, 0, len(annotatedENIs))
+	for _, annotatedENI := range annotatedENIs {
+		enis = append(enis, &attachedeni.ENIDetails{ENIDetails: *annotatedENI})
+	}
+	return enis, nil
+}
+
+func (s *dedicatedENIStrategy) Attached(ctx context.Context, pod *v1.Pod, _ []*attachedeni.ENIDetails,
+	annotation string) {
+	s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonResourceAllocated,
+		tracing.WithTraceID(ctx, fmt.Sprintf("Allocated %s to the pod", annotation)), v1.EventTypeNormal)
+	s.setNetworkingReadyCondition(pod, v1.ConditionTrue, ReasonResourceAllocated, "")
+}
+
+func (s *dedicatedENIStrategy) Failed(ctx context.Context, pod *v1.Pod, failure attachedeni.Failure, err error) {
+	reason := ReasonDedicatedENIAttachFailed
+	message := fmt.Sprintf("failed to attach dedicated ENI to pod: %v", err)
+	if failure == attachedeni.FailureAnnotate {
+		reason = ReasonDedicatedENIAnnotationFailed
+		message = fmt.Sprintf("failed to annotate pod with dedicated ENI details: %v", err)
+	}
+	s.apiWrapper.K8sAPI.BroadcastEvent(pod, reason, tracing.WithTraceID(ctx, message), v1.EventTypeWarning)
+	s.setNetworkingReadyCondition(pod, v1.ConditionFalse, reason, message)
+}
+
+func (s *dedicatedENIStrategy) RecordError(operation string) {
+	dedicatedENIProviderOperationsErrCount.WithLabelValues(operation).Inc()
+}
+
+// setNetworkingReadyCondition sets the networking ready condition on the pod, the failure to set the condition is
+// only logged as it doesn't affect the dedicated ENIs allocated to the pod
+func (s *dedicatedENIStrategy) setNetworkingReadyCondition(pod *v1.Pod, status v1.ConditionStatus, reason string,
+	message string) {
+	err := s.apiWrapper.PodAPI.SetPodCondition(pod, v1.PodCondition{
+		Type:    config.PodConditionNetworkingReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if err != nil {
+		s.log.Error(err, "failed to set the networking ready condition on the pod", "namespace", pod.Namespace,
+			"name", pod.Name, "status", status)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dedicated
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/attachedeni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/providertest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	securityGroups = []string{"sg-1", "sg-2"}
+
+	eni1 = &attachedeni.ENIDetails{ENIDetails: trunk.ENIDetails{ID: "eni-1", MACAdd: "0e:00:00:00:00:01",
+		IPV4Addr: "192.168.0.1", SubnetCIDR: "192.168.0.0/16"}, AttachmentID: "eni-attach-1",
+		InterfaceType: attachedeni.InterfaceTypeInterface, DeviceIndex: 1}
+
+	mockError = fmt.Errorf("mock error")
+)
+
+// getStrategyAndMocks returns the dedicated ENI strategy with the mocks
+func getStrategyAndMocks(ctrl *gomock.Controller) (*dedicatedENIStrategy, providertest.Mocks) {
+	m := providertest.NewMocks(ctrl)
+	return &dedicatedENIStrategy{
+		log:        zap.New(zap.UseDevMode(true)).WithName("dedicated eni strategy"),
+		apiWrapper: m.Wrapper(),
+	}, m
+}
+
+// expectCondition expects the networking ready condition to be set on the pod with the status
+func expectCondition(m providertest.Mocks, pod *v1.Pod, status v1.ConditionStatus) {
+	m.PodAPI.EXPECT().SetPodCondition(pod, gomock.Any()).DoAndReturn(
+		func(_ *v1.Pod, condition v1.PodCondition) error {
+			if condition.Type != config.PodConditionNetworkingReady || condition.Status != status {
+				return fmt.Errorf("unexpected condition %v", condition)
+			}
+			return nil
+		})
+}
+
+// TestDedicatedENIStrategy_NetworkCardIndex tests only the instance types not supporting trunking are supported on
+// their default network card
+func TestDedicatedENIStrategy_NetworkCardIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, _ := getStrategyAndMocks(ctrl)
+
+	index, found := strategy.NetworkCardIndex("c1.medium")
+	assert.True(t, found)
+	assert.Equal(t, int64(0), index)
+	_, found = strategy.NetworkCardIndex("c5.large")
+	assert.False(t, found)
+	_, found = strategy.NetworkCardIndex("unknown.large")
+	assert.False(t, found)
+}
+
+// TestDedicatedENIStrategy_NewRequest tests the pods get the security groups of their SecurityGroupPolicy, or the
+// security groups of the instance if they don't match any
+func TestDedicatedENIStrategy_NewRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, m := getStrategyAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+
+	m.SGPAPI.EXPECT().GetMatchingSecurityGroupForPods(pod).Return(securityGroups, nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeNormal)
+	request, err := strategy.NewRequest(context.TODO(), pod, m.Instance)
+	assert.NoError(t, err)
+	assert.Equal(t, attachedeni.Request{SecurityGroups: securityGroups,
+		InterfaceType: attachedeni.InterfaceTypeInterface}, request)
+
+	m.SGPAPI.EXPECT().GetMatchingSecurityGroupForPods(pod).Return(nil, nil)
+	m.Instance.EXPECT().CurrentInstanceSecurityGroups().Return([]string{"sg-instance"})
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonSecurityGroupRequested, gomock.Any(), v1.EventTypeWarning)
+	request, err = strategy.NewRequest(context.TODO(), pod, m.Instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sg-instance"}, request.SecurityGroups)
+
+	m.SGPAPI.EXPECT().GetMatchingSecurityGroupForPods(pod).Return(nil, mockError)
+	expectCondition(m, pod, v1.ConditionFalse)
+	_, err = strategy.NewRequest(context.TODO(), pod, m.Instance)
+	assert.ErrorIs(t, err, mockError)
+}
+
+// TestDedicatedENIStrategy_MarshalENIs tests the ENIs are annotated as branch ENIs without VLAN and only the
+// dedicated ENIs are loaded from the annotation
+func TestDedicatedENIStrategy_MarshalENIs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, _ := getStrategyAndMocks(ctrl)
+
+	annotation, err := strategy.MarshalENIs([]*attachedeni.ENIDetails{eni1})
+	assert.NoError(t, err)
+	expectedJSON, _ := json.Marshal([]*trunk.ENIDetails{&eni1.ENIDetails})
+	assert.Equal(t, string(expectedJSON), annotation)
+
+	enis, err := strategy.UnmarshalENIs(annotation)
+	assert.NoError(t, err)
+	assert.Equal(t, []*attachedeni.ENIDetails{{ENIDetails: eni1.ENIDetails}}, enis)
+
+	branchJSON, _ := json.Marshal([]*trunk.ENIDetails{{ID: "eni-branch", VlanID: 1}})
+	enis, err = strategy.UnmarshalENIs(string(branchJSON))
+	assert.NoError(t, err)
+	assert.Nil(t, enis)
+}
+
+// TestDedicatedENIStrategy_Events tests the pods are sent the events and the networking ready condition of the
+// attached and failed ENIs
+func TestDedicatedENIStrategy_Events(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, m := getStrategyAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonResourceAllocated, gomock.Any(), v1.EventTypeNormal)
+	expectCondition(m, pod, v1.ConditionTrue)
+	strategy.Attached(context.TODO(), pod, []*attachedeni.ENIDetails{eni1}, "")
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonDedicatedENIAttachFailed, gomock.Any(), v1.EventTypeWarning)
+	expectCondition(m, pod, v1.ConditionFalse)
+	strategy.Failed(context.TODO(), pod, attachedeni.FailureAttach, mockError)
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonDedicatedENIAnnotationFailed, gomock.Any(), v1.EventTypeWarning)
+	expectCondition(m, pod, v1.ConditionFalse)
+	strategy.Failed(context.TODO(), pod, attachedeni.FailureAnnotate, mockError)
+}
+
+// TestPodHasDedicatedENIs tests only the pods annotated with ENIs without VLAN have dedicated ENIs
+func TestPodHasDedicatedENIs(t *testing.T) {
+	dedicatedJSON, _ := json.Marshal([]*trunk.ENIDetails{&eni1.ENIDetails})
+	branchJSON, _ := json.Marshal([]*trunk.ENIDetails{{ID: "eni-branch", VlanID: 1}})
+
+	assert.True(t, PodHasDedicatedENIs(providertest.NewPod(map[string]string{
+		config.ResourceNamePodENI: string(dedicatedJSON)})))
+	assert.False(t, PodHasDedicatedENIs(providertest.NewPod(map[string]string{
+		config.ResourceNamePodENI: string(branchJSON)})))
+	assert.False(t, PodHasDedicatedENIs(providertest.NewPod(nil)))
+}
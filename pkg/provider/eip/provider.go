@@ -0,0 +1,892 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	ec2Errors "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/errors"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/cooldown"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	operationLabel = "elastic_ip_provider_operation"
+
+	ReasonElasticIPAllocated        = "ElasticIPAllocated"
+	ReasonElasticIPAllocationFailed = "ElasticIPAllocationFailed"
+)
+
+var (
+	elasticIPProviderOperationsErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "elastic_ip_provider_operations_err_count",
+			Help: "The number of errors encountered for Elastic IP provider operations",
+		},
+		[]string{operationLabel},
+	)
+
+	elasticIPCoolDownQueueLength = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "elastic_ip_cool_down_queue_length",
+			Help: "The number of Elastic IP addresses of the deleted pods waiting to be disassociated",
+		},
+		[]string{"node"},
+	)
+
+	deleteQueueRequeueRequest = ctrl.Result{RequeueAfter: time.Second * 30, Requeue: true}
+
+	// waitingForInterfaceRequeueRequest is the request to retry associating the Elastic IP address of a pod whose
+	// branch ENI or IP address is not allocated yet
+	waitingForInterfaceRequeueRequest = ctrl.Result{RequeueAfter: time.Second * 5, Requeue: true}
+
+	prometheusRegistered = false
+)
+
+// ElasticIPDetails is the Elastic IP address associated with a pod, the pod is annotated with the details
+type ElasticIPDetails struct {
+	// AllocationID is the allocation id of the Elastic IP address
+	AllocationID string `json:"allocationId"`
+	// AssociationID is the id of the association of the Elastic IP address with the network interface
+	AssociationID string `json:"associationId"`
+	// PublicIP is the public IPv4 address of the Elastic IP address
+	PublicIP string `json:"publicIp"`
+	// ENIID is the id of the network interface the Elastic IP address is associated with
+	ENIID string `json:"eniId"`
+	// PrivateIP is the private IPv4 address of the pod the Elastic IP address is associated with
+	PrivateIP string `json:"privateIp"`
+	// BranchENI is true if ENIID is the branch ENI of the pod, otherwise the private IP address belongs to an ENI of
+	// the instance and is reused by the next pods of the node
+	BranchENI bool `json:"branchEni,omitempty"`
+	// deletionTimeStamp is the time when the pod was marked deleted
+	deletionTimeStamp time.Time
+}
+
+type IntrospectResponse struct {
+	InstanceID     string
+	PodToElasticIP map[string]ElasticIPDetails
+	CoolDownQueue  []ElasticIPDetails
+}
+
+type IntrospectSummaryResponse struct {
+	InstanceID       string
+	ElasticIPCount   int
+	CoolDownQueueLen int
+}
+
+// FilterState returns the Elastic IPs of the node in the state, returns false if there are none
+func (i IntrospectResponse) FilterState(state string) (interface{}, bool) {
+	filtered := IntrospectResponse{InstanceID: i.InstanceID}
+	switch state {
+	case config.IntrospectStateUsed:
+		filtered.PodToElasticIP = i.PodToElasticIP
+		return filtered, len(filtered.PodToElasticIP) > 0
+	case config.IntrospectStateCooling:
+		filtered.CoolDownQueue = i.CoolDownQueue
+		return filtered, len(filtered.CoolDownQueue) > 0
+	}
+	return nil, false
+}
+
+// nodeElasticIPs are the Elastic IP addresses associated with the pods of a node
+type nodeElasticIPs struct {
+	instanceID string
+	// podToElasticIP is the map of the pod UID to the Elastic IP address associated with the pod
+	podToElasticIP map[string]*ElasticIPDetails
+	// coolDownQueue is the queue of the Elastic IP addresses of the deleted pods, they are disassociated once
+	// cooled down
+	coolDownQueue []*ElasticIPDetails
+}
+
+// elasticIPProvider associates the Elastic IP addresses of a pool with the branch ENI or the IP address of the pods
+type elasticIPProvider struct {
+	// log is the logger initialized with elastic ip provider value
+	log logr.Logger
+	// lock to prevent concurrent writes to the node map and the allocation set
+	lock sync.RWMutex
+	// nodes is the map of node name to the Elastic IP addresses of the node's pods
+	nodes map[string]*nodeElasticIPs
+	// allocations is the set of the allocation ids of the Elastic IP addresses that are being associated or are
+	// associated with a pod, they are not available to the other pods even if EC2 doesn't report the association yet
+	allocations map[string]struct{}
+	// workerPool is the worker pool and queue for submitting async job
+	workerPool worker.Worker
+	apiWrapper api.Wrapper
+	ctx        context.Context
+	checker    healthz.Checker
+}
+
+// NewElasticIPProvider returns the Elastic IP provider for all the nodes across the cluster
+func NewElasticIPProvider(logger logr.Logger, wrapper api.Wrapper, worker worker.Worker,
+	_ config.ResourceConfig, ctx context.Context) provider.ResourceProvider {
+	prometheusRegister()
+
+	provider := &elasticIPProvider{
+		log:         logger,
+		nodes:       make(map[string]*nodeElasticIPs),
+		allocations: make(map[string]struct{}),
+		workerPool:  worker,
+		apiWrapper:  wrapper,
+		ctx:         ctx,
+	}
+	provider.checker = provider.check()
+	return provider
+}
+
+// prometheusRegister registers prometheus metrics
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(
+			elasticIPProviderOperationsErrCount,
+			elasticIPCoolDownQueueLength)
+
+		prometheusRegistered = true
+	}
+}
+
+// InitResource loads the Elastic IP addresses annotated on the pods of the node and starts processing the node's
+// cool down queue
+func (p *elasticIPProvider) InitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	log := p.log.WithValues("nodeName", nodeName)
+
+	podList, err := p.apiWrapper.PodAPI.GetRunningPodsOnNode(nodeName)
+	if err != nil {
+		log.Error(err, "failed to get list of pod on node")
+		return err
+	}
+
+	node := &nodeElasticIPs{
+		instanceID:     instance.InstanceID(),
+		podToElasticIP: make(map[string]*ElasticIPDetails),
+	}
+	for _, pod := range podList {
+		annotation, ok := pod.Annotations[config.ResourceNameElasticIP]
+		if !ok {
+			continue
+		}
+		details := &ElasticIPDetails{}
+		if err := json.Unmarshal([]byte(annotation), details); err != nil {
+			log.Error(err, "failed to unmarshal the Elastic IP address of the pod", "namespace", pod.Namespace,
+				"name", pod.Name)
+			continue
+		}
+		node.podToElasticIP[string(pod.UID)] = details
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.nodes[nodeName]; ok {
+		elasticIPProviderOperationsErrCount.WithLabelValues("init").Inc()
+		return fmt.Errorf("node %s is already initialized", nodeName)
+	}
+	p.nodes[nodeName] = node
+	for _, details := range node.podToElasticIP {
+		p.allocations[details.AllocationID] = struct{}{}
+	}
+
+	// Submit periodic jobs for the given node name
+	p.workerPool.SubmitJob(worker.NewOnDemandProcessDeleteQueueJob(nodeName))
+
+	log.Info("initialized the resource provider successfully", "elastic ips", len(node.podToElasticIP))
+	return nil
+}
+
+// DeInitResource adds an asynchronous delete job to the worker, as the pods are evicted after the node is deleted
+func (p *elasticIPProvider) DeInitResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	p.log.Info("will clean up resources later to allow pods to be evicted first",
+		"node name", nodeName, "cleanup after", branch.NodeDeleteRequeueRequestDelay)
+	p.workerPool.SubmitJobAfter(worker.NewOnDemandDeleteNodeJob(nodeName), branch.NodeDeleteRequeueRequestDelay)
+	return nil
+}
+
+// ReleaseResource stops tracking the Elastic IP addresses of the node without disassociating them, the replica
+// taking over the node loads them again from the pod annotations
+func (p *elasticIPProvider) ReleaseResource(instance ec2.EC2Instance) error {
+	nodeName := instance.Name()
+	p.removeNode(nodeName)
+
+	p.log.Info("released resource provider", "node name", nodeName)
+	return nil
+}
+
+// UpdateResourceCapacity advertises the number of private IPv4 addresses of the instance, an Elastic IP address is
+// associated with each pod requesting one
+func (p *elasticIPProvider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
+	instanceName := instance.Name()
+	instanceType := instance.Type()
+
+	limits, found := vpc.GetLimits(instanceType)
+	if !found {
+		return nil
+	}
+	capacity := limits.Interface*limits.IPv4PerInterface + limits.BranchInterface
+
+	err := p.apiWrapper.K8sAPI.AdvertiseCapacityIfNotSet(instanceName, config.ResourceNameElasticIP, capacity)
+	if err != nil {
+		elasticIPProviderOperationsErrCount.WithLabelValues("advertise_capacity").Inc()
+		return err
+	}
+	p.log.V(1).Info("advertised capacity", "instance", instanceName, "instance type", instanceType,
+		"capacity", capacity)
+	return nil
+}
+
+// SubmitAsyncJob submits the job to the k8s worker queue and returns immediately without waiting for the job to
+// complete
+func (p *elasticIPProvider) SubmitAsyncJob(job interface{}) {
+	p.workerPool.SubmitJob(job)
+}
+
+// ProcessAsyncJob is the job being executed in the worker pool routine
+func (p *elasticIPProvider) ProcessAsyncJob(job interface{}) (ctrl.Result, error) {
+	onDemandJob, isValid := job.(worker.OnDemandJob)
+	if !isValid {
+		return ctrl.Result{}, fmt.Errorf("invalid job type")
+	}
+
+	switch onDemandJob.Operation {
+	case worker.OperationCreate:
+		return p.CreateAndAnnotateResources(worker.JobContext(job), onDemandJob.PodNamespace,
+			onDemandJob.PodName, onDemandJob.RequestCount)
+	case worker.OperationDeleted:
+		return p.DeleteElasticIPUsedByPod(onDemandJob.NodeName, string(onDemandJob.UID))
+	case worker.OperationProcessDeleteQueue:
+		return p.ProcessDeleteQueue(onDemandJob.NodeName)
+	case worker.OperationDeleteNode:
+		return p.DeleteNode(onDemandJob.NodeName)
+	}
+
+	return ctrl.Result{}, fmt.Errorf("unsupported operation type")
+}
+
+// CreateAndAnnotateResources associates an available Elastic IP address of the pod's pool with the branch ENI of
+// the pod, or with the IP address of the pod if it doesn't use a branch ENI, and annotates the pod with it
+func (p *elasticIPProvider) CreateAndAnnotateResources(ctx context.Context, podNamespace string, podName string,
+	resourceCount int) (result ctrl.Result, err error) {
+	ctx, span := tracing.StartSpan(ctx, "elasticIPProvider.CreateAndAnnotateResources",
+		attribute.String("pod.namespace", podNamespace),
+		attribute.String("pod.name", podName),
+		attribute.Int("resource.count", resourceCount))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Get the pod from cache
+	pod, err := p.apiWrapper.PodAPI.GetPod(podNamespace, podName)
+	if err != nil {
+		elasticIPProviderOperationsErrCount.WithLabelValues("create_get_pod").Inc()
+		return ctrl.Result{}, err
+	}
+	if _, ok := pod.Annotations[config.ResourceNameElasticIP]; ok {
+		return ctrl.Result{}, nil
+	}
+
+	// Get the pod object again directly from API Server as the cache can be stale
+	pod, err = p.apiWrapper.PodAPI.GetPodFromAPIServer(p.ctx, podNamespace, podName)
+	if err != nil {
+		elasticIPProviderOperationsErrCount.WithLabelValues("get_pod_api_server").Inc()
+		return ctrl.Result{}, err
+	}
+	if _, ok := pod.Annotations[config.ResourceNameElasticIP]; ok {
+		return ctrl.Result{}, nil
+	}
+
+	log := p.log.WithValues("pod namespace", pod.Namespace, "pod name", pod.Name, "nodeName", pod.Spec.NodeName)
+
+	// The invalid requests are not retried
+	poolName := pod.Annotations[config.ElasticIPPoolAnnotation]
+	var invalidRequest string
+	switch {
+	case poolName == "":
+		invalidRequest = fmt.Sprintf("pod doesn't have the %s annotation", config.ElasticIPPoolAnnotation)
+	case resourceCount != 1:
+		invalidRequest = fmt.Sprintf("pod requests %d Elastic IP addresses, only one is supported", resourceCount)
+	case pod.Spec.HostNetwork:
+		invalidRequest = "Elastic IP address can't be associated with a host network pod"
+	}
+	if invalidRequest != "" {
+		log.Info("not associating Elastic IP address", "reason", invalidRequest)
+		p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocationFailed,
+			tracing.WithTraceID(ctx, invalidRequest), v1.EventTypeWarning)
+		return ctrl.Result{}, nil
+	}
+
+	instanceID, found := p.getInstanceID(pod.Spec.NodeName)
+	if !found {
+		elasticIPProviderOperationsErrCount.WithLabelValues("get_node_create").Inc()
+		return ctrl.Result{}, fmt.Errorf("node %s of the pod is not initialized", pod.Spec.NodeName)
+	}
+
+	ec2APIHelper := ec2API.WithTraceContext(ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameElasticIP, NodeName: pod.Spec.NodeName,
+			PodUID: string(pod.UID)}), ctx)
+
+	eniID, privateIP, branchENI, err := p.getPodInterface(ec2APIHelper, pod, instanceID)
+	if err != nil {
+		elasticIPProviderOperationsErrCount.WithLabelValues("get_pod_interface").Inc()
+		return ctrl.Result{}, err
+	}
+	if eniID == "" {
+		log.V(1).Info("waiting for the branch ENI or the IP address of the pod")
+		return waitingForInterfaceRequeueRequest, nil
+	}
+
+	poolAddresses, err := ec2APIHelper.GetPoolElasticIPs(poolName)
+	if err != nil {
+		elasticIPProviderOperationsErrCount.WithLabelValues("get_pool_elastic_ips").Inc()
+		return ctrl.Result{}, err
+	}
+	// The pods of the namespaces the pool is not allowed in are not retried
+	addresses, allowed := availableElasticIPs(poolAddresses, pod.Namespace)
+	if !allowed {
+		log.Info("not associating Elastic IP address, namespace not allowed in the pool", "pool", poolName)
+		p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocationFailed, tracing.WithTraceID(ctx,
+			fmt.Sprintf("Elastic IP addresses of pool %s are not allowed in namespace %s", poolName,
+				pod.Namespace)), v1.EventTypeWarning)
+		return ctrl.Result{}, nil
+	}
+
+	var address *awsEC2.Address
+	var allocationID, associationID string
+	for {
+		if address = p.reserveElasticIP(addresses); address == nil {
+			// The Elastic IP addresses of the deleted pods are disassociated after the cool down period
+			p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocationFailed, tracing.WithTraceID(ctx,
+				fmt.Sprintf("No Elastic IP address available in pool %s", poolName)), v1.EventTypeWarning)
+			return ctrl.Result{RequeueAfter: cooldown.GetCoolDown().GetCoolDownPeriod(), Requeue: true}, nil
+		}
+		allocationID = aws.StringValue(address.AllocationId)
+
+		associationID, err = ec2APIHelper.AssociateElasticIP(allocationID, eniID, privateIP)
+		if err == nil {
+			break
+		}
+		p.releaseAllocation(allocationID)
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == ec2Errors.AlreadyAssociated {
+			// Another replica associated the Elastic IP address since the pool was described, the reservations
+			// are only known to each replica
+			log.Info("Elastic IP address already associated, trying the next address", "allocation id",
+				allocationID)
+			addresses = removeAddress(addresses, allocationID)
+			continue
+		}
+		elasticIPProviderOperationsErrCount.WithLabelValues("associate_elastic_ip").Inc()
+		p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocationFailed, tracing.WithTraceID(ctx,
+			fmt.Sprintf("failed to associate Elastic IP address %s: %v", allocationID, err)), v1.EventTypeWarning)
+		return ctrl.Result{}, err
+	}
+
+	details := &ElasticIPDetails{
+		AllocationID:  allocationID,
+		AssociationID: associationID,
+		PublicIP:      aws.StringValue(address.PublicIp),
+		ENIID:         eniID,
+		PrivateIP:     privateIP,
+		BranchENI:     branchENI,
+	}
+	jsonBytes, err := json.Marshal(details)
+	if err == nil {
+		err = p.apiWrapper.PodAPI.AnnotatePod(pod.Namespace, pod.Name, pod.UID, config.ResourceNameElasticIP,
+			string(jsonBytes))
+	}
+	if err != nil {
+		// The pod never used the Elastic IP address, it doesn't need to cool down
+		if errDisassociate := ec2APIHelper.DisassociateElasticIP(associationID); errDisassociate != nil {
+			log.Error(errDisassociate, "failed to disassociate the Elastic IP address after failing to annotate "+
+				"the pod", "association id", associationID)
+		} else {
+			p.releaseAllocation(allocationID)
+		}
+		elasticIPProviderOperationsErrCount.WithLabelValues("annotate_elastic_ip").Inc()
+		p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocationFailed, tracing.WithTraceID(ctx,
+			fmt.Sprintf("failed to annotate pod with the Elastic IP address: %v", err)), v1.EventTypeWarning)
+		return ctrl.Result{}, err
+	}
+
+	if !p.addPodElasticIP(pod.Spec.NodeName, string(pod.UID), details) {
+		// The node was released or deleted meanwhile, its next owner loads the Elastic IP address from the pod
+		log.Info("node removed while associating the Elastic IP address", "elastic ip", details.PublicIP)
+	}
+
+	p.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonElasticIPAllocated, tracing.WithTraceID(ctx,
+		fmt.Sprintf("Associated Elastic IP address %s from pool %s with the pod", details.PublicIP, poolName)),
+		v1.EventTypeNormal)
+	log.Info("associated and annotated Elastic IP address successfully", "elastic ip", details)
+
+	return ctrl.Result{}, nil
+}
+
+// getPodInterface returns the network interface and the private IP address the Elastic IP address of the pod is
+// associated with, the branch ENI of the pod or the network interface of the instance with the pod's IP address.
+// The returned interface is empty if the branch ENI or the IP address of the pod is not allocated yet, and the
+// returned bool is true if the interface is the branch ENI of the pod.
+func (p *elasticIPProvider) getPodInterface(ec2APIHelper ec2API.EC2APIHelper, pod *v1.Pod,
+	instanceID string) (string, string, bool, error) {
+	if annotation, ok := pod.Annotations[config.ResourceNamePodENI]; ok {
+		var branchENIs []*trunk.ENIDetails
+		if err := json.Unmarshal([]byte(annotation), &branchENIs); err != nil {
+			return "", "", false, fmt.Errorf("failed to unmarshal the branch ENI details of the pod: %w", err)
+		}
+		if len(branchENIs) == 0 {
+			return "", "", false, fmt.Errorf("pod has no branch ENI")
+		}
+		return branchENIs[0].ID, branchENIs[0].IPV4Addr, true, nil
+	}
+	if utils.PodHasENIRequest(pod) || pod.Status.PodIP == "" {
+		return "", "", false, nil
+	}
+
+	nwInterface, err := ec2APIHelper.GetNetworkInterfaceByPrivateIP(instanceID, pod.Status.PodIP)
+	if err != nil {
+		return "", "", false, err
+	}
+	return aws.StringValue(nwInterface.NetworkInterfaceId), pod.Status.PodIP, false, nil
+}
+
+// availableElasticIPs returns the Elastic IP addresses of the pool that are not associated and are allowed in the
+// namespace, the addresses tagged with ElasticIPPoolNamespacesTagKey are only allowed in the listed namespaces. The
+// returned bool is false if the pool has addresses but none of them is allowed in the namespace.
+func availableElasticIPs(addresses []*awsEC2.Address, namespace string) ([]*awsEC2.Address, bool) {
+	var available []*awsEC2.Address
+	allowed := len(addresses) == 0
+	for _, address := range addresses {
+		if !elasticIPAllowedInNamespace(address, namespace) {
+			continue
+		}
+		allowed = true
+		if address.AssociationId == nil {
+			available = append(available, address)
+		}
+	}
+	return available, allowed
+}
+
+// elasticIPAllowedInNamespace returns true if the Elastic IP address is not restricted to namespaces or its
+// namespaces tag lists the namespace
+func elasticIPAllowedInNamespace(address *awsEC2.Address, namespace string) bool {
+	for _, tag := range address.Tags {
+		if aws.StringValue(tag.Key) != config.ElasticIPPoolNamespacesTagKey {
+			continue
+		}
+		for _, allowedNamespace := range strings.Split(aws.StringValue(tag.Value), ",") {
+			if strings.TrimSpace(allowedNamespace) == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// reserveElasticIP returns an Elastic IP address of the pool that is not associated and reserves it, it returns
+// nil if all the Elastic IP addresses of the pool are in use
+func (p *elasticIPProvider) reserveElasticIP(addresses []*awsEC2.Address) *awsEC2.Address {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, address := range addresses {
+		allocationID := aws.StringValue(address.AllocationId)
+		if _, reserved := p.allocations[allocationID]; !reserved {
+			p.allocations[allocationID] = struct{}{}
+			return address
+		}
+	}
+	return nil
+}
+
+// removeAddress returns the addresses without the Elastic IP address with the allocation id
+func removeAddress(addresses []*awsEC2.Address, allocationID string) []*awsEC2.Address {
+	var remaining []*awsEC2.Address
+	for _, address := range addresses {
+		if aws.StringValue(address.AllocationId) != allocationID {
+			remaining = append(remaining, address)
+		}
+	}
+	return remaining
+}
+
+// releaseAllocation makes the Elastic IP address available to the other pods
+func (p *elasticIPProvider) releaseAllocation(allocationID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.allocations, allocationID)
+}
+
+// addPodElasticIP tracks the Elastic IP address associated with the pod, returns false if the node is not tracked
+func (p *elasticIPProvider) addPodElasticIP(nodeName string, uid string, details *ElasticIPDetails) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		delete(p.allocations, details.AllocationID)
+		return false
+	}
+	node.podToElasticIP[uid] = details
+	return true
+}
+
+// DeleteElasticIPUsedByPod pushes the Elastic IP address of the deleted pod to the cool down queue of the node. The
+// Elastic IP address associated with an IP address of an instance ENI is disassociated at once instead, as the IP
+// address is reused by the next pods of the node.
+func (p *elasticIPProvider) DeleteElasticIPUsedByPod(nodeName string, uid string) (ctrl.Result, error) {
+	details, found := p.removePodElasticIP(nodeName, uid)
+	if !found || details.BranchENI {
+		return ctrl.Result{}, nil
+	}
+
+	ec2APIHelper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameElasticIP, NodeName: nodeName, PodUID: uid})
+	if err := ec2APIHelper.DisassociateElasticIP(details.AssociationID); err != nil {
+		p.log.Error(err, "failed to disassociate the Elastic IP address of the deleted pod, will retry",
+			"nodeName", nodeName, "pod uid", uid, "elastic ip", details)
+		elasticIPProviderOperationsErrCount.WithLabelValues("disassociate_elastic_ip").Inc()
+		// The Elastic IP address is disassociated by the next run of the process delete queue job
+		p.pushToCoolDownQueue(nodeName, details)
+		return ctrl.Result{}, nil
+	}
+	p.releaseAllocation(details.AllocationID)
+
+	p.log.Info("disassociated the Elastic IP address of the deleted pod", "nodeName", nodeName, "pod uid", uid,
+		"elastic ip", details)
+	return ctrl.Result{}, nil
+}
+
+// removePodElasticIP stops tracking the Elastic IP address of the deleted pod, the Elastic IP address associated
+// with the branch ENI of the pod is pushed to the cool down queue of the node. Returns false if the node or the
+// pod's Elastic IP address is not tracked.
+func (p *elasticIPProvider) removePodElasticIP(nodeName string, uid string) (*ElasticIPDetails, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		p.log.Info("failed to find the node of the deleted pod", "nodeName", nodeName)
+		return nil, false
+	}
+
+	details, found := node.podToElasticIP[uid]
+	if !found {
+		return nil, false
+	}
+	delete(node.podToElasticIP, uid)
+	if details.BranchENI {
+		details.deletionTimeStamp = time.Now()
+		node.coolDownQueue = append(node.coolDownQueue, details)
+		p.log.Info("pushed the Elastic IP address of the deleted pod to the cool down queue", "nodeName", nodeName,
+			"pod uid", uid, "elastic ip", details.PublicIP)
+	}
+	return details, true
+}
+
+// pushToCoolDownQueue pushes the Elastic IP address to the cool down queue of the node without a deletion time, so
+// it's disassociated by the next run of the process delete queue job
+func (p *elasticIPProvider) pushToCoolDownQueue(nodeName string, details *ElasticIPDetails) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		delete(p.allocations, details.AllocationID)
+		return
+	}
+	details.deletionTimeStamp = time.Time{}
+	node.coolDownQueue = append(node.coolDownQueue, details)
+}
+
+// ProcessDeleteQueue disassociates the cooled down Elastic IP addresses of the node's deleted pods
+func (p *elasticIPProvider) ProcessDeleteQueue(nodeName string) (ctrl.Result, error) {
+	log := p.log.WithValues("node", nodeName)
+
+	cooledDown, found := p.takeCooledDownElasticIPs(nodeName)
+	if !found {
+		log.Info("stopping the process delete queue job")
+		elasticIPCoolDownQueueLength.DeleteLabelValues(nodeName)
+		return ctrl.Result{}, nil
+	}
+
+	var failed []*ElasticIPDetails
+	ec2APIHelper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameElasticIP, NodeName: nodeName})
+	for _, details := range cooledDown {
+		if err := ec2APIHelper.DisassociateElasticIP(details.AssociationID); err != nil {
+			log.Error(err, "failed to disassociate the Elastic IP address", "elastic ip", details)
+			elasticIPProviderOperationsErrCount.WithLabelValues("disassociate_elastic_ip").Inc()
+			failed = append(failed, details)
+			continue
+		}
+		p.releaseAllocation(details.AllocationID)
+		log.Info("disassociated the Elastic IP address of the deleted pod", "elastic ip", details)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if node, found := p.nodes[nodeName]; found {
+		// Retry the failed disassociations first on the next run
+		node.coolDownQueue = append(failed, node.coolDownQueue...)
+		elasticIPCoolDownQueueLength.WithLabelValues(nodeName).Set(float64(len(node.coolDownQueue)))
+	}
+	return deleteQueueRequeueRequest, nil
+}
+
+// takeCooledDownElasticIPs removes the cooled down Elastic IP addresses from the cool down queue of the node and
+// returns them, returns false if the node is not tracked
+func (p *elasticIPProvider) takeCooledDownElasticIPs(nodeName string) ([]*ElasticIPDetails, bool) {
+	coolDownPeriod := cooldown.GetCoolDown().GetCoolDownPeriod()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return nil, false
+	}
+
+	var cooledDown, coolingDown []*ElasticIPDetails
+	for _, details := range node.coolDownQueue {
+		if time.Since(details.deletionTimeStamp) >= coolDownPeriod {
+			cooledDown = append(cooledDown, details)
+		} else {
+			coolingDown = append(coolingDown, details)
+		}
+	}
+	node.coolDownQueue = coolingDown
+	return cooledDown, true
+}
+
+// DeleteNode disassociates all the Elastic IP addresses of the deleted node and stops tracking the node
+func (p *elasticIPProvider) DeleteNode(nodeName string) (ctrl.Result, error) {
+	p.lock.Lock()
+	node, found := p.nodes[nodeName]
+	delete(p.nodes, nodeName)
+	p.lock.Unlock()
+
+	if !found {
+		return ctrl.Result{}, fmt.Errorf("failed to find node %s", nodeName)
+	}
+
+	ec2APIHelper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameElasticIP, NodeName: nodeName})
+	elasticIPs := node.coolDownQueue
+	for _, details := range node.podToElasticIP {
+		elasticIPs = append(elasticIPs, details)
+	}
+	for _, details := range elasticIPs {
+		// The associations are removed with the network interfaces of the terminated instance
+		if err := ec2APIHelper.DisassociateElasticIP(details.AssociationID); err != nil {
+			p.log.Error(err, "failed to disassociate the Elastic IP address of the deleted node",
+				"nodeName", nodeName, "elastic ip", details)
+		}
+		p.releaseAllocation(details.AllocationID)
+	}
+	elasticIPCoolDownQueueLength.DeleteLabelValues(nodeName)
+
+	p.log.Info("de-initialized resource provider successfully", "nodeName", nodeName,
+		"elastic ips", len(elasticIPs))
+	return ctrl.Result{}, nil
+}
+
+// removeNode stops tracking the node and its Elastic IP addresses
+func (p *elasticIPProvider) removeNode(nodeName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return
+	}
+	for _, details := range node.podToElasticIP {
+		delete(p.allocations, details.AllocationID)
+	}
+	for _, details := range node.coolDownQueue {
+		delete(p.allocations, details.AllocationID)
+	}
+	delete(p.nodes, nodeName)
+	elasticIPCoolDownQueueLength.DeleteLabelValues(nodeName)
+}
+
+// getInstanceID returns the instance id of the node, returns false if the node is not tracked
+func (p *elasticIPProvider) getInstanceID(nodeName string) (string, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return "", false
+	}
+	return node.instanceID, true
+}
+
+// ReconcileNode pushes the Elastic IP addresses of the pods that don't exist anymore to the cool down queue,
+// returns true if any was found
+func (p *elasticIPProvider) ReconcileNode(nodeName string) bool {
+	podList, err := p.apiWrapper.PodAPI.ListPods(nodeName)
+	if err != nil {
+		p.log.Error(err, "failed to list pods, requeue node", "nodeName", nodeName)
+		return true
+	}
+	currentPodSet := make(map[string]struct{})
+	for _, pod := range podList.Items {
+		currentPodSet[string(pod.UID)] = struct{}{}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return false
+	}
+	leaked := 0
+	for uid, details := range node.podToElasticIP {
+		if _, exists := currentPodSet[uid]; exists {
+			continue
+		}
+		// Pod could have been deleted recently, set the timestamp to current time as controller is not aware of
+		// the actual time. The IP address of an instance ENI is reused by the next pods, so the Elastic IP address
+		// associated with it doesn't cool down.
+		details.deletionTimeStamp = time.Now()
+		if !details.BranchENI {
+			details.deletionTimeStamp = time.Time{}
+		}
+		node.coolDownQueue = append(node.coolDownQueue, details)
+		delete(node.podToElasticIP, uid)
+		leaked++
+		p.log.Info("leaked Elastic IP address pushed to the cool down queue, deleted non-existing pod",
+			"pod uid", uid, "elastic ip", details)
+	}
+	return leaked > 0
+}
+
+// GetPool is not supported for Elastic IP
+func (p *elasticIPProvider) GetPool(_ string) (pool.Pool, bool) {
+	return nil, false
+}
+
+// IsInstanceSupported returns true for the linux nodes of a known instance type
+func (p *elasticIPProvider) IsInstanceSupported(instance ec2.EC2Instance) bool {
+	if instance.Os() != config.OSLinux {
+		return false
+	}
+	_, found := vpc.GetLimits(instance.Type())
+	return found
+}
+
+func (p *elasticIPProvider) Introspect() interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	allResponse := provider.IntrospectNodes[IntrospectResponse]{}
+	for nodeName, node := range p.nodes {
+		allResponse[nodeName] = node.introspect()
+	}
+	return allResponse
+}
+
+func (p *elasticIPProvider) IntrospectSummary() interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	allResponse := provider.IntrospectNodes[IntrospectSummaryResponse]{}
+	for nodeName, node := range p.nodes {
+		allResponse[nodeName] = IntrospectSummaryResponse{
+			InstanceID:       node.instanceID,
+			ElasticIPCount:   len(node.podToElasticIP),
+			CoolDownQueueLen: len(node.coolDownQueue),
+		}
+	}
+	return allResponse
+}
+
+func (p *elasticIPProvider) IntrospectNode(nodeName string) interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node, found := p.nodes[nodeName]
+	if !found {
+		return struct{}{}
+	}
+	return node.introspect()
+}
+
+// introspect returns a copy of the Elastic IP addresses of the node, the caller must hold the provider lock
+func (n *nodeElasticIPs) introspect() IntrospectResponse {
+	response := IntrospectResponse{
+		InstanceID:     n.instanceID,
+		PodToElasticIP: make(map[string]ElasticIPDetails),
+	}
+	for uid, details := range n.podToElasticIP {
+		response.PodToElasticIP[uid] = *details
+	}
+	for _, details := range n.coolDownQueue {
+		response.CoolDownQueue = append(response.CoolDownQueue, *details)
+	}
+	return response
+}
+
+func (p *elasticIPProvider) check() healthz.Checker {
+	p.log.Info("Elastic IP provider's healthz subpath was added")
+	return func(req *http.Request) error {
+		err := rcHealthz.PingWithTimeout(func(c chan<- error) {
+			var ping interface{}
+			// check on job queue
+			p.SubmitAsyncJob(ping)
+			// check on node map
+			p.getInstanceID("test-node" + uuid.New().String())
+			c <- nil
+		}, p.log)
+
+		return err
+	}
+}
+
+func (p *elasticIPProvider) GetHealthChecker() healthz.Checker {
+	return p.checker
+}
@@ -0,0 +1,430 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_worker "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/worker"
+	ec2Errors "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/errors"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/cooldown"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/providertest"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8sCtrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName   = providertest.NodeName
+	instanceID = providertest.InstanceID
+	podUID     = providertest.PodUID
+	poolName   = "egress"
+
+	reservedAddress  = &awsEC2.Address{AllocationId: aws.String("eipalloc-1"), PublicIp: aws.String("3.0.0.1")}
+	availableAddress = &awsEC2.Address{AllocationId: aws.String("eipalloc-2"), PublicIp: aws.String("3.0.0.2")}
+
+	mockError = fmt.Errorf("mock error")
+)
+
+// getProviderAndMocks returns the provider tracking the node with the Elastic IP address eipalloc-1 reserved
+func getProviderAndMocks(ctrl *gomock.Controller) (*elasticIPProvider, providertest.Mocks) {
+	m := providertest.NewMocks(ctrl)
+	return &elasticIPProvider{
+		log:        zap.New(zap.UseDevMode(true)).WithName("elastic ip provider"),
+		apiWrapper: m.Wrapper(),
+		nodes: map[string]*nodeElasticIPs{
+			nodeName: {instanceID: instanceID, podToElasticIP: make(map[string]*ElasticIPDetails)},
+		},
+		allocations: map[string]struct{}{aws.StringValue(reservedAddress.AllocationId): {}},
+		ctx:         context.TODO(),
+	}, m
+}
+
+// initCoolDown initializes the cool down period with the default period
+func initCoolDown(m providertest.Mocks) {
+	m.K8sAPI.EXPECT().GetControllerConfigMap().Return(nil, mockError)
+	cooldown.InitCoolDownPeriod(m.K8sAPI, zap.New())
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_BranchENI tests an available Elastic IP address of the pool is
+// associated with the branch ENI of the pod and annotated on the pod
+func TestElasticIPProvider_CreateAndAnnotateResources_BranchENI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{
+		config.ElasticIPPoolAnnotation: poolName,
+		config.ResourceNamePodENI:      `[{"eniId":"eni-branch","privateIp":"192.168.0.10"}]`,
+	})
+	m.ExpectGetPod(pod)
+
+	expected := &ElasticIPDetails{AllocationID: "eipalloc-2", AssociationID: "eipassoc-2", PublicIP: "3.0.0.2",
+		ENIID: "eni-branch", PrivateIP: "192.168.0.10", BranchENI: true}
+	expectedJSON, _ := json.Marshal(expected)
+
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{reservedAddress, availableAddress}, nil)
+	m.EC2Helper.EXPECT().AssociateElasticIP("eipalloc-2", "eni-branch", "192.168.0.10").Return("eipassoc-2", nil)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, config.ResourceNameElasticIP,
+		string(expectedJSON)).Return(nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocated, gomock.Any(), v1.EventTypeNormal)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.Equal(t, expected, provider.nodes[nodeName].podToElasticIP[string(podUID)])
+	assert.Contains(t, provider.allocations, "eipalloc-2")
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_AlreadyAssociated tests the next Elastic IP address of the pool is
+// associated if another replica associated the first one since the pool was described
+func TestElasticIPProvider_CreateAndAnnotateResources_AlreadyAssociated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	provider.allocations = make(map[string]struct{})
+	pod := providertest.NewPod(map[string]string{
+		config.ElasticIPPoolAnnotation: poolName,
+		config.ResourceNamePodENI:      `[{"eniId":"eni-branch","privateIp":"192.168.0.10"}]`,
+	})
+	m.ExpectGetPod(pod)
+
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{reservedAddress, availableAddress}, nil)
+	m.EC2Helper.EXPECT().AssociateElasticIP("eipalloc-1", "eni-branch", "192.168.0.10").
+		Return("", awserr.New(ec2Errors.AlreadyAssociated, "already associated", nil))
+	m.EC2Helper.EXPECT().AssociateElasticIP("eipalloc-2", "eni-branch", "192.168.0.10").Return("eipassoc-2", nil)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, config.ResourceNameElasticIP, gomock.Any()).
+		Return(nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocated, gomock.Any(), v1.EventTypeNormal)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.Equal(t, "eipalloc-2", provider.nodes[nodeName].podToElasticIP[string(podUID)].AllocationID)
+	assert.Equal(t, map[string]struct{}{"eipalloc-2": {}}, provider.allocations)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_PodIP tests the Elastic IP address of a pod without branch ENI is
+// associated with the network interface of the instance with the pod's IP address
+func TestElasticIPProvider_CreateAndAnnotateResources_PodIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{config.ElasticIPPoolAnnotation: poolName})
+	pod.Status.PodIP = "192.168.0.20"
+	m.ExpectGetPod(pod)
+
+	m.EC2Helper.EXPECT().GetNetworkInterfaceByPrivateIP(instanceID, "192.168.0.20").Return(
+		&awsEC2.NetworkInterface{NetworkInterfaceId: aws.String("eni-primary")}, nil)
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{availableAddress}, nil)
+	m.EC2Helper.EXPECT().AssociateElasticIP("eipalloc-2", "eni-primary", "192.168.0.20").Return("eipassoc-2", nil)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, config.ResourceNameElasticIP, gomock.Any()).
+		Return(nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocated, gomock.Any(), v1.EventTypeNormal)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "eni-primary", provider.nodes[nodeName].podToElasticIP[string(podUID)].ENIID)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_WaitingForBranchENI tests the request is retried until the
+// branch ENI requested by the pod is allocated
+func TestElasticIPProvider_CreateAndAnnotateResources_WaitingForBranchENI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{config.ElasticIPPoolAnnotation: poolName})
+	pod.Status.PodIP = "192.168.0.20"
+	pod.Spec.Containers[0].Resources.Requests = v1.ResourceList{config.ResourceNamePodENI: resource.MustParse("1")}
+	m.ExpectGetPod(pod)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, waitingForInterfaceRequeueRequest, result)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_NoPool tests the pod without pool gets an event and the request
+// is not retried
+func TestElasticIPProvider_CreateAndAnnotateResources_NoPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{})
+	m.ExpectGetPod(pod)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_PoolExhausted tests the request is retried after the cool down
+// period if all the Elastic IP addresses of the pool are in use
+func TestElasticIPProvider_CreateAndAnnotateResources_PoolExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	initCoolDown(m)
+	pod := providertest.NewPod(map[string]string{
+		config.ElasticIPPoolAnnotation: poolName,
+		config.ResourceNamePodENI:      `[{"eniId":"eni-branch","privateIp":"192.168.0.10"}]`,
+	})
+	m.ExpectGetPod(pod)
+
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{reservedAddress}, nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{RequeueAfter: cooldown.DefaultCoolDownPeriod, Requeue: true}, result)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_NamespaceNotAllowed tests the pods are not retried if none of the
+// Elastic IP addresses of the pool is allowed in their namespace, and the associated addresses don't count as
+// available
+func TestElasticIPProvider_CreateAndAnnotateResources_NamespaceNotAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{
+		config.ElasticIPPoolAnnotation: poolName,
+		config.ResourceNamePodENI:      `[{"eniId":"eni-branch","privateIp":"192.168.0.10"}]`,
+	})
+	m.ExpectGetPod(pod)
+
+	restrictedAddress := &awsEC2.Address{AllocationId: aws.String("eipalloc-3"), PublicIp: aws.String("3.0.0.3"),
+		Tags: []*awsEC2.Tag{{Key: aws.String(config.ElasticIPPoolNamespacesTagKey),
+			Value: aws.String("team-a, team-b")}}}
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{restrictedAddress}, nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+
+	result, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+	assert.NotContains(t, provider.allocations, "eipalloc-3")
+}
+
+// TestAvailableElasticIPs tests only the addresses not associated and allowed in the namespace are available, and
+// the namespace is allowed if any address of the pool is allowed in it
+func TestAvailableElasticIPs(t *testing.T) {
+	restricted := &awsEC2.Address{AllocationId: aws.String("eipalloc-3"), Tags: []*awsEC2.Tag{
+		{Key: aws.String(config.ElasticIPPoolNamespacesTagKey), Value: aws.String("team-a,team-b")}}}
+	associated := &awsEC2.Address{AllocationId: aws.String("eipalloc-4"), AssociationId: aws.String("eipassoc-4"),
+		Tags: restricted.Tags}
+
+	available, allowed := availableElasticIPs([]*awsEC2.Address{availableAddress, restricted}, "default")
+	assert.True(t, allowed)
+	assert.Equal(t, []*awsEC2.Address{availableAddress}, available)
+
+	available, allowed = availableElasticIPs([]*awsEC2.Address{availableAddress, restricted}, "team-b")
+	assert.True(t, allowed)
+	assert.Equal(t, []*awsEC2.Address{availableAddress, restricted}, available)
+
+	// The pool is exhausted for the allowed namespace, but not allowed in the other namespaces
+	available, allowed = availableElasticIPs([]*awsEC2.Address{associated}, "team-a")
+	assert.True(t, allowed)
+	assert.Empty(t, available)
+	_, allowed = availableElasticIPs([]*awsEC2.Address{associated}, "default")
+	assert.False(t, allowed)
+
+	_, allowed = availableElasticIPs(nil, "default")
+	assert.True(t, allowed)
+}
+
+// TestElasticIPProvider_CreateAndAnnotateResources_AnnotateError tests the Elastic IP address is disassociated and
+// made available again if the pod fails to be annotated
+func TestElasticIPProvider_CreateAndAnnotateResources_AnnotateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	pod := providertest.NewPod(map[string]string{
+		config.ElasticIPPoolAnnotation: poolName,
+		config.ResourceNamePodENI:      `[{"eniId":"eni-branch","privateIp":"192.168.0.10"}]`,
+	})
+	m.ExpectGetPod(pod)
+
+	m.EC2Helper.EXPECT().GetPoolElasticIPs(poolName).Return([]*awsEC2.Address{availableAddress}, nil)
+	m.EC2Helper.EXPECT().AssociateElasticIP("eipalloc-2", "eni-branch", "192.168.0.10").Return("eipassoc-2", nil)
+	m.PodAPI.EXPECT().AnnotatePod(pod.Namespace, pod.Name, pod.UID, config.ResourceNameElasticIP, gomock.Any()).
+		Return(mockError)
+	m.EC2Helper.EXPECT().DisassociateElasticIP("eipassoc-2").Return(nil)
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonElasticIPAllocationFailed, gomock.Any(), v1.EventTypeWarning)
+
+	_, err := provider.CreateAndAnnotateResources(context.TODO(), pod.Namespace, pod.Name, 1)
+	assert.Error(t, err)
+	assert.NotContains(t, provider.allocations, "eipalloc-2")
+	assert.Empty(t, provider.nodes[nodeName].podToElasticIP)
+}
+
+// TestElasticIPProvider_ProcessDeleteQueue tests the Elastic IP address of a deleted pod is disassociated once
+// cooled down, and the failed disassociation is retried
+func TestElasticIPProvider_ProcessDeleteQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	initCoolDown(m)
+	node := provider.nodes[nodeName]
+	cooledDown := &ElasticIPDetails{AllocationID: "eipalloc-1", AssociationID: "eipassoc-1", BranchENI: true}
+	failed := &ElasticIPDetails{AllocationID: "eipalloc-2", AssociationID: "eipassoc-2",
+		deletionTimeStamp: time.Now().Add(-time.Hour)}
+	node.podToElasticIP[string(podUID)] = cooledDown
+	node.coolDownQueue = []*ElasticIPDetails{failed}
+	provider.allocations["eipalloc-2"] = struct{}{}
+
+	_, err := provider.DeleteElasticIPUsedByPod(nodeName, string(podUID))
+	assert.NoError(t, err)
+	assert.Empty(t, node.podToElasticIP)
+	cooledDown.deletionTimeStamp = time.Now().Add(-time.Hour)
+
+	m.EC2Helper.EXPECT().DisassociateElasticIP("eipassoc-2").Return(mockError)
+	m.EC2Helper.EXPECT().DisassociateElasticIP("eipassoc-1").Return(nil)
+
+	result, err := provider.ProcessDeleteQueue(nodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, deleteQueueRequeueRequest, result)
+	assert.Equal(t, []*ElasticIPDetails{failed}, node.coolDownQueue)
+	assert.Equal(t, map[string]struct{}{"eipalloc-2": {}}, provider.allocations)
+}
+
+// TestElasticIPProvider_DeleteElasticIPUsedByPod_PodIP tests the Elastic IP address associated with the IP address of
+// an instance ENI is disassociated as soon as the pod is deleted, and the failed disassociation is retried by the
+// next run of the process delete queue job
+func TestElasticIPProvider_DeleteElasticIPUsedByPod_PodIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	node := provider.nodes[nodeName]
+	disassociated := &ElasticIPDetails{AllocationID: "eipalloc-1", AssociationID: "eipassoc-1"}
+	failed := &ElasticIPDetails{AllocationID: "eipalloc-2", AssociationID: "eipassoc-2"}
+	node.podToElasticIP[string(podUID)] = disassociated
+	node.podToElasticIP["uid-2"] = failed
+	provider.allocations["eipalloc-2"] = struct{}{}
+
+	m.EC2Helper.EXPECT().DisassociateElasticIP("eipassoc-1").Return(nil)
+	m.EC2Helper.EXPECT().DisassociateElasticIP("eipassoc-2").Return(mockError)
+
+	_, err := provider.DeleteElasticIPUsedByPod(nodeName, string(podUID))
+	assert.NoError(t, err)
+	_, err = provider.DeleteElasticIPUsedByPod(nodeName, "uid-2")
+	assert.NoError(t, err)
+
+	assert.Empty(t, node.podToElasticIP)
+	assert.Equal(t, []*ElasticIPDetails{failed}, node.coolDownQueue)
+	assert.True(t, failed.deletionTimeStamp.IsZero())
+	assert.Equal(t, map[string]struct{}{"eipalloc-2": {}}, provider.allocations)
+}
+
+// TestElasticIPProvider_ProcessDeleteQueue_NotCooledDown tests the Elastic IP address of a pod deleted recently is
+// not disassociated
+func TestElasticIPProvider_ProcessDeleteQueue_NotCooledDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	initCoolDown(m)
+	coolingDown := &ElasticIPDetails{AllocationID: "eipalloc-1", AssociationID: "eipassoc-1",
+		deletionTimeStamp: time.Now()}
+	provider.nodes[nodeName].coolDownQueue = []*ElasticIPDetails{coolingDown}
+
+	_, err := provider.ProcessDeleteQueue(nodeName)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ElasticIPDetails{coolingDown}, provider.nodes[nodeName].coolDownQueue)
+
+	// The job stops once the node is not tracked
+	result, err := provider.ProcessDeleteQueue("other-node")
+	assert.NoError(t, err)
+	assert.Equal(t, k8sCtrl.Result{}, result)
+}
+
+// TestElasticIPProvider_InitResource tests the Elastic IP addresses annotated on the pods of the node are loaded
+func TestElasticIPProvider_InitResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	mockWorker := mock_worker.NewMockWorker(ctrl)
+	provider.workerPool = mockWorker
+	provider.nodes = make(map[string]*nodeElasticIPs)
+	provider.allocations = make(map[string]struct{})
+
+	details := &ElasticIPDetails{AllocationID: "eipalloc-1", AssociationID: "eipassoc-1", PublicIP: "3.0.0.1"}
+	detailsJSON, _ := json.Marshal(details)
+	pod := providertest.NewPod(map[string]string{config.ResourceNameElasticIP: string(detailsJSON)})
+
+	m.Instance.EXPECT().Name().Return(nodeName).AnyTimes()
+	m.Instance.EXPECT().InstanceID().Return(instanceID)
+	m.PodAPI.EXPECT().GetRunningPodsOnNode(nodeName).Return([]v1.Pod{*pod, *providertest.NewPod(nil)}, nil)
+	mockWorker.EXPECT().SubmitJob(worker.NewOnDemandProcessDeleteQueueJob(nodeName))
+
+	assert.NoError(t, provider.InitResource(m.Instance))
+	assert.Equal(t, details, provider.nodes[nodeName].podToElasticIP[string(podUID)])
+	assert.Contains(t, provider.allocations, "eipalloc-1")
+}
+
+// TestElasticIPProvider_ReconcileNode tests the Elastic IP addresses of the pods that don't exist anymore are pushed
+// to the cool down queue
+func TestElasticIPProvider_ReconcileNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	node := provider.nodes[nodeName]
+	existing := &ElasticIPDetails{AllocationID: "eipalloc-1"}
+	leaked := &ElasticIPDetails{AllocationID: "eipalloc-2"}
+	node.podToElasticIP[string(podUID)] = existing
+	node.podToElasticIP["uid-leaked"] = leaked
+
+	m.PodAPI.EXPECT().ListPods(nodeName).Return(&v1.PodList{Items: []v1.Pod{*providertest.NewPod(nil)}}, nil)
+
+	assert.True(t, provider.ReconcileNode(nodeName))
+	assert.Equal(t, map[string]*ElasticIPDetails{string(podUID): existing}, node.podToElasticIP)
+	assert.Equal(t, []*ElasticIPDetails{leaked}, node.coolDownQueue)
+}
+
+// TestElasticIPProvider_ReleaseResource tests the node and its Elastic IP addresses are not tracked anymore
+func TestElasticIPProvider_ReleaseResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, m := getProviderAndMocks(ctrl)
+	provider.nodes[nodeName].podToElasticIP[string(podUID)] = &ElasticIPDetails{AllocationID: "eipalloc-1"}
+
+	m.Instance.EXPECT().Name().Return(nodeName)
+
+	assert.NoError(t, provider.ReleaseResource(m.Instance))
+	assert.Empty(t, provider.nodes)
+	assert.Empty(t, provider.allocations)
+}
@@ -58,6 +58,8 @@ type ENIManager interface {
 	InitResources(ec2APIHelper api.EC2APIHelper) (*IPv4Resource, error)
 	CreateIPV4Resource(required int, resourceType config.ResourceType, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
 	DeleteIPV4Resource(ipList []string, resourceType config.ResourceType, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]string, error)
+	GetIPv4Resources(resourceType config.ResourceType, ec2APIHelper api.EC2APIHelper) ([]ENIResource, error)
+	UnassignENIResources(resources []ENIResource, resourceType config.ResourceType, ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]ENIResource, error)
 }
 
 // NewENIManager returns a new ENI Manager
@@ -78,7 +80,7 @@ func (e *eniManager) InitResources(ec2APIHelper api.EC2APIHelper) (*IPv4Resource
 		return nil, err
 	}
 
-	limits, found := vpc.Limits[e.instance.Type()]
+	limits, found := vpc.GetLimits(e.instance.Type())
 	if !found {
 		return nil, fmt.Errorf("unsupported instance type, error: %w", utils.ErrNotFound)
 	}
@@ -283,29 +285,32 @@ func (e *eniManager) DeleteIPV4Resource(resourceList []string, resourceType conf
 		log.Info("deleted IPv4 resources", "eni", eni.eniID, "resource type", resourceType, "resources", resources)
 	}
 
-	ipLimit := vpc.Limits[e.instance.Type()].IPv4PerInterface - 1
-	primaryENIID := e.instance.PrimaryNetworkInterfaceID()
-
-	// Clean up ENIs that just have the primary network interface attached to them
-	i := 0
-	for _, eni := range e.attachedENIs {
-		// ENI doesn't have any secondary IP or prefix attached to it and is not the primary network interface
-		if eni.remainingCapacity == ipLimit && primaryENIID != eni.eniID {
-			err := ec2APIHelper.DeleteNetworkInterface(&eni.eniID)
-			if err != nil {
-				errors = append(errors, err)
+	// The limits are found for any instance type the ENI manager was initialized for
+	if limits, found := vpc.GetLimits(e.instance.Type()); found {
+		ipLimit := limits.IPv4PerInterface - 1
+		primaryENIID := e.instance.PrimaryNetworkInterfaceID()
+
+		// Clean up ENIs that just have the primary network interface attached to them
+		i := 0
+		for _, eni := range e.attachedENIs {
+			// ENI doesn't have any secondary IP or prefix attached to it and is not the primary network interface
+			if eni.remainingCapacity == ipLimit && primaryENIID != eni.eniID {
+				err := ec2APIHelper.DeleteNetworkInterface(&eni.eniID)
+				if err != nil {
+					errors = append(errors, err)
+					e.attachedENIs[i] = eni
+					i++
+					continue
+				}
+				log.Info("deleted ENI successfully as it has no secondary IP or prefix attached",
+					"id", eni.eniID)
+			} else {
 				e.attachedENIs[i] = eni
 				i++
-				continue
 			}
-			log.Info("deleted ENI successfully as it has no secondary IP or prefix attached",
-				"id", eni.eniID)
-		} else {
-			e.attachedENIs[i] = eni
-			i++
 		}
+		e.attachedENIs = e.attachedENIs[:i]
 	}
-	e.attachedENIs = e.attachedENIs[:i]
 
 	if errors != nil && len(errors) > 0 {
 		return failedToUnAssign, fmt.Errorf("failed to unassign one or more %s: %v", resourceType, errors)
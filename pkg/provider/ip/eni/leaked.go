@@ -0,0 +1,325 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eni
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	prometheusRegistered = false
+
+	orphanedIPv4ResourceCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "orphaned_ipv4_resource_count",
+			Help: "The number of secondary IPv4 addresses or prefixes assigned to the ENIs of the Windows nodes " +
+				"but missing from the node's pool",
+		},
+		[]string{"resource_type"},
+	)
+
+	leakedIPv4ResourceUnassignedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "leaked_ipv4_resource_unassigned_count",
+			Help: "The number of leaked secondary IPv4 addresses or prefixes unassigned from the ENIs of the Windows nodes",
+		},
+		[]string{"resource_type"},
+	)
+
+	leakedIPv4ResourceUnassignErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "leaked_ipv4_resource_unassign_err_count",
+			Help: "The number of leaked secondary IPv4 addresses or prefixes that failed to be unassigned",
+		},
+		[]string{"resource_type"},
+	)
+)
+
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(
+			orphanedIPv4ResourceCount,
+			leakedIPv4ResourceUnassignedCount,
+			leakedIPv4ResourceUnassignErrCount,
+		)
+		prometheusRegistered = true
+	}
+}
+
+// ENIResource is a secondary IPv4 address or prefix assigned to an ENI of the instance
+type ENIResource struct {
+	ENIID string
+	// Resource is the IPv4 address with the subnet mask or the IPv4 prefix, in the format used by the pool
+	Resource string
+}
+
+// GetIPv4Resources returns the secondary IPv4 addresses or prefixes, depending on the resource type, assigned to the
+// ENIs of the instance as seen by EC2
+func (e *eniManager) GetIPv4Resources(resourceType config.ResourceType, ec2APIHelper api.EC2APIHelper) ([]ENIResource, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	nwInterfaces, err := ec2APIHelper.GetInstanceNetworkInterface(aws.String(e.instance.InstanceID()))
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []ENIResource
+	for _, nwInterface := range nwInterfaces {
+		eniID := aws.StringValue(nwInterface.NetworkInterfaceId)
+		switch resourceType {
+		case config.ResourceTypeIPv4Address:
+			for _, ip := range nwInterface.PrivateIpAddresses {
+				if aws.BoolValue(ip.Primary) {
+					continue
+				}
+				resources = append(resources, ENIResource{ENIID: eniID,
+					Resource: aws.StringValue(ip.PrivateIpAddress) + "/" + e.instance.SubnetMask()})
+			}
+		case config.ResourceTypeIPv4Prefix:
+			for _, prefix := range nwInterface.Ipv4Prefixes {
+				resources = append(resources, ENIResource{ENIID: eniID, Resource: aws.StringValue(prefix.Ipv4Prefix)})
+			}
+		}
+	}
+	return resources, nil
+}
+
+// UnassignENIResources unassigns the IPv4 resources from their ENI, including the resources that were not assigned
+// by the ENI manager, and returns the list of resources that failed to unassign along with the error
+func (e *eniManager) UnassignENIResources(resources []ENIResource, resourceType config.ResourceType,
+	ec2APIHelper api.EC2APIHelper, log logr.Logger) ([]ENIResource, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	log = log.WithValues("node name", e.instance.Name())
+
+	// Group the resources per ENI, preserving the order in which the ENIs are found
+	var eniIDs []string
+	groupedResources := map[string][]ENIResource{}
+	for _, resource := range resources {
+		if _, found := groupedResources[resource.ENIID]; !found {
+			eniIDs = append(eniIDs, resource.ENIID)
+		}
+		groupedResources[resource.ENIID] = append(groupedResources[resource.ENIID], resource)
+	}
+
+	var failed []ENIResource
+	var errors []error
+	for _, eniID := range eniIDs {
+		var toUnassign []string
+		for _, resource := range groupedResources[eniID] {
+			// IP address needs to have the subnet mask stripped, whereas prefix keeps its /28 suffix
+			if resourceType == config.ResourceTypeIPv4Address {
+				toUnassign = append(toUnassign, strings.Split(resource.Resource, "/")[0])
+			} else {
+				toUnassign = append(toUnassign, resource.Resource)
+			}
+		}
+
+		if err := ec2APIHelper.UnassignIPv4Resources(eniID, resourceType, toUnassign); err != nil {
+			errors = append(errors, err)
+			failed = append(failed, groupedResources[eniID]...)
+			continue
+		}
+		// The resources count against the capacity of the ENI whether or not they were assigned by the manager
+		for _, attachedENI := range e.attachedENIs {
+			if attachedENI.eniID == eniID {
+				attachedENI.remainingCapacity += len(toUnassign)
+			}
+		}
+		for _, resource := range toUnassign {
+			delete(e.resourceToENIMap, resource)
+		}
+		log.Info("unassigned leaked IPv4 resources", "eni", eniID, "resource type", resourceType,
+			"resources", toUnassign)
+	}
+
+	if len(errors) > 0 {
+		return failed, fmt.Errorf("failed to unassign one or more leaked %s: %v", resourceType, errors)
+	}
+	return nil, nil
+}
+
+// LeakTracker tracks since when the IPv4 resources of each node have been missing from the node's pool, so only
+// the resources that stay leaked for longer than the grace period are unassigned
+type LeakTracker struct {
+	resourceType config.ResourceType
+	// lock guards the following
+	lock sync.Mutex
+	// firstSeen is the time each leaked resource of a node was first found
+	firstSeen map[string]map[ENIResource]time.Time
+}
+
+// NewLeakTracker returns a new leak tracker for the resource type
+func NewLeakTracker(resourceType config.ResourceType) *LeakTracker {
+	prometheusRegister()
+
+	return &LeakTracker{
+		resourceType: resourceType,
+		firstSeen:    map[string]map[ENIResource]time.Time{},
+	}
+}
+
+// Track records the leaked resources of the node found by the latest check and returns the resources that were
+// already leaked in a previous check and for at least the grace period. The resources not found by the latest check
+// are forgotten.
+func (t *LeakTracker) Track(nodeName string, leaked []ENIResource, gracePeriod time.Duration,
+	now time.Time) []ENIResource {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	previous := t.firstSeen[nodeName]
+	current := make(map[ENIResource]time.Time, len(leaked))
+	var expired []ENIResource
+	for _, resource := range leaked {
+		firstSeen, found := previous[resource]
+		if !found {
+			current[resource] = now
+			continue
+		}
+		current[resource] = firstSeen
+		if now.Sub(firstSeen) >= gracePeriod {
+			expired = append(expired, resource)
+		}
+	}
+
+	if len(current) == 0 {
+		delete(t.firstSeen, nodeName)
+	} else {
+		t.firstSeen[nodeName] = current
+	}
+	t.updateMetricLocked()
+
+	return expired
+}
+
+// Remove stops tracking the resources of the node, it's called once the resources are unassigned
+func (t *LeakTracker) Remove(nodeName string, resources []ENIResource) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, resource := range resources {
+		delete(t.firstSeen[nodeName], resource)
+	}
+	if len(t.firstSeen[nodeName]) == 0 {
+		delete(t.firstSeen, nodeName)
+	}
+	t.updateMetricLocked()
+}
+
+// Forget stops tracking all the resources of the node, it's called once the node is de-initialized
+func (t *LeakTracker) Forget(nodeName string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.firstSeen, nodeName)
+	t.updateMetricLocked()
+}
+
+// Count returns the number of leaked resources tracked across all the nodes
+func (t *LeakTracker) Count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.countLocked()
+}
+
+func (t *LeakTracker) countLocked() int {
+	count := 0
+	for _, resources := range t.firstSeen {
+		count += len(resources)
+	}
+	return count
+}
+
+func (t *LeakTracker) updateMetricLocked() {
+	orphanedIPv4ResourceCount.WithLabelValues(string(t.resourceType)).Set(float64(t.countLocked()))
+}
+
+// ReconcileLeakedResources compares the IPv4 resources assigned to the ENIs of the node against the used, warm and
+// cooling resources of the node's pool, and unassigns the resources missing from the pool for longer than the
+// grace period. The resources being created or deleted by the pool are briefly missing from it, the grace period
+// must be long enough for the pending jobs to complete.
+func ReconcileLeakedResources(log logr.Logger, nodeName string, manager ENIManager, resourcePool pool.Pool,
+	tracker *LeakTracker, gracePeriod time.Duration, ec2APIHelper api.EC2APIHelper) error {
+	// Resources assigned by a concurrent create job are in EC2 before they are in the pool, so the pool is
+	// introspected after EC2 is described to avoid reporting them
+	assigned, err := manager.GetIPv4Resources(tracker.resourceType, ec2APIHelper)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]struct{}{}
+	details := resourcePool.Introspect()
+	for _, resource := range details.UsedResources {
+		known[resource.GroupID] = struct{}{}
+	}
+	for groupID, resources := range details.WarmResources {
+		known[groupID] = struct{}{}
+		for _, resource := range resources {
+			known[resource.GroupID] = struct{}{}
+		}
+	}
+	for _, resource := range details.CoolingResources {
+		known[resource.Resource.GroupID] = struct{}{}
+	}
+
+	var leaked []ENIResource
+	for _, resource := range assigned {
+		if _, found := known[resource.Resource]; !found {
+			leaked = append(leaked, resource)
+		}
+	}
+
+	expired := tracker.Track(nodeName, leaked, gracePeriod, time.Now())
+	if len(leaked) > 0 {
+		log.Info("found IPv4 resources missing from the pool", "node name", nodeName,
+			"resource type", tracker.resourceType, "leaked", len(leaked), "to unassign", len(expired))
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	failed, err := manager.UnassignENIResources(expired, tracker.resourceType, ec2APIHelper, log)
+	unassigned := len(expired) - len(failed)
+	leakedIPv4ResourceUnassignedCount.WithLabelValues(string(tracker.resourceType)).Add(float64(unassigned))
+	leakedIPv4ResourceUnassignErrCount.WithLabelValues(string(tracker.resourceType)).Add(float64(len(failed)))
+
+	failedSet := map[ENIResource]struct{}{}
+	for _, resource := range failed {
+		failedSet[resource] = struct{}{}
+	}
+	var removed []ENIResource
+	for _, resource := range expired {
+		if _, found := failedSet[resource]; !found {
+			removed = append(removed, resource)
+		}
+	}
+	tracker.Remove(nodeName, removed)
+
+	return err
+}
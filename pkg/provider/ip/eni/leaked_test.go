@@ -0,0 +1,145 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eni
+
+import (
+	"testing"
+	"time"
+
+	mock_pool "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEniManager_GetIPv4Resources tests the secondary IPs are returned with the subnet mask and the prefixes as is,
+// along with their ENI
+func TestEniManager_GetIPv4Resources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().SubnetMask().Return(subnetMask).Times(3)
+	mockEc2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(nwInterfaces, nil).Times(2)
+
+	ips, err := manager.GetIPv4Resources(config.ResourceTypeIPv4Address, mockEc2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, []ENIResource{{ENIID: eniID1, Resource: ip1WithMask}, {ENIID: eniID1, Resource: ip2WithMask},
+		{ENIID: eniID2, Resource: ip3WithMask}}, ips)
+
+	prefixes, err := manager.GetIPv4Resources(config.ResourceTypeIPv4Prefix, mockEc2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, []ENIResource{{ENIID: eniID1, Resource: prefix1}}, prefixes)
+}
+
+// TestEniManager_UnassignENIResources_SomeFail tests the resources are unassigned per ENI, the capacity of the ENI
+// is released and the resources of the ENI that failed are returned
+func TestEniManager_UnassignENIResources_SomeFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+	eni1, eni2 := createENIDetails(eniID1, 1), createENIDetails(eniID2, 2)
+	manager.attachedENIs = []*eni{eni1, eni2}
+	manager.resourceToENIMap = map[string]*eni{ip1: eni1, ip3: eni2}
+
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockEc2APIHelper.EXPECT().UnassignIPv4Resources(eniID1, config.ResourceTypeIPv4Address,
+		[]string{ip1, ip2}).Return(nil)
+	mockEc2APIHelper.EXPECT().UnassignIPv4Resources(eniID2, config.ResourceTypeIPv4Address,
+		[]string{ip3}).Return(mockError)
+
+	failed, err := manager.UnassignENIResources([]ENIResource{{ENIID: eniID1, Resource: ip1WithMask},
+		{ENIID: eniID2, Resource: ip3WithMask}, {ENIID: eniID1, Resource: ip2WithMask}},
+		config.ResourceTypeIPv4Address, mockEc2APIHelper, log)
+
+	assert.Error(t, err)
+	assert.Equal(t, []ENIResource{{ENIID: eniID2, Resource: ip3WithMask}}, failed)
+	assert.Equal(t, 3, eni1.remainingCapacity)
+	assert.Equal(t, 2, eni2.remainingCapacity)
+	assert.Equal(t, map[string]*eni{ip3: eni2}, manager.resourceToENIMap)
+}
+
+// TestLeakTracker_Track tests a resource is only returned once it was leaked in a previous check and for at least
+// the grace period, and is forgotten once it's no longer leaked
+func TestLeakTracker_Track(t *testing.T) {
+	tracker := NewLeakTracker(config.ResourceTypeIPv4Prefix)
+	leaked1 := ENIResource{ENIID: eniID1, Resource: prefix1}
+	leaked2 := ENIResource{ENIID: eniID1, Resource: prefix2}
+	now := time.Now()
+
+	// With no grace period the resources must still be leaked in two checks
+	assert.Empty(t, tracker.Track(instanceName, []ENIResource{leaked1}, 0, now))
+	assert.Equal(t, []ENIResource{leaked1}, tracker.Track(instanceName, []ENIResource{leaked1}, 0, now))
+
+	assert.Empty(t, tracker.Track(instanceName, []ENIResource{leaked1, leaked2}, time.Minute, now.Add(time.Second)))
+	assert.Equal(t, 2, tracker.Count())
+	assert.Equal(t, []ENIResource{leaked1}, tracker.Track(instanceName, []ENIResource{leaked1, leaked2},
+		time.Minute, now.Add(time.Minute)))
+
+	// The resource found in the pool again is forgotten
+	assert.Empty(t, tracker.Track(instanceName, []ENIResource{leaked2}, time.Minute, now.Add(time.Minute)))
+	assert.Equal(t, 1, tracker.Count())
+
+	tracker.Remove(instanceName, []ENIResource{leaked2})
+	assert.Equal(t, 0, tracker.Count())
+
+	tracker.Track(instanceName, []ENIResource{leaked1}, time.Minute, now)
+	tracker.Forget(instanceName)
+	assert.Equal(t, 0, tracker.Count())
+}
+
+// TestReconcileLeakedResources tests only the prefixes missing from the used, warm and cooling resources of the
+// pool for longer than the grace period are unassigned
+func TestReconcileLeakedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, mockInstance, mockEc2APIHelper := getMockManager(ctrl)
+	eni3 := createENIDetails(eniID3, 11)
+	manager.attachedENIs = []*eni{eni3}
+	mockPool := mock_pool.NewMockPool(ctrl)
+	tracker := NewLeakTracker(config.ResourceTypeIPv4Prefix)
+
+	interfaces := []*ec2.InstanceNetworkInterface{{
+		NetworkInterfaceId: &eniID3,
+		Ipv4Prefixes:       []*ec2.InstanceIpv4Prefix{{Ipv4Prefix: &prefix1}, {Ipv4Prefix: &prefix2}, {Ipv4Prefix: &prefix3}},
+	}}
+	details := pool.IntrospectResponse{
+		UsedResources: map[string]pool.Resource{"pod-uid": {GroupID: prefix1, ResourceID: "192.168.1.1"}},
+		WarmResources: map[string][]pool.Resource{},
+	}
+
+	mockInstance.EXPECT().InstanceID().Return(instanceID).Times(2)
+	mockInstance.EXPECT().Name().Return(instanceName)
+	mockEc2APIHelper.EXPECT().GetInstanceNetworkInterface(&instanceID).Return(interfaces, nil).Times(2)
+	mockPool.EXPECT().Introspect().Return(details).Times(2)
+	mockEc2APIHelper.EXPECT().UnassignIPv4Resources(eniID3, config.ResourceTypeIPv4Prefix,
+		[]string{prefix2, prefix3}).Return(nil)
+
+	// The first check only records the leaked prefixes
+	err := ReconcileLeakedResources(log, instanceName, &manager, mockPool, tracker, 0, mockEc2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tracker.Count())
+
+	err = ReconcileLeakedResources(log, instanceName, &manager, mockPool, tracker, 0, mockEc2APIHelper)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, tracker.Count())
+	assert.Equal(t, 13, eni3.remainingCapacity)
+}
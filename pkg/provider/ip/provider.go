@@ -19,8 +19,10 @@ import (
 	"net/http"
 	"sync"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
@@ -33,6 +35,7 @@ import (
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 )
@@ -54,6 +57,8 @@ type ipv4Provider struct {
 	conditions condition.Conditions
 	// healthz check subpath
 	checker healthz.Checker
+	// leakTracker tracks the secondary IPv4 addresses assigned to the nodes but missing from their pool
+	leakTracker *eni.LeakTracker
 }
 
 // ResourceProviderAndPool contains the instance's ENI manager and the resource pool
@@ -77,6 +82,7 @@ func NewIPv4Provider(log logr.Logger, apiWrapper api.Wrapper,
 		apiWrapper:              apiWrapper,
 		workerPool:              workerPool,
 		conditions:              conditions,
+		leakTracker:             eni.NewLeakTracker(config.ResourceTypeIPv4Address),
 	}
 	provider.checker = provider.check()
 	return provider
@@ -188,16 +194,28 @@ func (p *ipv4Provider) InitResource(instance ec2.EC2Instance) error {
 
 	// Submit the async job to periodically process the delete queue
 	p.SubmitAsyncJob(worker.NewWarmProcessDeleteQueueJob(nodeName))
+
+	// Submit the async job to periodically unassign the leaked secondary IPv4 addresses
+	if config.LeakedIPv4ResourceReconcileInterval > 0 {
+		p.SubmitAsyncJob(worker.NewWarmReconcileLeakedResourcesJob(nodeName))
+	}
 	return nil
 }
 
 func (p *ipv4Provider) DeInitResource(instance ec2.EC2Instance) error {
 	nodeName := instance.Name()
 	p.deleteInstanceProviderAndPool(nodeName)
+	p.leakTracker.Forget(nodeName)
 
 	return nil
 }
 
+// ReleaseResource removes the pool of the instance, the IPv4 resources are not unassigned as the pool is rebuilt from
+// the running pods by the replica taking over the node
+func (p *ipv4Provider) ReleaseResource(instance ec2.EC2Instance) error {
+	return p.DeInitResource(instance)
+}
+
 // UpdateResourceCapacity updates the resource capacity based on the type of instance
 func (p *ipv4Provider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
 	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(instance.Name())
@@ -284,6 +302,25 @@ func (p *ipv4Provider) ProcessDeleteQueue(job *worker.WarmPoolJob) (ctrl.Result,
 	return ctrl.Result{Requeue: true, RequeueAfter: config.CoolDownPeriod}, nil
 }
 
+// ReconcileLeakedResources unassigns the secondary IPv4 addresses assigned to the node's ENIs that have been missing from
+// the pool for longer than the grace period
+func (p *ipv4Provider) ReconcileLeakedResources(job *worker.WarmPoolJob) (ctrl.Result, error) {
+	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(job.NodeName)
+	if !isPresent {
+		p.log.Info("forgetting the leaked resources reconcile job", "node name", job.NodeName)
+		return ctrl.Result{}, nil
+	}
+
+	err := eni.ReconcileLeakedResources(p.log, job.NodeName, resourceProviderAndPool.eniManager,
+		resourceProviderAndPool.resourcePool, p.leakTracker, config.LeakedIPv4ResourceGracePeriod, p.ec2APIForJob(job))
+	if err != nil {
+		p.log.Error(err, "failed to reconcile the leaked secondary IPv4 addresses", "node name", job.NodeName)
+	}
+
+	// Re-submit the job to execute after the reconcile interval
+	return ctrl.Result{Requeue: true, RequeueAfter: config.LeakedIPv4ResourceReconcileInterval}, nil
+}
+
 // SubmitAsyncJob submits an asynchronous job to the worker pool
 func (p *ipv4Provider) SubmitAsyncJob(job interface{}) {
 	p.workerPool.SubmitJob(job)
@@ -306,6 +343,8 @@ func (p *ipv4Provider) ProcessAsyncJob(job interface{}) (ctrl.Result, error) {
 		p.ReSyncPool(warmPoolJob)
 	case worker.OperationProcessDeleteQueue:
 		return p.ProcessDeleteQueue(warmPoolJob)
+	case worker.OperationReconcileLeakedResources:
+		return p.ReconcileLeakedResources(warmPoolJob)
 	}
 
 	return ctrl.Result{}, nil
@@ -320,10 +359,17 @@ func (p *ipv4Provider) CreatePrivateIPv4AndUpdatePool(job *worker.WarmPoolJob) {
 		return
 	}
 	didSucceed := true
-	ips, err := instanceResource.eniManager.CreateIPV4Resource(job.ResourceCount, config.ResourceTypeIPv4Address, p.apiWrapper.EC2API, p.log)
+	ips, err := instanceResource.eniManager.CreateIPV4Resource(job.ResourceCount, config.ResourceTypeIPv4Address,
+		p.ec2APIForJob(job), p.log)
 	if err != nil {
 		p.log.Error(err, "failed to create all/some of the IPv4 addresses", "created ips", ips)
 		didSucceed = false
+		utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionIPPoolHealthy,
+			metav1.ConditionFalse, utils.IPPoolFailedReason,
+			fmt.Sprintf("failed to create the IPv4 addresses of the warm pool: %v", err), p.log)
+	} else {
+		utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionIPPoolHealthy,
+			metav1.ConditionTrue, utils.IPPoolReadyReason, "", p.log)
 	}
 	job.Resources = ips
 	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, didSucceed)
@@ -353,7 +399,8 @@ func (p *ipv4Provider) DeletePrivateIPv4AndUpdatePool(job *worker.WarmPoolJob) {
 		return
 	}
 	didSucceed := true
-	failedIPs, err := instanceResource.eniManager.DeleteIPV4Resource(job.Resources, config.ResourceTypeIPv4Address, p.apiWrapper.EC2API, p.log)
+	failedIPs, err := instanceResource.eniManager.DeleteIPV4Resource(job.Resources, config.ResourceTypeIPv4Address,
+		p.ec2APIForJob(job), p.log)
 	if err != nil {
 		p.log.Error(err, "failed to delete all/some of the IPv4 addresses", "failed ips", failedIPs)
 		didSucceed = false
@@ -362,6 +409,14 @@ func (p *ipv4Provider) DeletePrivateIPv4AndUpdatePool(job *worker.WarmPoolJob) {
 	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, didSucceed)
 }
 
+// ec2APIForJob returns the EC2 API helper with the mutating calls attributed to the provider and the job's node,
+// the calls are traced as part of the job's span
+func (p *ipv4Provider) ec2APIForJob(job *worker.WarmPoolJob) ec2API.EC2APIHelper {
+	helper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameIPAddress, NodeName: job.NodeName})
+	return ec2API.WithTraceContext(helper, worker.JobContext(job))
+}
+
 // updatePoolAndReconcileIfRequired updates the resource pool and reconcile again and submit a new job if required
 func (p *ipv4Provider) updatePoolAndReconcileIfRequired(resourcePool pool.Pool, job *worker.WarmPoolJob, didSucceed bool) {
 	// Update the pool to add the created/failed resource to the warm pool and decrement the pending count
@@ -411,7 +466,7 @@ func (p *ipv4Provider) deleteInstanceProviderAndPool(nodeName string) {
 // getCapacity returns the capacity based on the instance type and the instance os
 func getCapacity(instanceType string, instanceOs string) int {
 	// Assign only 1st ENIs non primary IP
-	limits, found := vpc.Limits[instanceType]
+	limits, found := vpc.GetLimits(instanceType)
 	if !found {
 		return 0
 	}
@@ -457,7 +512,7 @@ func (p *ipv4Provider) Introspect() interface{} {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	response := make(map[string]pool.IntrospectResponse)
+	response := provider.IntrospectNodes[pool.IntrospectResponse]{}
 	for nodeName, resource := range p.instanceProviderAndPool {
 		response[nodeName] = resource.resourcePool.Introspect()
 	}
@@ -468,7 +523,7 @@ func (p *ipv4Provider) IntrospectSummary() interface{} {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	response := make(map[string]pool.IntrospectSummaryResponse)
+	response := provider.IntrospectNodes[pool.IntrospectSummaryResponse]{}
 	for nodeName, resource := range p.instanceProviderAndPool {
 		response[nodeName] = ChangeToIntrospectSummary(resource.resourcePool.Introspect())
 
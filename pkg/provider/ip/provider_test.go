@@ -20,7 +20,9 @@ import (
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
@@ -30,12 +32,15 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip/eni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var (
@@ -227,6 +232,8 @@ func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool(t *testing.T) {
 	defer ctrl.Finish()
 
 	ipv4Provider := getMockIpProvider()
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	ipv4Provider.apiWrapper = api.Wrapper{K8sAPI: mockK8sWrapper}
 	mockPool := mock_pool.NewMockPool(ctrl)
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, false)
@@ -240,6 +247,12 @@ func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool(t *testing.T) {
 	}
 
 	mockManager.EXPECT().CreateIPV4Resource(2, config.ResourceTypeIPv4Address, nil, gomock.Any()).Return(createdResources, nil)
+	mockK8sWrapper.EXPECT().SetNodeCondition(nodeName, gomock.Any()).DoAndReturn(
+		func(_ string, condition metav1.Condition) error {
+			assert.Equal(t, rcv1alpha1.CNINodeConditionIPPoolHealthy, condition.Type)
+			assert.Equal(t, metav1.ConditionTrue, condition.Status)
+			return nil
+		})
 	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
 		Operations:    worker.OperationCreate,
 		Resources:     createdResources,
@@ -257,6 +270,8 @@ func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool_Fail(t *testing.T) {
 	defer ctrl.Finish()
 
 	ipv4Provider := getMockIpProvider()
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	ipv4Provider.apiWrapper = api.Wrapper{K8sAPI: mockK8sWrapper}
 	mockPool := mock_pool.NewMockPool(ctrl)
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, false)
@@ -270,6 +285,13 @@ func TestIPv4Provider_CreatePrivateIPv4AndUpdatePool_Fail(t *testing.T) {
 	}
 
 	mockManager.EXPECT().CreateIPV4Resource(2, config.ResourceTypeIPv4Address, nil, gomock.Any()).Return(createdResources, fmt.Errorf("failed"))
+	mockK8sWrapper.EXPECT().SetNodeCondition(nodeName, gomock.Any()).DoAndReturn(
+		func(_ string, condition metav1.Condition) error {
+			assert.Equal(t, rcv1alpha1.CNINodeConditionIPPoolHealthy, condition.Type)
+			assert.Equal(t, metav1.ConditionFalse, condition.Status)
+			assert.Equal(t, utils.IPPoolFailedReason, condition.Reason)
+			return nil
+		})
 	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
 		Operations:    worker.OperationCreate,
 		Resources:     createdResources,
@@ -306,6 +328,33 @@ func TestIpv4Provider_ReSyncPool(t *testing.T) {
 	ipv4Provider.ReSyncPool(reSyncJob)
 }
 
+// TestIpv4Provider_ReconcileLeakedResources tests the leaked secondary IPs are reconciled and the job is re-submitted
+// after the reconcile interval only while the node is initialized
+func TestIpv4Provider_ReconcileLeakedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ipv4Provider := getMockIpProvider()
+	ipv4Provider.leakTracker = eni.NewLeakTracker(config.ResourceTypeIPv4Address)
+	mockPool := mock_pool.NewMockPool(ctrl)
+	mockManager := mock_eni.NewMockENIManager(ctrl)
+	job := worker.NewWarmReconcileLeakedResourcesJob(nodeName)
+
+	result, err := ipv4Provider.ProcessAsyncJob(job)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+
+	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, false)
+	mockManager.EXPECT().GetIPv4Resources(config.ResourceTypeIPv4Address, gomock.Any()).Return(
+		[]eni.ENIResource{{ENIID: "eni-1", Resource: ip1}}, nil)
+	mockPool.EXPECT().Introspect().Return(pool.IntrospectResponse{
+		WarmResources: map[string][]pool.Resource{ip1: {{GroupID: ip1, ResourceID: ip1}}}})
+
+	result, err = ipv4Provider.ProcessAsyncJob(job)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{Requeue: true, RequeueAfter: config.LeakedIPv4ResourceReconcileInterval}, result)
+}
+
 // TestIPv4Provider_SubmitAsyncJob tests that the job is submitted to the worker on calling SubmitAsyncJob
 func TestIPv4Provider_SubmitAsyncJob(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -353,7 +402,7 @@ func TestIPv4Provider_UpdateResourceCapacity_FromFromPDToIP(t *testing.T) {
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, true)
 	mockConditions.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
-	mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(expectedVpcCNIConfig, nil)
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(expectedVpcCNIConfig, nil)
 
 	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
 	mockPool.EXPECT().SetToActive(&ipV4WarmPoolConfig).Return(job)
@@ -421,7 +470,7 @@ func TestIPv4Provider_UpdateResourceCapacity_FromFromIPToPD_NonNitro(t *testing.
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, false)
 	mockConditions.EXPECT().IsWindowsPrefixDelegationEnabled().Return(true)
-	mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(expectedVpcCNIConfig, nil)
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(expectedVpcCNIConfig, nil)
 
 	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
 	mockPool.EXPECT().SetToActive(&ipV4WarmPoolConfig).Return(job)
@@ -481,7 +530,7 @@ func TestIPv4Provider_UpdateResourceCapacity_FromIPToIP(t *testing.T) {
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	ipv4Provider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, false)
 	mockConditions.EXPECT().IsWindowsPrefixDelegationEnabled().Return(false)
-	mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(expectedVpcCNIConfig, nil)
+	mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(expectedVpcCNIConfig, nil)
 
 	job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
 	mockPool.EXPECT().SetToActive(&ipV4WarmPoolConfig).Return(job)
@@ -520,7 +569,7 @@ func TestIpv4Provider_Introspect(t *testing.T) {
 
 	mockPool.EXPECT().Introspect().Return(expectedResp)
 	resp := ipv4Provider.Introspect()
-	assert.True(t, reflect.DeepEqual(resp, map[string]pool.IntrospectResponse{nodeName: expectedResp}))
+	assert.True(t, reflect.DeepEqual(resp, provider.IntrospectNodes[pool.IntrospectResponse]{nodeName: expectedResp}))
 
 	mockPool.EXPECT().Introspect().Return(expectedResp)
 	resp = ipv4Provider.IntrospectNode(nodeName)
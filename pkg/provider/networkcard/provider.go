@@ -0,0 +1,182 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package networkcard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/attachedeni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	operationLabel   = "network_card_provider_operation"
+	networkCardLabel = "network_card"
+
+	ReasonNetworkInterfaceAttached       = "NetworkInterfaceAttached"
+	ReasonNetworkInterfaceAttachFailed   = "NetworkInterfaceAttachFailed"
+	ReasonNetworkInterfaceInvalidRequest = "NetworkInterfaceInvalidRequest"
+)
+
+var (
+	// NetworkInterfaceDescription is the description of the dedicated ENIs, it identifies the ENIs left attached to
+	// the instance after their pod was deleted
+	NetworkInterfaceDescription = "dedicated-eni"
+
+	networkCardProviderOperationsErrCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_card_provider_operations_err_count",
+			Help: "The number of errors encountered for the dedicated ENI operations of the network card providers",
+		},
+		[]string{networkCardLabel, operationLabel},
+	)
+
+	prometheusRegistered = false
+)
+
+// networkCardStrategy attaches dedicated ENIs to a network card of the instances for the pods requesting the network
+// card's resource. A provider is created for each network card.
+type networkCardStrategy struct {
+	// networkCardIndex is the index of the network card the ENIs are attached to
+	networkCardIndex int64
+	// resourceName is the extended resource requested by the pods
+	resourceName string
+	apiWrapper   api.Wrapper
+}
+
+// NewNetworkCardProvider returns the dedicated ENI provider of the network card for all the nodes across the cluster
+func NewNetworkCardProvider(logger logr.Logger, wrapper api.Wrapper, worker worker.Worker, networkCardIndex int64,
+	ctx context.Context) provider.ResourceProvider {
+	prometheusRegister()
+
+	return attachedeni.NewAttachedENIProvider(logger, wrapper, worker, &networkCardStrategy{
+		networkCardIndex: networkCardIndex,
+		resourceName:     config.NetworkCardResourceName(networkCardIndex),
+		apiWrapper:       wrapper,
+	}, ctx)
+}
+
+// prometheusRegister registers prometheus metrics
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(networkCardProviderOperationsErrCount)
+
+		prometheusRegistered = true
+	}
+}
+
+func (s *networkCardStrategy) Name() string {
+	return s.resourceName
+}
+
+func (s *networkCardStrategy) ResourceName() string {
+	return s.resourceName
+}
+
+func (s *networkCardStrategy) Description() string {
+	return NetworkInterfaceDescription
+}
+
+// NetworkCardIndex returns the network card of the strategy if the instance type has it, the default network card is
+// shared with the CNI
+func (s *networkCardStrategy) NetworkCardIndex(instanceType string) (int64, bool) {
+	limits, found := vpc.GetLimits(instanceType)
+	if !found {
+		return 0, false
+	}
+	for _, networkCard := range limits.NetworkCards {
+		if networkCard.NetworkCardIndex == s.networkCardIndex {
+			return s.networkCardIndex, true
+		}
+	}
+	return 0, false
+}
+
+// NewRequest returns the interface type requested by the pod with the security groups of the instance, the pods
+// requesting an invalid interface type are sent an event
+func (s *networkCardStrategy) NewRequest(ctx context.Context, pod *v1.Pod,
+	instance ec2.EC2Instance) (attachedeni.Request, error) {
+	interfaceType, err := getInterfaceType(pod)
+	if err != nil {
+		s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonNetworkInterfaceInvalidRequest,
+			tracing.WithTraceID(ctx, err.Error()), v1.EventTypeWarning)
+		return attachedeni.Request{}, fmt.Errorf("%w: %v", attachedeni.ErrInvalidRequest, err)
+	}
+	return attachedeni.Request{
+		SecurityGroups: instance.CurrentInstanceSecurityGroups(),
+		InterfaceType:  interfaceType,
+	}, nil
+}
+
+// getInterfaceType returns the type of the dedicated ENIs requested by the pod
+func getInterfaceType(pod *v1.Pod) (string, error) {
+	interfaceType, ok := pod.Annotations[config.NetworkInterfaceTypeAnnotation]
+	if !ok {
+		return attachedeni.InterfaceTypeInterface, nil
+	}
+	switch interfaceType {
+	case attachedeni.InterfaceTypeInterface, awsEC2.NetworkInterfaceCreationTypeEfa:
+		return interfaceType, nil
+	}
+	return "", fmt.Errorf("invalid %s %q, must be %s or %s", config.NetworkInterfaceTypeAnnotation, interfaceType,
+		attachedeni.InterfaceTypeInterface, awsEC2.NetworkInterfaceCreationTypeEfa)
+}
+
+// MarshalENIs annotates the pod with the ENIs and their attachment
+func (s *networkCardStrategy) MarshalENIs(enis []*attachedeni.ENIDetails) (string, error) {
+	jsonBytes, err := json.Marshal(enis)
+	return string(jsonBytes), err
+}
+
+func (s *networkCardStrategy) UnmarshalENIs(annotation string) ([]*attachedeni.ENIDetails, error) {
+	var enis []*attachedeni.ENIDetails
+	err := json.Unmarshal([]byte(annotation), &enis)
+	return enis, err
+}
+
+func (s *networkCardStrategy) Attached(ctx context.Context, pod *v1.Pod, enis []*attachedeni.ENIDetails,
+	_ string) {
+	s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonNetworkInterfaceAttached, tracing.WithTraceID(ctx,
+		fmt.Sprintf("Attached %d %s ENIs to network card %d", len(enis), enis[0].InterfaceType, s.networkCardIndex)),
+		v1.EventTypeNormal)
+}
+
+func (s *networkCardStrategy) Failed(ctx context.Context, pod *v1.Pod, failure attachedeni.Failure, err error) {
+	message := fmt.Sprintf("failed to attach ENIs to network card %d: %v", s.networkCardIndex, err)
+	if failure == attachedeni.FailureAnnotate {
+		message = fmt.Sprintf("failed to annotate pod with the dedicated ENIs: %v", err)
+	}
+	s.apiWrapper.K8sAPI.BroadcastEvent(pod, ReasonNetworkInterfaceAttachFailed, tracing.WithTraceID(ctx, message),
+		v1.EventTypeWarning)
+}
+
+// RecordError increments the error count of the operation on the network card
+func (s *networkCardStrategy) RecordError(operation string) {
+	networkCardProviderOperationsErrCount.WithLabelValues(strconv.FormatInt(s.networkCardIndex, 10), operation).Inc()
+}
@@ -0,0 +1,137 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package networkcard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/attachedeni"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/providertest"
+
+	awsEC2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+var (
+	securityGroups   = []string{"sg-1"}
+	networkCardIndex = int64(1)
+
+	eni1 = &attachedeni.ENIDetails{ENIDetails: trunk.ENIDetails{ID: "eni-1", MACAdd: "0e:00:00:00:00:01",
+		IPV4Addr: "192.168.0.1", SubnetCIDR: "192.168.0.0/16"}, AttachmentID: "eni-attach-1",
+		InterfaceType: awsEC2.NetworkInterfaceTypeEfa, NetworkCardIndex: networkCardIndex, DeviceIndex: 14}
+
+	mockError = fmt.Errorf("mock error")
+)
+
+// getStrategyAndMocks returns the strategy of the network card 1
+func getStrategyAndMocks(ctrl *gomock.Controller) (*networkCardStrategy, providertest.Mocks) {
+	m := providertest.NewMocks(ctrl)
+	return &networkCardStrategy{
+		networkCardIndex: networkCardIndex,
+		resourceName:     config.NetworkCardResourceName(networkCardIndex),
+		apiWrapper:       m.Wrapper(),
+	}, m
+}
+
+// TestNetworkCardStrategy_NetworkCardIndex tests only the instance types having the network card are supported,
+// including on their default network card
+func TestNetworkCardStrategy_NetworkCardIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, _ := getStrategyAndMocks(ctrl)
+
+	index, found := strategy.NetworkCardIndex("p4d.24xlarge")
+	assert.True(t, found)
+	assert.Equal(t, networkCardIndex, index)
+	_, found = strategy.NetworkCardIndex("c5.large")
+	assert.False(t, found)
+	_, found = strategy.NetworkCardIndex("unknown.large")
+	assert.False(t, found)
+
+	strategy.networkCardIndex = 0
+	index, found = strategy.NetworkCardIndex("c5n.18xlarge")
+	assert.True(t, found)
+	assert.Equal(t, int64(0), index)
+}
+
+// TestNetworkCardStrategy_NewRequest tests the pods get the interface type they request with the security groups of
+// the instance, and the pods requesting an invalid interface type are sent an event
+func TestNetworkCardStrategy_NewRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, m := getStrategyAndMocks(ctrl)
+
+	m.Instance.EXPECT().CurrentInstanceSecurityGroups().Return(securityGroups).Times(2)
+	request, err := strategy.NewRequest(context.TODO(), providertest.NewPod(nil), m.Instance)
+	assert.NoError(t, err)
+	assert.Equal(t, attachedeni.Request{SecurityGroups: securityGroups,
+		InterfaceType: attachedeni.InterfaceTypeInterface}, request)
+
+	request, err = strategy.NewRequest(context.TODO(), providertest.NewPod(map[string]string{
+		config.NetworkInterfaceTypeAnnotation: awsEC2.NetworkInterfaceCreationTypeEfa}), m.Instance)
+	assert.NoError(t, err)
+	assert.Equal(t, attachedeni.Request{SecurityGroups: securityGroups,
+		InterfaceType: awsEC2.NetworkInterfaceCreationTypeEfa}, request)
+
+	pod := providertest.NewPod(map[string]string{config.NetworkInterfaceTypeAnnotation: "trunk"})
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonNetworkInterfaceInvalidRequest, gomock.Any(), v1.EventTypeWarning)
+	_, err = strategy.NewRequest(context.TODO(), pod, m.Instance)
+	assert.True(t, errors.Is(err, attachedeni.ErrInvalidRequest))
+}
+
+// TestNetworkCardStrategy_MarshalENIs tests the ENIs are annotated with their attachment
+func TestNetworkCardStrategy_MarshalENIs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, _ := getStrategyAndMocks(ctrl)
+
+	annotation, err := strategy.MarshalENIs([]*attachedeni.ENIDetails{eni1})
+	assert.NoError(t, err)
+	assert.Contains(t, annotation, `"networkCardIndex":1`)
+
+	enis, err := strategy.UnmarshalENIs(annotation)
+	assert.NoError(t, err)
+	assert.Equal(t, []*attachedeni.ENIDetails{eni1}, enis)
+}
+
+// TestNetworkCardStrategy_Events tests the pods are sent the events of the attached and failed ENIs
+func TestNetworkCardStrategy_Events(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy, m := getStrategyAndMocks(ctrl)
+	pod := providertest.NewPod(nil)
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonNetworkInterfaceAttached,
+		"Attached 1 efa ENIs to network card 1", v1.EventTypeNormal)
+	strategy.Attached(context.TODO(), pod, []*attachedeni.ENIDetails{eni1}, "")
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonNetworkInterfaceAttachFailed,
+		"failed to attach ENIs to network card 1: mock error", v1.EventTypeWarning)
+	strategy.Failed(context.TODO(), pod, attachedeni.FailureAttach, mockError)
+
+	m.K8sAPI.EXPECT().BroadcastEvent(pod, ReasonNetworkInterfaceAttachFailed,
+		"failed to annotate pod with the dedicated ENIs: mock error", v1.EventTypeWarning)
+	strategy.Failed(context.TODO(), pod, attachedeni.FailureAnnotate, mockError)
+}
@@ -20,8 +20,10 @@ import (
 	"strings"
 	"sync"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/vpc"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
@@ -35,6 +37,7 @@ import (
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 )
@@ -56,6 +59,8 @@ type ipv4PrefixProvider struct {
 	conditions condition.Conditions
 	// healthz check subpath
 	checker healthz.Checker
+	// leakTracker tracks the IPv4 prefixes assigned to the nodes but missing from their pool
+	leakTracker *eni.LeakTracker
 }
 
 // ResourceProviderAndPool contains the instance's ENI manager and the resource pool
@@ -79,6 +84,7 @@ func NewIPv4PrefixProvider(log logr.Logger, apiWrapper api.Wrapper, workerPool w
 		apiWrapper:              apiWrapper,
 		workerPool:              workerPool,
 		conditions:              conditions,
+		leakTracker:             eni.NewLeakTracker(config.ResourceTypeIPv4Prefix),
 	}
 	provider.checker = provider.check()
 	return provider
@@ -189,16 +195,28 @@ func (p *ipv4PrefixProvider) InitResource(instance ec2.EC2Instance) error {
 
 	// Submit the async job to periodically process the delete queue
 	p.SubmitAsyncJob(worker.NewWarmProcessDeleteQueueJob(nodeName))
+
+	// Submit the async job to periodically unassign the leaked IPv4 prefixes
+	if config.LeakedIPv4ResourceReconcileInterval > 0 {
+		p.SubmitAsyncJob(worker.NewWarmReconcileLeakedResourcesJob(nodeName))
+	}
 	return nil
 }
 
 func (p *ipv4PrefixProvider) DeInitResource(instance ec2.EC2Instance) error {
 	nodeName := instance.Name()
 	p.deleteInstanceProviderAndPool(nodeName)
+	p.leakTracker.Forget(nodeName)
 
 	return nil
 }
 
+// ReleaseResource removes the pool of the instance, the IPv4 resources are not unassigned as the pool is rebuilt from
+// the running pods by the replica taking over the node
+func (p *ipv4PrefixProvider) ReleaseResource(instance ec2.EC2Instance) error {
+	return p.DeInitResource(instance)
+}
+
 func (p *ipv4PrefixProvider) UpdateResourceCapacity(instance ec2.EC2Instance) error {
 	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(instance.Name())
 	if !isPresent {
@@ -259,6 +277,25 @@ func (p *ipv4PrefixProvider) UpdateResourceCapacity(instance ec2.EC2Instance) er
 	return nil
 }
 
+// ReconcileLeakedResources unassigns the IPv4 prefixes assigned to the node's ENIs that have been missing from
+// the pool for longer than the grace period
+func (p *ipv4PrefixProvider) ReconcileLeakedResources(job *worker.WarmPoolJob) (ctrl.Result, error) {
+	resourceProviderAndPool, isPresent := p.getInstanceProviderAndPool(job.NodeName)
+	if !isPresent {
+		p.log.Info("forgetting the leaked resources reconcile job", "node name", job.NodeName)
+		return ctrl.Result{}, nil
+	}
+
+	err := eni.ReconcileLeakedResources(p.log, job.NodeName, resourceProviderAndPool.eniManager,
+		resourceProviderAndPool.resourcePool, p.leakTracker, config.LeakedIPv4ResourceGracePeriod, p.ec2APIForJob(job))
+	if err != nil {
+		p.log.Error(err, "failed to reconcile the leaked IPv4 prefixes", "node name", job.NodeName)
+	}
+
+	// Re-submit the job to execute after the reconcile interval
+	return ctrl.Result{Requeue: true, RequeueAfter: config.LeakedIPv4ResourceReconcileInterval}, nil
+}
+
 func (p *ipv4PrefixProvider) SubmitAsyncJob(job interface{}) {
 	p.workerPool.SubmitJob(job)
 }
@@ -278,6 +315,8 @@ func (p *ipv4PrefixProvider) ProcessAsyncJob(job interface{}) (ctrl.Result, erro
 		p.ReSyncPool(warmPoolJob)
 	case worker.OperationProcessDeleteQueue:
 		return p.ProcessDeleteQueue(warmPoolJob)
+	case worker.OperationReconcileLeakedResources:
+		return p.ReconcileLeakedResources(warmPoolJob)
 	}
 
 	return ctrl.Result{}, nil
@@ -297,8 +336,8 @@ func (p *ipv4PrefixProvider) CreateIPv4PrefixAndUpdatePool(job *worker.WarmPoolJ
 	// If subnet has sufficient cidr blocks, prefixAvailable is true, otherwise false.
 	prefixAvailable := true
 
-	resources, err := instanceResource.eniManager.CreateIPV4Resource(job.ResourceCount, config.ResourceTypeIPv4Prefix, p.apiWrapper.EC2API,
-		p.log)
+	resources, err := instanceResource.eniManager.CreateIPV4Resource(job.ResourceCount, config.ResourceTypeIPv4Prefix,
+		p.ec2APIForJob(job), p.log)
 
 	if err != nil {
 		p.log.Error(err, "failed to create all/some of the IPv4 prefixes", "created resources", resources)
@@ -315,7 +354,18 @@ func (p *ipv4PrefixProvider) CreateIPv4PrefixAndUpdatePool(job *worker.WarmPoolJ
 			// Send node event to inform user of insufficient CIDR blocks error
 			utils.SendNodeEventWithNodeName(p.apiWrapper.K8sAPI, job.NodeName, utils.InsufficientCidrBlocksReason,
 				utils.ErrInsufficientCidrBlocks.Error(), v1.EventTypeWarning, p.log)
+			utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionSubnetExhausted,
+				metav1.ConditionTrue, utils.InsufficientCidrBlocksReason, utils.ErrInsufficientCidrBlocks.Error(), p.log)
+		} else {
+			utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionIPPoolHealthy,
+				metav1.ConditionFalse, utils.IPPoolFailedReason,
+				fmt.Sprintf("failed to create the IPv4 prefixes of the warm pool: %v", err), p.log)
 		}
+	} else {
+		utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionSubnetExhausted,
+			metav1.ConditionFalse, utils.CidrBlocksAvailableReason, "", p.log)
+		utils.SetNodeCondition(p.apiWrapper.K8sAPI, job.NodeName, rcv1alpha1.CNINodeConditionIPPoolHealthy,
+			metav1.ConditionTrue, utils.IPPoolReadyReason, "", p.log)
 	}
 	job.Resources = resources
 	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, notRetry, prefixAvailable)
@@ -331,7 +381,7 @@ func (p *ipv4PrefixProvider) DeleteIPv4PrefixAndUpdatePool(job *worker.WarmPoolJ
 
 	didSucceed := true
 	failedResources, err := instanceResource.eniManager.DeleteIPV4Resource(job.Resources, config.ResourceTypeIPv4Prefix,
-		p.apiWrapper.EC2API, p.log)
+		p.ec2APIForJob(job), p.log)
 
 	if err != nil {
 		p.log.Error(err, "failed to delete all/some of the IPv4 prefixes", "failed resources", failedResources)
@@ -341,6 +391,14 @@ func (p *ipv4PrefixProvider) DeleteIPv4PrefixAndUpdatePool(job *worker.WarmPoolJ
 	p.updatePoolAndReconcileIfRequired(instanceResource.resourcePool, job, didSucceed, true)
 }
 
+// ec2APIForJob returns the EC2 API helper with the mutating calls attributed to the provider and the job's node,
+// the calls are traced as part of the job's span
+func (p *ipv4PrefixProvider) ec2APIForJob(job *worker.WarmPoolJob) ec2API.EC2APIHelper {
+	helper := ec2API.WithAuditCaller(p.apiWrapper.EC2API,
+		ec2API.AuditCaller{Provider: config.ResourceNameIPAddressFromPrefix, NodeName: job.NodeName})
+	return ec2API.WithTraceContext(helper, worker.JobContext(job))
+}
+
 func (p *ipv4PrefixProvider) ReSyncPool(job *worker.WarmPoolJob) {
 	providerAndPool, found := p.instanceProviderAndPool[job.NodeName]
 	if !found {
@@ -429,7 +487,7 @@ func (p *ipv4PrefixProvider) Introspect() interface{} {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	response := make(map[string]pool.IntrospectResponse)
+	response := provider.IntrospectNodes[pool.IntrospectResponse]{}
 	for nodeName, resource := range p.instanceProviderAndPool {
 		response[nodeName] = resource.resourcePool.Introspect()
 	}
@@ -440,7 +498,7 @@ func (p *ipv4PrefixProvider) IntrospectSummary() interface{} {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	response := make(map[string]pool.IntrospectSummaryResponse)
+	response := provider.IntrospectNodes[pool.IntrospectSummaryResponse]{}
 	for nodeName, resource := range p.instanceProviderAndPool {
 		response[nodeName] = ip.ChangeToIntrospectSummary(resource.resourcePool.Introspect())
 	}
@@ -494,7 +552,7 @@ func (p *ipv4PrefixProvider) deleteInstanceProviderAndPool(nodeName string) {
 // getCapacity returns the capacity for IPv4 addresses deconstructed from IPv4 prefixes based on the instance type and the instance os;
 func getCapacity(instanceType string, instanceOs string) int {
 	// Assign only 1st ENIs non-primary IP
-	limits, found := vpc.Limits[instanceType]
+	limits, found := vpc.GetLimits(instanceType)
 	if !found {
 		return 0
 	}
@@ -21,6 +21,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
 	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
@@ -30,6 +31,7 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip/eni"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
@@ -238,6 +240,8 @@ func TestIPv4PrefixProvider_CreateIPv4PrefixAndUpdatePool(t *testing.T) {
 	defer ctrl.Finish()
 
 	prefixProvider := getMockIPv4PrefixProvider()
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
+	prefixProvider.apiWrapper = api.Wrapper{K8sAPI: mockK8sWrapper}
 	mockPool := mock_pool.NewMockPool(ctrl)
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	prefixProvider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, true)
@@ -251,6 +255,8 @@ func TestIPv4PrefixProvider_CreateIPv4PrefixAndUpdatePool(t *testing.T) {
 	}
 
 	mockManager.EXPECT().CreateIPV4Resource(2, config.ResourceTypeIPv4Prefix, nil, gomock.Any()).Return(createdResources, nil)
+	expectNodeCondition(t, mockK8sWrapper, rcv1alpha1.CNINodeConditionSubnetExhausted, metav1.ConditionFalse)
+	expectNodeCondition(t, mockK8sWrapper, rcv1alpha1.CNINodeConditionIPPoolHealthy, metav1.ConditionTrue)
 	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
 		Operations:    worker.OperationCreate,
 		Resources:     createdResources,
@@ -268,8 +274,9 @@ func TestIPv4PrefixProvider_CreateIPv4PrefixAndUpdatePool_Fail(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockWorker := mock_worker.NewMockWorker(ctrl)
+	mockK8sWrapper := mock_k8s.NewMockK8sWrapper(ctrl)
 	prefixProvider := ipv4PrefixProvider{instanceProviderAndPool: map[string]*ResourceProviderAndPool{}, workerPool: mockWorker,
-		log: zap.New(zap.UseDevMode(true)).WithName("prefix provider")}
+		apiWrapper: api.Wrapper{K8sAPI: mockK8sWrapper}, log: zap.New(zap.UseDevMode(true)).WithName("prefix provider")}
 	mockPool := mock_pool.NewMockPool(ctrl)
 	mockManager := mock_eni.NewMockENIManager(ctrl)
 	prefixProvider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, true)
@@ -284,6 +291,7 @@ func TestIPv4PrefixProvider_CreateIPv4PrefixAndUpdatePool_Fail(t *testing.T) {
 
 	mockManager.EXPECT().CreateIPV4Resource(3, config.ResourceTypeIPv4Prefix, nil, gomock.Any()).Return(createdResources,
 		fmt.Errorf("failed"))
+	expectNodeCondition(t, mockK8sWrapper, rcv1alpha1.CNINodeConditionIPPoolHealthy, metav1.ConditionFalse)
 	mockPool.EXPECT().UpdatePool(&worker.WarmPoolJob{
 		Operations:    worker.OperationCreate,
 		Resources:     createdResources,
@@ -336,6 +344,7 @@ func TestIPv4PrefixProvider_CreateIPv4PrefixAndUpdatePool_Fail_InsufficientCidrB
 
 	mockK8sWrapper.EXPECT().GetNode(nodeName).Return(node, nil).Times(1)
 	mockK8sWrapper.EXPECT().BroadcastEvent(node, utils.InsufficientCidrBlocksReason, utils.ErrInsufficientCidrBlocks.Error(), v1.EventTypeWarning).Times(1)
+	expectNodeCondition(t, mockK8sWrapper, rcv1alpha1.CNINodeConditionSubnetExhausted, metav1.ConditionTrue)
 	prefixProvider.CreateIPv4PrefixAndUpdatePool(createJob)
 }
 
@@ -398,7 +407,7 @@ func TestIPv4PrefixProvider_UpdateResourceCapacity_FromFromIPToPD(t *testing.T)
 		log:                     zap.New(zap.UseDevMode(true)).WithName("prefix provider"), conditions: mockConditions}
 
 	for _, c := range []*v1.ConfigMap{vpcCNIConfig, vpcCNIConfigWindows} {
-		mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(c, nil)
+		mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(c, nil)
 		mockPool := mock_pool.NewMockPool(ctrl)
 		mockManager := mock_eni.NewMockENIManager(ctrl)
 		prefixProvider.putInstanceProviderAndPool(nodeName, mockPool, mockManager, nodeCapacity, true)
@@ -465,7 +474,7 @@ func TestIPv4PrefixProvider_UpdateResourceCapacity_FromPDToPD(t *testing.T) {
 
 	for _, c := range []*v1.ConfigMap{vpcCNIConfig, vpcCNIConfigWindows} {
 		mockConditions.EXPECT().IsWindowsPrefixDelegationEnabled().Return(true)
-		mockK8sWrapper.EXPECT().GetConfigMap(config.VpcCniConfigMapName, config.KubeSystemNamespace).Return(c, nil)
+		mockK8sWrapper.EXPECT().GetControllerConfigMap().Return(c, nil)
 
 		job := &worker.WarmPoolJob{Operations: worker.OperationCreate}
 		mockPool.EXPECT().SetToActive(pdWarmPoolConfig).Return(job)
@@ -529,7 +538,7 @@ func TestIpv4PrefixProvider_Introspect(t *testing.T) {
 
 	mockPool.EXPECT().Introspect().Return(expectedResp)
 	resp := prefixProvider.Introspect()
-	assert.True(t, reflect.DeepEqual(resp, map[string]pool.IntrospectResponse{nodeName: expectedResp}))
+	assert.True(t, reflect.DeepEqual(resp, provider.IntrospectNodes[pool.IntrospectResponse]{nodeName: expectedResp}))
 
 	mockPool.EXPECT().Introspect().Return(expectedResp)
 	resp = prefixProvider.IntrospectNode(nodeName)
@@ -539,6 +548,17 @@ func TestIpv4PrefixProvider_Introspect(t *testing.T) {
 	assert.Equal(t, resp, struct{}{})
 }
 
+// expectNodeCondition expects the condition to be set on the node with the status
+func expectNodeCondition(t *testing.T, mockK8sWrapper *mock_k8s.MockK8sWrapper, conditionType string,
+	status metav1.ConditionStatus) {
+	mockK8sWrapper.EXPECT().SetNodeCondition(nodeName, gomock.Any()).DoAndReturn(
+		func(_ string, condition metav1.Condition) error {
+			assert.Equal(t, conditionType, condition.Type)
+			assert.Equal(t, status, condition.Status)
+			return nil
+		})
+}
+
 func getMockIPv4PrefixProvider() ipv4PrefixProvider {
 	return ipv4PrefixProvider{instanceProviderAndPool: map[string]*ResourceProviderAndPool{},
 		log: zap.New(zap.UseDevMode(true)).WithName("prefix provider")}
@@ -14,6 +14,7 @@
 package provider
 
 import (
+	v1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
@@ -27,6 +28,9 @@ type ResourceProvider interface {
 	InitResource(instance ec2.EC2Instance) error
 	// DeInitResources de initializes the resource provider
 	DeInitResource(instance ec2.EC2Instance) error
+	// ReleaseResource stops managing the resources of the instance without deleting them, so they can be taken over
+	// by another replica
+	ReleaseResource(instance ec2.EC2Instance) error
 	// UpdateResourceCapacity updates the resource capacity
 	UpdateResourceCapacity(instance ec2.EC2Instance) error
 	// SubmitAsyncJob submits a job to the worker
@@ -37,7 +41,8 @@ type ResourceProvider interface {
 	GetPool(nodeName string) (pool.Pool, bool)
 	// IsInstanceSupported returns true if an instance type is supported by the provider
 	IsInstanceSupported(instance ec2.EC2Instance) bool
-	// Introspect allows introspection of all nodes for the given resource
+	// Introspect allows introspection of all nodes for the given resource, the response is listed per node by the
+	// introspection API if it implements IntrospectRecords
 	Introspect() interface{}
 	// IntrospectNode allows introspection of a node for the given resource
 	IntrospectNode(node string) interface{}
@@ -47,3 +52,34 @@ type ResourceProvider interface {
 	IntrospectSummary() interface{}
 	ReconcileNode(nodeName string) bool
 }
+
+// NodeUpdateHandler is implemented by the resource providers that depend on the state of the node object
+type NodeUpdateHandler interface {
+	// UpdateNodeState is called with the node object on every update of a managed node
+	UpdateNodeState(node *v1.Node)
+}
+
+// IntrospectRecords is implemented by the responses of Introspect and IntrospectSummary, so the introspection list
+// endpoints can split them per node without knowing the provider
+type IntrospectRecords interface {
+	// NodeDetails returns the details of each node
+	NodeDetails() map[string]interface{}
+}
+
+// IntrospectStateFilter is implemented by the node details that can be filtered by the state of the resources
+type IntrospectStateFilter interface {
+	// FilterState returns the details of the resources in the state, returns false if there are none
+	FilterState(state string) (interface{}, bool)
+}
+
+// IntrospectNodes is the introspection response with the details of each node
+type IntrospectNodes[T any] map[string]T
+
+// NodeDetails returns the details of each node
+func (n IntrospectNodes[T]) NodeDetails() map[string]interface{} {
+	details := make(map[string]interface{}, len(n))
+	for nodeName, nodeDetails := range n {
+		details[nodeName] = nodeDetails
+	}
+	return details
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package providertest has the fixtures shared by the tests of the on demand resource providers
+package providertest
+
+import (
+	mock_ec2 "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2"
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	mock_pod "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
+	mock_utils "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/utils"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+
+	"github.com/golang/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	NodeName   = "test-node"
+	InstanceID = "i-00000000000000000"
+
+	PodUID = types.UID("uid-1")
+)
+
+// Mocks are the mocked APIs of the provider and the mocked instance of the node
+type Mocks struct {
+	PodAPI    *mock_pod.MockPodClientAPIWrapper
+	K8sAPI    *mock_k8s.MockK8sWrapper
+	SGPAPI    *mock_utils.MockSecurityGroupForPodsAPI
+	EC2Helper *mock_api.MockEC2APIHelper
+	Instance  *mock_ec2.MockEC2Instance
+}
+
+// NewMocks returns the mocks of the controller
+func NewMocks(ctrl *gomock.Controller) Mocks {
+	return Mocks{
+		PodAPI:    mock_pod.NewMockPodClientAPIWrapper(ctrl),
+		K8sAPI:    mock_k8s.NewMockK8sWrapper(ctrl),
+		SGPAPI:    mock_utils.NewMockSecurityGroupForPodsAPI(ctrl),
+		EC2Helper: mock_api.NewMockEC2APIHelper(ctrl),
+		Instance:  mock_ec2.NewMockEC2Instance(ctrl),
+	}
+}
+
+// Wrapper returns the API wrapper with the mocked APIs
+func (m Mocks) Wrapper() api.Wrapper {
+	return api.Wrapper{PodAPI: m.PodAPI, K8sAPI: m.K8sAPI, SGPAPI: m.SGPAPI, EC2API: m.EC2Helper}
+}
+
+// ExpectGetPod expects the pod to be read from the cache and from the API Server
+func (m Mocks) ExpectGetPod(pod *v1.Pod) {
+	m.PodAPI.EXPECT().GetPod(pod.Namespace, pod.Name).Return(pod, nil)
+	m.PodAPI.EXPECT().GetPodFromAPIServer(gomock.Any(), pod.Namespace, pod.Name).Return(pod, nil)
+}
+
+// NewPod returns the pod with the annotations scheduled on the node
+func NewPod(annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:         PodUID,
+			Name:        "pod",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: v1.PodSpec{NodeName: NodeName, Containers: []v1.Container{{Name: "app"}}},
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/dedicated"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/eip"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/networkcard"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/prefix"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// init registers the resources built in the controller. The pod mutating webhook injects pod-eni itself, the
+// dedicated pod ENI and Elastic IP resources are only managed if enabled, the network card resources are registered
+// once enabled
+func init() {
+	// Load that static configuration of the resource
+	resourceConfig := config.LoadResourceConfig()
+
+	MustRegister(Registration{
+		Name:               config.ResourceNamePodENI,
+		HandlerType:        HandlerTypeOnDemand,
+		Config:             resourceConfig[config.ResourceNamePodENI],
+		HealthCheckSubpath: branchProviderHealthCheckSubpath,
+		NewProvider: func(ctx context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			resourceConfig config.ResourceConfig, _ condition.Conditions) provider.ResourceProvider {
+			return branch.NewBranchENIProvider(log.WithName("branch eni provider"), wrapper, workers,
+				resourceConfig, ctx)
+		},
+	})
+	MustRegister(Registration{
+		Name:        config.ResourceNameDedicatedPodENI,
+		HandlerType: HandlerTypeOnDemand,
+		// The pods request the pod-eni resource, the pod controller uses the handler on the instances not
+		// supporting trunking and the handler annotates the pods with the dedicated ENIs under pod-eni
+		HandlerResourceName: config.ResourceNamePodENI,
+		Config:              resourceConfig[config.ResourceNameDedicatedPodENI],
+		NewProvider: func(ctx context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			_ config.ResourceConfig, _ condition.Conditions) provider.ResourceProvider {
+			return dedicated.NewDedicatedENIProvider(log.WithName("dedicated eni provider"), wrapper, workers, ctx)
+		},
+	})
+	MustRegister(Registration{
+		Name:               config.ResourceNameIPAddress,
+		HandlerType:        HandlerTypeWarm,
+		Config:             resourceConfig[config.ResourceNameIPAddress],
+		HealthCheckSubpath: ipv4ProviderHealthCheckSubpath,
+		NewProvider: func(_ context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			resourceConfig config.ResourceConfig, conditions condition.Conditions) provider.ResourceProvider {
+			return ip.NewIPv4Provider(log.WithName("ipv4 provider"), wrapper, workers, resourceConfig, conditions)
+		},
+	})
+	MustRegister(Registration{
+		Name:        config.ResourceNameIPAddressFromPrefix,
+		HandlerType: HandlerTypeWarm,
+		// The pods request the IPv4 address resource, the handler annotates them with the address from the prefix
+		HandlerResourceName: config.ResourceNameIPAddress,
+		Config:              resourceConfig[config.ResourceNameIPAddressFromPrefix],
+		HealthCheckSubpath:  ipv4PrefixProviderHealthCheckSubpath,
+		NewProvider: func(_ context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			resourceConfig config.ResourceConfig, conditions condition.Conditions) provider.ResourceProvider {
+			return prefix.NewIPv4PrefixProvider(log.WithName("ipv4 prefix provider"), wrapper, workers,
+				resourceConfig, conditions)
+		},
+	})
+	MustRegister(Registration{
+		Name:               config.ResourceNameElasticIP,
+		HandlerType:        HandlerTypeOnDemand,
+		Config:             resourceConfig[config.ResourceNameElasticIP],
+		HealthCheckSubpath: elasticIPProviderHealthCheckSubpath,
+		NewProvider: func(ctx context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			resourceConfig config.ResourceConfig, _ condition.Conditions) provider.ResourceProvider {
+			return eip.NewElasticIPProvider(log.WithName("elastic ip provider"), wrapper, workers,
+				resourceConfig, ctx)
+		},
+		// The pods selecting a pool get an Elastic IP address
+		Injection: func(pod *corev1.Pod) int64 {
+			if pod.Annotations[config.ElasticIPPoolAnnotation] == "" {
+				return 0
+			}
+			return 1
+		},
+	})
+}
+
+// RegisterNetworkCard registers the dedicated ENI resource of the network card when it's enabled. The network cards
+// are not registered from the static limits, as the limits fetched from EC2 or loaded from the overrides file may
+// have more network cards. The pods request the dedicated ENIs of a network card explicitly.
+func RegisterNetworkCard(resourceName string) error {
+	networkCardIndex, found := config.NetworkCardIndex(resourceName)
+	if !found {
+		return fmt.Errorf("%s is not the resource of a network card", resourceName)
+	}
+	if _, found := GetRegistration(resourceName); found {
+		return nil
+	}
+	return Register(Registration{
+		Name:        resourceName,
+		HandlerType: HandlerTypeOnDemand,
+		Config:      config.NetworkCardResourceConfig(networkCardIndex),
+		NewProvider: func(ctx context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+			_ config.ResourceConfig, _ condition.Conditions) provider.ResourceProvider {
+			return networkcard.NewNetworkCardProvider(log.WithName("network card provider").
+				WithValues("network card", networkCardIndex), wrapper, workers, networkCardIndex, ctx)
+		},
+	})
+}
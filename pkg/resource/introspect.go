@@ -17,10 +17,14 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 )
 
@@ -28,12 +32,33 @@ const (
 	GetNodeResourcesPath    = "/node/"
 	GetAllResourcesPath     = "/resources/all"
 	GetResourcesSummaryPath = "/resources/summary"
+	GetEC2AuditLogPath      = "/ec2/audit"
+	GetENICleanupReportPath = "/eni-cleaner/report"
+	GetShardsPath           = "/shards"
+	GetStandbyPath          = "/standby"
 )
 
 type IntrospectHandler struct {
 	Log             logr.Logger
 	BindAddress     string
 	ResourceManager ResourceManager
+	// EC2AuditLog is the audit log of the mutating EC2 calls, nil if the audit log is disabled
+	EC2AuditLog *ec2API.AuditLog
+	// ENICleaner is the cleaner of the dangling ENIs whose last clean up report is served
+	ENICleaner *ec2API.ENICleaner
+	// Sharder is the owner of the node shards of the replica, nil if the nodes are not sharded
+	Sharder shard.Sharder
+	// WarmCache is the view of the nodes pre-fetched by the standby replica, nil if the replicas don't run as warm
+	// standby
+	WarmCache *ec2API.WarmCache
+	// NodeReader lists the nodes matching the node selector of the list requests
+	NodeReader client.Reader
+	// Authenticator authenticates the bearer token of the requests, nil if the requests are not authenticated
+	Authenticator IntrospectAuthenticator
+	// TLSCertFile and TLSKeyFile are the certificate and key the API is served with, the API is served over plain
+	// HTTP if not set
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
 // StartENICleaner starts the ENI Cleaner routine that cleans up dangling ENIs created by the controller
@@ -44,33 +69,49 @@ func (i *IntrospectHandler) Start(_ context.Context) error {
 	mux.HandleFunc(GetAllResourcesPath, i.ResourceHandler)
 	mux.HandleFunc(GetNodeResourcesPath, i.NodeResourceHandler)
 	mux.HandleFunc(GetResourcesSummaryPath, i.ResourceSummaryHandler)
+	mux.HandleFunc(GetEC2AuditLogPath, i.EC2AuditLogHandler)
+	mux.HandleFunc(GetENICleanupReportPath, i.ENICleanupReportHandler)
+	mux.HandleFunc(GetShardsPath, i.ShardsHandler)
+	mux.HandleFunc(GetStandbyPath, i.StandbyHandler)
 
+	var err error
+	if i.TLSCertFile != "" {
+		i.Log.Info("serving introspection API over TLS", "cert file", i.TLSCertFile)
+		err = http.ListenAndServeTLS(i.BindAddress, i.TLSCertFile, i.TLSKeyFile, i.authenticate(mux)) // #nosec G114
+	} else {
+		err = http.ListenAndServe(i.BindAddress, i.authenticate(mux)) // #nosec G114
+	}
 	// Should this be a fatal error?
-	err := http.ListenAndServe(i.BindAddress, mux) // #nosec G114
 	if err != nil {
 		i.Log.Error(err, "failed to run introspect API")
 	}
 	return err
 }
 
-// ResourceHandler returns all the nodes associated with the resource
-func (i *IntrospectHandler) ResourceHandler(w http.ResponseWriter, _ *http.Request) {
+// ResourceHandler returns all the nodes associated with the resource. The nodes can be filtered by resource, node
+// label selector and state, and paginated or streamed as JSON Lines
+func (i *IntrospectHandler) ResourceHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseListOptions(r.URL.Query(), true)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
 	response := make(map[string]interface{})
 	for resourceName, provider := range i.ResourceManager.GetResourceProviders() {
+		if options.resource != "" && resourceName != options.resource {
+			continue
+		}
 		data := provider.Introspect()
 		response[resourceName] = data
 	}
 
-	jsonData, err := json.MarshalIndent(response, "", "\t")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	if options.isFiltered() || options.isList() {
+		i.writeRecords(w, r, options, response)
 		return
 	}
-
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	writeJSON(w, response)
 }
 
 // NodeResourceHandler returns all the resources associated with the Node
@@ -97,15 +138,117 @@ func (i *IntrospectHandler) NodeResourceHandler(w http.ResponseWriter, r *http.R
 	w.Write(jsonData)
 }
 
-// ResourceSummaryHandler returns all the resources associated with the Node
+// ResourceSummaryHandler returns the summary of the resources of each node. The nodes can be filtered by resource and
+// node label selector, and paginated or streamed as JSON Lines
 func (i *IntrospectHandler) ResourceSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseListOptions(r.URL.Query(), false)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
 	response := make(map[string]interface{})
 	for resourceName, provider := range i.ResourceManager.GetResourceProviders() {
+		if options.resource != "" && resourceName != options.resource {
+			continue
+		}
 		data := provider.IntrospectSummary()
 		response[resourceName] = data
 	}
 
-	jsonData, err := json.MarshalIndent(response, "", "\t")
+	if options.isFiltered() || options.isList() {
+		i.writeRecords(w, r, options, response)
+		return
+	}
+	writeJSON(w, response)
+}
+
+// EC2AuditLogHandler returns the most recent mutating EC2 calls, the number of entries can be limited with the
+// limit query parameter
+func (i *IntrospectHandler) EC2AuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if i.EC2AuditLog == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("ec2 audit log is not enabled"))
+		return
+	}
+
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("limit must be a non negative integer"))
+			return
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(i.EC2AuditLog.Recent(limit), "", "\t")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ENICleanupReportHandler returns the report of the last dangling ENI clean up cycle
+func (i *IntrospectHandler) ENICleanupReportHandler(w http.ResponseWriter, _ *http.Request) {
+	var report *ec2API.ENICleanupReport
+	if i.ENICleaner != nil {
+		report = i.ENICleaner.LastReport()
+	}
+	if report == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no eni clean up cycle has completed yet"))
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ShardsHandler returns the ownership of the node shards as seen by the replica
+func (i *IntrospectHandler) ShardsHandler(w http.ResponseWriter, _ *http.Request) {
+	if i.Sharder == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("node sharding is not enabled"))
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(i.Sharder.Introspect(), "", "\t")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// StandbyHandler returns the view of the nodes pre-fetched by the standby replica, the view of a node is removed
+// once the replica is elected leader and the node is initialized
+func (i *IntrospectHandler) StandbyHandler(w http.ResponseWriter, _ *http.Request) {
+	if i.WarmCache == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("warm standby is not enabled"))
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(i.WarmCache.Introspect(), "", "\t")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
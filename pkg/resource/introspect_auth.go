@@ -0,0 +1,205 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationclientv1 "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationclientv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const (
+	// IntrospectAuthNone serves the introspection API without authentication
+	IntrospectAuthNone = "none"
+	// IntrospectAuthToken authenticates the requests with the bearer token read from a file
+	IntrospectAuthToken = "token"
+	// IntrospectAuthTokenReview authenticates the requests with a TokenReview of the bearer token
+	IntrospectAuthTokenReview = "tokenreview"
+
+	// IntrospectNonResourcePath is the non-resource URL the users of the introspection API must be allowed to get
+	// with tokenreview authentication
+	IntrospectNonResourcePath = "/vpc-resource-controller/introspect"
+
+	// tokenReviewCacheTTL is the duration an authenticated token is not reviewed again
+	tokenReviewCacheTTL = time.Minute
+)
+
+// ErrIntrospectForbidden is returned when the token is authenticated but its user isn't allowed to introspect
+var ErrIntrospectForbidden = errors.New("not allowed to get " + IntrospectNonResourcePath)
+
+// IntrospectAuthenticator authenticates the bearer token of the introspection API requests
+type IntrospectAuthenticator interface {
+	// Authenticate returns true if the token is valid, and ErrIntrospectForbidden if the authenticated user isn't
+	// allowed to introspect
+	Authenticate(ctx context.Context, token string) (bool, error)
+}
+
+// staticTokenAuthenticator accepts the single token it was created with
+type staticTokenAuthenticator struct {
+	token []byte
+}
+
+// NewStaticTokenAuthenticator returns an authenticator accepting the token read from the file
+func NewStaticTokenAuthenticator(tokenFile string) (IntrospectAuthenticator, error) {
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", tokenFile)
+	}
+	return &staticTokenAuthenticator{token: []byte(token)}, nil
+}
+
+func (s *staticTokenAuthenticator) Authenticate(_ context.Context, token string) (bool, error) {
+	return subtle.ConstantTimeCompare(s.token, []byte(token)) == 1, nil
+}
+
+// tokenReviewAuthenticator validates the token with the API Server and checks its user is allowed to get the
+// introspection non-resource URL, the tokens authorized recently are cached so each request doesn't create a
+// TokenReview and a SubjectAccessReview
+type tokenReviewAuthenticator struct {
+	tokenReviews         authenticationclientv1.TokenReviewInterface
+	subjectAccessReviews authorizationclientv1.SubjectAccessReviewInterface
+	audiences            []string
+	clock                func() time.Time
+
+	// lock guards the following
+	lock sync.Mutex
+	// authenticated is the time until which the hash of each token is authenticated and authorized
+	authenticated map[[sha256.Size]byte]time.Time
+}
+
+// NewTokenReviewAuthenticator returns an authenticator validating the token with a TokenReview, the token must be
+// issued for one of the audiences if any. The user of the token must be allowed to get IntrospectNonResourcePath.
+func NewTokenReviewAuthenticator(tokenReviews authenticationclientv1.TokenReviewInterface,
+	subjectAccessReviews authorizationclientv1.SubjectAccessReviewInterface, audiences []string) IntrospectAuthenticator {
+	return &tokenReviewAuthenticator{
+		tokenReviews:         tokenReviews,
+		subjectAccessReviews: subjectAccessReviews,
+		audiences:            audiences,
+		clock:                time.Now,
+		authenticated:        map[[sha256.Size]byte]time.Time{},
+	}
+}
+
+func (t *tokenReviewAuthenticator) Authenticate(ctx context.Context, token string) (bool, error) {
+	key := sha256.Sum256([]byte(token))
+	now := t.clock()
+
+	t.lock.Lock()
+	until, found := t.authenticated[key]
+	t.lock.Unlock()
+	if found && now.Before(until) {
+		return true, nil
+	}
+
+	review, err := t.tokenReviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: t.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !review.Status.Authenticated {
+		return false, nil
+	}
+	if err := t.authorize(ctx, review.Status.User); err != nil {
+		return false, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for cachedKey, cachedUntil := range t.authenticated {
+		if !now.Before(cachedUntil) {
+			delete(t.authenticated, cachedKey)
+		}
+	}
+	t.authenticated[key] = now.Add(tokenReviewCacheTTL)
+	return true, nil
+}
+
+// authorize returns ErrIntrospectForbidden if the user isn't allowed to get the introspection non-resource URL
+func (t *tokenReviewAuthenticator) authorize(ctx context.Context, user authenticationv1.UserInfo) error {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review, err := t.subjectAccessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: IntrospectNonResourcePath,
+				Verb: "get",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return ErrIntrospectForbidden
+	}
+	return nil
+}
+
+// authenticate rejects the requests without a valid bearer token, all requests are served if there's no
+// authenticator
+func (i *IntrospectHandler) authenticate(next http.Handler) http.Handler {
+	if i.Authenticator == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("bearer token required"))
+			return
+		}
+		authenticated, err := i.Authenticator.Authenticate(r.Context(), token)
+		if errors.Is(err, ErrIntrospectForbidden) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		if err != nil {
+			i.Log.Error(err, "failed to authenticate introspection request", "path", r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("failed to authenticate the request"))
+			return
+		}
+		if !authenticated {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
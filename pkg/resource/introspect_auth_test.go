@@ -0,0 +1,121 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func serveAuthenticated(handler IntrospectHandler, authorization string) int {
+	req := httptest.NewRequest("GET", GetShardsPath, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rr := httptest.NewRecorder()
+	handler.authenticate(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestIntrospectHandler_Authenticate_StaticToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0600))
+	authenticator, err := NewStaticTokenAuthenticator(tokenFile)
+	assert.NoError(t, err)
+
+	handler := IntrospectHandler{Log: zap.New(), Authenticator: authenticator}
+	assert.Equal(t, http.StatusOK, serveAuthenticated(handler, "Bearer secret-token"))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(handler, "Bearer other-token"))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(handler, "Basic secret-token"))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(handler, ""))
+
+	// Without authenticator all the requests are served
+	assert.Equal(t, http.StatusOK, serveAuthenticated(IntrospectHandler{}, ""))
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	assert.NoError(t, os.WriteFile(emptyFile, []byte(" \n"), 0600))
+	_, err = NewStaticTokenAuthenticator(emptyFile)
+	assert.Error(t, err)
+}
+
+// TestTokenReviewAuthenticator tests the token is reviewed with the audiences, its user is authorized and the
+// authorized tokens are cached
+func TestTokenReviewAuthenticator(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	reviews := 0
+	clientSet.PrependReactor("create", "tokenreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			reviews++
+			review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			assert.Equal(t, []string{"introspect"}, review.Spec.Audiences)
+			review.Status.Authenticated = review.Spec.Token != "invalid-token"
+			review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token + "-user",
+				Groups: []string{"group"}}
+			return true, review, nil
+		})
+	accessReviews := 0
+	clientSet.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			accessReviews++
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			assert.Equal(t, &authorizationv1.NonResourceAttributes{Path: IntrospectNonResourcePath, Verb: "get"},
+				review.Spec.NonResourceAttributes)
+			assert.Equal(t, []string{"group"}, review.Spec.Groups)
+			review.Status.Allowed = review.Spec.User == "valid-token-user"
+			return true, review, nil
+		})
+
+	authenticator := NewTokenReviewAuthenticator(clientSet.AuthenticationV1().TokenReviews(),
+		clientSet.AuthorizationV1().SubjectAccessReviews(), []string{"introspect"})
+	for i := 0; i < 2; i++ {
+		authenticated, err := authenticator.Authenticate(context.Background(), "valid-token")
+		assert.NoError(t, err)
+		assert.True(t, authenticated)
+	}
+	assert.Equal(t, 1, reviews)
+	assert.Equal(t, 1, accessReviews)
+
+	authenticated, err := authenticator.Authenticate(context.Background(), "invalid-token")
+	assert.NoError(t, err)
+	assert.False(t, authenticated)
+	assert.Equal(t, 2, reviews)
+	assert.Equal(t, 1, accessReviews)
+
+	// The authenticated user not allowed to introspect is rejected every time
+	for i := 0; i < 2; i++ {
+		authenticated, err = authenticator.Authenticate(context.Background(), "forbidden-token")
+		assert.ErrorIs(t, err, ErrIntrospectForbidden)
+		assert.False(t, authenticated)
+	}
+	assert.Equal(t, 3, accessReviews)
+
+	handler := IntrospectHandler{Log: zap.New(), Authenticator: authenticator}
+	assert.Equal(t, http.StatusOK, serveAuthenticated(handler, "Bearer valid-token"))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(handler, "Bearer invalid-token"))
+	assert.Equal(t, http.StatusForbidden, serveAuthenticated(handler, "Bearer forbidden-token"))
+}
@@ -0,0 +1,277 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Query parameters of the list endpoints
+	ResourceQueryParam     = "resource"
+	NodeSelectorQueryParam = "nodeSelector"
+	StateQueryParam        = "state"
+	LimitQueryParam        = "limit"
+	ContinueQueryParam     = "continue"
+	OutputQueryParam       = "output"
+
+	// OutputJSONLines streams one IntrospectRecord per line
+	OutputJSONLines = "jsonl"
+	// ContinueHeader is the header with the continue token of the JSON Lines responses
+	ContinueHeader = "X-Introspect-Continue"
+
+	// StateUsed are the resources allocated to pods
+	StateUsed = config.IntrospectStateUsed
+	// StateWarm are the resources in the warm pool
+	StateWarm = config.IntrospectStateWarm
+	// StateCooling are the resources in cool down, for branch ENIs the ENIs in the delete queue
+	StateCooling = config.IntrospectStateCooling
+
+	// jsonLinesFlushInterval is the number of records written between two flushes of the JSON Lines response
+	jsonLinesFlushInterval = 100
+)
+
+// IntrospectRecord is the details of a resource on a node
+type IntrospectRecord struct {
+	Resource string
+	Node     string
+	Details  interface{}
+}
+
+// IntrospectList is a page of the records, Continue is set if there are more records
+type IntrospectList struct {
+	Items    []IntrospectRecord
+	Continue string `json:",omitempty"`
+}
+
+// listOptions are the filters and the pagination of a list request
+type listOptions struct {
+	resource     string
+	nodeSelector labels.Selector
+	state        string
+	limit        int
+	continueKey  string
+	jsonLines    bool
+}
+
+// isFiltered returns true if the records are filtered, so the provider responses are converted to records
+func (o listOptions) isFiltered() bool {
+	return o.nodeSelector != nil || o.state != ""
+}
+
+// isList returns true if the records are returned as a list instead of being grouped by resource and node
+func (o listOptions) isList() bool {
+	return o.limit > 0 || o.continueKey != "" || o.jsonLines
+}
+
+// parseListOptions returns the options of the list request, the state filter is only allowed if withState is set
+func parseListOptions(query url.Values, withState bool) (listOptions, error) {
+	options := listOptions{resource: query.Get(ResourceQueryParam)}
+
+	if selector := query.Get(NodeSelectorQueryParam); selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return options, fmt.Errorf("invalid node selector: %v", err)
+		}
+		options.nodeSelector = parsed
+	}
+
+	if options.state = query.Get(StateQueryParam); options.state != "" {
+		if !withState {
+			return options, fmt.Errorf("state filter is not supported")
+		}
+		if options.state != StateUsed && options.state != StateWarm && options.state != StateCooling {
+			return options, fmt.Errorf("state must be one of %s, %s or %s", StateUsed, StateWarm, StateCooling)
+		}
+	}
+
+	if limitParam := query.Get(LimitQueryParam); limitParam != "" {
+		var err error
+		if options.limit, err = strconv.Atoi(limitParam); err != nil || options.limit < 0 {
+			return options, fmt.Errorf("limit must be a non negative integer")
+		}
+	}
+
+	if token := query.Get(ContinueQueryParam); token != "" {
+		key, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return options, fmt.Errorf("invalid continue token")
+		}
+		options.continueKey = string(key)
+	}
+
+	switch output := query.Get(OutputQueryParam); output {
+	case "", "json":
+	case OutputJSONLines:
+		options.jsonLines = true
+	default:
+		return options, fmt.Errorf("output must be json or %s", OutputJSONLines)
+	}
+	return options, nil
+}
+
+// recordKey is the sort key of the record, the node names can't contain a new line
+func recordKey(record IntrospectRecord) string {
+	return record.Resource + "\n" + record.Node
+}
+
+// introspectRecords converts the response of a provider to records, returns false if the response type is unknown
+func introspectRecords(resourceName string, data interface{}) ([]IntrospectRecord, bool) {
+	nodes, ok := data.(provider.IntrospectRecords)
+	if !ok {
+		return nil, false
+	}
+	details := nodes.NodeDetails()
+	records := make([]IntrospectRecord, 0, len(details))
+	for nodeName, nodeDetails := range details {
+		records = append(records, IntrospectRecord{Resource: resourceName, Node: nodeName, Details: nodeDetails})
+	}
+	return records, true
+}
+
+// filterState returns the details of the resources in the state, returns false if there are none
+func filterState(details interface{}, state string) (interface{}, bool) {
+	filter, ok := details.(provider.IntrospectStateFilter)
+	if !ok {
+		return nil, false
+	}
+	return filter.FilterState(state)
+}
+
+// selectedNodes returns the names of the nodes matching the selector
+func (i *IntrospectHandler) selectedNodes(ctx context.Context, selector labels.Selector) (map[string]struct{}, error) {
+	if i.NodeReader == nil {
+		return nil, fmt.Errorf("node selector is not supported")
+	}
+	nodeList := &corev1.NodeList{}
+	if err := i.NodeReader.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]struct{}, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		nodes[node.Name] = struct{}{}
+	}
+	return nodes, nil
+}
+
+// writeRecords writes the records filtered by the options, either grouped by resource and node, as a page of the
+// list or as JSON Lines
+func (i *IntrospectHandler) writeRecords(w http.ResponseWriter, r *http.Request, options listOptions,
+	responses map[string]interface{}) {
+	var nodes map[string]struct{}
+	if options.nodeSelector != nil {
+		var err error
+		if nodes, err = i.selectedNodes(r.Context(), options.nodeSelector); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	var records []IntrospectRecord
+	for resourceName, data := range responses {
+		resourceRecords, ok := introspectRecords(resourceName, data)
+		if !ok {
+			i.Log.Info("skipping resource with unknown introspection response", "resource", resourceName)
+			continue
+		}
+		for _, record := range resourceRecords {
+			if nodes != nil {
+				if _, found := nodes[record.Node]; !found {
+					continue
+				}
+			}
+			if options.state != "" {
+				var inState bool
+				if record.Details, inState = filterState(record.Details, options.state); !inState {
+					continue
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	if !options.isList() {
+		grouped := make(map[string]map[string]interface{})
+		for _, record := range records {
+			if grouped[record.Resource] == nil {
+				grouped[record.Resource] = make(map[string]interface{})
+			}
+			grouped[record.Resource][record.Node] = record.Details
+		}
+		writeJSON(w, grouped)
+		return
+	}
+
+	sort.Slice(records, func(a, b int) bool { return recordKey(records[a]) < recordKey(records[b]) })
+	if options.continueKey != "" {
+		start := sort.Search(len(records), func(n int) bool { return recordKey(records[n]) > options.continueKey })
+		records = records[start:]
+	}
+	list := IntrospectList{Items: records}
+	if options.limit > 0 && len(records) > options.limit {
+		list.Items = records[:options.limit]
+		list.Continue = base64.RawURLEncoding.EncodeToString([]byte(recordKey(list.Items[options.limit-1])))
+	}
+
+	if !options.jsonLines {
+		writeJSON(w, list)
+		return
+	}
+
+	w.Header().Set("content-type", "application/x-ndjson")
+	if list.Continue != "" {
+		w.Header().Set(ContinueHeader, list.Continue)
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for n, record := range list.Items {
+		if err := encoder.Encode(record); err != nil {
+			i.Log.Error(err, "failed to write introspection record", "resource", record.Resource, "node", record.Node)
+			return
+		}
+		if flusher != nil && (n+1)%jsonLinesFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+// writeJSON writes the indented JSON of the response
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	jsonData, err := json.MarshalIndent(response, "", "\t")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
@@ -0,0 +1,173 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/pool"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch/trunk"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeA = "node-a"
+	nodeB = "node-b"
+	nodeC = "node-c"
+
+	poolResponse = provider.IntrospectNodes[pool.IntrospectResponse]{
+		nodeA: {UsedResources: map[string]pool.Resource{"pod-uid": {ResourceID: "192.168.1.1"}}},
+		nodeB: {WarmResources: map[string][]pool.Resource{"192.168.1.2": {{ResourceID: "192.168.1.2"}}}},
+		nodeC: {UsedResources: map[string]pool.Resource{"pod-uid-2": {ResourceID: "192.168.1.3"}}},
+	}
+	trunkResponse = provider.IntrospectNodes[trunk.IntrospectResponse]{
+		nodeA: {TrunkENIID: "eni-1", PodToBranchENI: map[string][]trunk.ENIDetails{"pod-uid": {{ID: "eni-2"}}}},
+	}
+)
+
+// getListHandler returns the introspect handler with the IPv4 pool and branch ENI responses of the nodes, the node
+// selector role=web matches the first two nodes
+func getListHandler(ctrl *gomock.Controller) (IntrospectHandler, MockIntrospect) {
+	mock := NewMockIntrospectHandler(ctrl)
+	mockIPProvider := mock.mockProvider
+	mockBranchProvider := NewMockIntrospectHandler(ctrl).mockProvider
+
+	mock.mockManager.EXPECT().GetResourceProviders().Return(map[string]provider.ResourceProvider{
+		config.ResourceNameIPAddress: mockIPProvider,
+		config.ResourceNamePodENI:    mockBranchProvider,
+	})
+	mockIPProvider.EXPECT().Introspect().Return(poolResponse).AnyTimes()
+	mockBranchProvider.EXPECT().Introspect().Return(trunkResponse).AnyTimes()
+
+	var nodes []*corev1.Node
+	for _, name := range []string{nodeA, nodeB, nodeC} {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"role": "web"}}}
+		nodes = append(nodes, node)
+	}
+	nodes[2].Labels["role"] = "db"
+
+	handler := mock.handler
+	handler.Log = zap.New()
+	handler.NodeReader = fakeClient.NewClientBuilder().WithObjects(nodes[0], nodes[1], nodes[2]).Build()
+	return handler, mock
+}
+
+func list(t *testing.T, handler IntrospectHandler, query string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", GetAllResourcesPath+query, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ResourceHandler(rr, req)
+	return rr
+}
+
+// TestIntrospectHandler_ResourceHandler_Filter tests the nodes are filtered by resource, node selector and state
+func TestIntrospectHandler_ResourceHandler_Filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _ := getListHandler(ctrl)
+	rr := list(t, handler, "?nodeSelector=role%3Dweb&state=used")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	got := map[string]map[string]json.RawMessage{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got, 2)
+	assert.Contains(t, got[config.ResourceNameIPAddress], nodeA)
+	assert.Len(t, got[config.ResourceNameIPAddress], 1)
+	assert.Contains(t, got[config.ResourceNamePodENI], nodeA)
+
+	// The resource filter is applied before the providers are introspected
+	ctrl2 := gomock.NewController(t)
+	defer ctrl2.Finish()
+	handler, _ = getListHandler(ctrl2)
+	rr = list(t, handler, "?resource="+config.ResourceNamePodENI)
+	got = map[string]map[string]json.RawMessage{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got, 1)
+	assert.Contains(t, got, config.ResourceNamePodENI)
+}
+
+// TestIntrospectHandler_ResourceHandler_Paginate tests the records are returned in pages following the continue
+// token, and streamed as JSON Lines
+func TestIntrospectHandler_ResourceHandler_Paginate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var pages [][]string
+	continueToken := ""
+	for {
+		handler, _ := getListHandler(ctrl)
+		rr := list(t, handler, "?limit=3&continue="+continueToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var page IntrospectList
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		var keys []string
+		for _, record := range page.Items {
+			keys = append(keys, record.Resource+" "+record.Node)
+		}
+		pages = append(pages, keys)
+		if continueToken = page.Continue; continueToken == "" {
+			break
+		}
+	}
+	assert.Equal(t, [][]string{
+		{config.ResourceNameIPAddress + " " + nodeA, config.ResourceNameIPAddress + " " + nodeB,
+			config.ResourceNameIPAddress + " " + nodeC},
+		{config.ResourceNamePodENI + " " + nodeA},
+	}, pages)
+
+	handler, _ := getListHandler(ctrl)
+	rr := list(t, handler, "?output=jsonl&limit=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("content-type"))
+	assert.NotEmpty(t, rr.Header().Get(ContinueHeader))
+
+	var lines int
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var record IntrospectRecord
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, config.ResourceNameIPAddress, record.Resource)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestIntrospectHandler_ListOptions_Invalid(t *testing.T) {
+	for _, query := range []string{"?state=free", "?limit=-1", "?continue=%25", "?output=yaml",
+		"?nodeSelector=a%3D%3D%3Db"} {
+		handler := IntrospectHandler{}
+		rr := list(t, handler, query)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+
+	req, err := http.NewRequest("GET", GetResourcesSummaryPath+"?state=used", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	(&IntrospectHandler{}).ResourceSummaryHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
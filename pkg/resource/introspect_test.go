@@ -17,12 +17,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
 	mock_resource "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/resource"
+	mock_shard "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/shard"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -115,3 +119,97 @@ func VerifyResponse(t *testing.T, rr *httptest.ResponseRecorder, response map[st
 	assert.Equal(t, rr.Code, http.StatusOK)
 	assert.Equal(t, response, *got)
 }
+
+func TestIntrospectHandler_EC2AuditLogHandler(t *testing.T) {
+	auditLog, err := ec2API.NewAuditLog(filepath.Join(t.TempDir(), "audit.log"), 1, 1)
+	assert.NoError(t, err)
+	auditLog.Record(ec2API.AuditEntry{Operation: "delete_network_interface"})
+	auditLog.Record(ec2API.AuditEntry{Operation: "create_network_interface"})
+
+	handler := IntrospectHandler{EC2AuditLog: auditLog}
+
+	req, err := http.NewRequest("GET", GetEC2AuditLogPath+"?limit=1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.EC2AuditLogHandler(rr, req)
+
+	var got []ec2API.AuditEntry
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "create_network_interface", got[0].Operation)
+}
+
+func TestIntrospectHandler_EC2AuditLogHandler_Disabled(t *testing.T) {
+	handler := IntrospectHandler{}
+
+	req, err := http.NewRequest("GET", GetEC2AuditLogPath, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.EC2AuditLogHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestIntrospectHandler_ENICleanupReportHandler_NoReport(t *testing.T) {
+	handler := IntrospectHandler{ENICleaner: &ec2API.ENICleaner{}}
+
+	req, err := http.NewRequest("GET", GetENICleanupReportPath, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.ENICleanupReportHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestIntrospectHandler_ShardsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSharder := mock_shard.NewMockSharder(ctrl)
+	handler := IntrospectHandler{Sharder: mockSharder}
+	ownership := shard.IntrospectResponse{
+		Identity:     "replica-a",
+		ShardCount:   2,
+		Members:      []string{"replica-a", "replica-b"},
+		OwnedShards:  []int{0},
+		ShardHolders: map[int]string{0: "replica-a", 1: "replica-b"},
+	}
+
+	mockSharder.EXPECT().Introspect().Return(ownership)
+
+	req, err := http.NewRequest("GET", GetShardsPath, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.ShardsHandler(rr, req)
+
+	var got shard.IntrospectResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ownership.ShardHolders, got.ShardHolders)
+	assert.Equal(t, ownership.OwnedShards, got.OwnedShards)
+}
+
+func TestIntrospectHandler_ShardsHandler_Disabled(t *testing.T) {
+	handler := IntrospectHandler{}
+
+	req, err := http.NewRequest("GET", GetShardsPath, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.ShardsHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestIntrospectHandler_StandbyHandler_Disabled(t *testing.T) {
+	handler := IntrospectHandler{}
+
+	req, err := http.NewRequest("GET", GetStandbyPath, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	handler.StandbyHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -23,9 +23,6 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/handler"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/branch"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/ip"
-	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider/prefix"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
 	"github.com/go-logr/logr"
 
@@ -38,6 +35,7 @@ var (
 	branchProviderHealthCheckSubpath     = "health-branch-provider"
 	ipv4ProviderHealthCheckSubpath       = "health-ipv4-provider"
 	ipv4PrefixProviderHealthCheckSubpath = "health-ipv4-prefix-provider"
+	elasticIPProviderHealthCheckSubpath  = "health-elastic-ip-provider"
 )
 
 type Manager struct {
@@ -56,11 +54,9 @@ type ResourceManager interface {
 	GetResourceHandler(resourceName string) (handler.Handler, bool)
 }
 
+// NewResourceManager initializes the provider, the handler and the worker pool of the given registered resources
 func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api.Wrapper, log logr.Logger,
 	healthzHandler *rcHealthz.HealthzHandler, conditions condition.Conditions) (ResourceManager, error) {
-	// Load that static configuration of the resource
-	resourceConfig := config.LoadResourceConfig()
-
 	resources := make(map[string]Resource)
 
 	healthCheckers := make(map[string]healthz.Checker)
@@ -71,9 +67,16 @@ func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api
 	// For each supported resource, initialize the resource provider and handler
 	for _, resourceName := range resourceNames {
 
-		resourceConfig, ok := resourceConfig[resourceName]
+		registration, ok := GetRegistration(resourceName)
 		if !ok {
-			return nil, fmt.Errorf("failed to find resource configuration %s", resourceName)
+			return nil, fmt.Errorf("resource type is not registered %s", resourceName)
+		}
+
+		// The providers may update the warm pool configuration of the resource
+		resourceConfig := registration.Config
+		if resourceConfig.WarmPoolConfig != nil {
+			warmPoolConfig := *resourceConfig.WarmPoolConfig
+			resourceConfig.WarmPoolConfig = &warmPoolConfig
 		}
 
 		log.Info("initializing resource", "resource name",
@@ -85,29 +88,17 @@ func NewResourceManager(ctx context.Context, resourceNames []string, wrapper api
 			config.WorkQueueDefaultMaxRetries,
 			log.WithName(fmt.Sprintf("%s-%s", resourceName, "worker")), ctx)
 
-		var resourceHandler handler.Handler
-		var resourceProvider provider.ResourceProvider
+		resourceProvider := registration.NewProvider(ctx, ctrl.Log, wrapper, workers, resourceConfig, conditions)
+		healthCheckers[registration.HealthCheckSubpath] = resourceProvider.GetHealthChecker()
 
-		if resourceName == config.ResourceNameIPAddress {
-			resourceProvider = ip.NewIPv4Provider(ctrl.Log.WithName("ipv4 provider"),
-				wrapper, workers, resourceConfig, conditions)
-			healthCheckers[ipv4ProviderHealthCheckSubpath] = resourceProvider.GetHealthChecker()
-			resourceHandler = handler.NewWarmResourceHandler(ctrl.Log.WithName(resourceName), wrapper,
-				resourceName, resourceProvider, ctx)
-		} else if resourceName == config.ResourceNameIPAddressFromPrefix {
-			resourceProvider = prefix.NewIPv4PrefixProvider(ctrl.Log.WithName("ipv4 prefix provider"),
-				wrapper, workers, resourceConfig, conditions)
-			healthCheckers[ipv4PrefixProviderHealthCheckSubpath] = resourceProvider.GetHealthChecker()
+		var resourceHandler handler.Handler
+		switch registration.HandlerType {
+		case HandlerTypeWarm:
 			resourceHandler = handler.NewWarmResourceHandler(ctrl.Log.WithName(resourceName), wrapper,
-				config.ResourceNameIPAddress, resourceProvider, ctx)
-		} else if resourceName == config.ResourceNamePodENI {
-			resourceProvider = branch.NewBranchENIProvider(ctrl.Log.WithName("branch eni provider"),
-				wrapper, workers, resourceConfig, ctx)
-			healthCheckers[branchProviderHealthCheckSubpath] = resourceProvider.GetHealthChecker()
+				registration.HandlerResourceName, resourceProvider, ctx)
+		default:
 			resourceHandler = handler.NewOnDemandHandler(ctrl.Log.WithName(resourceName),
-				resourceName, resourceProvider)
-		} else {
-			return nil, fmt.Errorf("resource type is not defnied %s", resourceName)
+				registration.HandlerResourceName, resourceProvider)
 		}
 
 		err := workers.StartWorkerPool(resourceProvider.ProcessAsyncJob)
@@ -0,0 +1,158 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// HandlerType is the type of the handler delegating the pod events of a resource to its provider
+type HandlerType string
+
+const (
+	// HandlerTypeWarm serves the resources from the warm pool of the node, the pods are annotated with the
+	// resources by the handler
+	HandlerTypeWarm HandlerType = "warm"
+	// HandlerTypeOnDemand submits the create and delete jobs to the provider, which creates the resources when
+	// the pod is scheduled and annotates the pod
+	HandlerTypeOnDemand HandlerType = "on-demand"
+)
+
+// ProviderFactory returns the provider of a resource, the provider processes the jobs of the resource's worker pool
+type ProviderFactory func(ctx context.Context, log logr.Logger, wrapper api.Wrapper, workers worker.Worker,
+	resourceConfig config.ResourceConfig, conditions condition.Conditions) provider.ResourceProvider
+
+// InjectionRule returns the quantity of the resource the pod mutating webhook injects into a Linux pod, the
+// resource is not injected if the quantity is zero
+type InjectionRule func(pod *corev1.Pod) int64
+
+// Registration describes a resource type managed by the controller. The provider is initialized on the nodes it
+// supports and advertises the capacity of the resource on these nodes with UpdateResourceCapacity.
+type Registration struct {
+	// Name is the extended resource requested by the pods
+	Name string
+	// HandlerType is the type of the handler delegating the pod events to the provider
+	HandlerType HandlerType
+	// HandlerResourceName is the resource the handler annotates the pods with, defaults to Name
+	HandlerResourceName string
+	// Config is the configuration of the resource, the worker pool of the resource runs Config.WorkerCount workers
+	Config config.ResourceConfig
+	// NewProvider returns the provider of the resource
+	NewProvider ProviderFactory
+	// HealthCheckSubpath is the health check subpath of the provider, defaults to health-<Name>-provider with the
+	// dots and slashes of the name replaced by dashes
+	HealthCheckSubpath string
+	// Injection is the rule of the pod mutating webhook injecting the resource into the Linux pods, the resource is
+	// only requested explicitly by the pods if not set
+	Injection InjectionRule
+}
+
+var (
+	registryLock sync.RWMutex
+	// registry is the map of the resource name to the registration of the resource
+	registry = make(map[string]Registration)
+)
+
+// Register adds the resource type to the registry, so the resource can be enabled by name. The resource types
+// built out-of-tree are registered from the init function of their package.
+func Register(registration Registration) error {
+	if registration.Name == "" {
+		return fmt.Errorf("resource name must be set")
+	}
+	if registration.NewProvider == nil {
+		return fmt.Errorf("provider factory of resource %s must be set", registration.Name)
+	}
+	switch registration.HandlerType {
+	case HandlerTypeWarm, HandlerTypeOnDemand:
+	default:
+		return fmt.Errorf("invalid handler type %q of resource %s, must be one of %s or %s",
+			registration.HandlerType, registration.Name, HandlerTypeWarm, HandlerTypeOnDemand)
+	}
+	if registration.Config.WorkerCount <= 0 {
+		return fmt.Errorf("worker count of resource %s must be positive", registration.Name)
+	}
+	if registration.HandlerResourceName == "" {
+		registration.HandlerResourceName = registration.Name
+	}
+	if registration.Config.Name == "" {
+		registration.Config.Name = registration.Name
+	}
+	if registration.HealthCheckSubpath == "" {
+		registration.HealthCheckSubpath = fmt.Sprintf("health-%s-provider",
+			strings.NewReplacer(".", "-", "/", "-").Replace(registration.Name))
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, found := registry[registration.Name]; found {
+		return fmt.Errorf("resource %s is already registered", registration.Name)
+	}
+	registry[registration.Name] = registration
+	return nil
+}
+
+// MustRegister adds the resource type to the registry and panics if the registration is invalid
+func MustRegister(registration Registration) {
+	if err := Register(registration); err != nil {
+		panic(err)
+	}
+}
+
+// GetRegistration returns the registration of the resource
+func GetRegistration(resourceName string) (Registration, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	registration, found := registry[resourceName]
+	return registration, found
+}
+
+// RegisteredResources returns the sorted names of the registered resources
+func RegisteredResources() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetInjectionRules returns the webhook injection rules of the given resources, the resources without rule are
+// skipped
+func GetInjectionRules(resourceNames []string) map[string]InjectionRule {
+	rules := make(map[string]InjectionRule)
+	for _, resourceName := range resourceNames {
+		if registration, found := GetRegistration(resourceName); found && registration.Injection != nil {
+			rules[resourceName] = registration.Injection
+		}
+	}
+	return rules
+}
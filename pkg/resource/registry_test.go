@@ -0,0 +1,181 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"testing"
+
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	mock_provider "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/provider"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/worker"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var testResourceName = "example.com/test-resource"
+
+func getTestRegistration(newProvider ProviderFactory) Registration {
+	return Registration{
+		Name:        testResourceName,
+		HandlerType: HandlerTypeOnDemand,
+		Config:      config.ResourceConfig{WorkerCount: 1},
+		NewProvider: newProvider,
+		Injection:   func(_ *corev1.Pod) int64 { return 1 },
+	}
+}
+
+// unregisterTestResource removes the test resource from the registry
+func unregisterTestResource() {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	delete(registry, testResourceName)
+}
+
+// TestRegister_Builtin tests the resources built in the controller are registered
+func TestRegister_Builtin(t *testing.T) {
+	assert.Subset(t, RegisteredResources(), []string{config.ResourceNamePodENI, config.ResourceNameIPAddress,
+		config.ResourceNameIPAddressFromPrefix, config.ResourceNameElasticIP, config.ResourceNameDedicatedPodENI})
+
+	registration, found := GetRegistration(config.ResourceNameIPAddressFromPrefix)
+	assert.True(t, found)
+	assert.Equal(t, config.ResourceNameIPAddress, registration.HandlerResourceName)
+	assert.Equal(t, ipv4PrefixProviderHealthCheckSubpath, registration.HealthCheckSubpath)
+
+	registration, found = GetRegistration(config.ResourceNameDedicatedPodENI)
+	assert.True(t, found)
+	assert.Equal(t, config.ResourceNamePodENI, registration.HandlerResourceName)
+	assert.Nil(t, registration.Injection)
+}
+
+// TestRegisterNetworkCard tests the resource of any network card index is registered once, including the indexes
+// missing from the static limits, and the other resources are rejected
+func TestRegisterNetworkCard(t *testing.T) {
+	resourceName := config.NetworkCardResourceName(63)
+	defer func() {
+		registryLock.Lock()
+		defer registryLock.Unlock()
+		delete(registry, resourceName)
+	}()
+
+	_, found := GetRegistration(resourceName)
+	assert.False(t, found)
+
+	assert.NoError(t, RegisterNetworkCard(resourceName))
+	assert.NoError(t, RegisterNetworkCard(resourceName))
+	registration, found := GetRegistration(resourceName)
+	assert.True(t, found)
+	assert.Equal(t, HandlerTypeOnDemand, registration.HandlerType)
+	assert.Equal(t, config.NetworkCardDefaultWorker, registration.Config.WorkerCount)
+	assert.Equal(t, "health-vpc-amazonaws-com-network-card-63-provider", registration.HealthCheckSubpath)
+
+	assert.Error(t, RegisterNetworkCard(config.ResourceNamePodENI))
+}
+
+// TestRegister_Defaults tests the defaults of the registration are set and the resource can't be registered twice
+func TestRegister_Defaults(t *testing.T) {
+	defer unregisterTestResource()
+
+	newProvider := func(context.Context, logr.Logger, api.Wrapper, worker.Worker, config.ResourceConfig,
+		condition.Conditions) provider.ResourceProvider {
+		return nil
+	}
+	assert.NoError(t, Register(getTestRegistration(newProvider)))
+
+	registration, found := GetRegistration(testResourceName)
+	assert.True(t, found)
+	assert.Equal(t, testResourceName, registration.HandlerResourceName)
+	assert.Equal(t, testResourceName, registration.Config.Name)
+	assert.Equal(t, "health-example-com-test-resource-provider", registration.HealthCheckSubpath)
+
+	assert.Error(t, Register(getTestRegistration(newProvider)))
+}
+
+// TestRegister_Invalid tests the invalid registrations are rejected
+func TestRegister_Invalid(t *testing.T) {
+	newProvider := func(context.Context, logr.Logger, api.Wrapper, worker.Worker, config.ResourceConfig,
+		condition.Conditions) provider.ResourceProvider {
+		return nil
+	}
+
+	noName := getTestRegistration(newProvider)
+	noName.Name = ""
+	noProvider := getTestRegistration(nil)
+	invalidHandler := getTestRegistration(newProvider)
+	invalidHandler.HandlerType = "invalid"
+	noWorkers := getTestRegistration(newProvider)
+	noWorkers.Config.WorkerCount = 0
+
+	for _, registration := range []Registration{noName, noProvider, invalidHandler, noWorkers} {
+		assert.Error(t, Register(registration))
+	}
+	_, found := GetRegistration(testResourceName)
+	assert.False(t, found)
+}
+
+// TestGetInjectionRules tests only the rules of the given registered resources are returned
+func TestGetInjectionRules(t *testing.T) {
+	defer unregisterTestResource()
+
+	MustRegister(getTestRegistration(func(context.Context, logr.Logger, api.Wrapper, worker.Worker,
+		config.ResourceConfig, condition.Conditions) provider.ResourceProvider {
+		return nil
+	}))
+
+	rules := GetInjectionRules([]string{testResourceName, config.ResourceNamePodENI, "unregistered"})
+	assert.Len(t, rules, 1)
+	assert.Equal(t, int64(1), rules[testResourceName](&corev1.Pod{}))
+}
+
+// Test_NewResourceManager_Registered tests the resource manager initializes the provider and the handler of a
+// registered resource, and fails on an unregistered resource
+func Test_NewResourceManager_Registered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer unregisterTestResource()
+
+	mockProvider := mock_provider.NewMockResourceProvider(ctrl)
+	mockProvider.EXPECT().GetHealthChecker().Return(rcHealthz.SimplePing("test provider", zap.New()))
+
+	MustRegister(getTestRegistration(func(_ context.Context, _ logr.Logger, _ api.Wrapper, _ worker.Worker,
+		resourceConfig config.ResourceConfig, _ condition.Conditions) provider.ResourceProvider {
+		assert.Equal(t, testResourceName, resourceConfig.Name)
+		return mockProvider
+	}))
+
+	conditions := condition.NewControllerConditions(zap.New(), mock_k8s.NewMockK8sWrapper(ctrl), false)
+	manager, err := NewResourceManager(context.TODO(), []string{testResourceName}, api.Wrapper{}, zap.New(),
+		healthzHandler, conditions)
+	assert.NoError(t, err)
+
+	_, ok := manager.GetResourceHandler(testResourceName)
+	assert.True(t, ok)
+	resourceProvider, ok := manager.GetResourceProvider(testResourceName)
+	assert.True(t, ok)
+	assert.Equal(t, mockProvider, resourceProvider)
+
+	_, err = NewResourceManager(context.TODO(), []string{"unregistered"}, api.Wrapper{}, zap.New(),
+		healthzHandler, conditions)
+	assert.Error(t, err)
+}
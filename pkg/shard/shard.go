@@ -0,0 +1,605 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shard
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// LeaseTypeLabel is the label set on the Leases used for sharding, with the value LeaseTypeShard or
+	// LeaseTypeMember
+	LeaseTypeLabel = "vpcresources.k8s.aws/lease-type"
+	// LeaseTypeShard is the Lease held by the replica owning a shard
+	LeaseTypeShard = "shard"
+	// LeaseTypeMember is the Lease renewed by each replica to announce itself to the other replicas
+	LeaseTypeMember = "member"
+
+	shardLeasePrefix  = config.LeaderElectionKey + "-shard-"
+	memberLeasePrefix = config.LeaderElectionKey + "-member-"
+
+	// nodeEventsBufferSize is the number of node events that can be queued before notifying the node controller
+	// blocks
+	nodeEventsBufferSize = 1024
+)
+
+var (
+	prometheusRegistered = false
+
+	ownedShardCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "owned_shard_count",
+			Help: "The number of node shards owned by the replica",
+		},
+	)
+
+	shardMemberCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "shard_member_count",
+			Help: "The number of live replicas the node shards are spread across",
+		},
+	)
+
+	shardTransitionCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shard_transition_count",
+			Help: "The number of node shards acquired, released or lost by the replica",
+		},
+		[]string{"transition"},
+	)
+)
+
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(
+			ownedShardCount,
+			shardMemberCount,
+			shardTransitionCount,
+		)
+		prometheusRegistered = true
+	}
+}
+
+// Sharder tells whether a node is owned by the replica when the nodes are sharded across multiple active replicas
+type Sharder interface {
+	// OwnsNode returns true if the shard of the node is owned by the replica
+	OwnsNode(nodeName string) bool
+	// OwnsShard returns true if the shard is owned by the replica
+	OwnsShard(shard int) bool
+	// NodeEvents returns the channel receiving the nodes of the shards acquired or released by the replica
+	NodeEvents() <-chan event.GenericEvent
+	// Introspect returns the ownership of the shards as seen by the replica
+	Introspect() IntrospectResponse
+}
+
+// Config is the configuration of the Lease based sharder
+type Config struct {
+	// Identity is the unique name of the replica, it's the holder identity of the Leases
+	Identity string
+	// Namespace is the namespace of the Leases
+	Namespace string
+	// ShardCount is the number of shards the nodes are spread across, it must be the same on all the replicas
+	ShardCount int
+	// LeaseDuration is the duration the other replicas wait before taking over a Lease that isn't renewed
+	LeaseDuration time.Duration
+	// RenewDeadline is the duration the replica keeps owning a shard without renewing its Lease
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval between two syncs of the Leases
+	RetryPeriod time.Duration
+}
+
+// IntrospectResponse is the ownership of the shards as seen by the replica
+type IntrospectResponse struct {
+	Identity   string
+	ShardCount int
+	// Members are the live replicas the shards are spread across
+	Members []string
+	// OwnedShards are the shards owned by the replica
+	OwnedShards []int
+	// ShardHolders is the replica holding each shard as of the last sync, the shards without holder are missing
+	ShardHolders map[int]string
+	LastSyncTime time.Time
+}
+
+// LeaseSharder shards the nodes across the active replicas, each replica claims a fair share of the shards by
+// holding a Lease per shard and rebalances the shards when replicas join or leave
+type LeaseSharder struct {
+	log        logr.Logger
+	config     Config
+	leases     coordinationclientv1.LeaseInterface
+	nodeReader client.Reader
+	events     chan event.GenericEvent
+	clock      func() time.Time
+
+	// lock guards the following
+	lock sync.RWMutex
+	// owned is the time until which each owned shard can be used without renewing its Lease
+	owned        map[int]time.Time
+	holders      map[int]string
+	members      []string
+	lastSyncTime time.Time
+}
+
+// NewLeaseSharder returns a new Lease based sharder, the leases client must be scoped to the namespace of the config
+func NewLeaseSharder(log logr.Logger, cfg Config, leases coordinationclientv1.LeaseInterface,
+	nodeReader client.Reader) (*LeaseSharder, error) {
+	if cfg.ShardCount < 1 {
+		return nil, fmt.Errorf("shard count must be positive, got %d", cfg.ShardCount)
+	}
+	if cfg.Identity == "" {
+		return nil, fmt.Errorf("identity must not be empty")
+	}
+	if cfg.RenewDeadline >= cfg.LeaseDuration {
+		return nil, fmt.Errorf("renew deadline %s must be shorter than the lease duration %s",
+			cfg.RenewDeadline, cfg.LeaseDuration)
+	}
+
+	prometheusRegister()
+
+	return &LeaseSharder{
+		log:        log,
+		config:     cfg,
+		leases:     leases,
+		nodeReader: nodeReader,
+		events:     make(chan event.GenericEvent, nodeEventsBufferSize),
+		clock:      time.Now,
+		owned:      map[int]time.Time{},
+		holders:    map[int]string{},
+	}, nil
+}
+
+// ShardForNode returns the shard of the node using a jump consistent hash of the node name, so only a fair share of
+// the nodes move when the shard count changes
+func ShardForNode(nodeName string, shardCount int) int {
+	hash := fnv.New64a()
+	hash.Write([]byte(nodeName))
+	key := hash.Sum64()
+
+	var b, j int64 = -1, 0
+	for j < int64(shardCount) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// SetupWithManager adds the sharder to the manager, the sharder runs on all the replicas
+func (s *LeaseSharder) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(s)
+}
+
+// NeedLeaderElection returns false as every replica owns a share of the nodes
+func (s *LeaseSharder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start syncs the Leases every retry period till the context is cancelled, and then releases the owned shards so the
+// other replicas can take them over without waiting for the Leases to expire
+func (s *LeaseSharder) Start(ctx context.Context) error {
+	s.log.Info("starting node sharding", "identity", s.config.Identity, "shard count", s.config.ShardCount)
+
+	ticker := time.NewTicker(s.config.RetryPeriod)
+	defer ticker.Stop()
+	for {
+		s.sync(ctx)
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), s.config.RenewDeadline)
+			defer cancel()
+			s.releaseAll(releaseCtx)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// OwnsNode returns true if the shard of the node is owned by the replica
+func (s *LeaseSharder) OwnsNode(nodeName string) bool {
+	return s.OwnsShard(ShardForNode(nodeName, s.config.ShardCount))
+}
+
+// OwnsShard returns true if the shard is owned by the replica and its Lease was renewed within the renew deadline
+func (s *LeaseSharder) OwnsShard(shard int) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	until, owned := s.owned[shard]
+	return owned && s.clock().Before(until)
+}
+
+// NodeEvents returns the channel receiving the nodes of the shards acquired or released by the replica
+func (s *LeaseSharder) NodeEvents() <-chan event.GenericEvent {
+	return s.events
+}
+
+// Introspect returns the ownership of the shards as seen by the replica
+func (s *LeaseSharder) Introspect() IntrospectResponse {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	response := IntrospectResponse{
+		Identity:     s.config.Identity,
+		ShardCount:   s.config.ShardCount,
+		Members:      append([]string{}, s.members...),
+		ShardHolders: make(map[int]string, len(s.holders)),
+		LastSyncTime: s.lastSyncTime,
+	}
+	for shard := range s.owned {
+		response.OwnedShards = append(response.OwnedShards, shard)
+	}
+	sort.Ints(response.OwnedShards)
+	for shard, holder := range s.holders {
+		response.ShardHolders[shard] = holder
+	}
+	return response
+}
+
+// sync renews the member Lease of the replica, releases the shards above the fair share of the replica, renews the
+// Leases of the remaining owned shards and acquires the shards without a live holder up to the fair share. The nodes
+// of the shards acquired or released are sent to the node events channel.
+func (s *LeaseSharder) sync(ctx context.Context) {
+	now := s.clock()
+	if err := s.renewMemberLease(ctx, now); err != nil {
+		s.log.Error(err, "failed to renew member lease")
+	}
+
+	leaseList, err := s.leases.List(ctx, metav1.ListOptions{LabelSelector: LeaseTypeLabel})
+	if err != nil {
+		s.log.Error(err, "failed to list leases")
+		go s.notify(ctx, s.dropExpiredShards(now))
+		return
+	}
+
+	shardLeases := map[int]*coordinationv1.Lease{}
+	members := []string{s.config.Identity}
+	for i := range leaseList.Items {
+		lease := &leaseList.Items[i]
+		switch lease.Labels[LeaseTypeLabel] {
+		case LeaseTypeMember:
+			holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+			if holder == s.config.Identity {
+				continue
+			}
+			if isExpired(lease, now) {
+				s.deleteExpiredMemberLease(ctx, lease)
+				continue
+			}
+			members = append(members, holder)
+		case LeaseTypeShard:
+			if shard, ok := s.shardOfLease(lease.Name); ok {
+				shardLeases[shard] = lease
+			}
+		}
+	}
+	sort.Strings(members)
+	// Every replica claims at most its fair share so the shards are spread evenly, the total is at least the shard
+	// count so all the shards are claimed
+	fairShare := (s.config.ShardCount + len(members) - 1) / len(members)
+
+	var held []int
+	for shard := 0; shard < s.config.ShardCount; shard++ {
+		if lease, found := shardLeases[shard]; found && !isExpired(lease, now) &&
+			ptr.Deref(lease.Spec.HolderIdentity, "") == s.config.Identity {
+			held = append(held, shard)
+		}
+	}
+
+	newOwned := map[int]time.Time{}
+	var released []int
+	for i, shard := range held {
+		lease := shardLeases[shard]
+		if i >= fairShare {
+			// The shard is dropped before its Lease is released so it's never owned by two replicas
+			s.dropShard(shard)
+			released = append(released, shard)
+			if err := s.releaseLease(ctx, lease); err != nil {
+				s.log.Error(err, "failed to release shard lease, it will expire", "shard", shard)
+			}
+			continue
+		}
+		if err := s.renewLease(ctx, lease, now); err != nil {
+			s.log.Error(err, "failed to renew shard lease", "shard", shard)
+			if until, owned := s.ownedUntil(shard); owned && now.Before(until) {
+				newOwned[shard] = until
+			}
+			continue
+		}
+		newOwned[shard] = now.Add(s.config.RenewDeadline)
+	}
+
+	// Start from the replica's preferred shards to limit the conflicts between replicas acquiring shards at once
+	offset := sort.SearchStrings(members, s.config.Identity) * fairShare
+	for i := 0; i < s.config.ShardCount && len(newOwned) < fairShare; i++ {
+		shard := (offset + i) % s.config.ShardCount
+		if _, owned := newOwned[shard]; owned {
+			continue
+		}
+		lease, found := shardLeases[shard]
+		if found && !isExpired(lease, now) {
+			continue
+		}
+		acquired, err := s.acquireLease(ctx, shard, lease, now)
+		if err != nil {
+			s.log.V(1).Info("failed to acquire shard lease", "shard", shard, "error", err.Error())
+			continue
+		}
+		shardLeases[shard] = acquired
+		newOwned[shard] = now.Add(s.config.RenewDeadline)
+	}
+
+	holders := map[int]string{}
+	for shard, lease := range shardLeases {
+		if !isExpired(lease, now) {
+			holders[shard] = ptr.Deref(lease.Spec.HolderIdentity, "")
+		}
+	}
+	for _, shard := range released {
+		delete(holders, shard)
+	}
+
+	changed := s.setOwned(newOwned, released)
+
+	s.lock.Lock()
+	s.holders = holders
+	s.members = members
+	s.lastSyncTime = now
+	s.lock.Unlock()
+	shardMemberCount.Set(float64(len(members)))
+
+	// The nodes are notified in the background so the Leases keep being renewed while the node controller is busy,
+	// the node controller checks the ownership again when processing the node
+	go s.notify(ctx, changed)
+}
+
+// setOwned replaces the owned shards and returns the shards acquired or lost since the last sync, along with the
+// released shards
+func (s *LeaseSharder) setOwned(newOwned map[int]time.Time, released []int) []int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	changed := append([]int{}, released...)
+	for shard := range newOwned {
+		if _, owned := s.owned[shard]; !owned {
+			s.log.Info("acquired shard", "shard", shard)
+			shardTransitionCount.WithLabelValues("acquired").Inc()
+			changed = append(changed, shard)
+		}
+	}
+	for shard := range s.owned {
+		if _, owned := newOwned[shard]; !owned {
+			s.log.Info("lost shard", "shard", shard)
+			shardTransitionCount.WithLabelValues("lost").Inc()
+			changed = append(changed, shard)
+		}
+	}
+	s.owned = newOwned
+	ownedShardCount.Set(float64(len(newOwned)))
+	return changed
+}
+
+// dropShard stops owning the shard
+func (s *LeaseSharder) dropShard(shard int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.owned, shard)
+	s.log.Info("released shard", "shard", shard)
+	shardTransitionCount.WithLabelValues("released").Inc()
+}
+
+// dropExpiredShards stops owning the shards that couldn't be renewed within the renew deadline and returns them
+func (s *LeaseSharder) dropExpiredShards(now time.Time) []int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var expired []int
+	for shard, until := range s.owned {
+		if !now.Before(until) {
+			delete(s.owned, shard)
+			expired = append(expired, shard)
+			s.log.Info("lost shard", "shard", shard)
+			shardTransitionCount.WithLabelValues("lost").Inc()
+		}
+	}
+	ownedShardCount.Set(float64(len(s.owned)))
+	return expired
+}
+
+func (s *LeaseSharder) ownedUntil(shard int) (time.Time, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	until, owned := s.owned[shard]
+	return until, owned
+}
+
+// notify sends the nodes of the shards to the node events channel, so the node controller adds the nodes of the
+// acquired shards and releases the nodes of the released shards
+func (s *LeaseSharder) notify(ctx context.Context, shards []int) {
+	if len(shards) == 0 {
+		return
+	}
+	changed := map[int]struct{}{}
+	for _, shard := range shards {
+		changed[shard] = struct{}{}
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := s.nodeReader.List(ctx, nodeList); err != nil {
+		s.log.Error(err, "failed to list nodes of the changed shards, they are processed on the next node event")
+		return
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if _, found := changed[ShardForNode(node.Name, s.config.ShardCount)]; !found {
+			continue
+		}
+		select {
+		case s.events <- event.GenericEvent{Object: node}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// releaseAll releases the Leases of all the owned shards and deletes the member Lease of the replica
+func (s *LeaseSharder) releaseAll(ctx context.Context) {
+	s.lock.Lock()
+	var owned []int
+	for shard := range s.owned {
+		owned = append(owned, shard)
+	}
+	s.owned = map[int]time.Time{}
+	s.lock.Unlock()
+
+	for _, shard := range owned {
+		lease, err := s.leases.Get(ctx, shardLeaseName(shard), metav1.GetOptions{})
+		if err == nil && ptr.Deref(lease.Spec.HolderIdentity, "") == s.config.Identity {
+			err = s.releaseLease(ctx, lease)
+		}
+		if err != nil {
+			s.log.Error(err, "failed to release shard lease on shutdown", "shard", shard)
+		}
+	}
+	if err := s.leases.Delete(ctx, memberLeaseName(s.config.Identity), metav1.DeleteOptions{}); err != nil &&
+		!apierrors.IsNotFound(err) {
+		s.log.Error(err, "failed to delete member lease on shutdown")
+	}
+	s.log.Info("released all shards on shutdown", "shards", owned)
+}
+
+func (s *LeaseSharder) renewMemberLease(ctx context.Context, now time.Time) error {
+	name := memberLeaseName(s.config.Identity)
+	lease, err := s.leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = s.leases.Create(ctx, s.newLease(name, LeaseTypeMember, now), metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return s.renewLease(ctx, lease, now)
+}
+
+// acquireLease creates the Lease of the shard if it's missing, or takes over the released or expired Lease. The update
+// fails on conflict if another replica acquired the Lease since it was listed.
+func (s *LeaseSharder) acquireLease(ctx context.Context, shard int, lease *coordinationv1.Lease,
+	now time.Time) (*coordinationv1.Lease, error) {
+	if lease == nil {
+		return s.leases.Create(ctx, s.newLease(shardLeaseName(shard), LeaseTypeShard, now), metav1.CreateOptions{})
+	}
+	updated := lease.DeepCopy()
+	updated.Spec.HolderIdentity = ptr.To(s.config.Identity)
+	updated.Spec.LeaseDurationSeconds = ptr.To(int32(s.config.LeaseDuration.Seconds()))
+	updated.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+	updated.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	updated.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	return s.leases.Update(ctx, updated, metav1.UpdateOptions{})
+}
+
+func (s *LeaseSharder) renewLease(ctx context.Context, lease *coordinationv1.Lease, now time.Time) error {
+	updated := lease.DeepCopy()
+	updated.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	_, err := s.leases.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// releaseLease clears the holder of the Lease so another replica can acquire it right away
+func (s *LeaseSharder) releaseLease(ctx context.Context, lease *coordinationv1.Lease) error {
+	updated := lease.DeepCopy()
+	updated.Spec.HolderIdentity = nil
+	updated.Spec.AcquireTime = nil
+	updated.Spec.RenewTime = nil
+	_, err := s.leases.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *LeaseSharder) deleteExpiredMemberLease(ctx context.Context, lease *coordinationv1.Lease) {
+	err := s.leases.Delete(ctx, lease.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+	})
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		s.log.Error(err, "failed to delete expired member lease", "lease", lease.Name)
+		return
+	}
+	s.log.Info("deleted expired member lease", "holder", ptr.Deref(lease.Spec.HolderIdentity, ""))
+}
+
+func (s *LeaseSharder) newLease(name, leaseType string, now time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.config.Namespace,
+			Labels:    map[string]string{LeaseTypeLabel: leaseType},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(s.config.Identity),
+			LeaseDurationSeconds: ptr.To(int32(s.config.LeaseDuration.Seconds())),
+			AcquireTime:          &metav1.MicroTime{Time: now},
+			RenewTime:            &metav1.MicroTime{Time: now},
+		},
+	}
+}
+
+// shardOfLease returns the shard of the Lease, the Leases of the shards beyond the shard count are ignored
+func (s *LeaseSharder) shardOfLease(name string) (int, bool) {
+	if !strings.HasPrefix(name, shardLeasePrefix) {
+		return 0, false
+	}
+	shard, err := strconv.Atoi(strings.TrimPrefix(name, shardLeasePrefix))
+	if err != nil || shard < 0 || shard >= s.config.ShardCount {
+		return 0, false
+	}
+	return shard, true
+}
+
+// isExpired returns true if the Lease has no holder or wasn't renewed within its duration
+func isExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if ptr.Deref(lease.Spec.HolderIdentity, "") == "" || lease.Spec.RenewTime == nil {
+		return true
+	}
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+	return !now.Before(lease.Spec.RenewTime.Add(duration))
+}
+
+func shardLeaseName(shard int) string {
+	return shardLeasePrefix + strconv.Itoa(shard)
+}
+
+func memberLeaseName(identity string) string {
+	return memberLeasePrefix + identity
+}
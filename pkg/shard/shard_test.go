@@ -0,0 +1,197 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package shard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	namespace  = "kube-system"
+	shardCount = 4
+	nodeCount  = 20
+)
+
+var (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+)
+
+func getSharder(t *testing.T, identity string, leases coordinationclientv1.LeaseInterface, nodeReader client.Reader,
+	now *time.Time) *LeaseSharder {
+	sharder, err := NewLeaseSharder(zap.New(), Config{
+		Identity:      identity,
+		Namespace:     namespace,
+		ShardCount:    shardCount,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   2 * time.Second,
+	}, leases, nodeReader)
+	assert.NoError(t, err)
+	sharder.clock = func() time.Time { return *now }
+	return sharder
+}
+
+func getNodeReader() client.Reader {
+	var nodes []client.Object
+	for i := 0; i < nodeCount; i++ {
+		nodes = append(nodes, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	return fakeClient.NewClientBuilder().WithObjects(nodes...).Build()
+}
+
+// receiveNodes returns the names of the nodes received on the node events channel till no event is received for a
+// short time
+func receiveNodes(sharder *LeaseSharder) map[string]struct{} {
+	nodes := map[string]struct{}{}
+	for {
+		select {
+		case event := <-sharder.NodeEvents():
+			nodes[event.Object.GetName()] = struct{}{}
+		case <-time.After(200 * time.Millisecond):
+			return nodes
+		}
+	}
+}
+
+// TestShardForNode tests the nodes are spread across the shards and only the nodes moving to the new shard change
+// shard when the shard count is increased
+func TestShardForNode(t *testing.T) {
+	perShard := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		nodeName := fmt.Sprintf("ip-192-168-%d-%d.us-west-2.compute.internal", i/256, i%256)
+		shard := ShardForNode(nodeName, 4)
+		assert.Equal(t, shard, ShardForNode(nodeName, 4))
+		perShard[shard]++
+
+		if grown := ShardForNode(nodeName, 5); grown != shard {
+			assert.Equal(t, 4, grown)
+		}
+	}
+	assert.Len(t, perShard, 4)
+	for _, count := range perShard {
+		assert.InDelta(t, 250, count, 60)
+	}
+	assert.Equal(t, 0, ShardForNode("any", 1))
+}
+
+func TestNewLeaseSharder_InvalidConfig(t *testing.T) {
+	leases := fake.NewSimpleClientset().CoordinationV1().Leases(namespace)
+
+	_, err := NewLeaseSharder(zap.New(), Config{Identity: "replica-a", LeaseDuration: leaseDuration}, leases, nil)
+	assert.Error(t, err)
+	_, err = NewLeaseSharder(zap.New(), Config{ShardCount: 1, LeaseDuration: leaseDuration}, leases, nil)
+	assert.Error(t, err)
+	_, err = NewLeaseSharder(zap.New(), Config{Identity: "replica-a", ShardCount: 1, LeaseDuration: leaseDuration,
+		RenewDeadline: leaseDuration}, leases, nil)
+	assert.Error(t, err)
+}
+
+// TestLeaseSharder_Rebalance tests the shards are spread across the replicas as they join, and taken over once the
+// Leases of a replica that stopped renewing them expire
+func TestLeaseSharder_Rebalance(t *testing.T) {
+	ctx := context.Background()
+	leases := fake.NewSimpleClientset().CoordinationV1().Leases(namespace)
+	nodeReader := getNodeReader()
+	now := time.Now()
+
+	replicaA := getSharder(t, "replica-a", leases, nodeReader, &now)
+	replicaA.sync(ctx)
+	assert.Equal(t, []int{0, 1, 2, 3}, replicaA.Introspect().OwnedShards)
+	assert.Len(t, receiveNodes(replicaA), nodeCount)
+	for i := 0; i < nodeCount; i++ {
+		assert.True(t, replicaA.OwnsNode(fmt.Sprintf("node-%d", i)))
+	}
+
+	// The new replica doesn't take over the shards held by the other replica
+	replicaB := getSharder(t, "replica-b", leases, nodeReader, &now)
+	replicaB.sync(ctx)
+	assert.Empty(t, replicaB.Introspect().OwnedShards)
+	assert.Equal(t, []string{"replica-a", "replica-b"}, replicaB.Introspect().Members)
+
+	// The shards above the fair share are released by the first replica and acquired by the new replica
+	now = now.Add(2 * time.Second)
+	replicaA.sync(ctx)
+	assert.Equal(t, []int{0, 1}, replicaA.Introspect().OwnedShards)
+	released := receiveNodes(replicaA)
+	assert.NotEmpty(t, released)
+	for nodeName := range released {
+		assert.False(t, replicaA.OwnsNode(nodeName))
+	}
+
+	replicaB.sync(ctx)
+	assert.Equal(t, []int{2, 3}, replicaB.Introspect().OwnedShards)
+	assert.Equal(t, released, receiveNodes(replicaB))
+	assert.Equal(t, map[int]string{0: "replica-a", 1: "replica-a", 2: "replica-b", 3: "replica-b"},
+		replicaB.Introspect().ShardHolders)
+
+	// The first replica stops renewing its Leases, it no longer owns the shards past the renew deadline and the
+	// other replica takes them over once the Leases expire
+	now = now.Add(leaseDuration)
+	assert.False(t, replicaA.OwnsShard(0))
+	replicaB.sync(ctx)
+	assert.Equal(t, []int{0, 1, 2, 3}, replicaB.Introspect().OwnedShards)
+	assert.Equal(t, []string{"replica-b"}, replicaB.Introspect().Members)
+	_, err := leases.Get(ctx, memberLeaseName("replica-a"), metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// The shards are released on shut down
+	replicaB.releaseAll(ctx)
+	assert.Empty(t, replicaB.Introspect().OwnedShards)
+	for shard := 0; shard < shardCount; shard++ {
+		lease, err := leases.Get(ctx, shardLeaseName(shard), metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, ptr.Deref(lease.Spec.HolderIdentity, ""))
+	}
+	_, err = leases.Get(ctx, memberLeaseName("replica-b"), metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+// TestLeaseSharder_LostShard tests the replica stops owning the shards it can't renew past the renew deadline
+func TestLeaseSharder_LostShard(t *testing.T) {
+	ctx := context.Background()
+	clientSet := fake.NewSimpleClientset()
+	leases := clientSet.CoordinationV1().Leases(namespace)
+	now := time.Now()
+
+	sharder := getSharder(t, "replica-a", leases, getNodeReader(), &now)
+	sharder.sync(ctx)
+	assert.True(t, sharder.OwnsShard(1))
+
+	// Another replica took over the Lease
+	lease, err := leases.Get(ctx, shardLeaseName(1), metav1.GetOptions{})
+	assert.NoError(t, err)
+	lease.Spec.HolderIdentity = ptr.To("replica-b")
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Second)
+	sharder.sync(ctx)
+	assert.False(t, sharder.OwnsShard(1))
+	assert.True(t, sharder.OwnsShard(0))
+	assert.Equal(t, "replica-b", sharder.Introspect().ShardHolders[1])
+}
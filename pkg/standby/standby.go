@@ -0,0 +1,271 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package standby
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/node/manager"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultReadyTimeout is the duration the new leader waits for all the nodes to be ready before giving up on
+	// measuring the failover duration
+	DefaultReadyTimeout = 10 * time.Minute
+	// readyCheckInterval is the interval between two checks of the nodes after the replica is elected leader
+	readyCheckInterval = time.Second
+)
+
+var (
+	prometheusRegistered = false
+
+	standbyReplica = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "standby_replica",
+			Help: "Set to 1 while the replica is a warm standby waiting to be elected leader, 0 otherwise",
+		},
+	)
+
+	leaderFailoverDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "leader_failover_duration_seconds",
+			Help:    "The time from the replica being elected leader till all the nodes are initialized by the replica",
+			Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+		},
+	)
+)
+
+func prometheusRegister() {
+	if !prometheusRegistered {
+		metrics.Registry.MustRegister(
+			standbyReplica,
+			leaderFailoverDuration,
+		)
+		prometheusRegistered = true
+	}
+}
+
+// WarmStandby keeps a replica that isn't the leader ready to take over. Till the replica is elected leader it
+// refreshes the view of the nodes' trunk ENIs, branch ENIs and IP pools in batches. Once elected it describes only
+// the nodes that changed since, the nodes are initialized from the view, and it measures the time taken to
+// initialize all the nodes.
+type WarmStandby struct {
+	Log logr.Logger
+	// Client reads the nodes from the cache, which is kept in sync on all the replicas
+	Client client.Reader
+	// PodAPI reads the pods of the nodes from the data store, which is kept in sync on all the replicas
+	PodAPI pod.PodClientAPIWrapper
+	// Cache holds the view of the nodes, the nodes are not pre-fetched if the cache is nil
+	Cache *ec2API.WarmCache
+	// NodeManager is checked for the nodes initialized after the replica is elected leader
+	NodeManager manager.Manager
+	// RefreshInterval is the interval between two refreshes of the cache
+	RefreshInterval time.Duration
+	// ReadyTimeout is the duration the replica waits for all the nodes to be initialized after it's elected leader
+	ReadyTimeout time.Duration
+	// Elected is closed once the replica is elected leader
+	Elected <-chan struct{}
+}
+
+// SetupWithManager adds the warm standby to the manager, it runs on all the replicas
+func (w *WarmStandby) SetupWithManager(mgr ctrl.Manager) error {
+	prometheusRegister()
+
+	if w.Elected == nil {
+		w.Elected = mgr.Elected()
+	}
+	if w.ReadyTimeout == 0 {
+		w.ReadyTimeout = DefaultReadyTimeout
+	}
+	return mgr.Add(w)
+}
+
+// NeedLeaderElection returns false so the warm standby runs before the replica is elected leader
+func (w *WarmStandby) NeedLeaderElection() bool {
+	return false
+}
+
+// Start refreshes the cache till the replica is elected leader and then waits for all the nodes to be initialized.
+// If the replica was elected as soon as it started, there was no standby and so no failover to measure.
+func (w *WarmStandby) Start(ctx context.Context) error {
+	select {
+	case <-w.Elected:
+		w.Log.Info("replica elected leader on start, skipping warm standby")
+		return nil
+	default:
+	}
+
+	w.Log.Info("replica is a warm standby", "refresh interval", w.RefreshInterval,
+		"pre-fetch enabled", w.Cache != nil)
+	standbyReplica.Set(1)
+
+	var ticker <-chan time.Time
+	if w.Cache != nil && w.RefreshInterval > 0 {
+		refreshTicker := time.NewTicker(w.RefreshInterval)
+		defer refreshTicker.Stop()
+		ticker = refreshTicker.C
+		w.refresh(ctx)
+	}
+
+	for elected := false; !elected; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker:
+			w.refresh(ctx)
+		case <-w.Elected:
+			elected = true
+		}
+	}
+
+	electedTime := time.Now()
+	standbyReplica.Set(0)
+	w.Log.Info("replica elected leader, waiting for the nodes to be initialized")
+
+	// Describe the nodes that changed since the last refresh, the nodes are initialized as their events are
+	// reconciled
+	if w.Cache != nil {
+		w.reconcile(ctx)
+		// The view of the nodes not initialized by then is outdated
+		defer w.Cache.Clear()
+	}
+
+	timeout := time.NewTimer(w.ReadyTimeout)
+	defer timeout.Stop()
+	check := time.NewTicker(readyCheckInterval)
+	defer check.Stop()
+	for {
+		if w.allNodesReady(ctx) {
+			duration := time.Since(electedTime)
+			leaderFailoverDuration.Observe(duration.Seconds())
+			w.Log.Info("all nodes initialized after the replica was elected leader", "duration", duration)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timeout.C:
+			w.Log.Info("nodes not initialized after the replica was elected leader, failover duration not "+
+				"recorded", "timeout", w.ReadyTimeout)
+			return nil
+		case <-check.C:
+		}
+	}
+}
+
+// refresh describes the view of all the nodes with an instance ID
+func (w *WarmStandby) refresh(ctx context.Context) {
+	nodes, err := w.listNodes(ctx)
+	if err != nil {
+		w.Log.Error(err, "failed to list nodes to pre-fetch their view")
+		return
+	}
+	if err := w.Cache.Refresh(nodes); err != nil {
+		w.Log.Error(err, "failed to pre-fetch the view of the nodes", "node count", len(nodes))
+		return
+	}
+	w.Log.V(1).Info("pre-fetched the view of the nodes", "instance count", w.Cache.Len())
+}
+
+// reconcile describes the nodes whose view is missing or changed since the last refresh
+func (w *WarmStandby) reconcile(ctx context.Context) {
+	nodes, err := w.listNodes(ctx)
+	if err != nil {
+		w.Log.Error(err, "failed to list nodes to reconcile their view")
+		w.Cache.Clear()
+		return
+	}
+	described, err := w.Cache.Reconcile(nodes)
+	if err != nil {
+		// The nodes are described while they are initialized instead
+		w.Log.Error(err, "failed to reconcile the view of the nodes", "node count", len(nodes))
+		w.Cache.Clear()
+		return
+	}
+	w.Log.Info("reconciled the view of the nodes", "node count", len(nodes), "described", described)
+}
+
+// listNodes returns the version of the pods of the nodes with an instance ID by instance ID
+func (w *WarmStandby) listNodes(ctx context.Context) (map[string]string, error) {
+	nodeList := &corev1.NodeList{}
+	if err := w.Client.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]string)
+	for i := range nodeList.Items {
+		instanceID := manager.GetNodeInstanceID(&nodeList.Items[i])
+		if instanceID == "" {
+			continue
+		}
+		podList, err := w.PodAPI.ListPods(nodeList.Items[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		nodes[instanceID] = podsVersion(podList.Items)
+	}
+	return nodes, nil
+}
+
+// podsVersion returns the digest of the pods' UID, phase and annotations of the controller, it changes when a pod is
+// created, deleted or is allocated other resources
+func podsVersion(pods []corev1.Pod) string {
+	entries := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		annotations, _ := json.Marshal(pod.Annotations)
+		entries = append(entries, string(pod.UID)+"/"+string(pod.Status.Phase)+"/"+string(annotations))
+	}
+	sort.Strings(entries)
+	digest := sha256.New()
+	for _, entry := range entries {
+		digest.Write([]byte(entry + "\n"))
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// allNodesReady returns true if all the nodes are known to the node manager and the managed nodes are initialized.
+// The view of the initialized nodes is removed so the later lookups see the changes made to them.
+func (w *WarmStandby) allNodesReady(ctx context.Context) bool {
+	nodeList := &corev1.NodeList{}
+	if err := w.Client.List(ctx, nodeList); err != nil {
+		w.Log.Error(err, "failed to list nodes")
+		return false
+	}
+	ready := true
+	for i := range nodeList.Items {
+		cachedNode, found := w.NodeManager.GetNode(nodeList.Items[i].Name)
+		if !found || (cachedNode.IsManaged() && !cachedNode.IsReady()) {
+			ready = false
+			continue
+		}
+		if w.Cache != nil {
+			w.Cache.Forget(manager.GetNodeInstanceID(&nodeList.Items[i]))
+		}
+	}
+	return ready
+}
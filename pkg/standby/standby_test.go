@@ -0,0 +1,155 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package standby
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	mock_pod "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
+	mock_node "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node"
+	mock_manager "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/node/manager"
+	ec2API "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	nodeName1   = "node-1"
+	nodeName2   = "node-2"
+	instanceID1 = "i-00000000000000001"
+)
+
+func getWarmStandby(ctrl *gomock.Controller, cache *ec2API.WarmCache, elected chan struct{}) (*WarmStandby,
+	*mock_manager.MockManager, *mock_pod.MockPodClientAPIWrapper) {
+	prometheusRegister()
+	mockManager := mock_manager.NewMockManager(ctrl)
+	mockPodAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+	client := fakeClient.NewClientBuilder().WithObjects(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: nodeName1},
+			Spec:       corev1.NodeSpec{ProviderID: "aws:///us-west-2a/" + instanceID1},
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName2}},
+	).Build()
+	return &WarmStandby{
+		Log:             zap.New(),
+		Client:          client,
+		PodAPI:          mockPodAPI,
+		Cache:           cache,
+		NodeManager:     mockManager,
+		RefreshInterval: time.Hour,
+		ReadyTimeout:    time.Minute,
+		Elected:         elected,
+	}, mockManager, mockPodAPI
+}
+
+// failoverCount returns the number of failover durations recorded
+func failoverCount() uint64 {
+	metric := &dto.Metric{}
+	_ = leaderFailoverDuration.Write(metric)
+	return metric.GetHistogram().GetSampleCount()
+}
+
+// TestWarmStandby_ElectedOnStart tests nothing is pre-fetched or measured if the replica is elected on start
+func TestWarmStandby_ElectedOnStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	elected := make(chan struct{})
+	close(elected)
+	standby, _, _ := getWarmStandby(ctrl, ec2API.NewWarmCache(mock_api.NewMockEC2Wrapper(ctrl), time.Hour), elected)
+
+	count := failoverCount()
+	assert.NoError(t, standby.Start(context.Background()))
+	assert.Equal(t, count, failoverCount())
+	assert.Equal(t, float64(0), testutil.ToFloat64(standbyReplica))
+}
+
+// TestWarmStandby_Failover tests the details of the nodes with an instance ID are pre-fetched while standby, and the
+// failover duration is recorded once all the managed nodes are ready
+func TestWarmStandby_Failover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWrapper := mock_api.NewMockEC2Wrapper(ctrl)
+	elected := make(chan struct{})
+	standby, mockManager, mockPodAPI := getWarmStandby(ctrl, ec2API.NewWarmCache(mockWrapper, time.Hour),
+		elected)
+	mockNode1 := mock_node.NewMockNode(ctrl)
+	mockNode2 := mock_node.NewMockNode(ctrl)
+
+	instanceInput := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: []*string{&instanceID1}}},
+	}
+	// Once while standby and once after being elected, as the node is missing from the view
+	mockPodAPI.EXPECT().ListPods(nodeName1).Return(&corev1.PodList{}, nil).Times(2)
+	mockWrapper.EXPECT().DescribeInstances(instanceInput).Return(&ec2.DescribeInstancesOutput{}, nil).Times(2)
+
+	// The first node is initialized after the second check
+	gomock.InOrder(
+		mockManager.EXPECT().GetNode(nodeName1).Return(nil, false),
+		mockManager.EXPECT().GetNode(nodeName1).Return(mockNode1, true),
+		mockManager.EXPECT().GetNode(nodeName1).Return(mockNode1, true),
+	)
+	mockNode1.EXPECT().IsManaged().Return(true).Times(2)
+	mockNode1.EXPECT().IsReady().Return(false)
+	mockNode1.EXPECT().IsReady().Return(true)
+	// The second node is checked on every check, as the view of the ready nodes is removed
+	mockManager.EXPECT().GetNode(nodeName2).Return(mockNode2, true).Times(3)
+	mockNode2.EXPECT().IsManaged().Return(false).Times(3)
+
+	count := failoverCount()
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(t, standby.Start(context.Background()))
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return testutil.ToFloat64(standbyReplica) == 1 }, time.Second,
+		10*time.Millisecond)
+
+	close(elected)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("failover duration not recorded")
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(standbyReplica))
+	assert.Equal(t, count+1, failoverCount())
+}
+
+// TestPodsVersion tests the version of the pods doesn't depend on their order and changes when a pod is created or is
+// allocated other resources
+func TestPodsVersion(t *testing.T) {
+	pod1 := corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	pod2 := corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid-2"}, Status: corev1.PodStatus{Phase: corev1.PodPending}}
+
+	version := podsVersion([]corev1.Pod{pod1, pod2})
+	assert.Equal(t, version, podsVersion([]corev1.Pod{pod2, pod1}))
+	assert.NotEqual(t, version, podsVersion([]corev1.Pod{pod1}))
+
+	pod2.Annotations = map[string]string{"vpc.amazonaws.com/pod-eni": "[]"}
+	assert.NotEqual(t, version, podsVersion([]corev1.Pod{pod1, pod2}))
+}
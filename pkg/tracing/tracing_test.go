@@ -0,0 +1,83 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// useRecorder sets a tracer provider that records the spans in memory for the duration of the test
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// TestInit_Disabled tests that no exporter is set up when the endpoint is empty
+func TestInit_Disabled(t *testing.T) {
+	shutdown, err := Init(context.TODO(), Config{}, zap.New())
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.TODO()))
+
+	_, span := StartSpan(context.TODO(), "test")
+	assert.False(t, span.SpanContext().IsValid())
+}
+
+// TestInit_InvalidSampleRatio tests that an error is returned for a sample ratio outside of [0, 1]
+func TestInit_InvalidSampleRatio(t *testing.T) {
+	_, err := Init(context.TODO(), Config{Endpoint: "localhost:4317", SampleRatio: 2}, zap.New())
+	assert.Error(t, err)
+}
+
+// TestStartSpan_Child tests that the span is started as a child of the span in the context and the error is
+// recorded when the span ends
+func TestStartSpan_Child(t *testing.T) {
+	recorder := useRecorder(t)
+
+	ctx, parent := StartSpan(context.TODO(), "parent")
+	_, child := StartSpan(ctx, "child")
+	EndSpan(child, fmt.Errorf("failed"))
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+// TestWithTraceID tests the trace ID is appended to the message only if the context has a sampled span
+func TestWithTraceID(t *testing.T) {
+	assert.Equal(t, "message", WithTraceID(context.TODO(), "message"))
+
+	useRecorder(t)
+	ctx, span := StartSpan(context.TODO(), "test")
+	defer span.End()
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), TraceID(ctx))
+	assert.Equal(t, fmt.Sprintf("message [trace_id=%s]", span.SpanContext().TraceID()),
+		WithTraceID(ctx, "message"))
+}
@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package utils
+
+import (
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons of the node conditions, in addition to the reasons of the node events
+const (
+	SupportedInstanceTypeReason = "Supported"
+	IPPoolReadyReason           = "IPPoolReady"
+	IPPoolFailedReason          = "IPPoolFailed"
+	CidrBlocksAvailableReason   = "CidrBlocksAvailable"
+)
+
+// SetNodeCondition sets the condition on the CNINode and the Node, the failure to set the condition is only logged as
+// the condition is set again on the next change
+func SetNodeCondition(client k8s.K8sWrapper, nodeName, conditionType string, status metav1.ConditionStatus, reason,
+	msg string, logger logr.Logger) {
+	err := client.SetNodeCondition(nodeName, metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: msg,
+	})
+	if err != nil {
+		logger.Error(err, "failed to set the condition on the node", "Node", nodeName, "Condition", conditionType,
+			"Status", status)
+	}
+}
@@ -29,6 +29,13 @@ const (
 	EniConfigNameNotFoundReason         = "EniConfigNameNotFound"
 	VersionNotice                       = "ControllerVersionNotice"
 	BranchENICoolDownUpdateReason       = "BranchENICoolDownPeriodUpdated"
+	InvalidENIConfigReason              = "InvalidENIConfig"
+	ENIConfigSubnetLowOnAddressesReason = "ENIConfigSubnetLowOnAddresses"
+	ENIConfigUpdatedReason              = "ENIConfigUpdated"
+	SecurityGroupRequestedReason        = "SecurityGroupRequested"
+	WebhookBypassedReason               = "WebhookBypassed"
+	SecurityGroupsChangedReason         = "SecurityGroupsChanged"
+	SecurityGroupsUpdatedReason         = "SecurityGroupsUpdated"
 )
 
 func SendNodeEventWithNodeName(client k8s.K8sWrapper, nodeName, reason, msg, eventType string, logger logr.Logger) {
@@ -16,6 +16,7 @@ package utils
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -39,6 +40,9 @@ const (
 	AccountIndex   = 4
 )
 
+// securityGroupIDRegex matches the short and the long format of the EC2 security group IDs
+var securityGroupIDRegex = regexp.MustCompile(`^sg-([0-9a-f]{8}|[0-9a-f]{17})$`)
+
 // RemoveDuplicatedSg removes duplicated items from a string slice.
 // It returns a no duplicates string slice.
 func RemoveDuplicatedSg(list []string) []string {
@@ -55,6 +59,8 @@ func RemoveDuplicatedSg(list []string) []string {
 
 type SecurityGroupForPodsAPI interface {
 	GetMatchingSecurityGroupForPods(pod *corev1.Pod) ([]string, error)
+	GetMatchingSecurityGroupPoliciesForPods(pod *corev1.Pod) ([]string, []string, error)
+	GetMatchingSecurityGroupForServiceAccount(pod *corev1.Pod, sa *corev1.ServiceAccount) ([]string, error)
 }
 
 type SecurityGroupForPods struct {
@@ -74,23 +80,20 @@ func NewSecurityGroupForPodsAPI(client client.Client, log logr.Logger) SecurityG
 // GetMatchingSecurityGroupForPods returns the list of security groups that should be associated
 // with the Pod by matching against all the SecurityGroupPolicy
 func (s *SecurityGroupForPods) GetMatchingSecurityGroupForPods(pod *corev1.Pod) ([]string, error) {
+	_, sgList, err := s.GetMatchingSecurityGroupPoliciesForPods(pod)
+	return sgList, err
+}
+
+// GetMatchingSecurityGroupPoliciesForPods returns the names of the SecurityGroupPolicy matching the Pod
+// along with the list of security groups that should be associated with the Pod
+func (s *SecurityGroupForPods) GetMatchingSecurityGroupPoliciesForPods(pod *corev1.Pod) ([]string, []string, error) {
 	helperLog := s.Log.WithValues("Pod name", pod.Name, "Pod namespace", pod.Namespace)
 
 	// Build SGP list from cache.
 	ctx := context.Background()
-	sgpList := &vpcresourcesv1beta1.SecurityGroupPolicyList{}
-
-	if err := s.Client.List(ctx, sgpList, &client.ListOptions{Namespace: pod.Namespace}); err != nil {
-		// If the CRD was removed intentionally or accidentally, we don't want to interrupt pods creation.
-		// GroupVersionResource or GroupKind not matched check.
-		if meta.IsNoMatchError(err) {
-			helperLog.Error(err,
-				"Webhook couldn't find SGP definition: "+
-					"GroupVersionResource or GroupKind didn't match. Will allow regular pods creation.")
-			return nil, nil
-		}
-		helperLog.Error(err, "Client Listing SGP failed in Webhook.")
-		return nil, err
+	sgpList, err := s.listSecurityGroupPolicies(ctx, pod.Namespace, helperLog)
+	if err != nil || sgpList == nil {
+		return nil, nil, err
 	}
 
 	sa := &corev1.ServiceAccount{}
@@ -100,21 +103,65 @@ func (s *SecurityGroupForPods) GetMatchingSecurityGroupForPods(pod *corev1.Pod)
 
 	// Get metadata of SA associated with Pod from cache
 	if err := s.Client.Get(ctx, key, sa); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	sgList := s.filterPodSecurityGroups(sgpList, pod, sa)
+	policies, sgList := s.matchPodSecurityGroupPolicies(sgpList, pod, sa)
 	if len(sgList) > 0 {
 		helperLog.V(1).Info("Pod matched a SecurityGroupPolicy and will get the following Security Groups:",
-			"Security Groups", sgList)
+			"Security Groups", sgList, "Security Group Policies", policies)
+	}
+	return policies, sgList, nil
+}
+
+// GetMatchingSecurityGroupForServiceAccount returns the list of security groups that should be associated with the
+// Pod if it used the given service account, the labels of the service account can differ from the cached ones
+func (s *SecurityGroupForPods) GetMatchingSecurityGroupForServiceAccount(pod *corev1.Pod,
+	sa *corev1.ServiceAccount) ([]string, error) {
+	helperLog := s.Log.WithValues("Pod name", pod.Name, "Pod namespace", pod.Namespace)
+
+	sgpList, err := s.listSecurityGroupPolicies(context.Background(), pod.Namespace, helperLog)
+	if err != nil || sgpList == nil {
+		return nil, err
+	}
+	return s.filterPodSecurityGroups(sgpList, pod, sa), nil
+}
+
+// listSecurityGroupPolicies lists the SecurityGroupPolicy of the namespace from cache, a nil list is returned
+// without error if the SecurityGroupPolicy CRD is not installed
+func (s *SecurityGroupForPods) listSecurityGroupPolicies(ctx context.Context, namespace string,
+	helperLog logr.Logger) (*vpcresourcesv1beta1.SecurityGroupPolicyList, error) {
+	sgpList := &vpcresourcesv1beta1.SecurityGroupPolicyList{}
+	if err := s.Client.List(ctx, sgpList, &client.ListOptions{Namespace: namespace}); err != nil {
+		// If the CRD was removed intentionally or accidentally, we don't want to interrupt pods creation.
+		// GroupVersionResource or GroupKind not matched check.
+		if meta.IsNoMatchError(err) {
+			helperLog.Error(err,
+				"Webhook couldn't find SGP definition: "+
+					"GroupVersionResource or GroupKind didn't match. Will allow regular pods creation.")
+			return nil, nil
+		}
+		helperLog.Error(err, "Client Listing SGP failed in Webhook.")
+		return nil, err
 	}
-	return sgList, nil
+	return sgpList, nil
 }
 
 func (s *SecurityGroupForPods) filterPodSecurityGroups(
 	sgpList *vpcresourcesv1beta1.SecurityGroupPolicyList,
 	pod *corev1.Pod,
 	sa *corev1.ServiceAccount) []string {
+	_, sgList := s.matchPodSecurityGroupPolicies(sgpList, pod, sa)
+	return sgList
+}
+
+// matchPodSecurityGroupPolicies returns the names of the valid SecurityGroupPolicy matching the pod and its
+// service account, and their deduplicated security groups
+func (s *SecurityGroupForPods) matchPodSecurityGroupPolicies(
+	sgpList *vpcresourcesv1beta1.SecurityGroupPolicyList,
+	pod *corev1.Pod,
+	sa *corev1.ServiceAccount) ([]string, []string) {
+	var policies []string
 	var sgList []string
 	sgpLogger := s.Log.WithValues("Pod name", pod.Name, "Pod namespace", pod.Namespace)
 	for _, sgp := range sgpList.Items {
@@ -131,36 +178,78 @@ func (s *SecurityGroupForPods) filterPodSecurityGroups(
 			continue
 		}
 
-		podMatched, saMatched := false, false
-		if podSelector, podSelectorError :=
-			metav1.LabelSelectorAsSelector(sgp.Spec.PodSelector); podSelectorError == nil {
-			if podSelector.Matches(labels.Set(pod.Labels)) {
-				podMatched = true
-			}
-		} else {
-			sgpLogger.Error(podSelectorError, "Failed converting SGP pod selector to match pod labels.",
-				"SGP name", sgp.Name, "SGP namespace", sgp.Namespace)
-		}
-
-		if saSelector, saSelectorError :=
-			metav1.LabelSelectorAsSelector(sgp.Spec.ServiceAccountSelector); saSelectorError == nil {
-			if saSelector.Matches(labels.Set(sa.Labels)) {
-				saMatched = true
-			}
-		} else {
-			sgpLogger.Error(saSelectorError, "Failed converting SGP SA selector to match pod labels.",
+		matched, err := SecurityGroupPolicyMatches(sgp.Spec, pod, sa)
+		if err != nil {
+			sgpLogger.Error(err, "Failed converting SGP selector to match pod labels.",
 				"SGP name", sgp.Name, "SGP namespace", sgp.Namespace)
 		}
-
-		if (hasPodSelector && !podMatched) || (hasSASelector && !saMatched) {
+		if !matched {
 			continue
 		}
 
+		policies = append(policies, sgp.Name)
 		sgList = append(sgList, sgp.Spec.SecurityGroups.Groups...)
 	}
 
 	sgList = RemoveDuplicatedSg(sgList)
-	return sgList
+	return policies, sgList
+}
+
+// SecurityGroupPolicyMatches returns true if the pod and its service account match all the selectors of the
+// SecurityGroupPolicy spec. A selector that cannot be converted doesn't match any pod.
+func SecurityGroupPolicyMatches(spec vpcresourcesv1beta1.SecurityGroupPolicySpec, pod *corev1.Pod,
+	sa *corev1.ServiceAccount) (bool, error) {
+	if spec.PodSelector != nil {
+		podSelector, err := metav1.LabelSelectorAsSelector(spec.PodSelector)
+		if err != nil {
+			return false, fmt.Errorf("converting pod selector, %w", err)
+		}
+		if !podSelector.Matches(labels.Set(pod.Labels)) {
+			return false, nil
+		}
+	}
+	if spec.ServiceAccountSelector != nil {
+		saSelector, err := metav1.LabelSelectorAsSelector(spec.ServiceAccountSelector)
+		if err != nil {
+			return false, fmt.Errorf("converting service account selector, %w", err)
+		}
+		if !saSelector.Matches(labels.Set(sa.Labels)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ValidateSecurityGroupPolicySpec returns an error for each invalid field of the SecurityGroupPolicy spec
+func ValidateSecurityGroupPolicySpec(spec vpcresourcesv1beta1.SecurityGroupPolicySpec) []error {
+	var errs []error
+	if spec.PodSelector == nil && spec.ServiceAccountSelector == nil {
+		errs = append(errs, fmt.Errorf("either podSelector or serviceAccountSelector must be set"))
+	}
+	if spec.PodSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.PodSelector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid podSelector: %w", err))
+		}
+	}
+	if spec.ServiceAccountSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.ServiceAccountSelector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid serviceAccountSelector: %w", err))
+		}
+	}
+	if len(spec.SecurityGroups.Groups) == 0 {
+		errs = append(errs, fmt.Errorf("securityGroups.groupIds must not be empty"))
+	}
+	for _, group := range spec.SecurityGroups.Groups {
+		if !IsSecurityGroupID(group) {
+			errs = append(errs, fmt.Errorf("invalid security group id %q", group))
+		}
+	}
+	return errs
+}
+
+// IsSecurityGroupID returns true if the string is a well formed EC2 security group ID
+func IsSecurityGroupID(id string) bool {
+	return securityGroupIDRegex.MatchString(id)
 }
 
 // DeconstructIPsFromPrefix deconstructs a IPv4 prefix into a list of /32 IPv4 addresses
@@ -203,7 +292,7 @@ func DeconstructIPsFromPrefix(prefix string) ([]string, error) {
 }
 
 func IsNitroInstance(instanceType string) (bool, error) {
-	limits, found := vpc.Limits[instanceType]
+	limits, found := vpc.GetLimits(instanceType)
 	if !found {
 		return false, ErrNotFound
 	}
@@ -234,14 +323,35 @@ func GetSourceAcctAndArn(roleARN, region, clusterName string) (string, string, s
 	return parsedArn.AccountID, parsedArn.Partition, sourceArn, nil
 }
 
-// PodHasENIRequest will return true if first container of pod spec has request for eni indicating
-// it needs trunk interface from vpc-rc
+// PodHasENIRequest will return true if a container or an init container of pod spec has request for eni
+// indicating it needs trunk interface from vpc-rc
 func PodHasENIRequest(pod *corev1.Pod) bool {
 	if pod == nil {
 		return false
 	}
-	for _, container := range pod.Spec.Containers {
-		if _, hasEniRequest := container.Resources.Requests[config.ResourceNamePodENI]; hasEniRequest {
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, container := range containers {
+			if _, hasEniRequest := container.Resources.Requests[config.ResourceNamePodENI]; hasEniRequest {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsNodeDraining returns true if the node is cordoned or tainted for termination, no new pod is expected to need
+// resources on the node
+func IsNodeDraining(node *corev1.Node) bool {
+	if node == nil {
+		return false
+	}
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		switch taint.Key {
+		case config.UnschedulableTaint, config.KarpenterDisruptedTaint, config.KarpenterDisruptionTaint,
+			config.SpotInterruptionTaint, config.ASGLifecycleTerminationTaint:
 			return true
 		}
 	}
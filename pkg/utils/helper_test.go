@@ -227,6 +227,61 @@ func TestEmptySecurityGroupInSGP(t *testing.T) {
 	assert.True(t, len(sgs) == 0)
 }
 
+// TestValidateSecurityGroupPolicySpec tests the invalid fields of the SecurityGroupPolicy spec are reported
+func TestValidateSecurityGroupPolicySpec(t *testing.T) {
+	validGroups := vpcresourcesv1beta1.GroupIds{Groups: []string{"sg-0123abcd", "sg-0123456789abcdef0"}}
+	tests := []struct {
+		name           string
+		spec           vpcresourcesv1beta1.SecurityGroupPolicySpec
+		expectedErrors int
+	}{
+		{
+			name: "valid spec",
+			spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+				PodSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+				SecurityGroups: validGroups,
+			},
+		},
+		{
+			name:           "no selector",
+			spec:           vpcresourcesv1beta1.SecurityGroupPolicySpec{SecurityGroups: validGroups},
+			expectedErrors: 1,
+		},
+		{
+			name: "invalid selectors",
+			spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+				PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: "Matches"}}},
+				ServiceAccountSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"invalid key!": "test"}},
+				SecurityGroups:         validGroups,
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "no security group",
+			spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+				ServiceAccountSelector: &metav1.LabelSelector{},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "malformed security group ids",
+			spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+				ServiceAccountSelector: &metav1.LabelSelector{},
+				SecurityGroups:         vpcresourcesv1beta1.GroupIds{Groups: []string{"sg-0123abcd", "sg-xyz", "my-group"}},
+			},
+			expectedErrors: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := ValidateSecurityGroupPolicySpec(test.spec)
+			assert.Len(t, errs, test.expectedErrors)
+		})
+	}
+}
+
 // TestShouldAddENILimits tests if pod is valid for SGP to inject ENI limits/requests.
 func TestShouldAddENILimits(t *testing.T) {
 	sgList, _ := helper.GetMatchingSecurityGroupForPods(testPod)
@@ -239,6 +294,14 @@ func TestShouldAddENILimits(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// TestGetMatchingSecurityGroupPoliciesForPods tests the names of the matching SGP are returned with their security groups
+func TestGetMatchingSecurityGroupPoliciesForPods(t *testing.T) {
+	policies, sgList, err := helper.GetMatchingSecurityGroupPoliciesForPods(testPod)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{name + "_1"}, policies)
+	assert.Equal(t, testSecurityGroupsOne, sgList)
+}
+
 func isEverySecurityGroupIncluded(retrievedSgs []string) bool {
 	if len(retrievedSgs) != len(testSecurityGroupsOne) {
 		return false
@@ -654,6 +717,24 @@ func TestPodHasENIRequest(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "Pod with ENI request in init container",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{}},
+					InitContainers: []v1.Container{
+						{
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									config.ResourceNamePodENI: resource.MustParse("1"),
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: true,
+		},
 		{
 			name: "Pod with empty containers",
 			pod: &v1.Pod{
@@ -679,3 +760,45 @@ func TestPodHasENIRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestIsNodeDraining(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *v1.Node
+		expected bool
+	}{
+		{
+			name:     "Nil node",
+			node:     nil,
+			expected: false,
+		},
+		{
+			name:     "Schedulable node",
+			node:     &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{{Key: "dedicated", Effect: v1.TaintEffectNoSchedule}}}},
+			expected: false,
+		},
+		{
+			name:     "Cordoned node",
+			node:     &v1.Node{Spec: v1.NodeSpec{Unschedulable: true}},
+			expected: true,
+		},
+		{
+			name: "Node disrupted by Karpenter",
+			node: &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{
+				{Key: config.KarpenterDisruptedTaint, Effect: v1.TaintEffectNoSchedule}}}},
+			expected: true,
+		},
+		{
+			name: "Node receiving a spot interruption notice",
+			node: &v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{
+				{Key: config.SpotInterruptionTaint, Effect: v1.TaintEffectNoExecute}}}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsNodeDraining(tt.node))
+		})
+	}
+}
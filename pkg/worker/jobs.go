@@ -13,7 +13,13 @@
 
 package worker
 
-import "k8s.io/apimachinery/pkg/types"
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/types"
+)
 
 // Operations are the supported operations for on demand resource handler
 type Operations string
@@ -34,6 +40,8 @@ const (
 	OperationReSyncPool Operations = "ReSyncPool"
 	// OperationDeleteNode represents the job to delete the node
 	OperationDeleteNode Operations = "NodeDelete"
+	// OperationReconcileLeakedResources represents the job to unassign the IPv4 resources of a node missing from its pool
+	OperationReconcileLeakedResources Operations = "ReconcileLeakedResources"
 )
 
 // OnDemandJob represents the job that will be executed by the respective worker
@@ -139,3 +147,55 @@ func NewWarmProcessDeleteQueueJob(nodeName string) *WarmPoolJob {
 		NodeName:   nodeName,
 	}
 }
+
+// NewWarmReconcileLeakedResourcesJob returns a job to periodically detect and unassign the leaked IPv4 resources of
+// the node
+func NewWarmReconcileLeakedResourcesJob(nodeName string) *WarmPoolJob {
+	return &WarmPoolJob{
+		Operations: OperationReconcileLeakedResources,
+		NodeName:   nodeName,
+	}
+}
+
+// jobSpanContexts holds the span context of the request that submitted a job. The span context is not stored on the
+// job itself because the jobs are used as the work queue keys, a per request field would stop the queue from
+// de-duplicating the jobs submitted for the same pod.
+var jobSpanContexts sync.Map
+
+// activeJobContexts holds the context of the span of the jobs that are being processed by the worker routines
+var activeJobContexts sync.Map
+
+// WithJobContext propagates the span in the context to the job. If the job is already queued with a span, the
+// span of the first submitter is kept.
+func WithJobContext(ctx context.Context, job interface{}) {
+	if job == nil {
+		return
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	jobSpanContexts.LoadOrStore(job, spanContext)
+}
+
+// JobContext returns the context of the span processing the job. If the job is not being processed the returned
+// context carries the span the job was submitted with, if any.
+func JobContext(job interface{}) context.Context {
+	if ctx, ok := activeJobContexts.Load(job); ok {
+		return ctx.(context.Context)
+	}
+	return submittedJobContext(context.Background(), job)
+}
+
+// submittedJobContext returns the parent context with the span the job was submitted with as the current span
+func submittedJobContext(parent context.Context, job interface{}) context.Context {
+	if spanContext, ok := jobSpanContexts.Load(job); ok {
+		return trace.ContextWithSpanContext(parent, spanContext.(trace.SpanContext))
+	}
+	return parent
+}
+
+// forgetJobContext removes the span context of a job that is not going to be retried
+func forgetJobContext(job interface{}) {
+	jobSpanContexts.Delete(job)
+}
@@ -14,9 +14,11 @@
 package worker
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -85,3 +87,26 @@ func TestNewWarmPoolReSyncJob(t *testing.T) {
 	assert.Equal(t, OperationReSyncPool, WarmPoolJob.Operations)
 	assert.Equal(t, nodeName, WarmPoolJob.NodeName)
 }
+
+// TestWithJobContext tests that the span of the first submitter is kept for a job and that jobs submitted without
+// a span have no span context
+func TestWithJobContext(t *testing.T) {
+	job := NewOnDemandCreateJob(podNamespace, podName, reqCount)
+	defer forgetJobContext(job)
+
+	assert.False(t, trace.SpanContextFromContext(JobContext(job)).IsValid())
+
+	first := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled})
+	second := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{2}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled})
+
+	WithJobContext(trace.ContextWithSpanContext(context.Background(), first), job)
+	WithJobContext(trace.ContextWithSpanContext(context.Background(), second), job)
+	// A job with equal fields is the same work queue key and shares the span
+	assert.Equal(t, first, trace.SpanContextFromContext(
+		JobContext(NewOnDemandCreateJob(podNamespace, podName, reqCount))))
+
+	forgetJobContext(job)
+	assert.False(t, trace.SpanContextFromContext(JobContext(job)).IsValid())
+}
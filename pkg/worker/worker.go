@@ -16,10 +16,14 @@ package worker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/tracing"
+
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -159,16 +163,28 @@ func (w *worker) processNextItem() (cont bool) {
 
 	cont = true
 
-	if result, err := w.workerFunc(job); err != nil {
+	// Continue the trace of the request that submitted the job, the providers retrieve the span using JobContext
+	ctx, span := tracing.StartSpan(submittedJobContext(context.Background(), job), "worker.ProcessJob",
+		attribute.String("resource", w.resourceName),
+		attribute.String("job.type", fmt.Sprintf("%T", job)),
+		attribute.Int("job.retries", w.queue.NumRequeues(job)))
+	activeJobContexts.Store(job, ctx)
+	result, err := w.workerFunc(job)
+	activeJobContexts.Delete(job)
+	tracing.EndSpan(span, err)
+
+	if err != nil {
 		if w.queue.NumRequeues(job) >= w.maxRetriesOnErr {
 			log.Error(err, "exceeded maximum retries", "max retries", w.maxRetriesOnErr)
 			w.queue.Forget(job)
+			forgetJobContext(job)
 			jobsFailedCount.WithLabelValues(w.resourceName).Inc()
 			return
 		} else if apierrors.IsNotFound(err) {
 			//similar to upstream https://github.com/kubernetes-sigs/controller-runtime/issues/377#issue-426207628
 			log.Error(err, "won't requeue a not found errored job", "job", job)
 			w.queue.Forget(job)
+			forgetJobContext(job)
 			jobsNotFoundCount.WithLabelValues(w.resourceName).Inc()
 			return
 		}
@@ -184,6 +200,7 @@ func (w *worker) processNextItem() (cont bool) {
 	log.V(1).Info("completed job successfully")
 
 	w.queue.Forget(job)
+	forgetJobContext(job)
 	jobsCompletedCount.WithLabelValues(w.resourceName).Inc()
 
 	return
@@ -21,6 +21,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -144,3 +147,43 @@ func TestWorker_SubmitJob_NotRequeueOnError(t *testing.T) {
 	assert.Equal(t, actualInqueue, invoked)
 	mu.RUnlock()
 }
+
+// TestWorker_SubmitJob_PropagatesSpan tests that the job is processed in a child span of the span it was submitted
+// with and the span is no longer associated with the job once the job completes
+func TestWorker_SubmitJob_PropagatesSpan(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	defer otel.SetTracerProvider(previous)
+
+	var processedSpanContext trace.SpanContext
+	workerFunc := func(job interface{}) (result ctrl.Result, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		processedSpanContext = trace.SpanContextFromContext(JobContext(job))
+		return ctrl.Result{}, nil
+	}
+
+	w := GetMockWorkerPool(ctx)
+	err := w.StartWorkerPool(workerFunc)
+	assert.NoError(t, err)
+
+	requestCtx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	job := NewOnDemandCreateJob(podNamespace, podName, reqCount)
+	WithJobContext(requestCtx, job)
+	w.SubmitJob(job)
+
+	time.Sleep((mockTimeToProcessWorkerFunc + bufferTimeBwWorkerFuncExecution) * time.Millisecond)
+
+	mu.RLock()
+	assert.Equal(t, span.SpanContext().TraceID(), processedSpanContext.TraceID())
+	assert.NotEqual(t, span.SpanContext().SpanID(), processedSpanContext.SpanID())
+	mu.RUnlock()
+
+	_, found := jobSpanContexts.Load(job)
+	assert.False(t, found)
+}
@@ -26,3 +26,5 @@ mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/pool/mock_p
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/resource/mock_resources.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource ResourceManager
 # package condition mocks
 mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/condition/mock_condition.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition Conditions
+# package shard mocks
+mockgen -destination=../mocks/amazon-vcp-resource-controller-k8s/pkg/shard/mock_shard.go github.com/aws/amazon-vpc-resource-controller-k8s/pkg/shard Sharder
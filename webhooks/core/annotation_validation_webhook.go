@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
@@ -94,7 +95,7 @@ func (a *AnnotationValidator) handleCreate(req admission.Request) admission.Resp
 	}
 	// The annotation is added by vpc-resource-controller which will come as an update event
 	// so we should block all request on create event
-	for _, annotationKey := range a.getAnnotationKeysToBeValidated() {
+	for _, annotationKey := range a.getAnnotationKeysToBeValidated(pod) {
 		if val, ok := pod.Annotations[annotationKey]; ok {
 			a.Log.Info("blocking request", "event", "create",
 				"annotation key", annotationKey, "annotation value", val)
@@ -102,6 +103,12 @@ func (a *AnnotationValidator) handleCreate(req admission.Request) admission.Resp
 				fmt.Sprintf("pod cannot be created with %s annotation", annotationKey))
 		}
 	}
+	if val, ok := pod.Annotations[config.BranchENISubnetsAnnotation]; ok {
+		if _, err := config.ParseBranchENISubnets(val); err != nil {
+			a.Log.Info("blocking request", "event", "create", "error", err)
+			return admission.Denied(err.Error())
+		}
+	}
 	return admission.Allowed("")
 }
 
@@ -116,18 +123,19 @@ func (a *AnnotationValidator) handleUpdate(req admission.Request) admission.Resp
 	}
 	logger := a.Log.WithValues("name", pod.Name, "namespace", pod.Namespace, "uid", pod.UID)
 
-	// Block any update on Fargate SGP Annotation Key. The Fargate Security Group Annotation is
-	// added by the mutating WebHook on Create Event.
-	if pod.Annotations[FargatePodSGAnnotationKey] !=
-		oldPod.Annotations[FargatePodSGAnnotationKey] {
-		logger.Info("denying annotation", "username", req.UserInfo.Username,
-			"annotation key", FargatePodSGAnnotationKey)
-		return admission.Denied("annotation is not set by mutating webhook")
+	// Block any update on Fargate SGP Annotation Keys. The Fargate Security Group Annotation and the
+	// matching Security Group Policies Annotation are added by the mutating WebHook on Create Event.
+	for _, annotationKey := range []string{FargatePodSGAnnotationKey, config.SecurityGroupPoliciesAnnotation} {
+		if pod.Annotations[annotationKey] != oldPod.Annotations[annotationKey] {
+			logger.Info("denying annotation", "username", req.UserInfo.Username,
+				"annotation key", annotationKey)
+			return admission.Denied("annotation is not set by mutating webhook")
+		}
 	}
 
 	// This will block any update on the specific annotation from non vpc resource controller
 	// service accounts
-	for _, annotationKey := range a.getAnnotationKeysToBeValidated() {
+	for _, annotationKey := range a.getAnnotationKeysToBeValidated(pod, oldPod) {
 		if pod.Annotations[annotationKey] != oldPod.Annotations[annotationKey] {
 			// Checking for two users, as the Service Account used by controller was changed
 			// after first release.
@@ -139,13 +147,31 @@ func (a *AnnotationValidator) handleUpdate(req admission.Request) admission.Resp
 			}
 		}
 	}
+
+	// The branch ENI subnets are set by the user, only their format is validated
+	if val, ok := pod.Annotations[config.BranchENISubnetsAnnotation]; ok &&
+		val != oldPod.Annotations[config.BranchENISubnetsAnnotation] {
+		if _, err := config.ParseBranchENISubnets(val); err != nil {
+			logger.Info("denying annotation", "annotation key", config.BranchENISubnetsAnnotation, "error", err)
+			return admission.Denied(err.Error())
+		}
+	}
 	return admission.Allowed("")
 }
 
-// getAnnotationKeysToBeValidated returns the list of
-func (a *AnnotationValidator) getAnnotationKeysToBeValidated() []string {
-	// Pod ENI annotation is validated by default
-	annotationsToValidate := []string{config.ResourceNamePodENI}
+// getAnnotationKeysToBeValidated returns the list of annotations set by the controller on the given pods
+func (a *AnnotationValidator) getAnnotationKeysToBeValidated(pods ...*corev1.Pod) []string {
+	// Pod ENI and Elastic IP annotations are validated by default
+	annotationsToValidate := []string{config.ResourceNamePodENI, config.ResourceNameElasticIP}
+	// The dedicated ENI annotations are named after the network card of the ENIs
+	for _, pod := range pods {
+		for annotationKey := range pod.Annotations {
+			if config.IsNetworkCardResourceName(annotationKey) &&
+				!slices.Contains(annotationsToValidate, annotationKey) {
+				annotationsToValidate = append(annotationsToValidate, annotationKey)
+			}
+		}
+	}
 	if a.Condition.IsWindowsIPAMEnabled() {
 		// Windows IPv4 Annotation is validated if feature is enabled, as the older controller could
 		// be installed on Customer Data Plane and new controller should not block it's annotations
@@ -99,6 +99,26 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 	fargatePodWithDifferentAnnotationRaw, err := json.Marshal(fargatePodWithDifferentAnnotation)
 	assert.NoError(t, err)
 
+	fargatePodWithDifferentPolicies := fargatePodWithAnnotation.DeepCopy()
+	fargatePodWithDifferentPolicies.Annotations[config.SecurityGroupPoliciesAnnotation] = "other-policy"
+	fargatePodWithDifferentPoliciesRaw, err := json.Marshal(fargatePodWithDifferentPolicies)
+	assert.NoError(t, err)
+
+	podWithNetworkCardAnnotation := basePod.DeepCopy()
+	podWithNetworkCardAnnotation.Annotations[config.NetworkCardResourceName(1)] = "annotation-value"
+	podWithNetworkCardAnnotationRaw, err := json.Marshal(podWithNetworkCardAnnotation)
+	assert.NoError(t, err)
+
+	podWithBranchSubnets := basePod.DeepCopy()
+	podWithBranchSubnets.Annotations[config.BranchENISubnetsAnnotation] = "subnet-123,tag:routing=strict"
+	podWithBranchSubnetsRaw, err := json.Marshal(podWithBranchSubnets)
+	assert.NoError(t, err)
+
+	podWithInvalidBranchSubnets := basePod.DeepCopy()
+	podWithInvalidBranchSubnets.Annotations[config.BranchENISubnetsAnnotation] = "10.0.0.0/24"
+	podWithInvalidBranchSubnetsRaw, err := json.Marshal(podWithInvalidBranchSubnets)
+	assert.NoError(t, err)
+
 	test := []struct {
 		name           string
 		req            []admission.Request // Club tests with similar response & diff request in 1 test
@@ -335,6 +355,45 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 				mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(true)
 			},
 		},
+		{
+			name: "[create/update] network card annotation set or deleted by unauthorized user, deny request",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Create,
+						Object: runtime.RawExtension{
+							Raw:    podWithNetworkCardAnnotationRaw,
+							Object: podWithNetworkCardAnnotation,
+						},
+					},
+				},
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						UserInfo:  v1.UserInfo{Username: "some unauthorized user"},
+						Operation: admissionv1.Update,
+						Object: runtime.RawExtension{
+							Raw:    podWithoutAnnotationRaw,
+							Object: podWithoutAnnotation,
+						},
+						OldObject: runtime.RawExtension{
+							Raw:    podWithNetworkCardAnnotationRaw,
+							Object: podWithNetworkCardAnnotation,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Code: http.StatusForbidden,
+					},
+				},
+			},
+			mockInvocation: func(mock MockAnnotationWebHook) {
+				mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
+			},
+		},
 		{
 			name: "[update] annotation updated by unauthorized user, deny request",
 			req: []admission.Request{
@@ -475,6 +534,32 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[update] fargate security group policies annotation updated during UPDATE event, deny request",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Update,
+						Object: runtime.RawExtension{
+							Raw:    fargatePodWithDifferentPoliciesRaw,
+							Object: fargatePodWithDifferentPolicies,
+						},
+						OldObject: runtime.RawExtension{
+							Raw:    fargatePodWithAnnotationRaw,
+							Object: fargatePodWithAnnotation,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Code: http.StatusForbidden,
+					},
+				},
+			},
+		},
 		{
 			name: "[update] fargate pod-sg annotation deleted during UPDATE event, deny request",
 			req: []admission.Request{
@@ -501,6 +586,84 @@ func TestAnnotationValidator_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[create/update] valid branch eni subnets annotation, allow request",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Create,
+						Object: runtime.RawExtension{
+							Raw:    podWithBranchSubnetsRaw,
+							Object: podWithBranchSubnets,
+						},
+					},
+				},
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Update,
+						UserInfo:  v1.UserInfo{Username: "some user"},
+						Object: runtime.RawExtension{
+							Raw:    podWithBranchSubnetsRaw,
+							Object: podWithBranchSubnets,
+						},
+						OldObject: runtime.RawExtension{
+							Raw:    podWithoutAnnotationRaw,
+							Object: podWithoutAnnotation,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: true,
+					Result: &metav1.Status{
+						Code: http.StatusOK,
+					},
+				},
+			},
+			mockInvocation: func(mock MockAnnotationWebHook) {
+				mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
+			},
+		},
+		{
+			name: "[create/update] invalid branch eni subnets annotation, deny request",
+			req: []admission.Request{
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Create,
+						Object: runtime.RawExtension{
+							Raw:    podWithInvalidBranchSubnetsRaw,
+							Object: podWithInvalidBranchSubnets,
+						},
+					},
+				},
+				{
+					AdmissionRequest: admissionv1.AdmissionRequest{
+						Operation: admissionv1.Update,
+						UserInfo:  v1.UserInfo{Username: "some user"},
+						Object: runtime.RawExtension{
+							Raw:    podWithInvalidBranchSubnetsRaw,
+							Object: podWithInvalidBranchSubnets,
+						},
+						OldObject: runtime.RawExtension{
+							Raw:    podWithBranchSubnetsRaw,
+							Object: podWithBranchSubnets,
+						},
+					},
+				},
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Code: http.StatusForbidden,
+					},
+				},
+			},
+			mockInvocation: func(mock MockAnnotationWebHook) {
+				mock.MockCondition.EXPECT().IsWindowsIPAMEnabled().Return(false)
+			},
+		},
 		{
 			name: "[delete] delete, allow request",
 			req: []admission.Request{
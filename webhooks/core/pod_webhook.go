@@ -16,6 +16,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -28,6 +29,8 @@ import (
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/condition"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
 	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+	rcResource "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 )
 
@@ -35,30 +38,48 @@ const (
 	DefaultResourceLimit         = "1"
 	FargatePodSGAnnotationKey    = "fargate.amazonaws.com/pod-sg"
 	FargatePodIdentifierLabelKey = "eks.amazonaws.com/fargate-profile"
+	// FargateMaxSecurityGroups is the maximum number of security groups of a Fargate pod
+	FargateMaxSecurityGroups = 5
+	// PodWebhookFailClosedLabel is the namespace label selecting the fail closed pod mutating webhook
+	PodWebhookFailClosedLabel = "vpc.amazonaws.com/pod-webhook-fail-closed"
 )
 
-// +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,matchPolicy=Equivalent,failurePolicy=ignore,groups="",resources=pods,verbs=create,versions=v1,name=mpod.vpc.k8s.aws,sideEffects=None,admissionReviewVersions=v1
+// The pods of the namespaces labeled with PodWebhookFailClosedLabel are mutated by the fail closed webhook, they are
+// rejected when the webhook is unavailable instead of being admitted without the pod-eni resource. The namespace
+// selectors of both webhooks are set by config/webhook/namespace_selector_patch.yaml
+// +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,matchPolicy=Equivalent,failurePolicy=ignore,groups="",resources=pods,verbs=create,versions=v1,name=mpod.vpc.k8s.aws,sideEffects=NoneOnDryRun,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,matchPolicy=Equivalent,failurePolicy=fail,groups="",resources=pods,verbs=create,versions=v1,name=mpod-fail-closed.vpc.k8s.aws,sideEffects=NoneOnDryRun,admissionReviewVersions=v1
 
 // PodResourceInjector injects resources into Pods
 type PodMutationWebHook struct {
 	decoder   admission.Decoder
 	SGPAPI    utils.SecurityGroupForPodsAPI
+	K8sAPI    k8s.K8sWrapper
 	Log       logr.Logger
 	Condition condition.Conditions
+	// FargateDefaultSecurityGroups are the security groups of the Fargate pods not matching any SecurityGroupPolicy,
+	// the Fargate pods get the security groups of their profile if empty
+	FargateDefaultSecurityGroups []string
+	// InjectionRules are the rules of the additional registered resources injected into the Linux pods
+	InjectionRules map[string]rcResource.InjectionRule
 }
 
 func NewPodMutationWebHook(
 	sgpAPI utils.SecurityGroupForPodsAPI,
+	k8sAPI k8s.K8sWrapper,
+	fargateDefaultSecurityGroups []string,
 	log logr.Logger,
 	condition condition.Conditions,
 	d admission.Decoder,
 	healthzHandler *rcHealthz.HealthzHandler,
 ) *PodMutationWebHook {
 	podWebhook := &PodMutationWebHook{
-		SGPAPI:    sgpAPI,
-		Log:       log,
-		Condition: condition,
-		decoder:   d,
+		SGPAPI:                       sgpAPI,
+		K8sAPI:                       k8sAPI,
+		FargateDefaultSecurityGroups: fargateDefaultSecurityGroups,
+		Log:                          log,
+		Condition:                    condition,
+		decoder:                      d,
 	}
 	// add health check on subpath for pod mutation webhook
 	healthzHandler.AddControllersHealthCheckers(
@@ -125,22 +146,37 @@ func WhichPod(pod *corev1.Pod) PodType {
 
 // HandleFargatePod mutates the Fargate Pod if the Pod Matches a SGP. This also acts like
 // a validation WebHook by removing any existing Annotation on the Pod on Create Event.
+// The Pod not matching any SGP gets the default Fargate security groups if configured.
 func (i *PodMutationWebHook) HandleFargatePod(req admission.Request, pod *corev1.Pod,
 	log logr.Logger) (response admission.Response) {
-	sgList, err := i.SGPAPI.GetMatchingSecurityGroupForPods(pod)
+	policies, sgList, err := i.SGPAPI.GetMatchingSecurityGroupPoliciesForPods(pod)
 	if err != nil {
 		i.Log.Error(err, "failed to get matching SGP for Pods",
 			"namespace", pod.Namespace, "name", pod.Name)
 		return admission.Denied("Failed to get Matching SGP for Pods, rejecting event")
 	}
 
+	if len(sgList) == 0 && len(i.FargateDefaultSecurityGroups) > 0 {
+		sgList = i.FargateDefaultSecurityGroups
+	}
+
+	if len(sgList) > FargateMaxSecurityGroups {
+		log.Info("denying Fargate pod matching too many security groups",
+			"security groups", sgList, "security group policies", policies)
+		return admission.Denied(fmt.Sprintf("Fargate pod can have up to %d security groups, the pod "+
+			"matched %d security groups from SecurityGroupPolicy %v", FargateMaxSecurityGroups, len(sgList), policies))
+	}
+
 	switch len(sgList) {
 	case 0:
 		// If Pod is created, with the annotation then this event should be rejected. Only
 		// the controller is allowed to modify this key. This webhook blocks such Create
 		// Events and the validation Webhook blocks all Update events on this key.
-		if _, ok := pod.Annotations[FargatePodSGAnnotationKey]; ok {
+		_, hasSGAnnotation := pod.Annotations[FargatePodSGAnnotationKey]
+		_, hasSGPAnnotation := pod.Annotations[config.SecurityGroupPoliciesAnnotation]
+		if hasSGAnnotation || hasSGPAnnotation {
 			delete(pod.Annotations, FargatePodSGAnnotationKey)
+			delete(pod.Annotations, config.SecurityGroupPoliciesAnnotation)
 			log.Info("Overriding pod-sg annotation added outside of mutating webhook",
 				" Annotation", pod.Annotations)
 			response = i.GetPatchResponse(req, pod, log)
@@ -150,15 +186,38 @@ func (i *PodMutationWebHook) HandleFargatePod(req admission.Request, pod *corev1
 		}
 	default:
 		// If more than 1 SG match for the Pod then add all matching SG to the Annotation
+		// along with the SGP they matched for auditing
 		pod.Annotations[FargatePodSGAnnotationKey] = strings.Join(sgList, ",")
+		var message string
+		if len(policies) > 0 {
+			pod.Annotations[config.SecurityGroupPoliciesAnnotation] = strings.Join(policies, ",")
+			message = fmt.Sprintf("Pod will get the following Security Groups %v from SecurityGroupPolicy %v",
+				sgList, policies)
+		} else {
+			delete(pod.Annotations, config.SecurityGroupPoliciesAnnotation)
+			message = fmt.Sprintf("Pod will get the default Fargate Security Groups %v as the pod didn't match "+
+				"any SecurityGroupPolicy", sgList)
+		}
 		log.Info("annotating Fargate pod with matching security groups",
 			"Annotations", pod.Annotations)
+		i.broadcastPodEvent(req, pod, utils.SecurityGroupRequestedReason, message, corev1.EventTypeNormal)
 		response = i.GetPatchResponse(req, pod, log)
 	}
 
 	return response
 }
 
+// broadcastPodEvent sends the event on the Pod being created. The event is skipped for the dry run requests, as the
+// webhook has no side effects on dry run, and for the Pod created with a generated name, as the name is only known
+// once the Pod is persisted.
+func (i *PodMutationWebHook) broadcastPodEvent(req admission.Request, pod *corev1.Pod, reason, message,
+	eventType string) {
+	if i.K8sAPI == nil || pod.Name == "" || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	i.K8sAPI.BroadcastEvent(pod, reason, message, eventType)
+}
+
 // HandleWindowsPod mutates the Windows Pod by injecting a secondary IPv4 Address
 // Limit to the Pod when the Windows IPAM feature is enabled via ConfigMap
 func (i *PodMutationWebHook) HandleWindowsPod(req admission.Request, pod *corev1.Pod,
@@ -168,12 +227,16 @@ func (i *PodMutationWebHook) HandleWindowsPod(req admission.Request, pod *corev1
 		return admission.Allowed("")
 	}
 
-	i.Log.Info("injecting resource to the first container of the pod",
+	container, err := getInjectionContainer(pod)
+	if err != nil {
+		log.Info("failed to find the container to inject the resource to", "error", err.Error())
+		return admission.Denied(err.Error())
+	}
+
+	i.Log.Info("injecting resource to the container of the pod", "container", container.Name,
 		"resource name", config.ResourceNameIPAddress, "resource count", DefaultResourceLimit)
-	pod.Spec.Containers[0].
-		Resources.Limits[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
-	pod.Spec.Containers[0].
-		Resources.Requests[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
+	container.Resources.Limits[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
+	container.Resources.Requests[config.ResourceNameIPAddress] = resource.MustParse(DefaultResourceLimit)
 
 	return i.GetPatchResponse(req, pod, log)
 }
@@ -189,29 +252,85 @@ func (i *PodMutationWebHook) HandleLinuxPod(req admission.Request, pod *corev1.P
 			"namespace", pod.Namespace, "name", pod.Name)
 		return admission.Denied("Failed to get Matching SGP for Pods, rejecting event")
 	}
-	if len(sgList) == 0 {
+
+	injectedResources := make(map[corev1.ResourceName]int64)
+	if len(sgList) > 0 {
+		injectedResources[config.ResourceNamePodENI] = 1
+	}
+	for resourceName, rule := range i.InjectionRules {
+		if quantity := rule(pod); quantity > 0 {
+			injectedResources[corev1.ResourceName(resourceName)] = quantity
+		}
+	}
+	if len(injectedResources) == 0 {
 		return admission.Allowed("Pod didn't match any SGP")
 	}
 
-	log.Info("injecting resource to the first container of the pod", "resource name",
-		config.ResourceNamePodENI, "resource count", DefaultResourceLimit)
+	container, err := getInjectionContainer(pod)
+	if err != nil {
+		log.Info("failed to find the container to inject the resource to", "error", err.Error())
+		return admission.Denied(err.Error())
+	}
+
+	for resourceName, quantity := range injectedResources {
+		log.Info("injecting resource to the container of the pod", "container", container.Name,
+			"resource name", resourceName, "resource count", quantity)
 
-	pod.Spec.Containers[0].Resources.
-		Limits[config.ResourceNamePodENI] = resource.MustParse(DefaultResourceLimit)
-	pod.Spec.Containers[0].Resources.
-		Requests[config.ResourceNamePodENI] = resource.MustParse(DefaultResourceLimit)
+		container.Resources.Limits[resourceName] = *resource.NewQuantity(quantity, resource.DecimalSI)
+		container.Resources.Requests[resourceName] = *resource.NewQuantity(quantity, resource.DecimalSI)
+	}
 
 	return i.GetPatchResponse(req, pod, log)
 }
 
-// InitializeEmptyFields inits the empty fields in the request
-func (i *PodMutationWebHook) InitializeEmptyFields(req admission.Request, pod *corev1.Pod) {
-	if pod.Spec.Containers[0].Resources.Limits == nil {
-		pod.Spec.Containers[0].Resources.Limits = make(corev1.ResourceList)
+// getInjectionContainer returns the container the VPC resources are injected into, the container named by the
+// resource injection container annotation or the first container of the pod. The resources can't be injected
+// into a regular init container since it exits before the containers start.
+func getInjectionContainer(pod *corev1.Pod) (*corev1.Container, error) {
+	container, err := findInjectionContainer(pod)
+	if err != nil {
+		return nil, err
 	}
-	if pod.Spec.Containers[0].Resources.Requests == nil {
-		pod.Spec.Containers[0].Resources.Requests = make(corev1.ResourceList)
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = make(corev1.ResourceList)
+	}
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = make(corev1.ResourceList)
+	}
+	return container, nil
+}
+
+// findInjectionContainer returns the container named by the annotation, or the first container by default
+func findInjectionContainer(pod *corev1.Pod) (*corev1.Container, error) {
+	name, ok := pod.Annotations[config.ResourceInjectionContainerAnnotation]
+	if !ok {
+		if len(pod.Spec.Containers) == 0 {
+			return nil, fmt.Errorf("pod has no container to inject the resources to")
+		}
+		return &pod.Spec.Containers[0], nil
 	}
+	for idx := range pod.Spec.Containers {
+		if pod.Spec.Containers[idx].Name == name {
+			return &pod.Spec.Containers[idx], nil
+		}
+	}
+	for idx := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[idx]
+		if container.Name != name {
+			continue
+		}
+		if container.RestartPolicy == nil || *container.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+			return nil, fmt.Errorf("annotation %s references the init container %s, only the containers and "+
+				"the sidecar init containers are supported", config.ResourceInjectionContainerAnnotation, name)
+		}
+		return container, nil
+	}
+	return nil, fmt.Errorf("annotation %s references the container %s which doesn't exist",
+		config.ResourceInjectionContainerAnnotation, name)
+}
+
+// InitializeEmptyFields inits the empty fields in the request
+func (i *PodMutationWebHook) InitializeEmptyFields(req admission.Request, pod *corev1.Pod) {
 	// To avoid empty string namespace failing client retrieving service account later.
 	if pod.Namespace == "" {
 		pod.Namespace = req.Namespace
@@ -230,8 +349,7 @@ func (i *PodMutationWebHook) GetPatchResponse(req admission.Request, pod *corev1
 		i.Log.Error(err, "failed to convert Pod to JSON")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
-	log.V(1).Info("mutated the pod with resource limit",
-		"Injected Limits", pod.Spec.Containers[0].Resources.Limits)
+	log.V(1).Info("mutated the pod with resource limit")
 
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
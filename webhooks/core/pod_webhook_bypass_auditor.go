@@ -0,0 +1,296 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	vpcresourcesv1beta1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1beta1"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
+
+// PodWebhookBypassAction is the action taken on the pods that bypassed the pod mutating webhook
+type PodWebhookBypassAction string
+
+const (
+	// PodWebhookBypassActionCondition sets the WebhookBypassed condition on the pod and sends an event
+	PodWebhookBypassActionCondition PodWebhookBypassAction = "condition"
+	// PodWebhookBypassActionEvict evicts the pod so it's re-created by its controller through the webhook, the pods
+	// without controller get the condition instead
+	PodWebhookBypassActionEvict PodWebhookBypassAction = "evict"
+
+	reasonSecurityGroupPolicyNotEnforced = "SecurityGroupPolicyNotEnforced"
+)
+
+var (
+	prometheusRegistered = false
+
+	webhookBypassedPodCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "pod_webhook_bypassed_pod_count",
+			Help: "The number of pods matching a SecurityGroupPolicy without the pod-eni resource found by the last audit",
+		},
+	)
+
+	webhookBypassedPodActionCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pod_webhook_bypassed_pod_action_count",
+			Help: "The number of pods matching a SecurityGroupPolicy without the pod-eni resource marked or evicted",
+		},
+		[]string{"action"},
+	)
+)
+
+func prometheusRegister() {
+	metrics.Registry.MustRegister(
+		webhookBypassedPodCount,
+		webhookBypassedPodActionCount)
+
+	prometheusRegistered = true
+}
+
+// PodWebhookBypassAuditor periodically looks for the pods that were admitted while the pod mutating webhook was
+// unavailable. These pods match a SecurityGroupPolicy but don't request the pod-eni resource, so they silently use
+// the security groups of their node.
+type PodWebhookBypassAuditor struct {
+	Log logr.Logger
+	// Reader reads the pods, the service accounts and the SecurityGroupPolicy from the API Server, the pods are not
+	// cached by the manager
+	Reader client.Reader
+	PodAPI pod.PodClientAPIWrapper
+	K8sAPI k8s.K8sWrapper
+	// Action is the action taken on the pods that bypassed the webhook, defaults to PodWebhookBypassActionCondition
+	Action PodWebhookBypassAction
+	// Interval is the time between two audits
+	Interval time.Duration
+	// PageLimit is the number of pods listed per request
+	PageLimit int
+	// IsActive returns false if the audit must be skipped because another replica runs it, the audit always runs if
+	// it's not set
+	IsActive func() bool
+}
+
+func (a *PodWebhookBypassAuditor) SetupWithManager(mgr ctrl.Manager, healthzHandler *rcHealthz.HealthzHandler) error {
+	switch a.Action {
+	case "":
+		a.Action = PodWebhookBypassActionCondition
+	case PodWebhookBypassActionCondition, PodWebhookBypassActionEvict:
+	default:
+		return fmt.Errorf("invalid pod webhook bypass action %q, must be one of %s or %s", a.Action,
+			PodWebhookBypassActionCondition, PodWebhookBypassActionEvict)
+	}
+	if a.Interval <= 0 {
+		return fmt.Errorf("pod webhook bypass audit interval must be positive")
+	}
+
+	if !prometheusRegistered {
+		prometheusRegister()
+	}
+
+	healthzHandler.AddControllersHealthCheckers(
+		map[string]healthz.Checker{
+			"health-pod-webhook-bypass-auditor": rcHealthz.SimplePing("pod webhook bypass auditor", a.Log),
+		},
+	)
+
+	return mgr.Add(a)
+}
+
+// Start audits the pods after fixed time intervals till the context is cancelled
+func (a *PodWebhookBypassAuditor) Start(ctx context.Context) error {
+	a.Log.Info("starting pod webhook bypass audit routine", "action", a.Action, "interval", a.Interval)
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if a.IsActive != nil && !a.IsActive() {
+				a.Log.V(1).Info("skipping pod webhook bypass audit run by another replica")
+				continue
+			}
+			if err := a.audit(ctx); err != nil {
+				a.Log.Error(err, "failed to audit the pods bypassing the pod mutating webhook")
+			}
+		}
+	}
+}
+
+// audit looks for the pods bypassing the webhook in the namespaces having valid SecurityGroupPolicy
+func (a *PodWebhookBypassAuditor) audit(ctx context.Context) error {
+	sgpList := &vpcresourcesv1beta1.SecurityGroupPolicyList{}
+	if err := a.Reader.List(ctx, sgpList); err != nil {
+		return fmt.Errorf("listing security group policies, %w", err)
+	}
+
+	policies := make(map[string][]vpcresourcesv1beta1.SecurityGroupPolicy)
+	for _, sgp := range sgpList.Items {
+		if len(utils.ValidateSecurityGroupPolicySpec(sgp.Spec)) == 0 {
+			policies[sgp.Namespace] = append(policies[sgp.Namespace], sgp)
+		}
+	}
+
+	bypassed := 0
+	for namespace, namespacePolicies := range policies {
+		count, err := a.auditNamespace(ctx, namespace, namespacePolicies)
+		if err != nil {
+			return err
+		}
+		bypassed += count
+	}
+
+	webhookBypassedPodCount.Set(float64(bypassed))
+	if bypassed > 0 {
+		a.Log.Info("found pods bypassing the pod mutating webhook", "count", bypassed)
+	}
+	return nil
+}
+
+// auditNamespace lists the pods of the namespace page by page and handles the pods bypassing the webhook, it
+// returns the number of these pods
+func (a *PodWebhookBypassAuditor) auditNamespace(ctx context.Context, namespace string,
+	policies []vpcresourcesv1beta1.SecurityGroupPolicy) (int, error) {
+	serviceAccounts := make(map[string]*corev1.ServiceAccount)
+	bypassed := 0
+
+	continueToken := ""
+	for {
+		podList := &corev1.PodList{}
+		if err := a.Reader.List(ctx, podList, client.InNamespace(namespace), client.Limit(int64(a.PageLimit)),
+			client.Continue(continueToken)); err != nil {
+			return bypassed, fmt.Errorf("listing pods in namespace %s, %w", namespace, err)
+		}
+
+		for idx := range podList.Items {
+			pod := &podList.Items[idx]
+			if !isAuditedPod(pod) {
+				continue
+			}
+			sa, err := a.getServiceAccount(ctx, namespace, pod.Spec.ServiceAccountName, serviceAccounts)
+			if err != nil {
+				a.Log.Error(err, "failed to get the service account of the pod", "namespace", namespace,
+					"name", pod.Name)
+				continue
+			}
+			if matched := getBypassedPolicies(pod, sa, policies); len(matched) > 0 {
+				bypassed++
+				a.handleBypassedPod(pod, matched)
+			}
+		}
+
+		if continueToken = podList.Continue; continueToken == "" {
+			return bypassed, nil
+		}
+	}
+}
+
+// isAuditedPod returns true for the running or pending Linux pods without the pod-eni resource
+func isAuditedPod(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded ||
+		pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	return WhichPod(pod) == Linux && !utils.PodHasENIRequest(pod)
+}
+
+// getServiceAccount returns the service account from the API Server once per audit of the namespace, an empty
+// service account is returned if it doesn't exist
+func (a *PodWebhookBypassAuditor) getServiceAccount(ctx context.Context, namespace, name string,
+	serviceAccounts map[string]*corev1.ServiceAccount) (*corev1.ServiceAccount, error) {
+	if sa, ok := serviceAccounts[name]; ok {
+		return sa, nil
+	}
+	sa := &corev1.ServiceAccount{}
+	if err := a.Reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, sa); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	serviceAccounts[name] = sa
+	return sa, nil
+}
+
+// getBypassedPolicies returns the names of the policies matching the pod that were created before the pod, the
+// pod didn't get the pod-eni resource for the policies created after it
+func getBypassedPolicies(pod *corev1.Pod, sa *corev1.ServiceAccount,
+	policies []vpcresourcesv1beta1.SecurityGroupPolicy) []string {
+	var matched []string
+	for _, sgp := range policies {
+		if !sgp.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		if ok, err := utils.SecurityGroupPolicyMatches(sgp.Spec, pod, sa); err == nil && ok {
+			matched = append(matched, sgp.Name)
+		}
+	}
+	return matched
+}
+
+// handleBypassedPod evicts the pod or sets the WebhookBypassed condition on the pod, along with a warning event
+func (a *PodWebhookBypassAuditor) handleBypassedPod(pod *corev1.Pod, policies []string) {
+	log := a.Log.WithValues("namespace", pod.Namespace, "name", pod.Name, "security group policies", policies)
+	message := fmt.Sprintf("Pod matches SecurityGroupPolicy %v but was admitted without the %s resource, "+
+		"the pod uses the security groups of the node", policies, config.ResourceNamePodENI)
+
+	if a.Action == PodWebhookBypassActionEvict && metav1.GetControllerOf(pod) != nil {
+		if err := a.PodAPI.EvictPod(pod); err != nil {
+			log.Error(err, "failed to evict the pod bypassing the pod mutating webhook")
+			return
+		}
+		log.Info("evicted the pod bypassing the pod mutating webhook")
+		a.K8sAPI.BroadcastEvent(pod, utils.WebhookBypassedReason, message+", evicting the pod",
+			corev1.EventTypeWarning)
+		webhookBypassedPodActionCount.WithLabelValues(string(PodWebhookBypassActionEvict)).Inc()
+		return
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == config.PodConditionWebhookBypassed && condition.Status == corev1.ConditionTrue {
+			return
+		}
+	}
+	if err := a.PodAPI.SetPodCondition(pod, corev1.PodCondition{
+		Type:    config.PodConditionWebhookBypassed,
+		Status:  corev1.ConditionTrue,
+		Reason:  reasonSecurityGroupPolicyNotEnforced,
+		Message: message,
+	}); err != nil {
+		log.Error(err, "failed to set the condition on the pod bypassing the pod mutating webhook")
+		return
+	}
+	log.Info("marked the pod bypassing the pod mutating webhook")
+	a.K8sAPI.BroadcastEvent(pod, utils.WebhookBypassedReason, message, corev1.EventTypeWarning)
+	webhookBypassedPodActionCount.WithLabelValues(string(PodWebhookBypassActionCondition)).Inc()
+}
@@ -0,0 +1,189 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	vpcresourcesv1beta1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1beta1"
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
+	mock_pod "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	auditNamespace = "audit"
+	sgpCreation    = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	auditSGP = &vpcresourcesv1beta1.SecurityGroupPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "sgp", Namespace: auditNamespace, CreationTimestamp: sgpCreation},
+		Spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+			PodSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			SecurityGroups: vpcresourcesv1beta1.GroupIds{Groups: []string{"sg-0123456789abcdef0"}},
+		},
+	}
+)
+
+func newAuditedPod(name string, created time.Duration, controlled bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         auditNamespace,
+			UID:               types.UID("uid-" + name),
+			Labels:            map[string]string{"app": "db"},
+			CreationTimestamp: metav1.NewTime(sgpCreation.Add(created)),
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "default",
+			Containers:         []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if controlled {
+		isController := true
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs",
+			UID: "rs-uid", Controller: &isController}}
+	}
+	return pod
+}
+
+func getAuditor(t *testing.T, ctrl *gomock.Controller, action PodWebhookBypassAction,
+	objects ...runtime.Object) (*PodWebhookBypassAuditor, *mock_pod.MockPodClientAPIWrapper, *mock_k8s.MockK8sWrapper) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, vpcresourcesv1beta1.AddToScheme(scheme))
+
+	podAPI := mock_pod.NewMockPodClientAPIWrapper(ctrl)
+	k8sAPI := mock_k8s.NewMockK8sWrapper(ctrl)
+	return &PodWebhookBypassAuditor{
+		Log:       zap.New(),
+		Reader:    fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		PodAPI:    podAPI,
+		K8sAPI:    k8sAPI,
+		Action:    action,
+		Interval:  time.Minute,
+		PageLimit: 100,
+	}, podAPI, k8sAPI
+}
+
+// TestPodWebhookBypassAuditor_Audit_Condition tests only the pods matching a policy created before them and
+// without the pod-eni resource get the condition and the event
+func TestPodWebhookBypassAuditor_Audit_Condition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	bypassed := newAuditedPod("bypassed", time.Minute, true)
+
+	mutated := newAuditedPod("mutated", time.Minute, true)
+	mutated.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		config.ResourceNamePodENI: resource.MustParse("1"),
+	}
+	createdBeforePolicy := newAuditedPod("created-before-policy", -time.Minute, true)
+	notMatching := newAuditedPod("not-matching", time.Minute, true)
+	notMatching.Labels = map[string]string{"app": "web"}
+	hostNetwork := newAuditedPod("host-network", time.Minute, true)
+	hostNetwork.Spec.HostNetwork = true
+	completed := newAuditedPod("completed", time.Minute, true)
+	completed.Status.Phase = corev1.PodSucceeded
+	alreadyMarked := newAuditedPod("already-marked", time.Minute, true)
+	alreadyMarked.Status.Conditions = []corev1.PodCondition{
+		{Type: config.PodConditionWebhookBypassed, Status: corev1.ConditionTrue},
+	}
+
+	auditor, podAPI, k8sAPI := getAuditor(t, ctrl, PodWebhookBypassActionCondition, auditSGP, bypassed, mutated,
+		createdBeforePolicy, notMatching, hostNetwork, completed, alreadyMarked)
+
+	podAPI.EXPECT().SetPodCondition(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(pod *corev1.Pod, condition corev1.PodCondition) error {
+			assert.Equal(t, bypassed.Name, pod.Name)
+			assert.Equal(t, corev1.PodConditionType(config.PodConditionWebhookBypassed), condition.Type)
+			assert.Equal(t, corev1.ConditionTrue, condition.Status)
+			return nil
+		})
+	k8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.WebhookBypassedReason, gomock.Any(),
+		corev1.EventTypeWarning)
+
+	assert.NoError(t, auditor.audit(context.TODO()))
+	assert.Equal(t, float64(2), testutil.ToFloat64(webhookBypassedPodCount))
+}
+
+// TestPodWebhookBypassAuditor_Audit_Evict tests the pods managed by a controller are evicted, and the other pods
+// get the condition
+func TestPodWebhookBypassAuditor_Audit_Evict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	controlled := newAuditedPod("controlled", time.Minute, true)
+	standalone := newAuditedPod("standalone", time.Minute, false)
+
+	auditor, podAPI, k8sAPI := getAuditor(t, ctrl, PodWebhookBypassActionEvict, auditSGP, controlled, standalone)
+
+	podAPI.EXPECT().EvictPod(gomock.Any()).DoAndReturn(func(pod *corev1.Pod) error {
+		assert.Equal(t, controlled.Name, pod.Name)
+		return nil
+	})
+	podAPI.EXPECT().SetPodCondition(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(pod *corev1.Pod, _ corev1.PodCondition) error {
+			assert.Equal(t, standalone.Name, pod.Name)
+			return nil
+		})
+	k8sAPI.EXPECT().BroadcastEvent(gomock.Any(), utils.WebhookBypassedReason, gomock.Any(),
+		corev1.EventTypeWarning).Times(2)
+
+	assert.NoError(t, auditor.audit(context.TODO()))
+}
+
+// TestPodWebhookBypassAuditor_Audit_EvictError tests no event is sent if the pod fails to be evicted
+func TestPodWebhookBypassAuditor_Audit_EvictError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auditor, podAPI, _ := getAuditor(t, ctrl, PodWebhookBypassActionEvict, auditSGP,
+		newAuditedPod("controlled", time.Minute, true))
+
+	podAPI.EXPECT().EvictPod(gomock.Any()).Return(fmt.Errorf("too many requests"))
+
+	assert.NoError(t, auditor.audit(context.TODO()))
+}
+
+// TestPodWebhookBypassAuditor_Audit_InvalidPolicy tests the pods are not audited against an invalid policy
+func TestPodWebhookBypassAuditor_Audit_InvalidPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invalidSGP := auditSGP.DeepCopy()
+	invalidSGP.Spec.SecurityGroups.Groups = nil
+
+	auditor, _, _ := getAuditor(t, ctrl, PodWebhookBypassActionCondition, invalidSGP,
+		newAuditedPod("bypassed", time.Minute, true))
+
+	assert.NoError(t, auditor.audit(context.TODO()))
+	assert.Equal(t, float64(0), testutil.ToFloat64(webhookBypassedPodCount))
+}
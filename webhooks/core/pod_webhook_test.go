@@ -21,8 +21,11 @@ import (
 	"testing"
 
 	mock_condition "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/condition"
+	mock_k8s "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/k8s"
 	mock_utils "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/utils"
 	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcResource "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/resource"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +36,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -47,6 +51,7 @@ var (
 
 type Mock struct {
 	SGPMock       *mock_utils.MockSecurityGroupForPodsAPI
+	K8sMock       *mock_k8s.MockK8sWrapper
 	ConditionMock *mock_condition.MockConditions
 }
 
@@ -141,9 +146,38 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 	sgpPodWithoutLimitsRaw, err := json.Marshal(sgpPodWithoutLimits)
 	assert.NoError(t, err)
 
+	// Security Group Pod injecting the resource to the second container
+	sgpPodSecondContainer := basePod.DeepCopy()
+	sgpPodSecondContainer.Spec.Containers = append(sgpPodSecondContainer.Spec.Containers, corev1.Container{Name: "app"})
+	sgpPodSecondContainer.Annotations[config.ResourceInjectionContainerAnnotation] = "app"
+	sgpPodSecondContainerRaw, err := json.Marshal(sgpPodSecondContainer)
+	assert.NoError(t, err)
+
+	// Security Group Pod injecting the resource to a sidecar init container
+	sidecarRestartPolicy := corev1.ContainerRestartPolicyAlways
+	sgpPodSidecar := basePod.DeepCopy()
+	sgpPodSidecar.Spec.InitContainers = []corev1.Container{{Name: "init"}, {Name: "proxy", RestartPolicy: &sidecarRestartPolicy}}
+	sgpPodSidecar.Annotations[config.ResourceInjectionContainerAnnotation] = "proxy"
+	sgpPodSidecarRaw, err := json.Marshal(sgpPodSidecar)
+	assert.NoError(t, err)
+
+	// Security Group Pod injecting the resource to a regular init container
+	sgpPodInitContainer := sgpPodSidecar.DeepCopy()
+	sgpPodInitContainer.Annotations[config.ResourceInjectionContainerAnnotation] = "init"
+	sgpPodInitContainerRaw, err := json.Marshal(sgpPodInitContainer)
+	assert.NoError(t, err)
+
+	// Security Group Pod injecting the resource to a container that doesn't exist
+	sgpPodMissingContainer := basePod.DeepCopy()
+	sgpPodMissingContainer.Annotations[config.ResourceInjectionContainerAnnotation] = "missing"
+	sgpPodMissingContainerRaw, err := json.Marshal(sgpPodMissingContainer)
+	assert.NoError(t, err)
+
 	test := []struct {
 		name           string
 		mockInvocation func(mock Mock)
+		defaultSGs     []string
+		injectionRules map[string]rcResource.InjectionRule
 		req            admission.Request
 		want           admission.Response
 	}{
@@ -233,6 +267,79 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[Linux] Pod matches SG and an injection rule of a registered resource",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodWithoutLimitsRaw,
+						Object: sgpPodWithoutLimits,
+					},
+				},
+			},
+			injectionRules: map[string]rcResource.InjectionRule{
+				"example.com/efa": func(_ *corev1.Pod) int64 { return 2 },
+				"example.com/eip": func(_ *corev1.Pod) int64 { return 0 },
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI,
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1", "example.com/efa": "2"},
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI,
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1", "example.com/efa": "2"},
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
+		{
+			name: "[Linux] Pod doesn't match SG but matches an injection rule of a registered resource",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodWithoutLimitsRaw,
+						Object: sgpPodWithoutLimits,
+					},
+				},
+			},
+			injectionRules: map[string]rcResource.InjectionRule{
+				"example.com/efa": func(_ *corev1.Pod) int64 { return 1 },
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return([]string{}, nil)
+			},
+
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      firstContainerPatchLimitURI,
+						Value:     map[string]interface{}{"example.com/efa": "1"},
+					},
+					{
+						Operation: "add",
+						Path:      firstContainerPatchRequestURI,
+						Value:     map[string]interface{}{"example.com/efa": "1"},
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
 		{
 			name: "[Linux] SGP returns error",
 			req: admission.Request{
@@ -264,7 +371,7 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 			mockInvocation: func(mock Mock) {
-				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(fargatePod)).Return([]string{}, nil)
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, []string{}, nil)
 			},
 
 			want: admission.Response{
@@ -284,7 +391,8 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 			mockInvocation: func(mock Mock) {
-				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(fargatePod)).Return([]string{"sg1"}, nil)
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return([]string{"sgp"}, []string{"sg1"}, nil)
+				mock.K8sMock.EXPECT().BroadcastEvent(gomock.Any(), utils.SecurityGroupRequestedReason, gomock.Any(), corev1.EventTypeNormal)
 			},
 
 			want: admission.Response{
@@ -294,6 +402,11 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 						Path:      "/metadata/annotations/" + jsonPointer(FargatePodSGAnnotationKey),
 						Value:     "sg1",
 					},
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations/" + jsonPointer(config.SecurityGroupPoliciesAnnotation),
+						Value:     "sgp",
+					},
 				},
 				AdmissionResponse: admissionv1.AdmissionResponse{
 					Allowed:   true,
@@ -312,7 +425,7 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 			mockInvocation: func(mock Mock) {
-				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, nil)
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, nil, nil)
 			},
 
 			want: admission.Response{
@@ -339,7 +452,42 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 			mockInvocation: func(mock Mock) {
-				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(fargatePod)).Return(sgList, nil)
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return([]string{"sgp"}, sgList, nil)
+				mock.K8sMock.EXPECT().BroadcastEvent(gomock.Any(), utils.SecurityGroupRequestedReason, gomock.Any(), corev1.EventTypeNormal)
+			},
+
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations/" + jsonPointer(FargatePodSGAnnotationKey),
+						Value:     strings.Join(sgList, ","),
+					},
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations/" + jsonPointer(config.SecurityGroupPoliciesAnnotation),
+						Value:     "sgp",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
+		{
+			name: "[Fargate] matching some SG on dry run, no event",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    fargatePodRaw,
+						Object: fargatePod,
+					},
+					DryRun: ptr.To(true),
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return([]string{"sgp"}, sgList, nil)
 			},
 
 			want: admission.Response{
@@ -349,6 +497,41 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 						Path:      "/metadata/annotations/" + jsonPointer(FargatePodSGAnnotationKey),
 						Value:     strings.Join(sgList, ","),
 					},
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations/" + jsonPointer(config.SecurityGroupPoliciesAnnotation),
+						Value:     "sgp",
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
+		{
+			name: "[Fargate] not matching any SG, gets the default SG",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    fargatePodRaw,
+						Object: fargatePod,
+					},
+				},
+			},
+			defaultSGs: []string{"sg-default"},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, nil, nil)
+				mock.K8sMock.EXPECT().BroadcastEvent(gomock.Any(), utils.SecurityGroupRequestedReason, gomock.Any(), corev1.EventTypeNormal)
+			},
+
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations/" + jsonPointer(FargatePodSGAnnotationKey),
+						Value:     "sg-default",
+					},
 				},
 				AdmissionResponse: admissionv1.AdmissionResponse{
 					Allowed:   true,
@@ -356,6 +539,27 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[Fargate] matching more SG than supported, should be denied",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    fargatePodRaw,
+						Object: fargatePod,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).
+					Return([]string{"sgp-1", "sgp-2"}, []string{"sg-1", "sg-2", "sg-3", "sg-4", "sg-5", "sg-6"}, nil)
+			},
+
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+				},
+			},
+		},
 		{
 			name: "[Fargate] SGP returns error",
 			req: admission.Request{
@@ -367,7 +571,7 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 			mockInvocation: func(mock Mock) {
-				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, mockErr)
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupPoliciesForPods(gomock.AssignableToTypeOf(fargatePod)).Return(nil, nil, mockErr)
 			},
 
 			want: admission.Response{
@@ -392,6 +596,108 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "[Linux] Pod matches SG with the annotation selecting the second container",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodSecondContainerRaw,
+						Object: sgpPodSecondContainer,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      "/spec/containers/1/resources/limits",
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+					{
+						Operation: "add",
+						Path:      "/spec/containers/1/resources/requests",
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
+		{
+			name: "[Linux] Pod matches SG with the annotation selecting a sidecar init container",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodSidecarRaw,
+						Object: sgpPodSidecar,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+			want: admission.Response{
+				Patches: []jsonpatch.JsonPatchOperation{
+					{
+						Operation: "add",
+						Path:      "/spec/initContainers/1/resources/limits",
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+					{
+						Operation: "add",
+						Path:      "/spec/initContainers/1/resources/requests",
+						Value:     map[string]interface{}{config.ResourceNamePodENI: "1"},
+					},
+				},
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed:   true,
+					PatchType: &jsonPatchType,
+				},
+			},
+		},
+		{
+			name: "[Linux] Pod matches SG with the annotation selecting a regular init container, should be denied",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodInitContainerRaw,
+						Object: sgpPodInitContainer,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+				},
+			},
+		},
+		{
+			name: "[Linux] Pod matches SG with the annotation selecting a missing container, should be denied",
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: runtime.RawExtension{
+						Raw:    sgpPodMissingContainerRaw,
+						Object: sgpPodMissingContainer,
+					},
+				},
+			},
+			mockInvocation: func(mock Mock) {
+				mock.SGPMock.EXPECT().GetMatchingSecurityGroupForPods(gomock.AssignableToTypeOf(sgpPod)).Return(sgList, nil)
+			},
+			want: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					Allowed: false,
+				},
+			},
+		},
 		{
 			name: "[Windows] with non-beta label, should be allowed",
 			req: admission.Request{
@@ -517,13 +823,17 @@ func TestPodMutationWebHook_Handle(t *testing.T) {
 			ctx := context.TODO()
 			mock := Mock{
 				SGPMock:       mock_utils.NewMockSecurityGroupForPodsAPI(ctrl),
+				K8sMock:       mock_k8s.NewMockK8sWrapper(ctrl),
 				ConditionMock: mock_condition.NewMockConditions(ctrl),
 			}
 			h := &PodMutationWebHook{
-				decoder:   decoder,
-				Log:       zap.New(),
-				SGPAPI:    mock.SGPMock,
-				Condition: mock.ConditionMock,
+				decoder:                      decoder,
+				Log:                          zap.New(),
+				SGPAPI:                       mock.SGPMock,
+				K8sAPI:                       mock.K8sMock,
+				Condition:                    mock.ConditionMock,
+				FargateDefaultSecurityGroups: tt.defaultSGs,
+				InjectionRules:               tt.injectionRules,
 			}
 
 			if tt.mockInvocation != nil {
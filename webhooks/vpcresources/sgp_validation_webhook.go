@@ -0,0 +1,188 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpcresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	vpcresourcesv1beta1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1beta1"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	rcHealthz "github.com/aws/amazon-vpc-resource-controller-k8s/pkg/healthz"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/utils"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-vpcresources-v1beta1-securitygrouppolicy,mutating=false,matchPolicy=Equivalent,failurePolicy=ignore,groups=vpcresources.k8s.aws,resources=securitygrouppolicies,verbs=create;update,versions=v1beta1,name=vsecuritygrouppolicy.vpc.k8s.aws,sideEffects=None,admissionReviewVersions=v1
+
+// SecurityGroupPolicyValidator rejects the SecurityGroupPolicy objects that would be ignored at pod admission and
+// warns when an update changes the security groups of the running pods.
+type SecurityGroupPolicyValidator struct {
+	decoder admission.Decoder
+	// PodAPI lists the pods of the namespace from the data store of the pod controller
+	PodAPI pod.PodClientAPIWrapper
+	// Client reads the service accounts from the cache
+	Client client.Reader
+	// EC2APIHelper verifies the security groups exist in the VPC, nil if the verification is disabled
+	EC2APIHelper api.EC2APIHelper
+	VPCID        string
+	Log          logr.Logger
+}
+
+func NewSecurityGroupPolicyValidator(podAPI pod.PodClientAPIWrapper, k8sClient client.Reader,
+	ec2APIHelper api.EC2APIHelper, vpcID string, log logr.Logger, d admission.Decoder,
+	healthzHandler *rcHealthz.HealthzHandler) *SecurityGroupPolicyValidator {
+	sgpValidator := &SecurityGroupPolicyValidator{
+		decoder:      d,
+		PodAPI:       podAPI,
+		Client:       k8sClient,
+		EC2APIHelper: ec2APIHelper,
+		VPCID:        vpcID,
+		Log:          log,
+	}
+
+	// add health check on subpath for security group policy validating webhook
+	healthzHandler.AddControllersHealthCheckers(
+		map[string]healthz.Checker{
+			"health-sgp-validating-webhook": rcHealthz.SimplePing("security group policy validating webhook", log),
+		},
+	)
+
+	return sgpValidator
+}
+
+func (s *SecurityGroupPolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	sgp := &vpcresourcesv1beta1.SecurityGroupPolicy{}
+	if err := s.decoder.DecodeRaw(req.Object, sgp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var oldSGP *vpcresourcesv1beta1.SecurityGroupPolicy
+	if req.Operation == admissionv1.Update {
+		oldSGP = &vpcresourcesv1beta1.SecurityGroupPolicy{}
+		if err := s.decoder.DecodeRaw(req.OldObject, oldSGP); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(sgp.Spec, oldSGP.Spec) {
+			return admission.Allowed("")
+		}
+	}
+	logger := s.Log.WithValues("name", sgp.Name, "namespace", sgp.Namespace, "operation", req.Operation)
+
+	if errs := utils.ValidateSecurityGroupPolicySpec(sgp.Spec); len(errs) > 0 {
+		logger.Info("denying invalid security group policy", "errors", errs)
+		return admission.Denied(errors.Join(errs...).Error())
+	}
+
+	var warnings []string
+	if s.EC2APIHelper != nil && (oldSGP == nil ||
+		!slices.Equal(sgp.Spec.SecurityGroups.Groups, oldSGP.Spec.SecurityGroups.Groups)) {
+		missing, err := s.EC2APIHelper.GetMissingSecurityGroups(s.VPCID, sgp.Spec.SecurityGroups.Groups)
+		if err != nil {
+			// Don't block the policy if EC2 is unavailable, the pods fail to get their branch ENIs instead
+			logger.Error(err, "failed to verify the security groups")
+			warnings = append(warnings, fmt.Sprintf("could not verify the security groups exist in %s: %v",
+				s.VPCID, err))
+		} else if len(missing) > 0 {
+			logger.Info("denying security group policy with missing security groups", "missing", missing)
+			return admission.Denied(fmt.Sprintf("security groups %s not found in %s",
+				strings.Join(missing, ", "), s.VPCID))
+		}
+	}
+
+	if oldSGP != nil {
+		affected, err := s.countAffectedPods(ctx, oldSGP.Spec, sgp.Spec, sgp.Namespace)
+		if err != nil {
+			logger.Error(err, "failed to find the running pods matching the security group policy")
+		} else if affected > 0 {
+			warnings = append(warnings, fmt.Sprintf("the security groups of %d running pods change with this "+
+				"policy, the running pods keep their current security groups until they are recreated", affected))
+		}
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// countAffectedPods returns the number of running pods with branch ENIs in the namespace whose security groups
+// from the policy change between the old and the new spec. The pods are read from the data store of the pod
+// controller and the service accounts from the cache.
+func (s *SecurityGroupPolicyValidator) countAffectedPods(ctx context.Context, oldSpec,
+	newSpec vpcresourcesv1beta1.SecurityGroupPolicySpec, namespace string) (int, error) {
+	podList, err := s.PodAPI.ListNamespacePods(namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	serviceAccounts := make(map[string]*corev1.ServiceAccount)
+	affected := 0
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if _, ok := pod.Annotations[config.ResourceNamePodENI]; !ok ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		sa, ok := serviceAccounts[pod.Spec.ServiceAccountName]
+		if !ok {
+			sa = &corev1.ServiceAccount{}
+			if err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace,
+				Name: pod.Spec.ServiceAccountName}, sa); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return 0, err
+				}
+				// The service account was deleted, it has no labels
+				sa = &corev1.ServiceAccount{}
+			}
+			serviceAccounts[pod.Spec.ServiceAccountName] = sa
+		}
+
+		if !slices.Equal(policyGroups(oldSpec, pod, sa), policyGroups(newSpec, pod, sa)) {
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// policyGroups returns the sorted security groups the pod gets from the policy at admission, the policies without
+// selectors are ignored like the selectors that cannot be converted
+func policyGroups(spec vpcresourcesv1beta1.SecurityGroupPolicySpec, pod *corev1.Pod,
+	sa *corev1.ServiceAccount) []string {
+	if spec.PodSelector == nil && spec.ServiceAccountSelector == nil {
+		return nil
+	}
+	if matched, _ := utils.SecurityGroupPolicyMatches(spec, pod, sa); !matched {
+		return nil
+	}
+	groups := slices.Clone(spec.SecurityGroups.Groups)
+	slices.Sort(groups)
+	return slices.Compact(groups)
+}
@@ -0,0 +1,232 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package vpcresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	vpcresourcesv1beta1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1beta1"
+	mock_api "github.com/aws/amazon-vpc-resource-controller-k8s/mocks/amazon-vcp-resource-controller-k8s/pkg/aws/ec2/api"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/config"
+	"github.com/aws/amazon-vpc-resource-controller-k8s/pkg/k8s/pod"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clientgocache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var (
+	vpcID  = "vpc-0123456789abcdef0"
+	group1 = "sg-0123456789abcdef0"
+	group2 = "sg-0123456789abcdef1"
+
+	sgp = &vpcresourcesv1beta1.SecurityGroupPolicy{
+		TypeMeta:   metav1.TypeMeta{Kind: "SecurityGroupPolicy", APIVersion: "vpcresources.k8s.aws/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "sgp", Namespace: "default"},
+		Spec: vpcresourcesv1beta1.SecurityGroupPolicySpec{
+			PodSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			SecurityGroups: vpcresourcesv1beta1.GroupIds{Groups: []string{group1}},
+		},
+	}
+
+	serviceAccount = &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default"}}
+)
+
+func newPod(name string, labels map[string]string, hasBranchENI bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels,
+			Annotations: map[string]string{}},
+		Spec:   corev1.PodSpec{ServiceAccountName: "default"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if hasBranchENI {
+		pod.Annotations[config.ResourceNamePodENI] = "[]"
+	}
+	return pod
+}
+
+func getValidator(t *testing.T, ec2APIHelper *mock_api.MockEC2APIHelper,
+	pods ...*corev1.Pod) *SecurityGroupPolicyValidator {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, vpcresourcesv1beta1.AddToScheme(scheme))
+
+	// The pods are stripped down like in the data store of the pod controller
+	converter := pod.PodConverter{}
+	dataStore := clientgocache.NewIndexer(converter.Indexer, pod.NodeNameIndexer())
+	for _, p := range pods {
+		assert.NoError(t, dataStore.Add(converter.StripDownPod(p)))
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(serviceAccount).Build()
+
+	validator := &SecurityGroupPolicyValidator{
+		decoder: admission.NewDecoder(scheme),
+		PodAPI:  pod.NewPodAPIWrapper(dataStore, k8sClient, nil),
+		Client:  k8sClient,
+		VPCID:   vpcID,
+		Log:     zap.New(),
+	}
+	// Keep the interface nil when the verification with EC2 is disabled
+	if ec2APIHelper != nil {
+		validator.EC2APIHelper = ec2APIHelper
+	}
+	return validator
+}
+
+func getRequest(t *testing.T, operation admissionv1.Operation, newSGP,
+	oldSGP *vpcresourcesv1beta1.SecurityGroupPolicy) admission.Request {
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: operation}}
+	raw, err := json.Marshal(newSGP)
+	assert.NoError(t, err)
+	req.Object = runtime.RawExtension{Raw: raw}
+	if oldSGP != nil {
+		raw, err = json.Marshal(oldSGP)
+		assert.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
+
+func TestSecurityGroupPolicyValidator_Handle_Create(t *testing.T) {
+	invalidSGP := sgp.DeepCopy()
+	invalidSGP.Spec.PodSelector = nil
+	invalidSGP.Spec.SecurityGroups.Groups = []string{"my-group"}
+
+	tests := []struct {
+		name    string
+		sgp     *vpcresourcesv1beta1.SecurityGroupPolicy
+		allowed bool
+	}{
+		{name: "valid policy, allow request", sgp: sgp, allowed: true},
+		{name: "invalid policy, deny request", sgp: invalidSGP, allowed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := getValidator(t, nil)
+			response := validator.Handle(context.TODO(), getRequest(t, admissionv1.Create, test.sgp, nil))
+			assert.Equal(t, test.allowed, response.Allowed)
+		})
+	}
+}
+
+func TestSecurityGroupPolicyValidator_Handle_VerifySecurityGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEC2APIHelper := mock_api.NewMockEC2APIHelper(ctrl)
+	validator := getValidator(t, mockEC2APIHelper)
+
+	mockEC2APIHelper.EXPECT().GetMissingSecurityGroups(vpcID, []string{group1}).Return(nil, nil)
+	response := validator.Handle(context.TODO(), getRequest(t, admissionv1.Create, sgp, nil))
+	assert.True(t, response.Allowed)
+
+	mockEC2APIHelper.EXPECT().GetMissingSecurityGroups(vpcID, []string{group1}).Return([]string{group1}, nil)
+	response = validator.Handle(context.TODO(), getRequest(t, admissionv1.Create, sgp, nil))
+	assert.False(t, response.Allowed)
+	assert.Contains(t, response.Result.Message, group1)
+
+	// The policy is allowed with a warning if the security groups cannot be verified
+	mockEC2APIHelper.EXPECT().GetMissingSecurityGroups(vpcID, []string{group1}).Return(nil, fmt.Errorf("mock error"))
+	response = validator.Handle(context.TODO(), getRequest(t, admissionv1.Create, sgp, nil))
+	assert.True(t, response.Allowed)
+	assert.Len(t, response.Warnings, 1)
+}
+
+func TestSecurityGroupPolicyValidator_Handle_Update(t *testing.T) {
+	newGroupsSGP := sgp.DeepCopy()
+	newGroupsSGP.Spec.SecurityGroups.Groups = []string{group1, group2}
+
+	newSelectorSGP := sgp.DeepCopy()
+	newSelectorSGP.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}}
+
+	unmatchedSelectorSGP := sgp.DeepCopy()
+	unmatchedSelectorSGP.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "none"}}
+	unmatchedSelectorSGP.Spec.SecurityGroups.Groups = []string{group2}
+
+	reorderedGroupsSGP := newGroupsSGP.DeepCopy()
+	reorderedGroupsSGP.Spec.SecurityGroups.Groups = []string{group2, group1}
+
+	labelledSGP := sgp.DeepCopy()
+	labelledSGP.Labels = map[string]string{"team": "test"}
+
+	pods := []*corev1.Pod{
+		newPod("matching-pod", map[string]string{"app": "test"}, true),
+		newPod("matching-pod-without-eni", map[string]string{"app": "test"}, false),
+		newPod("other-pod", map[string]string{"app": "other"}, true),
+		newPod("unrelated-pod", map[string]string{"app": "unrelated"}, true),
+	}
+	otherPods := []*corev1.Pod{
+		newPod("other-pod", map[string]string{"app": "other"}, true),
+		newPod("unrelated-pod", map[string]string{"app": "unrelated"}, true),
+	}
+
+	tests := []struct {
+		name             string
+		sgp              *vpcresourcesv1beta1.SecurityGroupPolicy
+		oldSGP           *vpcresourcesv1beta1.SecurityGroupPolicy
+		pods             []*corev1.Pod
+		expectedWarnings int
+	}{
+		{name: "security groups changed, warn about the matching pods", sgp: newGroupsSGP, oldSGP: sgp, pods: pods,
+			expectedWarnings: 1},
+		{name: "selector changed, warn about the old and new matching pods", sgp: newSelectorSGP, oldSGP: sgp,
+			pods: pods, expectedWarnings: 1},
+		{name: "selector changed without matching pods, no warning", sgp: unmatchedSelectorSGP, oldSGP: sgp,
+			pods: otherPods, expectedWarnings: 0},
+		{name: "security groups reordered, no warning", sgp: reorderedGroupsSGP, oldSGP: newGroupsSGP, pods: pods,
+			expectedWarnings: 0},
+		{name: "spec not changed, no warning", sgp: labelledSGP, oldSGP: sgp, pods: pods, expectedWarnings: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := getValidator(t, nil, test.pods...)
+			response := validator.Handle(context.TODO(), getRequest(t, admissionv1.Update, test.sgp, test.oldSGP))
+			assert.True(t, response.Allowed)
+			assert.Len(t, response.Warnings, test.expectedWarnings)
+		})
+	}
+}
+
+func TestSecurityGroupPolicyValidator_countAffectedPods(t *testing.T) {
+	newSelectorSGP := sgp.DeepCopy()
+	newSelectorSGP.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}}
+
+	completedPod := newPod("completed-pod", map[string]string{"app": "test"}, true)
+	completedPod.Status.Phase = corev1.PodSucceeded
+	otherNamespacePod := newPod("other-namespace-pod", map[string]string{"app": "test"}, true)
+	otherNamespacePod.Namespace = "other"
+
+	validator := getValidator(t, nil,
+		newPod("matching-pod", map[string]string{"app": "test"}, true),
+		newPod("other-pod", map[string]string{"app": "other"}, true),
+		newPod("unrelated-pod", map[string]string{"app": "unrelated"}, true),
+		completedPod, otherNamespacePod)
+
+	affected, err := validator.countAffectedPods(context.TODO(), sgp.Spec, newSelectorSGP.Spec, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, affected)
+}